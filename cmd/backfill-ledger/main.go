@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hidenkeys/motiv-backend/config"
+	"github.com/hidenkeys/motiv-backend/repository"
+	"github.com/hidenkeys/motiv-backend/services"
+	"github.com/joho/godotenv"
+)
+
+// Posts ledger transactions for payments, refunds and payouts recorded before
+// the ledger existed. Safe to run more than once; anything already posted is
+// skipped.
+func main() {
+	// Load .env file
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		log.Println("Error loading .env file, using environment variables")
+	}
+
+	// Connect to the database and make sure the ledger tables exist
+	config.ConnectDatabase()
+	config.MigrateDatabase()
+
+	ledgerService := services.NewLedgerService(repository.NewLedgerRepoPG(config.DB), repository.NewUnitOfWorkPG(config.DB))
+
+	log.Println("Backfilling ledger...")
+	posted, err := ledgerService.Backfill()
+	if err != nil {
+		log.Fatalf("Backfill stopped after %d records: %v", posted, err)
+	}
+	log.Printf("✅ Posted %d payments, refunds and payouts to the ledger", posted)
+
+	balances, err := ledgerService.GetAccountBalances()
+	if err != nil {
+		log.Fatalf("Failed to load account balances: %v", err)
+	}
+	for _, balance := range balances {
+		log.Printf("   %-16s %s  debit %12.2f  credit %12.2f  balance %12.2f",
+			balance.Account, balance.Currency, balance.Debit, balance.Credit, balance.Balance)
+	}
+}
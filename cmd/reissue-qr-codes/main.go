@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/config"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+	"github.com/hidenkeys/motiv-backend/services"
+	"github.com/joho/godotenv"
+)
+
+// Re-signs ticket QR codes that aren't signed with the current key: unsigned
+// codes issued before signing existed, and codes signed with a key that has
+// since been rotated out. Old codes stop scanning once replaced, so each
+// ticket for an event that hasn't happened yet is emailed to its attendee
+// again with the new code. Safe to run more than once; codes already signed
+// with the current key are skipped.
+func main() {
+	// Load .env file
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		log.Println("Error loading .env file, using environment variables")
+	}
+
+	config.ConnectDatabase()
+
+	signer, err := services.NewTicketSignerFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid QR signing keys: %v", err)
+	}
+	ticketRepo := repository.NewTicketRepoPG(config.DB)
+	attendeeRepo := repository.NewAttendeeRepoPG(config.DB)
+	userRepo := repository.NewUserRepoPG(config.DB)
+	ticketService := services.NewTicketService(ticketRepo, attendeeRepo, repository.NewUnitOfWorkPG(config.DB), signer)
+	emailService := services.NewZohoEmailService()
+
+	log.Printf("Re-issuing ticket QR codes with key %q...", signer.CurrentKeyID())
+	sent := 0
+	reissued, err := ticketService.ReissueQRCodes(func(reissued *models.Ticket) {
+		if sendNewCode(ticketRepo, attendeeRepo, userRepo, emailService, reissued) {
+			sent++
+		}
+	})
+	if err != nil {
+		log.Fatalf("Re-issue stopped after %d tickets (%d emailed): %v", reissued, sent, err)
+	}
+	log.Printf("✅ Re-issued %d ticket QR codes and emailed %d", reissued, sent)
+}
+
+// sendNewCode emails a re-issued ticket to its attendee, unless the ticket is
+// cancelled or its event is over, and reports whether it did.
+func sendNewCode(ticketRepo repository.TicketRepository, attendeeRepo repository.AttendeeRepository, userRepo repository.UserRepository, emailService services.EmailService, reissued *models.Ticket) bool {
+	ticket, err := ticketRepo.GetTicketByID(reissued.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load ticket %s to email its new code: %v", reissued.ID, err)
+		return false
+	}
+	if ticket.Event.StartDate.Before(time.Now().AddDate(0, 0, -1)) {
+		return false
+	}
+	records, err := attendeeRepo.GetByTicketIDs([]uuid.UUID{ticket.ID})
+	if err != nil {
+		log.Printf("⚠️ Failed to load attendee for ticket %s: %v", ticket.ID, err)
+		return false
+	}
+	for _, record := range records {
+		if record.Status == models.AttendeeCancelled {
+			return false
+		}
+	}
+	user, err := userRepo.GetUserByID(ticket.UserID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load holder of ticket %s: %v", ticket.ID, err)
+		return false
+	}
+
+	if err := emailService.SendTicketConfirmation(ticket, &ticket.Event, user); err != nil {
+		log.Printf("❌ Failed to email new code for ticket %s: %v", ticket.ID, err)
+		return false
+	}
+	return true
+}
@@ -43,20 +43,64 @@ func MigrateDatabase() {
 	createEnumIfNotExists("payment_method", "('bank_transfer', 'card', 'wallet')")
 	createEnumIfNotExists("attendee_status", "('active', 'checked_in', 'cancelled')")
 
+	// Values added to existing enums after their initial creation
+	addEnumValueIfNotExists := func(enumName, value string) {
+		err := DB.Exec("ALTER TYPE " + enumName + " ADD VALUE IF NOT EXISTS '" + value + "'").Error
+		if err != nil {
+			log.Printf("Warning: failed to add value %s to enum %s: %v", value, enumName, err)
+		}
+	}
+
+	addEnumValueIfNotExists("payment_status", "disputed")
+	addEnumValueIfNotExists("payment_status", "expired")
+	addEnumValueIfNotExists("payment_method", "cash")
+	addEnumValueIfNotExists("payment_method", "pos")
+	addEnumValueIfNotExists("payment_method", "comp")
+
 	// Try to migrate advanced models
 	err = DB.AutoMigrate(
 		&models.Review{},
 		&models.Payment{},
+		&models.PaymentLineItem{},
+		&models.PaymentAttendee{},
 		&models.Payout{},
 		&models.EventView{},
 		&models.EventAnalytics{},
 		&models.HostAnalytics{},
 		&models.Attendee{},
+		&models.WebhookEvent{},
+		&models.TicketReservation{},
+		&models.Refund{},
+		&models.PromoCode{},
+		&models.PromoRedemption{},
+		&models.AccessCode{},
+		&models.WaitlistEntry{},
+		&models.TicketTransfer{},
+		&models.CheckInEvent{},
+		&models.EventStaff{},
+		&models.HostBankAccount{},
+		&models.LedgerTransaction{},
+		&models.LedgerEntry{},
+		&models.FeeSchedule{},
+		&models.TicketPriceChange{},
+		&models.PaymentReconciliation{},
+		&models.AttendeeImport{},
+		&models.AttendeeImportRow{},
 	)
 	if err != nil {
 		log.Printf("Warning: failed to migrate advanced models: %v", err)
 		log.Println("Basic functionality will still work. Advanced features may be limited.")
 	}
 
+	// One fee schedule per scope; host and event IDs are null where the scope doesn't use them
+	err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_fee_schedules_scope ON fee_schedules (
+		scope,
+		COALESCE(host_id, '00000000-0000-0000-0000-000000000000'),
+		COALESCE(event_id, '00000000-0000-0000-0000-000000000000')
+	) WHERE deleted_at IS NULL`).Error
+	if err != nil {
+		log.Printf("Warning: failed to create fee schedule index: %v", err)
+	}
+
 	log.Println("Database migration completed")
 }
\ No newline at end of file
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// AccessCodeHandler handles hosts managing the codes and links that unlock
+// their events' hidden ticket types
+type AccessCodeHandler struct {
+	accessCodeService services.AccessCodeService
+	eventService      services.EventService
+}
+
+func NewAccessCodeHandler(accessCodeService services.AccessCodeService, eventService services.EventService) *AccessCodeHandler {
+	return &AccessCodeHandler{
+		accessCodeService: accessCodeService,
+		eventService:      eventService,
+	}
+}
+
+// loadHostAccessCode loads the access code in the :id param and checks the caller owns it.
+func (h *AccessCodeHandler) loadHostAccessCode(c *fiber.Ctx) (*models.AccessCode, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	codeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid access code ID"})
+	}
+
+	code, err := h.accessCodeService.GetAccessCode(codeID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Access code not found"})
+	}
+	if code.HostID != hostID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this access code"})
+	}
+	return code, nil
+}
+
+// applyAccessCodeRequest copies the fields set on req onto code. Ticket types
+// must be hidden ticket types of the event.
+func applyAccessCodeRequest(code *models.AccessCode, req *models.AccessCodeRequest, event *models.Event) error {
+	if req.Code != "" && code.Kind == models.AccessCodeTyped {
+		code.Code = req.Code
+	}
+	if req.Label != nil {
+		code.Label = *req.Label
+	}
+	if req.ExpiresAt != nil {
+		code.ExpiresAt = req.ExpiresAt
+	}
+	if req.Active != nil {
+		code.Active = *req.Active
+	}
+
+	if req.TicketTypeIDs != nil {
+		hiddenTicketTypes := make(map[uuid.UUID]bool, len(event.TicketTypes))
+		for _, tt := range event.TicketTypes {
+			if tt.Visibility == models.TicketHidden {
+				hiddenTicketTypes[tt.ID] = true
+			}
+		}
+
+		ticketTypeIDs := make([]string, 0, len(req.TicketTypeIDs))
+		for _, raw := range req.TicketTypeIDs {
+			ticketTypeID, err := uuid.Parse(raw)
+			if err != nil || !hiddenTicketTypes[ticketTypeID] {
+				return fmt.Errorf("ticket type %s is not a hidden ticket type of this event", raw)
+			}
+			ticketTypeIDs = append(ticketTypeIDs, ticketTypeID.String())
+		}
+		code.TicketTypeIDs = ticketTypeIDs
+	}
+	return nil
+}
+
+// GET /api/v1/hosts/me/events/:eventId/access-codes
+func (h *AccessCodeHandler) GetEventAccessCodes(c *fiber.Ctx) error {
+	event, err := loadHostEvent(c, h.eventService, "You are not authorized to manage access codes for this event")
+	if event == nil {
+		return err
+	}
+
+	codes, err := h.accessCodeService.GetEventAccessCodes(event.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get access codes"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": codes,
+	})
+}
+
+// POST /api/v1/hosts/me/events/:eventId/access-codes
+func (h *AccessCodeHandler) CreateAccessCode(c *fiber.Ctx) error {
+	event, err := loadHostEvent(c, h.eventService, "You are not authorized to manage access codes for this event")
+	if event == nil {
+		return err
+	}
+
+	var req models.AccessCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	code := &models.AccessCode{
+		EventID: event.ID,
+		HostID:  event.HostID,
+		Kind:    models.AccessCodeTyped,
+		Active:  true,
+	}
+	if req.Kind != "" {
+		code.Kind = models.AccessCodeKind(req.Kind)
+	}
+	if err := applyAccessCodeRequest(code, &req, event); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.accessCodeService.CreateAccessCode(code); err != nil {
+		if errors.Is(err, services.ErrAccessCodeSetup) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create access code"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": code,
+	})
+}
+
+// PUT /api/v1/hosts/me/access-codes/:id
+func (h *AccessCodeHandler) UpdateAccessCode(c *fiber.Ctx) error {
+	code, err := h.loadHostAccessCode(c)
+	if code == nil {
+		return err
+	}
+
+	var req models.AccessCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Kind != "" && models.AccessCodeKind(req.Kind) != code.Kind {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "An access code's kind can't be changed"})
+	}
+
+	event, err := h.eventService.GetEventByID(code.EventID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if err := applyAccessCodeRequest(code, &req, event); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.accessCodeService.UpdateAccessCode(code); err != nil {
+		if errors.Is(err, services.ErrAccessCodeSetup) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update access code"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": code,
+	})
+}
+
+// DELETE /api/v1/hosts/me/access-codes/:id
+func (h *AccessCodeHandler) DeleteAccessCode(c *fiber.Ctx) error {
+	code, err := h.loadHostAccessCode(c)
+	if code == nil {
+		return err
+	}
+
+	if err := h.accessCodeService.DeleteAccessCode(code.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete access code"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Access code deleted successfully"})
+}
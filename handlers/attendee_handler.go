@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -11,18 +12,21 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
 	"github.com/hidenkeys/motiv-backend/services"
 )
 
 type AttendeeHandler struct {
 	attendeeService services.AttendeeService
 	eventService    services.EventService
+	staffService    services.StaffService
 }
 
-func NewAttendeeHandler(attendeeService services.AttendeeService, eventService services.EventService) *AttendeeHandler {
+func NewAttendeeHandler(attendeeService services.AttendeeService, eventService services.EventService, staffService services.StaffService) *AttendeeHandler {
 	return &AttendeeHandler{
 		attendeeService: attendeeService,
 		eventService:    eventService,
+		staffService:    staffService,
 	}
 }
 
@@ -33,10 +37,10 @@ func (h *AttendeeHandler) GetEventAttendees(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
 	}
 
-	// Verify the host owns this event
+	// Verify the caller hosts this event or is staff who can look attendees up
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
-	hostID, err := uuid.Parse(claims["user_id"].(string))
+	userID, err := uuid.Parse(claims["user_id"].(string))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
 	}
@@ -46,7 +50,11 @@ func (h *AttendeeHandler) GetEventAttendees(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
 	}
 
-	if event.HostID != hostID {
+	allowed, err := h.staffService.Authorize(event, userID, services.PermViewAttendees)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to view attendees for this event"})
 	}
 
@@ -68,6 +76,13 @@ func (h *AttendeeHandler) GetEventAttendees(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get attendees"})
 	}
 
+	// Staff don't see what tickets cost
+	if event.HostID != userID {
+		for i := range attendees {
+			attendees[i].Amount = 0
+		}
+	}
+
 	// Get total count for pagination
 	totalCount, err := h.attendeeService.GetEventAttendeesTotalCount(eventID)
 	if err != nil {
@@ -97,16 +112,17 @@ func (h *AttendeeHandler) CheckInAttendee(c *fiber.Ctx) error {
 	var req struct {
 		QRCode  string `json:"qrCode" validate:"required"`
 		EventID string `json:"eventId" validate:"required"`
+		Gate    string `json:"gate"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	// Get host ID from JWT
+	// Get the host or staff member's ID from JWT
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
-	hostID, err := uuid.Parse(claims["user_id"].(string))
+	userID, err := uuid.Parse(claims["user_id"].(string))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
 	}
@@ -116,18 +132,22 @@ func (h *AttendeeHandler) CheckInAttendee(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
 	}
 
-	// Verify the host owns this event
+	// Verify the caller hosts this event or is staff who can check in
 	event, err := h.eventService.GetEventByID(eventID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
 	}
 
-	if event.HostID != hostID {
+	allowed, err := h.staffService.Authorize(event, userID, services.PermCheckIn)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to check in attendees for this event"})
 	}
 
-	// Find attendee by QR code (assuming QR code is the ticket ID or a unique identifier)
-	result, err := h.attendeeService.CheckInByQRCode(req.QRCode, eventID, hostID)
+	// Find attendee by QR code and record who scanned it
+	result, err := h.attendeeService.CheckInByQRCode(req.QRCode, req.Gate, eventID, userID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -276,3 +296,121 @@ func (h *AttendeeHandler) ExportHostAttendees(c *fiber.Ctx) error {
 
 	return c.Send(buf.Bytes())
 }
+
+// GetCheckInLog handles listing an event's check-in scans, or one ticket's
+func (h *AttendeeHandler) GetCheckInLog(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("eventId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	// Verify the caller hosts this event or is staff who can review scans
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	event, err := h.eventService.GetEventByID(eventID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	allowed, err := h.staffService.Authorize(event, userID, services.PermViewScans)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to view check-ins for this event"})
+	}
+
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	offset := (page - 1) * limit
+
+	var ticketID *uuid.UUID
+	if ticketIDStr := c.Query("ticket_id"); ticketIDStr != "" {
+		parsedTicketID, err := uuid.Parse(ticketIDStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ticket ID"})
+		}
+		ticketID = &parsedTicketID
+	}
+
+	checkIns, totalCount, err := h.attendeeService.GetCheckInLog(eventID, ticketID, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get check-ins"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    checkIns,
+		"total":   totalCount,
+		"page":    page,
+		"limit":   limit,
+		"hasMore": int64(offset+limit) < totalCount,
+	})
+}
+
+// UndoCheckIn handles taking back a check-in made by mistake
+func (h *AttendeeHandler) UndoCheckIn(c *fiber.Ctx) error {
+	checkInID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in ID"})
+	}
+
+	var req models.UndoCheckInRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A reason is required"})
+	}
+
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	checkIn, err := h.attendeeService.GetCheckIn(checkInID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Check-in not found"})
+	}
+
+	// Verify the caller hosts the event or is staff who can undo check-ins
+	event, err := h.eventService.GetEventByID(checkIn.EventID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	allowed, err := h.staffService.Authorize(event, userID, services.PermUndoCheckIn)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to undo check-ins for this event"})
+	}
+
+	if err := h.attendeeService.UndoCheckIn(checkIn, userID, reason); err != nil {
+		if errors.Is(err, services.ErrCheckInNotUndoable) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to undo check-in"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": checkIn,
+	})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// maxImportFileSize is the largest attendee CSV accepted, in bytes.
+const maxImportFileSize = 2 << 20
+
+// AttendeeImportHandler handles hosts importing attendees from a CSV
+type AttendeeImportHandler struct {
+	importService services.AttendeeImportService
+	eventService  services.EventService
+}
+
+func NewAttendeeImportHandler(importService services.AttendeeImportService, eventService services.EventService) *AttendeeImportHandler {
+	return &AttendeeImportHandler{
+		importService: importService,
+		eventService:  eventService,
+	}
+}
+
+// loadHostImport loads the import in the :id param and checks the caller owns it.
+func (h *AttendeeImportHandler) loadHostImport(c *fiber.Ctx) (*models.AttendeeImport, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	importID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid import ID"})
+	}
+
+	attendeeImport, err := h.importService.GetImport(importID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Import not found"})
+	}
+	if attendeeImport.HostID != hostID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to view this import"})
+	}
+	return attendeeImport, nil
+}
+
+// POST /api/v1/hosts/me/events/:eventId/attendee-imports
+func (h *AttendeeImportHandler) UploadImport(c *fiber.Ctx) error {
+	event, err := loadHostEvent(c, h.eventService, "You are not authorized to import attendees for this event")
+	if event == nil {
+		return err
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A CSV file is required"})
+	}
+	if fileHeader.Size > maxImportFileSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "CSV file is too large"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Failed to read CSV file"})
+	}
+	defer file.Close()
+
+	attendeeImport, err := h.importService.Preview(event, event.HostID, fileHeader.Filename, file)
+	if err != nil {
+		if errors.Is(err, services.ErrImportInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("❌ IMPORT ERROR: Failed to preview import for event %s: %v", event.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read attendees"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": attendeeImport,
+	})
+}
+
+// GET /api/v1/hosts/me/events/:eventId/attendee-imports
+func (h *AttendeeImportHandler) GetEventImports(c *fiber.Ctx) error {
+	event, err := loadHostEvent(c, h.eventService, "You are not authorized to import attendees for this event")
+	if event == nil {
+		return err
+	}
+
+	imports, err := h.importService.GetEventImports(event.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get imports"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": imports,
+	})
+}
+
+// GET /api/v1/hosts/me/attendee-imports/:id
+func (h *AttendeeImportHandler) GetImport(c *fiber.Ctx) error {
+	attendeeImport, err := h.loadHostImport(c)
+	if attendeeImport == nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data": attendeeImport,
+	})
+}
+
+// GET /api/v1/hosts/me/attendee-imports/:id/rows
+func (h *AttendeeImportHandler) GetImportRows(c *fiber.Ctx) error {
+	attendeeImport, err := h.loadHostImport(c)
+	if attendeeImport == nil {
+		return err
+	}
+
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	offset := (page - 1) * limit
+
+	status := models.AttendeeImportRowStatus(c.Query("status"))
+	switch status {
+	case "", models.ImportRowValid, models.ImportRowInvalid, models.ImportRowDuplicate, models.ImportRowImported, models.ImportRowFailed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid row status"})
+	}
+
+	rows, totalCount, err := h.importService.GetRows(attendeeImport.ID, status, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get import rows"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    rows,
+		"total":   totalCount,
+		"page":    page,
+		"limit":   limit,
+		"hasMore": int64(offset+limit) < totalCount,
+	})
+}
+
+// POST /api/v1/hosts/me/attendee-imports/:id/commit
+func (h *AttendeeImportHandler) CommitImport(c *fiber.Ctx) error {
+	attendeeImport, err := h.loadHostImport(c)
+	if attendeeImport == nil {
+		return err
+	}
+
+	var req models.AttendeeImportCommitRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	attendeeImport, err = h.importService.Commit(attendeeImport, req.ImportDuplicates, req.SendInvites)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImportInvalid):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrImportNotPending):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("❌ IMPORT ERROR: Failed to commit import %s: %v", c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to import attendees"})
+	}
+
+	// Large imports carry on in the background; poll the import for progress
+	status := fiber.StatusOK
+	if attendeeImport.Status == models.ImportQueued || attendeeImport.Status == models.ImportProcessing {
+		status = fiber.StatusAccepted
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"data": attendeeImport,
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// BoxOfficeHandler handles tickets sold or given away at an event's door
+type BoxOfficeHandler struct {
+	boxOfficeService services.BoxOfficeService
+	eventService     services.EventService
+	staffService     services.StaffService
+}
+
+func NewBoxOfficeHandler(boxOfficeService services.BoxOfficeService, eventService services.EventService, staffService services.StaffService) *BoxOfficeHandler {
+	return &BoxOfficeHandler{
+		boxOfficeService: boxOfficeService,
+		eventService:     eventService,
+		staffService:     staffService,
+	}
+}
+
+// POST /api/v1/hosts/me/events/:eventId/box-office
+func (h *BoxOfficeHandler) Sell(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("eventId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	var req models.BoxOfficeSaleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	event, err := h.eventService.GetEventByID(eventID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	// Giving tickets away takes more than selling them
+	perm := services.PermSellTickets
+	if models.PaymentMethod(strings.ToLower(strings.TrimSpace(req.PaymentMethod))) == models.Comp {
+		perm = services.PermCompTickets
+	}
+	allowed, err := h.staffService.Authorize(event, userID, perm)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to issue these tickets for this event"})
+	}
+
+	sale, err := h.boxOfficeService.Sell(event, userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBoxOfficeInvalid):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrBoxOfficeClosed),
+			errors.Is(err, services.ErrTicketsUnavailable):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("❌ BOX OFFICE ERROR: Failed to issue tickets for event %s: %v", event.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to issue tickets"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": sale,
+	})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+const (
+	// dashboardRefreshInterval is the most often a stream re-sends the
+	// dashboard totals, and only after check-ins have come in
+	dashboardRefreshInterval = 2 * time.Second
+	// dashboardHeartbeatInterval keeps idle streams open through proxies
+	// and notices clients that have gone away
+	dashboardHeartbeatInterval = 15 * time.Second
+)
+
+// CheckInDashboardHandler streams an event's check-ins to a live dashboard
+// as Server-Sent Events
+type CheckInDashboardHandler struct {
+	dashboardService services.CheckInDashboardService
+	eventService     services.EventService
+	staffService     services.StaffService
+}
+
+func NewCheckInDashboardHandler(dashboardService services.CheckInDashboardService, eventService services.EventService, staffService services.StaffService) *CheckInDashboardHandler {
+	return &CheckInDashboardHandler{
+		dashboardService: dashboardService,
+		eventService:     eventService,
+		staffService:     staffService,
+	}
+}
+
+// writeEvent writes one Server-Sent Event and flushes it to the client.
+func writeEvent(w *bufio.Writer, name string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, body); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// GET /api/v1/hosts/me/events/:eventId/check-ins/live
+func (h *CheckInDashboardHandler) StreamDashboard(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("eventId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	// Verify the caller hosts this event or is staff who can review scans
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	event, err := h.eventService.GetEventByID(eventID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	allowed, err := h.staffService.Authorize(event, userID, services.PermViewScans)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to view check-ins for this event"})
+	}
+
+	// Subscribe before totalling up, so no check-in falls between the two
+	checkIns, stop := h.dashboardService.Watch(event.ID)
+	dashboard, err := h.dashboardService.GetDashboard(event.ID)
+	if err != nil {
+		stop()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get check-in dashboard"})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer stop()
+
+		if err := writeEvent(w, "dashboard", dashboard); err != nil {
+			return
+		}
+
+		refresh := time.NewTicker(dashboardRefreshInterval)
+		defer refresh.Stop()
+		heartbeat := time.NewTicker(dashboardHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		stale := false
+		for {
+			select {
+			case checkIn, ok := <-checkIns:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, "check_in", checkIn); err != nil {
+					return
+				}
+				stale = true
+			case <-refresh.C:
+				if !stale {
+					continue
+				}
+				dashboard, err := h.dashboardService.GetDashboard(event.ID)
+				if err != nil {
+					log.Printf("⚠️ DASHBOARD WARNING: Failed to refresh check-in dashboard for event %s: %v", event.ID, err)
+					continue
+				}
+				if err := writeEvent(w, "dashboard", dashboard); err != nil {
+					return
+				}
+				stale = false
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,12 +16,15 @@ import (
 
 // EventHandler handles event-related requests
 type EventHandler struct {
-	eventService  services.EventService
-	ticketService services.TicketService
+	eventService       services.EventService
+	ticketService      services.TicketService
+	reservationService services.ReservationService
+	accessCodeService  services.AccessCodeService
+	gateways           services.GatewayRegistry
 }
 
-func NewEventHandler(eventService services.EventService, ticketService services.TicketService) *EventHandler {
-	return &EventHandler{eventService, ticketService}
+func NewEventHandler(eventService services.EventService, ticketService services.TicketService, reservationService services.ReservationService, accessCodeService services.AccessCodeService, gateways services.GatewayRegistry) *EventHandler {
+	return &EventHandler{eventService, ticketService, reservationService, accessCodeService, gateways}
 }
 
 // GetAllEvents handles retrieving all events with pagination
@@ -57,6 +62,11 @@ func (h *EventHandler) GetAllEvents(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get events"})
 	}
+	h.setSaleStates(result.Data)
+	// Hidden ticket types are only shown on the event's own page, with a code
+	for _, event := range result.Data {
+		event.TicketTypes = services.VisibleTicketTypes(event.TicketTypes, nil)
+	}
 
 	return c.JSON(result)
 }
@@ -73,7 +83,124 @@ func (h *EventHandler) GetEventByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
 	}
 
-	return c.JSON(event)
+	// A code or magic link token reveals the hidden ticket types it unlocks
+	access, err := h.accessCodeService.Unlock(event.ID, c.Query("access_code"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	held := h.setSaleStates([]*models.Event{event})
+	event.TicketTypes = services.VisibleTicketTypes(event.TicketTypes, access)
+	return c.JSON(toEventResponse(event, held))
+}
+
+// setSaleStates works out which of the events' ticket types are on sale, and
+// returns the quantity of each held by in-progress checkouts, which aren't
+// available to other buyers.
+func (h *EventHandler) setSaleStates(events []*models.Event) map[uuid.UUID]int {
+	var ticketTypeIDs []uuid.UUID
+	for _, event := range events {
+		for _, tt := range event.TicketTypes {
+			ticketTypeIDs = append(ticketTypeIDs, tt.ID)
+		}
+	}
+	held, err := h.reservationService.GetHeldQuantities(ticketTypeIDs)
+	if err != nil {
+		log.Printf("Failed to load ticket holds: %v", err)
+		held = map[uuid.UUID]int{}
+	}
+
+	now := time.Now()
+	for _, event := range events {
+		services.SetSaleStates(event.TicketTypes, held, now)
+	}
+	return held
+}
+
+// toEventResponse builds the public view of an event. held is the quantity of
+// each ticket type currently reserved by pending checkouts; ticket types must
+// have their sale states set.
+func toEventResponse(event *models.Event, held map[uuid.UUID]int) models.EventResponse {
+	ticketTypes := make([]models.TicketTypeResponse, 0, len(event.TicketTypes))
+	for _, tt := range event.TicketTypes {
+		available := tt.TotalQuantity - tt.SoldQuantity - held[tt.ID]
+		if available < 0 || tt.SaleState != models.TicketOnSale {
+			available = 0
+		}
+		ticketTypes = append(ticketTypes, models.TicketTypeResponse{
+			ID:                tt.ID,
+			Name:              tt.Name,
+			Price:             tt.Price,
+			Description:       tt.Description,
+			TotalQuantity:     tt.TotalQuantity,
+			SoldQuantity:      tt.SoldQuantity,
+			AvailableQuantity: available,
+			MinPerOrder:       tt.MinPerOrder,
+			MaxPerOrder:       tt.MaxPerOrder,
+			MaxPerUser:        tt.MaxPerUser,
+			OnePerEmail:       tt.OnePerEmail,
+			OnePerPhone:       tt.OnePerPhone,
+			SalesStart:        tt.SalesStart,
+			SalesEnd:          tt.SalesEnd,
+			PreviousTierID:    tt.PreviousTierID,
+			SaleState:         tt.SaleState,
+			Visibility:        tt.Visibility,
+			EntryLimit:        tt.EntryLimit,
+			EntryPerDay:       tt.EntryPerDay,
+		})
+	}
+
+	return models.EventResponse{
+		ID:                event.ID,
+		Title:             event.Title,
+		Description:       event.Description,
+		StartDate:         event.StartDate,
+		StartTime:         event.StartTime,
+		EndTime:           event.EndTime,
+		Location:          event.Location,
+		ManualDescription: event.ManualDescription,
+		Latitude:          event.Latitude,
+		Longitude:         event.Longitude,
+		PlaceID:           event.PlaceID,
+		Tags:              event.Tags,
+		BannerImageURL:    event.BannerImageURL,
+		EventType:         event.EventType,
+		HostID:            event.HostID,
+		Host: models.UserResponse{
+			ID:       event.Host.ID,
+			Name:     event.Host.Name,
+			Username: event.Host.Username,
+			Email:    event.Host.Email,
+			Avatar:   event.Host.Avatar,
+			Role:     string(event.Host.Role),
+		},
+		TicketTypes:       ticketTypes,
+		Currency:          event.Currency,
+		TransfersDisabled: event.TransfersDisabled,
+		Status:            event.Status,
+		CreatedAt:         event.CreatedAt,
+		UpdatedAt:         event.UpdatedAt,
+	}
+}
+
+// validateTierOrder checks that each ticket type's opensAfter names another
+// ticket type in the request, and that tiers don't follow each other in a loop.
+func validateTierOrder(ticketTypes []models.CreateTicketTypeRequest) error {
+	for i, tt := range ticketTypes {
+		if tt.OpensAfter != nil && (*tt.OpensAfter < 0 || *tt.OpensAfter >= len(ticketTypes) || *tt.OpensAfter == i) {
+			return fmt.Errorf("%s: opensAfter must be the index of another ticket type", tt.Name)
+		}
+	}
+	for i, tt := range ticketTypes {
+		steps := 0
+		for next := tt.OpensAfter; next != nil; next = ticketTypes[*next].OpensAfter {
+			if *next == i || steps == len(ticketTypes) {
+				return fmt.Errorf("%s: tiers cannot follow each other in a loop", tt.Name)
+			}
+			steps++
+		}
+	}
+	return nil
 }
 
 // GetMyEvents handles retrieving events for the current host
@@ -89,6 +216,7 @@ func (h *EventHandler) GetMyEvents(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get events"})
 	}
+	h.setSaleStates(events)
 
 	return c.JSON(events)
 }
@@ -156,6 +284,21 @@ func (h *EventHandler) CreateEvent(c *fiber.Ctx) error {
 		EventType:      req.EventType,
 		HostID:         hostID,
 		Status:         status,
+		Currency:       "NGN",
+		PaymentGateway: strings.ToLower(req.PaymentGateway),
+	}
+	if req.Currency != "" {
+		newEvent.Currency = strings.ToUpper(req.Currency)
+	}
+	if req.TransfersDisabled != nil {
+		newEvent.TransfersDisabled = *req.TransfersDisabled
+	}
+
+	// Make sure tickets can actually be sold in this currency
+	if req.EventType == "ticketed" {
+		if _, err := h.gateways.ForEvent(&newEvent); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unsupported payment setup: " + err.Error()})
+		}
 	}
 
 	// Add location data if provided
@@ -173,13 +316,34 @@ func (h *EventHandler) CreateEvent(c *fiber.Ctx) error {
 	// Create ticket types slice for possible ticketed event
 	var ticketTypes []models.TicketType
 	if req.EventType == "ticketed" {
+		if err := validateTierOrder(req.TicketTypes); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		for _, ticketReq := range req.TicketTypes {
+			if err := services.ValidatePurchaseLimits(&ticketReq.TicketTypeLimitsRequest); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": ticketReq.Name + ": " + err.Error()})
+			}
+			if ticketReq.SalesStart != nil && ticketReq.SalesEnd != nil && !ticketReq.SalesEnd.After(*ticketReq.SalesStart) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": ticketReq.Name + ": sales must end after they start"})
+			}
+			visibility, err := services.ParseTicketVisibility(ticketReq.Visibility)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": ticketReq.Name + ": " + err.Error()})
+			}
 			ticketType := models.TicketType{
 				Name:          ticketReq.Name,
 				Price:         ticketReq.Price,
 				Description:   ticketReq.Description,
 				TotalQuantity: ticketReq.TotalQuantity,
 				SoldQuantity:  0,
+				MinPerOrder:   ticketReq.MinPerOrder,
+				MaxPerOrder:   ticketReq.MaxPerOrder,
+				MaxPerUser:    ticketReq.MaxPerUser,
+				OnePerEmail:   ticketReq.OnePerEmail,
+				OnePerPhone:   ticketReq.OnePerPhone,
+				SalesStart:    ticketReq.SalesStart,
+				SalesEnd:      ticketReq.SalesEnd,
+				Visibility:    visibility,
 			}
 			ticketTypes = append(ticketTypes, ticketType)
 		}
@@ -203,6 +367,21 @@ func (h *EventHandler) CreateEvent(c *fiber.Ctx) error {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create ticket types"})
 			}
 		}
+		// Tiers can only point at each other once they all have IDs
+		for i, ticketReq := range req.TicketTypes {
+			if ticketReq.OpensAfter == nil {
+				continue
+			}
+			previousTierID := ticketTypes[*ticketReq.OpensAfter].ID.String()
+			if err := h.ticketService.UpdateTicketTypeSales(&ticketTypes[i], &models.TicketTypeSalesRequest{
+				SalesStart:     ticketTypes[i].SalesStart,
+				SalesEnd:       ticketTypes[i].SalesEnd,
+				PreviousTierID: &previousTierID,
+			}); err != nil {
+				log.Printf("Error linking ticket tiers: %v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create ticket types"})
+			}
+		}
 		// Attach ticket types to the event for response
 		newEvent.TicketTypes = ticketTypes
 	case "free":
@@ -305,6 +484,20 @@ func (h *EventHandler) UpdateEvent(c *fiber.Ctx) error {
 	if req.EventType != "" {
 		event.EventType = req.EventType
 	}
+	if req.Currency != "" {
+		event.Currency = strings.ToUpper(req.Currency)
+	}
+	if req.PaymentGateway != "" {
+		event.PaymentGateway = strings.ToLower(req.PaymentGateway)
+	}
+	if req.TransfersDisabled != nil {
+		event.TransfersDisabled = *req.TransfersDisabled
+	}
+	if event.EventType == "ticketed" && (req.Currency != "" || req.PaymentGateway != "") {
+		if _, err := h.gateways.ForEvent(event); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unsupported payment setup: " + err.Error()})
+		}
+	}
 
 	// Handle status update - default to active if not specified in request
 	if req.Status != "" {
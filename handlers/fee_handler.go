@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// FeeHandler handles admin fee schedules and hosts choosing who pays fees
+type FeeHandler struct {
+	feeService   services.FeeService
+	eventService services.EventService
+}
+
+func NewFeeHandler(feeService services.FeeService, eventService services.EventService) *FeeHandler {
+	return &FeeHandler{
+		feeService:   feeService,
+		eventService: eventService,
+	}
+}
+
+// scheduleTarget reads the host or event a schedule route is for from the
+// :id param. Event schedules are stored against the event's host too.
+func (h *FeeHandler) scheduleTarget(c *fiber.Ctx, scope models.FeeScope) (uuid.UUID, uuid.UUID, error) {
+	if scope == models.FeeScopePlatform {
+		return uuid.Nil, uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+	}
+	if scope == models.FeeScopeHost {
+		return id, uuid.Nil, nil
+	}
+
+	event, err := h.eventService.GetEventByID(id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	return event.HostID, event.ID, nil
+}
+
+func (h *FeeHandler) getSchedule(c *fiber.Ctx, scope models.FeeScope) error {
+	hostID, eventID, err := h.scheduleTarget(c, scope)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := h.feeService.GetSchedule(scope, hostID, eventID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get fee schedule"})
+	}
+	settings, err := h.feeService.GetSettings(hostID, eventID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get fee schedule"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":      schedule,
+		"effective": settings,
+	})
+}
+
+func (h *FeeHandler) saveSchedule(c *fiber.Ctx, scope models.FeeScope) error {
+	hostID, eventID, err := h.scheduleTarget(c, scope)
+	if err != nil {
+		return err
+	}
+
+	var req models.FeeScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	schedule, err := h.feeService.SaveSchedule(scope, hostID, eventID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrFeeScheduleInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save fee schedule"})
+	}
+	settings, err := h.feeService.GetSettings(hostID, eventID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get fee schedule"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":      schedule,
+		"effective": settings,
+	})
+}
+
+// GET /api/v1/admin/fees
+func (h *FeeHandler) GetPlatformFees(c *fiber.Ctx) error {
+	return h.getSchedule(c, models.FeeScopePlatform)
+}
+
+// PUT /api/v1/admin/fees
+func (h *FeeHandler) SavePlatformFees(c *fiber.Ctx) error {
+	return h.saveSchedule(c, models.FeeScopePlatform)
+}
+
+// GET /api/v1/admin/hosts/:id/fees
+func (h *FeeHandler) GetHostFeeSchedule(c *fiber.Ctx) error {
+	return h.getSchedule(c, models.FeeScopeHost)
+}
+
+// PUT /api/v1/admin/hosts/:id/fees
+func (h *FeeHandler) SaveHostFeeSchedule(c *fiber.Ctx) error {
+	return h.saveSchedule(c, models.FeeScopeHost)
+}
+
+// GET /api/v1/admin/events/:id/fees
+func (h *FeeHandler) GetEventFeeSchedule(c *fiber.Ctx) error {
+	return h.getSchedule(c, models.FeeScopeEvent)
+}
+
+// PUT /api/v1/admin/events/:id/fees
+func (h *FeeHandler) SaveEventFeeSchedule(c *fiber.Ctx) error {
+	return h.saveSchedule(c, models.FeeScopeEvent)
+}
+
+// hostFeeTarget reads the caller and, on event routes, the :eventId param,
+// checking the caller hosts the event.
+func (h *FeeHandler) hostFeeTarget(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+	if c.Params("eventId") == "" {
+		return hostID, uuid.Nil, nil
+	}
+
+	eventID, err := uuid.Parse(c.Params("eventId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+	event, err := h.eventService.GetEventByID(eventID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if event.HostID != hostID {
+		return uuid.Nil, uuid.Nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage fees for this event"})
+	}
+	return hostID, eventID, nil
+}
+
+// GET /api/v1/hosts/me/fees
+// GET /api/v1/hosts/me/events/:eventId/fees
+func (h *FeeHandler) GetHostFees(c *fiber.Ctx) error {
+	hostID, eventID, err := h.hostFeeTarget(c)
+	if err != nil {
+		return err
+	}
+
+	settings, err := h.feeService.GetSettings(hostID, eventID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get fees"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": settings,
+	})
+}
+
+// PUT /api/v1/hosts/me/fees
+// PUT /api/v1/hosts/me/events/:eventId/fees
+func (h *FeeHandler) SetPassFeesToBuyer(c *fiber.Ctx) error {
+	hostID, eventID, err := h.hostFeeTarget(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.PassFeesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	settings, err := h.feeService.SetPassToBuyer(hostID, eventID, req.PassFeesToBuyer)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save fees"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": settings,
+	})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// loadHostEvent loads the event in the :eventId param and checks the caller
+// hosts it, responding with forbidden as the error if they don't.
+func loadHostEvent(c *fiber.Ctx, eventService services.EventService, forbidden string) (*models.Event, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	eventID, err := uuid.Parse(c.Params("eventId"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	event, err := eventService.GetEventByID(eventID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if event.HostID != hostID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": forbidden})
+	}
+	return event, nil
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// LedgerHandler handles admin ledger reports
+type LedgerHandler struct {
+	ledgerService services.LedgerService
+}
+
+func NewLedgerHandler(ledgerService services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+	}
+}
+
+// GET /api/v1/admin/ledger/balances
+func (h *LedgerHandler) GetAccountBalances(c *fiber.Ctx) error {
+	balances, err := h.ledgerService.GetAccountBalances()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get ledger balances"})
+	}
+
+	// Every posting balances, so each currency's accounts must sum to zero
+	totals := make(map[string]float64)
+	for _, balance := range balances {
+		totals[balance.Currency] += balance.Balance
+	}
+	balanced := make(map[string]bool, len(totals))
+	for currency, total := range totals {
+		balanced[currency] = math.Abs(total) < 0.005
+	}
+
+	return c.JSON(fiber.Map{
+		"data":     balances,
+		"balanced": balanced,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// OfflineCheckInHandler handles door scanners that check tickets without a
+// connection and sync their check-ins afterwards
+type OfflineCheckInHandler struct {
+	offlineCheckInService services.OfflineCheckInService
+	eventService          services.EventService
+	staffService          services.StaffService
+}
+
+func NewOfflineCheckInHandler(offlineCheckInService services.OfflineCheckInService, eventService services.EventService, staffService services.StaffService) *OfflineCheckInHandler {
+	return &OfflineCheckInHandler{
+		offlineCheckInService: offlineCheckInService,
+		eventService:          eventService,
+		staffService:          staffService,
+	}
+}
+
+// loadEvent loads the event in the :eventId param and checks the caller
+// hosts it or is staff allowed perm.
+func (h *OfflineCheckInHandler) loadEvent(c *fiber.Ctx, perm services.StaffPermission) (*models.Event, uuid.UUID, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, uuid.Nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	eventID, err := uuid.Parse(c.Params("eventId"))
+	if err != nil {
+		return nil, uuid.Nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	event, err := h.eventService.GetEventByID(eventID)
+	if err != nil {
+		return nil, uuid.Nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	allowed, err := h.staffService.Authorize(event, userID, perm)
+	if err != nil {
+		return nil, uuid.Nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
+		return nil, uuid.Nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to check in attendees for this event"})
+	}
+	return event, userID, nil
+}
+
+// GET /api/v1/hosts/me/events/:eventId/checkin-manifest
+func (h *OfflineCheckInHandler) GetManifest(c *fiber.Ctx) error {
+	event, _, err := h.loadEvent(c, services.PermCheckIn)
+	if event == nil {
+		return err
+	}
+
+	manifest, err := h.offlineCheckInService.GetManifest(event)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build check-in manifest"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": manifest,
+	})
+}
+
+// GET /api/v1/checkin-manifest/keys
+func (h *OfflineCheckInHandler) GetManifestKeys(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"data": h.offlineCheckInService.GetManifestKeys(),
+	})
+}
+
+// POST /api/v1/hosts/me/events/:eventId/checkin-sync
+func (h *OfflineCheckInHandler) SyncCheckIns(c *fiber.Ctx) error {
+	event, userID, err := h.loadEvent(c, services.PermCheckIn)
+	if event == nil {
+		return err
+	}
+
+	var req models.OfflineCheckInSyncRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	result, err := h.offlineCheckInService.SyncCheckIns(event.ID, userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrCheckInSyncInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to sync check-ins"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": result,
+	})
+}
@@ -1,14 +1,12 @@
 package handlers
 
 import (
-	"crypto/hmac"
-	"crypto/sha512"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,20 +17,40 @@ import (
 )
 
 type PaymentHandler struct {
-	paymentService services.PaymentService
-	ticketService  services.TicketService
-	eventService   services.EventService
-	userService    services.UserService
-	emailService   services.EmailService
+	paymentService     services.PaymentService
+	ticketService      services.TicketService
+	eventService       services.EventService
+	userService        services.UserService
+	emailService       services.EmailService
+	webhookService     services.WebhookService
+	fulfilmentService  services.FulfilmentService
+	reservationService services.ReservationService
+	refundService      services.RefundService
+	gateways           services.GatewayRegistry
+	promoService       services.PromoService
+	payoutService      services.PayoutService
+	feeService         services.FeeService
+	accessCodeService  services.AccessCodeService
+	waitlistService    services.WaitlistService
 }
 
-func NewPaymentHandler(paymentService services.PaymentService, ticketService services.TicketService, eventService services.EventService, userService services.UserService, emailService services.EmailService) *PaymentHandler {
+func NewPaymentHandler(paymentService services.PaymentService, ticketService services.TicketService, eventService services.EventService, userService services.UserService, emailService services.EmailService, webhookService services.WebhookService, fulfilmentService services.FulfilmentService, reservationService services.ReservationService, refundService services.RefundService, gateways services.GatewayRegistry, promoService services.PromoService, payoutService services.PayoutService, feeService services.FeeService, accessCodeService services.AccessCodeService, waitlistService services.WaitlistService) *PaymentHandler {
 	return &PaymentHandler{
-		paymentService: paymentService,
-		ticketService:  ticketService,
-		eventService:   eventService,
-		userService:    userService,
-		emailService:   emailService,
+		paymentService:     paymentService,
+		ticketService:      ticketService,
+		eventService:       eventService,
+		userService:        userService,
+		emailService:       emailService,
+		webhookService:     webhookService,
+		fulfilmentService:  fulfilmentService,
+		reservationService: reservationService,
+		refundService:      refundService,
+		gateways:           gateways,
+		promoService:       promoService,
+		payoutService:      payoutService,
+		feeService:         feeService,
+		accessCodeService:  accessCodeService,
+		waitlistService:    waitlistService,
 	}
 }
 
@@ -54,6 +72,17 @@ func (h *PaymentHandler) GetHostEarnings(c *fiber.Ctx) error {
 		})
 	}
 
+	nextPayoutDate, err := h.payoutService.NextPayoutDate(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to get earnings",
+		})
+	}
+	earnings["next_payout_date"] = nil
+	if nextPayoutDate != nil {
+		earnings["next_payout_date"] = nextPayoutDate.Format("2006-01-02")
+	}
+
 	return c.JSON(fiber.Map{
 		"data": earnings,
 	})
@@ -181,13 +210,59 @@ func (h *PaymentHandler) InitiatePayment(c *fiber.Ctx) error {
 	}
 	log.Printf("📅 EVENT VERIFIED: Event '%s' found for payment", eventDetails.Title)
 
-	// Calculate total amount and validate ticket availability
+	gateway, err := h.gateways.ForEvent(eventDetails)
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: No payment gateway for event %s: %v", eventID.String(), err)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Payments are not available for this event"})
+	}
+	currency := eventDetails.Currency
+	log.Printf("🏦 PAYMENT GATEWAY: Using %s for %s payment", gateway.Name(), currency)
+
+	// Hidden ticket types can only be bought with a code or link token that
+	// unlocks them, or by a buyer the waitlist has offered them to
+	access, err := h.accessCodeService.Unlock(eventID, req.AccessCode)
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: Access code %q rejected for event %s", req.AccessCode, eventID.String())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	offers, err := h.waitlistService.GetUserOffers(userID, eventID)
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: Failed to load waitlist offers for user %s: %v", userID.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check ticket availability"})
+	}
+
+	// Ticket types outside their sale window, or waiting on an earlier tier, can't be bought
+	eventTypeIDs := make([]uuid.UUID, 0, len(eventDetails.TicketTypes))
+	for _, tt := range eventDetails.TicketTypes {
+		eventTypeIDs = append(eventTypeIDs, tt.ID)
+	}
+	held, err := h.reservationService.GetHeldQuantities(eventTypeIDs)
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: Failed to load ticket holds for event %s: %v", eventID.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check ticket availability"})
+	}
+	services.SetSaleStates(eventDetails.TicketTypes, held, time.Now())
+	saleStates := make(map[uuid.UUID]models.TicketSaleState, len(eventDetails.TicketTypes))
+	for _, tt := range eventDetails.TicketTypes {
+		saleStates[tt.ID] = tt.SaleState
+	}
+
+	// Calculate total amount from the ticket types' own prices and validate availability.
+	// Client-supplied prices are ignored; each line item snapshots the price we charge.
 	var totalAmount float64
+	var lineItems []models.PaymentLineItem
+	var ticketCount int
+	ticketTypes := make(map[uuid.UUID]*models.TicketType)
 	log.Printf("💰 CALCULATING TOTAL: Starting ticket validation and amount calculation")
 
 	for i, ticketDetail := range req.TicketDetails {
-		log.Printf("🎫 TICKET VALIDATION %d/%d: Type=%s, Quantity=%d, Price=%.2f",
-			i+1, len(req.TicketDetails), ticketDetail.TicketTypeID, ticketDetail.Quantity, ticketDetail.Price)
+		log.Printf("🎫 TICKET VALIDATION %d/%d: Type=%s, Quantity=%d",
+			i+1, len(req.TicketDetails), ticketDetail.TicketTypeID, ticketDetail.Quantity)
+
+		if ticketDetail.Quantity < 1 {
+			log.Printf("❌ PAYMENT INIT ERROR: Invalid quantity %d for ticket type %s", ticketDetail.Quantity, ticketDetail.TicketTypeID)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ticket quantity must be at least 1"})
+		}
 
 		ticketTypeID, err := uuid.Parse(ticketDetail.TicketTypeID)
 		if err != nil {
@@ -202,6 +277,24 @@ func (h *PaymentHandler) InitiatePayment(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket type not found"})
 		}
 
+		if ticketType.EventID != eventID {
+			log.Printf("❌ PAYMENT INIT ERROR: Ticket type %s does not belong to event %s", ticketTypeID.String(), eventID.String())
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ticket type does not belong to this event"})
+		}
+		if offers[ticketType.ID] == nil && !services.TicketTypeVisible(ticketType, access) {
+			log.Printf("❌ PAYMENT INIT ERROR: Ticket type %s is hidden and wasn't unlocked", ticketTypeID.String())
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket type not found"})
+		}
+
+		// Sold out is left to the availability checks below and the hold
+		if state := saleStates[ticketType.ID]; state != models.TicketOnSale && state != models.TicketSoldOut {
+			log.Printf("❌ PAYMENT INIT ERROR: Ticket type %s is not on sale (%s)", ticketType.Name, state)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":      fmt.Sprintf("%s is not on sale", ticketType.Name),
+				"sale_state": state,
+			})
+		}
+
 		log.Printf("🎫 TICKET TYPE VERIFIED: %s - Available: %d, Sold: %d, Requesting: %d",
 			ticketType.Name, ticketType.TotalQuantity-ticketType.SoldQuantity, ticketType.SoldQuantity, ticketDetail.Quantity)
 
@@ -213,12 +306,69 @@ func (h *PaymentHandler) InitiatePayment(c *fiber.Ctx) error {
 			})
 		}
 
-		subtotal := ticketDetail.Price * float64(ticketDetail.Quantity)
+		if ticketDetail.Price != 0 && ticketDetail.Price != ticketType.Price {
+			log.Printf("⚠️ PRICE MISMATCH: Client sent %.2f for %s, charging %.2f", ticketDetail.Price, ticketType.Name, ticketType.Price)
+		}
+
+		ticketTypes[ticketType.ID] = ticketType
+		subtotal := ticketType.Price * float64(ticketDetail.Quantity)
 		totalAmount += subtotal
-		log.Printf("💰 SUBTOTAL: %s x %d = %.2f NGN", ticketType.Name, ticketDetail.Quantity, subtotal)
+		lineItems = append(lineItems, models.PaymentLineItem{
+			TicketTypeID:   ticketType.ID,
+			TicketTypeName: ticketType.Name,
+			UnitPrice:      ticketType.Price,
+			Quantity:       ticketDetail.Quantity,
+			Subtotal:       subtotal,
+		})
+		log.Printf("💰 SUBTOTAL: %s x %d = %.2f %s", ticketType.Name, ticketDetail.Quantity, subtotal, currency)
+		ticketCount += ticketDetail.Quantity
+	}
+
+	// Each named attendee gets one of the tickets; any left over go to the buyer to name later
+	attendees, err := paymentAttendees(&req, ticketCount)
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Printf("👥 ATTENDEES: %d named for %d tickets", len(attendees), ticketCount)
+
+	if err := h.checkPurchaseLimits(lineItems, ticketTypes, attendees); err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: %v", err)
+		if errors.Is(err, services.ErrPurchaseLimit) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check purchase limits"})
+	}
+
+	// Apply the promo code; the discount is recorded on each eligible line item
+	var promo *models.PromoCode
+	var discount float64
+	if req.PromoCode != "" {
+		promo, discount, err = h.promoService.ApplyPromoCode(eventID, userID, req.PromoCode, lineItems)
+		if err != nil {
+			log.Printf("❌ PAYMENT INIT ERROR: Promo code %s rejected: %v", req.PromoCode, err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		totalAmount = math.Round((totalAmount-discount)*100) / 100
+		log.Printf("🏷️ PROMO APPLIED: %s takes %.2f %s off", promo.Code, discount, currency)
+
+		if totalAmount <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Promo code cannot make a paid order free"})
+		}
 	}
 
-	log.Printf("💰 TOTAL AMOUNT: %.2f NGN (%.0f kobo)", totalAmount, totalAmount*100)
+	// Fees passed to the buyer are added on; the rest come out of the host's payout
+	fees, err := h.feeService.Quote(eventDetails, gateway, totalAmount)
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: Failed to calculate fees for event %s: %v", eventID.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to calculate fees"})
+	}
+	totalAmount = fees.Total
+	log.Printf("🧾 FEES: Platform %.2f, gateway %.2f; buyer pays %.2f, host pays %.2f %s",
+		fees.PlatformFee, fees.GatewayFee, fees.BuyerFee, fees.HostFee, currency)
+
+	amountMinor := int64(math.Round(totalAmount * 100))
+	log.Printf("💰 TOTAL AMOUNT: %.2f %s (%d minor units)", totalAmount, currency, amountMinor)
 
 	// Generate payment reference
 	reference := fmt.Sprintf("motiv_%s_%s_%d", req.EventID, userID.String()[:8], time.Now().Unix())
@@ -229,117 +379,439 @@ func (h *PaymentHandler) InitiatePayment(c *fiber.Ctx) error {
 		EventID:   eventID,
 		UserID:    userID,
 		Amount:    totalAmount,
-		Currency:  "NGN",
+		Currency:  currency,
 		Status:    models.PaymentPending,
 		Method:    models.Card,
 		Reference: reference,
+		LineItems: lineItems,
+		Gateway:   gateway.Name(),
+
+		PlatformFee: fees.PlatformFee,
+		GatewayFee:  fees.GatewayFee,
+		BuyerFee:    fees.BuyerFee,
+		HostFee:     fees.HostFee,
+
+		Attendees: attendees,
+	}
+	if promo != nil {
+		payment.PromoCodeID = &promo.ID
+		payment.DiscountAmount = discount
 	}
 
+	// Hold the tickets so nobody else can buy them while this buyer pays
+	holdExpiresAt, err := h.reservationService.HoldTickets(eventID, userID, reference, lineItems, attendees)
+	if errors.Is(err, services.ErrTicketsUnavailable) || errors.Is(err, services.ErrPurchaseLimit) {
+		log.Printf("❌ PAYMENT INIT ERROR: Could not hold tickets for %s: %v", reference, err)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: Error holding tickets for %s: %v", reference, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reserve tickets"})
+	}
+	log.Printf("🔒 TICKETS HELD: Tickets for %s held until %s", reference, holdExpiresAt.Format(time.RFC3339))
+
 	log.Printf("💾 PAYMENT RECORD: Creating payment record with status PENDING")
 	err = h.paymentService.CreatePayment(payment)
 	if err != nil {
 		log.Printf("❌ PAYMENT INIT ERROR: Error creating payment record: %v", err)
+		if releaseErr := h.reservationService.ReleaseHold(reference); releaseErr != nil {
+			log.Printf("⚠️ HOLD WARNING: Failed to release hold for %s: %v", reference, releaseErr)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create payment"})
 	}
 	log.Printf("✅ PAYMENT RECORD: Payment record created successfully with ID: %s", payment.ID.String())
 
+	if promo != nil {
+		if err := h.promoService.RedeemPromoCode(promo, payment); err != nil {
+			log.Printf("❌ PAYMENT INIT ERROR: Could not redeem promo code %s for %s: %v", promo.Code, reference, err)
+			h.abandonCheckout(reference, "promo code could not be redeemed")
+			if errors.Is(err, services.ErrPromoCodeExhausted) || errors.Is(err, services.ErrPromoCodeUserLimit) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to apply promo code"})
+		}
+	}
+
+	checkout, err := gateway.InitializeTransaction(services.GatewayCheckoutRequest{
+		Reference:  reference,
+		Amount:     amountMinor,
+		Currency:   currency,
+		Email:      req.Email,
+		EventID:    eventID.String(),
+		EventTitle: eventDetails.Title,
+		Attendee:   req.AttendeeData,
+	})
+	if err != nil {
+		log.Printf("❌ PAYMENT INIT ERROR: %s could not start checkout for %s: %v", gateway.Name(), reference, err)
+		h.abandonCheckout(reference, "checkout could not be started")
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Failed to start checkout"})
+	}
+
 	// Return payment initiation response
 	response := models.PaymentInitiationResponse{
-		Reference:   reference,
-		Amount:      int64(totalAmount * 100), // Convert to kobo
-		PaystackURL: "https://checkout.paystack.com",
-		PublicKey:   os.Getenv("PAYSTACK_PUBLIC_KEY"),
-		Email:       req.Email,
-		Currency:    "NGN",
+		Reference:     reference,
+		Amount:        amountMinor,
+		Discount:      discount,
+		Gateway:       gateway.Name(),
+		CheckoutURL:   checkout.CheckoutURL,
+		AccessCode:    checkout.AccessCode,
+		PublicKey:     checkout.PublicKey,
+		Email:         req.Email,
+		Currency:      currency,
+		HoldExpiresAt: holdExpiresAt,
+		Fees:          fees,
+	}
+	if gateway.Name() == services.PaystackGateway {
+		response.PaystackURL = checkout.CheckoutURL
 	}
 
-	log.Printf("🚀 PAYMENT INITIATED: Returning payment initiation response for %.2f NGN", totalAmount)
+	log.Printf("🚀 PAYMENT INITIATED: Returning payment initiation response for %.2f %s", totalAmount, currency)
 	log.Printf("⏳ AWAITING WEBHOOK: Payment %s is now pending webhook confirmation", reference)
 	return c.JSON(response)
 }
 
-// POST /api/v1/payments/webhook
+// abandonCheckout fails a payment that never reached the gateway and gives
+// back its ticket hold and promo code use.
+func (h *PaymentHandler) abandonCheckout(reference, reason string) {
+	if err := h.paymentService.UpdatePaymentStatus(reference, models.PaymentFailed, reason); err != nil {
+		log.Printf("⚠️ PAYMENT WARNING: Failed to mark payment %s failed: %v", reference, err)
+	}
+	if err := h.reservationService.ReleaseHold(reference); err != nil {
+		log.Printf("⚠️ HOLD WARNING: Failed to release hold for %s: %v", reference, err)
+	}
+	if err := h.promoService.ReleaseRedemption(reference); err != nil {
+		log.Printf("⚠️ PROMO WARNING: Failed to release promo code use for %s: %v", reference, err)
+	}
+}
+
+// paymentAttendees lists the named attendees for an order of ticketCount
+// tickets, from the attendee list or, for older clients, the primary
+// attendee. Each keeps its place in the list, which is the ticket it gets;
+// entries with no name are left for the buyer to fill in.
+func paymentAttendees(req *models.PaymentInitiationRequest, ticketCount int) ([]models.PaymentAttendee, error) {
+	named := req.Attendees
+	if len(named) == 0 {
+		named = []models.AttendeeDataRequest{req.AttendeeData}
+	}
+	if len(named) > ticketCount {
+		return nil, fmt.Errorf("%d attendees given for %d tickets", len(named), ticketCount)
+	}
+
+	var attendees []models.PaymentAttendee
+	for i, attendee := range named {
+		name := strings.TrimSpace(attendee.FullName)
+		email := strings.TrimSpace(attendee.Email)
+		if name == "" {
+			continue
+		}
+		if !isValidEmail(email) {
+			return nil, fmt.Errorf("attendee %s has no valid email", name)
+		}
+		attendees = append(attendees, models.PaymentAttendee{
+			Position: i,
+			FullName: name,
+			Email:    email,
+			Phone:    strings.TrimSpace(attendee.Phone),
+		})
+	}
+	return attendees, nil
+}
+
+// checkPurchaseLimits checks each ticket type in an order against its
+// purchase limits, along with the attendees its tickets will go to.
+func (h *PaymentHandler) checkPurchaseLimits(lineItems []models.PaymentLineItem, ticketTypes map[uuid.UUID]*models.TicketType, attendees []models.PaymentAttendee) error {
+	byPosition := make(map[int]models.PaymentAttendee, len(attendees))
+	for _, attendee := range attendees {
+		byPosition[attendee.Position] = attendee
+	}
+
+	// Tickets are issued in line item order, so the nth ticket is the nth attendee's
+	var typeIDs []uuid.UUID
+	quantities := make(map[uuid.UUID]int)
+	named := make(map[uuid.UUID][]models.PaymentAttendee)
+	position := 0
+	for _, item := range lineItems {
+		if _, seen := quantities[item.TicketTypeID]; !seen {
+			typeIDs = append(typeIDs, item.TicketTypeID)
+		}
+		quantities[item.TicketTypeID] += item.Quantity
+		for i := 0; i < item.Quantity; i++ {
+			if attendee, ok := byPosition[position]; ok {
+				named[item.TicketTypeID] = append(named[item.TicketTypeID], attendee)
+			}
+			position++
+		}
+	}
+
+	for _, typeID := range typeIDs {
+		if err := h.ticketService.CheckPurchaseLimits(ticketTypes[typeID], quantities[typeID], named[typeID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// POST /api/v1/payments/webhook (Paystack)
+// POST /api/v1/payments/webhook/:provider
 func (h *PaymentHandler) PaymentWebhook(c *fiber.Ctx) error {
 	log.Printf("🔔 WEBHOOK RECEIVED: Payment webhook called from IP: %s", c.IP())
 	log.Printf("🔔 WEBHOOK HEADERS: %+v", c.GetReqHeaders())
 
-	// Verify Paystack signature
-	signature := c.Get("x-paystack-signature")
-	if signature == "" {
-		log.Printf("❌ WEBHOOK ERROR: Missing Paystack signature")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing signature"})
+	gateway, err := h.gateways.Get(c.Params("provider", services.PaystackGateway))
+	if err != nil {
+		log.Printf("❌ WEBHOOK ERROR: %v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Unknown payment provider"})
 	}
 
 	body := c.Body()
-	secretKey := os.Getenv("PAYSTACK_SECRET_KEY")
+	log.Printf("🔐 WEBHOOK VERIFICATION: Verifying %s signature for payload length: %d bytes", gateway.Name(), len(body))
 
-	if secretKey == "" {
-		log.Printf("❌ WEBHOOK ERROR: PAYSTACK_SECRET_KEY not configured")
+	verifyErr := gateway.VerifyWebhook(func(key string) string { return c.Get(key) }, body)
+	if errors.Is(verifyErr, services.ErrGatewayNotConfigured) {
+		log.Printf("❌ WEBHOOK ERROR: %s webhook secret not configured", gateway.Name())
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Server configuration error"})
 	}
 
-	log.Printf("🔐 WEBHOOK VERIFICATION: Verifying signature for payload length: %d bytes", len(body))
+	webhookEvent, parseErr := gateway.ParseWebhook(body)
 
-	// Verify signature
-	mac := hmac.New(sha512.New, []byte(secretKey))
-	mac.Write(body)
-	expectedSignature := hex.EncodeToString(mac.Sum(nil))
+	// Every delivery is stored, including ones we reject, so they can be audited
+	delivery := &models.WebhookEvent{
+		Provider:       gateway.Name(),
+		RawBody:        string(body),
+		SignatureValid: verifyErr == nil,
+		Status:         models.WebhookReceived,
+	}
+	if parseErr == nil {
+		delivery.EventType = webhookEvent.RawType
+		delivery.Reference = webhookEvent.Reference
+	}
 
-	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
-		log.Printf("❌ WEBHOOK ERROR: Invalid Paystack signature. Expected: %s, Got: %s", expectedSignature, signature)
+	if verifyErr != nil {
+		delivery.Status = models.WebhookRejected
+		delivery.Error = "missing or invalid signature"
+		if _, _, err := h.webhookService.RecordDelivery(delivery); err != nil {
+			log.Printf("⚠️ WEBHOOK WARNING: Failed to record rejected delivery: %v", err)
+		}
+		if errors.Is(verifyErr, services.ErrWebhookSignatureMissing) {
+			log.Printf("❌ WEBHOOK ERROR: Missing %s signature", gateway.Name())
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing signature"})
+		}
+		log.Printf("❌ WEBHOOK ERROR: Invalid %s signature", gateway.Name())
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid signature"})
 	}
 
 	log.Printf("✅ WEBHOOK VERIFICATION: Signature verified successfully")
 
-	var webhookEvent models.PaystackWebhookEvent
-	if err := json.Unmarshal(body, &webhookEvent); err != nil {
-		log.Printf("❌ WEBHOOK ERROR: Error parsing webhook payload: %v", err)
+	if parseErr != nil {
+		log.Printf("❌ WEBHOOK ERROR: Error parsing webhook payload: %v", parseErr)
+		delivery.Status = models.WebhookFailed
+		delivery.Error = fmt.Sprintf("invalid payload: %v", parseErr)
+		if _, _, err := h.webhookService.RecordDelivery(delivery); err != nil {
+			log.Printf("⚠️ WEBHOOK WARNING: Failed to record unparseable delivery: %v", err)
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid payload"})
 	}
 
-	log.Printf("🎯 WEBHOOK EVENT: Type=%s, Reference=%s, Amount=%v, Status=%s",
-		webhookEvent.Event,
-		webhookEvent.Data.Reference,
-		webhookEvent.Data.Amount,
-		webhookEvent.Data.Status)
-
-	log.Printf("📧 WEBHOOK CUSTOMER: Email=%s", webhookEvent.Data.Customer.Email)
-	log.Printf("📊 WEBHOOK METADATA: EventID=%s", webhookEvent.Data.Metadata.EventID)
-	log.Printf("📊 WEBHOOK ATTENDEE: Name=%s, Email=%s, Phone=%s",
-		webhookEvent.Data.Metadata.AttendeeData.FullName,
-		webhookEvent.Data.Metadata.AttendeeData.Email,
-		webhookEvent.Data.Metadata.AttendeeData.Phone)
-	log.Printf("📊 WEBHOOK TICKETS: %d ticket types", len(webhookEvent.Data.Metadata.TicketDetails))
-	for i, ticket := range webhookEvent.Data.Metadata.TicketDetails {
-		log.Printf("📊 WEBHOOK TICKET %d: TypeID=%s, Name=%s, Quantity=%d, Price=%.2f",
-			i+1, ticket.TicketTypeID, ticket.TicketTypeName, ticket.Quantity, ticket.Price)
-	} // Handle different webhook events
-	switch webhookEvent.Event {
-	case "charge.success":
-		log.Printf("💳 WEBHOOK PROCESSING: Handling successful payment for reference: %s", webhookEvent.Data.Reference)
-		err := h.handleSuccessfulPayment(webhookEvent)
-		if err != nil {
-			log.Printf("❌ WEBHOOK ERROR: Error handling successful payment: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process payment"})
-		}
-		log.Printf("✅ WEBHOOK SUCCESS: Successfully processed payment for reference: %s", webhookEvent.Data.Reference)
-	case "charge.failed":
-		log.Printf("💔 WEBHOOK PROCESSING: Handling failed payment for reference: %s", webhookEvent.Data.Reference)
-		err := h.handleFailedPayment(webhookEvent)
-		if err != nil {
-			log.Printf("❌ WEBHOOK ERROR: Error handling failed payment: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process payment failure"})
+	providerEventID := webhookEvent.EventID
+	delivery.ProviderEventID = &providerEventID
+
+	stored, duplicate, err := h.webhookService.RecordDelivery(delivery)
+	if err != nil {
+		log.Printf("❌ WEBHOOK ERROR: Failed to record webhook delivery %s: %v", providerEventID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to record webhook"})
+	}
+
+	if duplicate {
+		log.Printf("🔁 WEBHOOK DUPLICATE: Delivery %s already recorded with status %s", providerEventID, stored.Status)
+		if stored.Status == models.WebhookProcessed || stored.Status == models.WebhookIgnored {
+			return c.JSON(fiber.Map{"message": "Webhook already processed"})
 		}
-		log.Printf("✅ WEBHOOK SUCCESS: Successfully processed payment failure for reference: %s", webhookEvent.Data.Reference)
-	default:
-		log.Printf("⚠️ WEBHOOK WARNING: Unhandled webhook event: %s for reference: %s", webhookEvent.Event, webhookEvent.Data.Reference)
 	}
 
-	log.Printf("🎉 WEBHOOK COMPLETE: Webhook processed successfully for event: %s, reference: %s", webhookEvent.Event, webhookEvent.Data.Reference)
+	claimed, err := h.webhookService.BeginProcessing(stored.ID)
+	if err != nil {
+		log.Printf("❌ WEBHOOK ERROR: Failed to claim webhook %s for processing: %v", stored.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process webhook"})
+	}
+	if !claimed {
+		log.Printf("🔁 WEBHOOK DUPLICATE: Delivery %s is already being processed", providerEventID)
+		return c.JSON(fiber.Map{"message": "Webhook already being processed"})
+	}
+
+	if err := h.processWebhookEvent(stored.ID, webhookEvent); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process webhook"})
+	}
+
+	log.Printf("🎉 WEBHOOK COMPLETE: Webhook processed successfully for event: %s, reference: %s", webhookEvent.RawType, webhookEvent.Reference)
 	return c.JSON(fiber.Map{"message": "Webhook processed successfully"})
 }
 
+// processWebhookEvent runs a claimed webhook event and records the outcome
+// against the stored delivery.
+func (h *PaymentHandler) processWebhookEvent(storedID uuid.UUID, webhookEvent *services.GatewayWebhookEvent) error {
+	log.Printf("🎯 WEBHOOK EVENT: Provider=%s, Type=%s, Reference=%s, Amount=%v, Status=%s",
+		webhookEvent.Provider,
+		webhookEvent.RawType,
+		webhookEvent.Reference,
+		webhookEvent.Amount,
+		webhookEvent.Status)
+
+	var err error
+	switch webhookEvent.Type {
+	case services.GatewayChargeSuccess:
+		log.Printf("💳 WEBHOOK PROCESSING: Handling successful payment for reference: %s", webhookEvent.Reference)
+		err = h.handleSuccessfulPayment(webhookEvent)
+	case services.GatewayChargeFailed:
+		log.Printf("💔 WEBHOOK PROCESSING: Handling failed payment for reference: %s", webhookEvent.Reference)
+		err = h.handleFailedPayment(webhookEvent)
+	case services.GatewayRefundProcessed:
+		log.Printf("💸 WEBHOOK PROCESSING: Handling processed refund for reference: %s", webhookEvent.Reference)
+		err = h.refundService.HandleRefundProcessed(webhookEvent.RefundID, webhookEvent.Reference)
+	case services.GatewayRefundFailed:
+		log.Printf("💔 WEBHOOK PROCESSING: Handling failed refund for reference: %s", webhookEvent.Reference)
+		err = h.refundService.HandleRefundFailed(webhookEvent.RefundID, webhookEvent.Reference, webhookEvent.Message)
+	case services.GatewayTransferSuccess:
+		log.Printf("🏦 WEBHOOK PROCESSING: Handling completed payout for reference: %s", webhookEvent.Reference)
+		err = h.payoutService.HandleTransferSuccess(webhookEvent.Reference, webhookEvent.TransferID)
+	case services.GatewayTransferFailed:
+		log.Printf("💔 WEBHOOK PROCESSING: Handling failed payout for reference: %s", webhookEvent.Reference)
+		err = h.payoutService.HandleTransferFailed(webhookEvent.Reference, webhookEvent.TransferID, webhookEvent.Message)
+	case services.GatewayTransferReversed:
+		log.Printf("↩️ WEBHOOK PROCESSING: Handling reversed payout for reference: %s", webhookEvent.Reference)
+		err = h.payoutService.HandleTransferFailed(webhookEvent.Reference, webhookEvent.TransferID, "transfer reversed by gateway")
+	default:
+		log.Printf("⚠️ WEBHOOK WARNING: Unhandled webhook event: %s for reference: %s", webhookEvent.RawType, webhookEvent.Reference)
+		if markErr := h.webhookService.MarkIgnored(storedID, "unhandled event type"); markErr != nil {
+			log.Printf("⚠️ WEBHOOK WARNING: Failed to mark webhook %s ignored: %v", storedID, markErr)
+		}
+		return nil
+	}
+
+	if err != nil {
+		log.Printf("❌ WEBHOOK ERROR: Error handling %s for reference %s: %v", webhookEvent.RawType, webhookEvent.Reference, err)
+		if markErr := h.webhookService.MarkFailed(storedID, err.Error()); markErr != nil {
+			log.Printf("⚠️ WEBHOOK WARNING: Failed to mark webhook %s failed: %v", storedID, markErr)
+		}
+		return err
+	}
+
+	log.Printf("✅ WEBHOOK SUCCESS: Successfully processed %s for reference: %s", webhookEvent.RawType, webhookEvent.Reference)
+	if markErr := h.webhookService.MarkProcessed(storedID); markErr != nil {
+		log.Printf("⚠️ WEBHOOK WARNING: Failed to mark webhook %s processed: %v", storedID, markErr)
+	}
+	return nil
+}
+
+// GET /api/v1/admin/webhooks
+func (h *PaymentHandler) ListWebhookEvents(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := h.webhookService.ListWebhookEvents(c.Query("provider"), c.Query("status"), page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to get webhook events",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": events,
+		"meta": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GET /api/v1/admin/webhooks/:id
+func (h *PaymentHandler) GetWebhookEvent(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid webhook event ID",
+		})
+	}
+
+	event, err := h.webhookService.GetWebhookEvent(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Webhook event not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": event,
+	})
+}
+
+// POST /api/v1/admin/webhooks/:id/replay
+func (h *PaymentHandler) ReplayWebhookEvent(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid webhook event ID",
+		})
+	}
+
+	stored, err := h.webhookService.BeginReplay(id)
+	if errors.Is(err, services.ErrWebhookNotReplayable) {
+		return c.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Webhook event not found",
+		})
+	}
+
+	log.Printf("🔁 WEBHOOK REPLAY: Replaying webhook %s (%s, reference: %s)", stored.ID, stored.EventType, stored.Reference)
+
+	gateway, err := h.gateways.Get(stored.Provider)
+	if err != nil {
+		h.webhookService.MarkFailed(stored.ID, err.Error())
+		return c.Status(422).JSON(fiber.Map{
+			"error": "Payment provider is no longer configured",
+		})
+	}
+
+	webhookEvent, err := gateway.ParseWebhook([]byte(stored.RawBody))
+	if err != nil {
+		h.webhookService.MarkFailed(stored.ID, fmt.Sprintf("invalid payload: %v", err))
+		return c.Status(422).JSON(fiber.Map{
+			"error": "Stored payload could not be parsed",
+		})
+	}
+
+	processErr := h.processWebhookEvent(stored.ID, webhookEvent)
+
+	updated, err := h.webhookService.GetWebhookEvent(stored.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to reload webhook event",
+		})
+	}
+
+	if processErr != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Replay failed",
+			"data":  updated,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook replayed successfully",
+		"data":    updated,
+	})
+}
+
 // GET /api/v1/payments/webhook/test - Test endpoint to verify webhook is reachable
 func (h *PaymentHandler) TestWebhook(c *fiber.Ctx) error {
 	log.Printf("Webhook test endpoint accessed from IP: %s", c.IP())
@@ -350,30 +822,35 @@ func (h *PaymentHandler) TestWebhook(c *fiber.Ctx) error {
 	})
 }
 
-func (h *PaymentHandler) handleSuccessfulPayment(event models.PaystackWebhookEvent) error {
-	log.Printf("🚀 PAYMENT SUCCESS: Processing successful payment for reference: %s", event.Data.Reference)
-	log.Printf("💰 PAYMENT DETAILS: Amount=%v, Currency=%s, Channel=%s", event.Data.Amount, event.Data.Currency, event.Data.Channel)
+func (h *PaymentHandler) handleSuccessfulPayment(event *services.GatewayWebhookEvent) error {
+	log.Printf("🚀 PAYMENT SUCCESS: Processing successful payment for reference: %s", event.Reference)
+	log.Printf("💰 PAYMENT DETAILS: Amount=%v, Currency=%s, Provider=%s", event.Amount, event.Currency, event.Provider)
 
-	// Update payment status
-	err := h.paymentService.UpdatePaymentStatus(event.Data.Reference, models.PaymentCompleted, "")
+	// A payment that is already completed has had its tickets issued
+	existing, err := h.paymentService.GetPaymentByReference(event.Reference)
 	if err != nil {
-		log.Printf("❌ PAYMENT ERROR: Failed to update payment status for reference %s: %v", event.Data.Reference, err)
-		return fmt.Errorf("failed to update payment status: %w", err)
+		log.Printf("❌ PAYMENT ERROR: Payment not found for reference %s: %v", event.Reference, err)
+		return fmt.Errorf("payment not found: %w", err)
 	}
-	log.Printf("✅ PAYMENT UPDATE: Payment status updated to completed for reference: %s", event.Data.Reference)
-
-	// Validate event ID in metadata
-	if event.Data.Metadata.EventID == "" {
-		log.Printf("❌ PAYMENT ERROR: Missing event ID in metadata for reference: %s", event.Data.Reference)
-		return fmt.Errorf("missing event ID in payment metadata")
+	if existing.Status == models.PaymentCompleted {
+		log.Printf("🔁 PAYMENT SKIPPED: Payment %s is already completed, not issuing tickets again", event.Reference)
+		return nil
 	}
 
-	// Create tickets for the user
-	eventID, err := uuid.Parse(event.Data.Metadata.EventID)
+	// Verify the transaction with Paystack and check it against our stored payment
+	payment, err := h.paymentService.VerifyPayment(event.Reference)
+	if errors.Is(err, services.ErrPaymentDisputed) {
+		log.Printf("🚩 PAYMENT DISPUTED: %v - payment %s held for review, no tickets issued", err, event.Reference)
+		return nil
+	}
 	if err != nil {
-		log.Printf("❌ PAYMENT ERROR: Invalid event ID format in metadata for reference %s: %v", event.Data.Reference, err)
-		return fmt.Errorf("invalid event ID in metadata: %w", err)
+		log.Printf("❌ PAYMENT ERROR: Failed to verify payment for reference %s: %v", event.Reference, err)
+		return fmt.Errorf("failed to verify payment: %w", err)
 	}
+	log.Printf("✅ PAYMENT VERIFIED: Gateway transaction matches stored payment for reference: %s", event.Reference)
+
+	// Event and buyer come from the stored payment, not the webhook payload
+	eventID := payment.EventID
 	log.Printf("🎫 TICKET CREATION: Creating tickets for event: %s", eventID.String())
 
 	// Get event details for email
@@ -382,7 +859,7 @@ func (h *PaymentHandler) handleSuccessfulPayment(event models.PaystackWebhookEve
 		log.Printf("❌ PAYMENT ERROR: Failed to get event details for event %s: %v", eventID.String(), err)
 		return fmt.Errorf("failed to get event details: %w", err)
 	}
-	log.Printf("📅 EVENT DETAILS: Found event '%s' for payment reference: %s", eventDetails.Title, event.Data.Reference)
+	log.Printf("📅 EVENT DETAILS: Found event '%s' for payment reference: %s", eventDetails.Title, event.Reference)
 
 	// Get host details for email
 	host, err := h.userService.GetUserByID(eventDetails.HostID)
@@ -392,16 +869,7 @@ func (h *PaymentHandler) handleSuccessfulPayment(event models.PaystackWebhookEve
 	}
 	log.Printf("👤 HOST DETAILS: Found host '%s' for event: %s", host.Email, eventDetails.Title)
 
-	// Find user by email (assuming the customer email matches user email)
-	log.Printf("🔍 USER LOOKUP: Searching for user with email: %s", event.Data.Customer.Email)
-	userID, err := h.paymentService.GetUserIDByEmail(event.Data.Customer.Email)
-	if err != nil {
-		log.Printf("❌ PAYMENT ERROR: Failed to find user by email %s: %v", event.Data.Customer.Email, err)
-		log.Printf("💡 EMAIL MISMATCH: The webhook customer email (%s) doesn't match any user in database", event.Data.Customer.Email)
-		log.Printf("💡 EMAIL MISMATCH: This could mean the payment email differs from the registered user email")
-		return fmt.Errorf("failed to find user by email: %w", err)
-	}
-	log.Printf("👤 USER DETAILS: Found user ID %s for email: %s", userID.String(), event.Data.Customer.Email)
+	userID := payment.UserID
 
 	// Get user details for email
 	user, err := h.userService.GetUserByID(userID)
@@ -409,83 +877,45 @@ func (h *PaymentHandler) handleSuccessfulPayment(event models.PaystackWebhookEve
 		log.Printf("❌ PAYMENT ERROR: Failed to get user details for user %s: %v", userID.String(), err)
 		return fmt.Errorf("failed to get user details: %w", err)
 	}
-
-	// Create tickets for each ticket type
-	var ticketsCreated []*models.Ticket
-	attendeeIndex := 0
-
-	// Default to primary attendee if no additional attendees data available
-	attendees := []struct {
-		FullName string
-		Email    string
-		Phone    string
-	}{{
-		FullName: event.Data.Metadata.AttendeeData.FullName,
-		Email:    event.Data.Metadata.AttendeeData.Email,
-		Phone:    event.Data.Metadata.AttendeeData.Phone,
-	}}
-
-	log.Printf("🎟️ TICKET PROCESSING: Creating tickets for %d ticket types", len(event.Data.Metadata.TicketDetails))
-	log.Printf("👥 ATTENDEE INFO: Primary attendee: %s (%s)", attendees[0].FullName, attendees[0].Email)
-
-	if len(event.Data.Metadata.TicketDetails) == 0 {
-		log.Printf("⚠️ TICKET WARNING: No ticket details found in payment metadata for reference: %s", event.Data.Reference)
-		return fmt.Errorf("no ticket details found in payment metadata")
+	log.Printf("👤 USER DETAILS: Found user %s (%s) for payment reference: %s", user.ID.String(), user.Email, event.Reference)
+
+	// Payments from before attendee lists were stored fall back to the webhook's primary attendee
+	if len(payment.Attendees) == 0 && event.Attendee.FullName != "" && event.Attendee.Email != "" {
+		payment.Attendees = []models.PaymentAttendee{{
+			FullName: event.Attendee.FullName,
+			Email:    event.Attendee.Email,
+			Phone:    event.Attendee.Phone,
+		}}
 	}
 
-	for _, ticketDetail := range event.Data.Metadata.TicketDetails {
-		ticketTypeID, err := uuid.Parse(ticketDetail.TicketTypeID)
-		if err != nil {
-			log.Printf("❌ TICKET ERROR: Invalid ticket type ID: %s for reference: %s", ticketDetail.TicketTypeID, event.Data.Reference)
-			continue
-		}
-
-		log.Printf("🎫 TICKET CREATION: Creating %d tickets for ticket type: %s (ID: %s, Price: %v)",
-			ticketDetail.Quantity,
-			ticketDetail.TicketTypeName,
-			ticketDetail.TicketTypeID,
-			ticketDetail.Price)
+	log.Printf("🎟️ TICKET PROCESSING: Creating tickets for %d line items", len(payment.LineItems))
+	log.Printf("👥 ATTENDEE INFO: %d named attendees stored with the payment", len(payment.Attendees))
 
-		for i := 0; i < ticketDetail.Quantity; i++ {
-			// Cycle through attendees if we have more tickets than attendees
-			currentAttendee := attendees[attendeeIndex%len(attendees)]
-
-			ticket := &models.Ticket{
-				EventID:          eventID,
-				UserID:           userID,
-				TicketTypeID:     ticketTypeID,
-				PaymentReference: event.Data.Reference,
-				AttendeeFullName: currentAttendee.FullName,
-				AttendeeEmail:    currentAttendee.Email,
-				AttendeePhone:    currentAttendee.Phone,
-				Quantity:         1, // Each ticket is for one person
-			}
-
-			log.Printf("🎫 TICKET CREATING: Ticket %d/%d for attendee: %s", i+1, ticketDetail.Quantity, currentAttendee.FullName)
+	// Tickets, attendees, sold quantities and the payment status commit together
+	ticketsCreated, err := h.fulfilmentService.FulfilPayment(payment)
+	if errors.Is(err, services.ErrPaymentAlreadyFulfilled) {
+		log.Printf("🔁 PAYMENT SKIPPED: Payment %s was fulfilled concurrently, not issuing tickets again", event.Reference)
+		return nil
+	}
+	if errors.Is(err, services.ErrPaymentUnfulfillable) {
+		log.Printf("🚩 PAYMENT REFUNDED: %v - payment %s refunded, no tickets issued", err, event.Reference)
+		return nil
+	}
+	if err != nil {
+		log.Printf("❌ TICKET ERROR: Fulfilment rolled back for payment reference %s: %v", event.Reference, err)
+		return fmt.Errorf("failed to fulfil payment: %w", err)
+	}
+	log.Printf("✅ PAYMENT UPDATE: Payment status updated to completed for reference: %s", event.Reference)
 
-			err = h.ticketService.CreateTicketWithQR(ticket)
-			if err != nil {
-				log.Printf("❌ TICKET ERROR: Failed to create ticket %d for type %s: %v", i+1, ticketDetail.TicketTypeName, err)
-				continue
-			}
+	log.Printf("🎉 TICKETS CREATED: Created %d tickets total for payment reference: %s", len(ticketsCreated), event.Reference)
 
-			log.Printf("✅ TICKET CREATED: Successfully created ticket %s for attendee %s", ticket.ID.String(), currentAttendee.FullName)
-			ticketsCreated = append(ticketsCreated, ticket)
-			attendeeIndex++
-		}
-
-		// Update ticket type sold quantity
-		log.Printf("📊 UPDATING SALES: Updating sold quantity for ticket type %s by %d", ticketTypeID.String(), ticketDetail.Quantity)
-		err = h.ticketService.UpdateSoldQuantity(ticketTypeID, ticketDetail.Quantity)
-		if err != nil {
-			log.Printf("❌ SALES ERROR: Failed to update sold quantity for ticket type %s: %v", ticketTypeID.String(), err)
-		} else {
-			log.Printf("✅ SALES UPDATED: Successfully updated sold quantity for ticket type %s", ticketTypeID.String())
-		}
+	// One receipt per payment, with the fee breakdown stored at checkout
+	if err := h.emailService.SendPaymentReceipt(payment, eventDetails, user); err != nil {
+		log.Printf("❌ EMAIL ERROR: Failed to send payment receipt for %s to %s: %v", event.Reference, user.Email, err)
+	} else {
+		log.Printf("✅ EMAIL SENT: Payment receipt sent for %s to %s", event.Reference, user.Email)
 	}
 
-	log.Printf("🎉 TICKETS CREATED: Created %d tickets total for payment reference: %s", len(ticketsCreated), event.Data.Reference)
-
 	// Send email notifications for each ticket created
 	log.Printf("📧 EMAIL NOTIFICATIONS: Starting email notifications for %d tickets", len(ticketsCreated))
 	for i, ticket := range ticketsCreated {
@@ -493,12 +923,8 @@ func (h *PaymentHandler) handleSuccessfulPayment(event models.PaystackWebhookEve
 		log.Printf("📧 EMAIL DETAILS: Customer=%s (UserID: %s), Attendee=%s (%s)", user.Email, user.ID.String(), ticket.AttendeeFullName, ticket.AttendeeEmail)
 		log.Printf("📧 EMAIL DETAILS: Host=%s (UserID: %s), Event=%s", host.Email, host.ID.String(), eventDetails.Title)
 
-		// Send ticket confirmation email to customer
-		log.Printf("📧 SENDING CUSTOMER EMAIL: To %s for ticket %s", ticket.AttendeeEmail, ticket.ID.String())
-		log.Printf("📧 EMAIL COMPARISON: User.Email=%s, Ticket.AttendeeEmail=%s", user.Email, ticket.AttendeeEmail)
-		if user.Email != ticket.AttendeeEmail {
-			log.Printf("⚠️ EMAIL MISMATCH: User email differs from attendee email - this could cause delivery issues")
-		}
+		// Send each attendee their own ticket and QR code
+		log.Printf("📧 SENDING ATTENDEE EMAIL: To %s for ticket %s", ticket.AttendeeEmail, ticket.ID.String())
 
 		if err := h.emailService.SendTicketConfirmation(ticket, eventDetails, user); err != nil {
 			log.Printf("❌ EMAIL ERROR: Failed to send ticket confirmation email for ticket %s to %s: %v", ticket.ID.String(), ticket.AttendeeEmail, err)
@@ -518,12 +944,20 @@ func (h *PaymentHandler) handleSuccessfulPayment(event models.PaystackWebhookEve
 		}
 	}
 
-	log.Printf("🎉 WEBHOOK SUCCESS: Webhook processing completed successfully for reference: %s - Created %d tickets", event.Data.Reference, len(ticketsCreated))
+	log.Printf("🎉 WEBHOOK SUCCESS: Webhook processing completed successfully for reference: %s - Created %d tickets", event.Reference, len(ticketsCreated))
 	return nil
 }
 
-func (h *PaymentHandler) handleFailedPayment(event models.PaystackWebhookEvent) error {
-	return h.paymentService.UpdatePaymentStatus(event.Data.Reference, models.PaymentFailed, event.Data.Message)
+func (h *PaymentHandler) handleFailedPayment(event *services.GatewayWebhookEvent) error {
+	if err := h.paymentService.UpdatePaymentStatus(event.Reference, models.PaymentFailed, event.Message); err != nil {
+		return err
+	}
+
+	// Give the held tickets and any promo code use back
+	if err := h.reservationService.ReleaseHold(event.Reference); err != nil {
+		return err
+	}
+	return h.promoService.ReleaseRedemption(event.Reference)
 }
 
 // POST /api/v1/payments/simulate-success - For testing without webhooks
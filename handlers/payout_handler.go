@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+	"gorm.io/gorm"
+)
+
+// PayoutHandler handles host payout accounts and admin settlement
+type PayoutHandler struct {
+	payoutService services.PayoutService
+}
+
+func NewPayoutHandler(payoutService services.PayoutService) *PayoutHandler {
+	return &PayoutHandler{
+		payoutService: payoutService,
+	}
+}
+
+// GET /api/v1/hosts/me/bank-account
+func (h *PayoutHandler) GetBankAccount(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	account, err := h.payoutService.GetBankAccount(hostID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No bank account set up for payouts"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get bank account"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": account,
+	})
+}
+
+// PUT /api/v1/hosts/me/bank-account
+func (h *PayoutHandler) SaveBankAccount(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	var req models.BankAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	account, err := h.payoutService.SaveBankAccount(hostID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrBankAccountInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save bank account"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": account,
+	})
+}
+
+// POST /api/v1/admin/payouts/run
+func (h *PayoutHandler) RunSettlement(c *fiber.Ctx) error {
+	sent, err := h.payoutService.RunSettlement()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to run settlement"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{"payouts_sent": sent},
+	})
+}
+
+// POST /api/v1/admin/events/:id/settle
+func (h *PayoutHandler) SettleEvent(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	payout, err := h.payoutService.SettleEvent(eventID)
+	if payout == nil {
+		switch {
+		case errors.Is(err, services.ErrNothingToSettle):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrEventNotEnded), errors.Is(err, services.ErrNoBankAccount), errors.Is(err, services.ErrPayoutCurrency):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to settle event"})
+	}
+
+	// The payout was recorded but the gateway refused the transfer
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": err.Error(),
+			"data":  payout,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": payout,
+	})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// PromoHandler handles hosts managing promo codes for their events
+type PromoHandler struct {
+	promoService     services.PromoService
+	eventService     services.EventService
+	analyticsService services.AnalyticsService
+}
+
+func NewPromoHandler(promoService services.PromoService, eventService services.EventService, analyticsService services.AnalyticsService) *PromoHandler {
+	return &PromoHandler{
+		promoService:     promoService,
+		eventService:     eventService,
+		analyticsService: analyticsService,
+	}
+}
+
+// loadHostPromoCode loads the promo code in the :id param and checks the caller owns it.
+func (h *PromoHandler) loadHostPromoCode(c *fiber.Ctx) (*models.PromoCode, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	promoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid promo code ID"})
+	}
+
+	promo, err := h.promoService.GetPromoCode(promoID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Promo code not found"})
+	}
+	if promo.HostID != hostID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this promo code"})
+	}
+	return promo, nil
+}
+
+// applyPromoCodeRequest copies the fields set on req onto promo. Ticket types
+// must belong to the event.
+func applyPromoCodeRequest(promo *models.PromoCode, req *models.PromoCodeRequest, event *models.Event) error {
+	if req.Code != "" {
+		promo.Code = req.Code
+	}
+	if req.Description != nil {
+		promo.Description = *req.Description
+	}
+	if req.DiscountType != "" {
+		promo.DiscountType = models.DiscountType(req.DiscountType)
+	}
+	if req.DiscountValue != nil {
+		promo.DiscountValue = *req.DiscountValue
+	}
+	// A cap of 0 lifts it, since an omitted field leaves it unchanged
+	if req.MaxUses != nil {
+		promo.MaxUses = req.MaxUses
+		if *req.MaxUses == 0 {
+			promo.MaxUses = nil
+		}
+	}
+	if req.MaxUsesPerUser != nil {
+		promo.MaxUsesPerUser = req.MaxUsesPerUser
+		if *req.MaxUsesPerUser == 0 {
+			promo.MaxUsesPerUser = nil
+		}
+	}
+	if req.StartsAt != nil {
+		promo.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		promo.EndsAt = req.EndsAt
+	}
+	if req.Active != nil {
+		promo.Active = *req.Active
+	}
+
+	if req.TicketTypeIDs != nil {
+		eventTicketTypes := make(map[uuid.UUID]bool, len(event.TicketTypes))
+		for _, tt := range event.TicketTypes {
+			eventTicketTypes[tt.ID] = true
+		}
+
+		ticketTypeIDs := make([]string, 0, len(req.TicketTypeIDs))
+		for _, raw := range req.TicketTypeIDs {
+			ticketTypeID, err := uuid.Parse(raw)
+			if err != nil || !eventTicketTypes[ticketTypeID] {
+				return fmt.Errorf("ticket type %s does not belong to this event", raw)
+			}
+			ticketTypeIDs = append(ticketTypeIDs, ticketTypeID.String())
+		}
+		promo.TicketTypeIDs = ticketTypeIDs
+	}
+	return nil
+}
+
+// GET /api/v1/hosts/me/events/:eventId/promo-codes
+func (h *PromoHandler) GetEventPromoCodes(c *fiber.Ctx) error {
+	event, err := loadHostEvent(c, h.eventService, "You are not authorized to manage promo codes for this event")
+	if event == nil {
+		return err
+	}
+
+	promos, err := h.promoService.GetEventPromoCodes(event.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get promo codes"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": promos,
+	})
+}
+
+// POST /api/v1/hosts/me/events/:eventId/promo-codes
+func (h *PromoHandler) CreatePromoCode(c *fiber.Ctx) error {
+	event, err := loadHostEvent(c, h.eventService, "You are not authorized to manage promo codes for this event")
+	if event == nil {
+		return err
+	}
+
+	var req models.PromoCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	promo := &models.PromoCode{
+		EventID: event.ID,
+		HostID:  event.HostID,
+		Active:  true,
+	}
+	if err := applyPromoCodeRequest(promo, &req, event); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.promoService.CreatePromoCode(promo); err != nil {
+		if errors.Is(err, services.ErrPromoCodeSetup) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create promo code"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": promo,
+	})
+}
+
+// PUT /api/v1/hosts/me/promo-codes/:id
+func (h *PromoHandler) UpdatePromoCode(c *fiber.Ctx) error {
+	promo, err := h.loadHostPromoCode(c)
+	if promo == nil {
+		return err
+	}
+
+	var req models.PromoCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	event, err := h.eventService.GetEventByID(promo.EventID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if err := applyPromoCodeRequest(promo, &req, event); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.promoService.UpdatePromoCode(promo); err != nil {
+		if errors.Is(err, services.ErrPromoCodeSetup) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update promo code"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": promo,
+	})
+}
+
+// DELETE /api/v1/hosts/me/promo-codes/:id
+func (h *PromoHandler) DeletePromoCode(c *fiber.Ctx) error {
+	promo, err := h.loadHostPromoCode(c)
+	if promo == nil {
+		return err
+	}
+
+	if err := h.promoService.DeletePromoCode(promo.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete promo code"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Promo code deleted successfully"})
+}
+
+// GET /api/v1/hosts/me/events/:eventId/promo-codes/stats
+func (h *PromoHandler) GetPromoCodeStats(c *fiber.Ctx) error {
+	event, err := loadHostEvent(c, h.eventService, "You are not authorized to manage promo codes for this event")
+	if event == nil {
+		return err
+	}
+
+	stats, err := h.analyticsService.GetPromoCodeStats(event.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get promo code stats"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": stats,
+	})
+}
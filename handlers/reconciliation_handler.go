@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// ReconciliationHandler handles admin payment reconciliation
+type ReconciliationHandler struct {
+	reconciliationService services.ReconciliationService
+}
+
+func NewReconciliationHandler(reconciliationService services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// POST /api/v1/admin/reconciliation/run
+func (h *ReconciliationHandler) RunReconciliation(c *fiber.Ctx) error {
+	summary, err := h.reconciliationService.Reconcile()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to run reconciliation"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": summary,
+	})
+}
+
+// GET /api/v1/admin/reconciliation/report
+func (h *ReconciliationHandler) GetReport(c *fiber.Ctx) error {
+	days, _ := strconv.Atoi(c.Query("days", "7"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if days < 1 {
+		days = 7
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	summary, discrepancies, total, err := h.reconciliationService.GetReport(since, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get reconciliation report"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"since":         since,
+			"summary":       summary,
+			"discrepancies": discrepancies,
+		},
+		"meta": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// RefundHandler handles refund requests from hosts and admins
+type RefundHandler struct {
+	refundService  services.RefundService
+	paymentService services.PaymentService
+}
+
+func NewRefundHandler(refundService services.RefundService, paymentService services.PaymentService) *RefundHandler {
+	return &RefundHandler{
+		refundService:  refundService,
+		paymentService: paymentService,
+	}
+}
+
+// loadRefundablePayment loads the payment in the :id param and checks the
+// caller may refund it: admins may refund any payment, hosts only payments
+// for their own events.
+func (h *RefundHandler) loadRefundablePayment(c *fiber.Ctx) (*models.Payment, uuid.UUID, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, uuid.Nil, c.Status(500).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	paymentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, uuid.Nil, c.Status(400).JSON(fiber.Map{"error": "Invalid payment ID"})
+	}
+
+	payment, err := h.paymentService.GetPaymentByID(paymentID)
+	if err != nil {
+		return nil, uuid.Nil, c.Status(404).JSON(fiber.Map{"error": "Payment not found"})
+	}
+
+	role, _ := claims["role"].(string)
+	if models.UserRole(role) != models.AdminRole && payment.Event.HostID != userID {
+		return nil, uuid.Nil, c.Status(403).JSON(fiber.Map{"error": "You can only refund payments for your own events"})
+	}
+
+	return payment, userID, nil
+}
+
+// POST /api/v1/hosts/me/payments/:id/refunds
+// POST /api/v1/admin/payments/:id/refunds
+func (h *RefundHandler) CreateRefund(c *fiber.Ctx) error {
+	payment, userID, err := h.loadRefundablePayment(c)
+	if payment == nil {
+		return err
+	}
+
+	var req models.CreateRefundRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Amount < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Refund amount cannot be negative"})
+	}
+
+	ticketIDs := make([]uuid.UUID, 0, len(req.TicketIDs))
+	for _, raw := range req.TicketIDs {
+		ticketID, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid ticket ID: " + raw})
+		}
+		ticketIDs = append(ticketIDs, ticketID)
+	}
+
+	refund, err := h.refundService.InitiateRefund(payment.ID, userID, req.Amount, ticketIDs, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPaymentNotRefundable),
+			errors.Is(err, services.ErrRefundExceedsBalance),
+			errors.Is(err, services.ErrRefundInvalidTickets),
+			errors.Is(err, services.ErrRefundInvalidAmount),
+			errors.Is(err, services.ErrRefundBoxOffice):
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("❌ REFUND ERROR: Failed to refund payment %s: %v", payment.Reference, err)
+		return c.Status(502).JSON(fiber.Map{"error": "Failed to initiate refund"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Refund initiated",
+		"data":    refund,
+	})
+}
+
+// GET /api/v1/hosts/me/payments/:id/refunds
+// GET /api/v1/admin/payments/:id/refunds
+func (h *RefundHandler) GetPaymentRefunds(c *fiber.Ctx) error {
+	payment, _, err := h.loadRefundablePayment(c)
+	if payment == nil {
+		return err
+	}
+
+	refunds, err := h.refundService.GetPaymentRefunds(payment.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get refunds"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": refunds,
+	})
+}
+
+// GET /api/v1/hosts/me/refunds
+func (h *RefundHandler) GetHostRefunds(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	refunds, err := h.refundService.GetHostRefunds(hostID, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get refunds"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": refunds,
+	})
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// StaffHandler handles hosts inviting door staff to their events, and staff
+// answering those invites
+type StaffHandler struct {
+	staffService services.StaffService
+	eventService services.EventService
+	userService  services.UserService
+}
+
+func NewStaffHandler(staffService services.StaffService, eventService services.EventService, userService services.UserService) *StaffHandler {
+	return &StaffHandler{
+		staffService: staffService,
+		eventService: eventService,
+		userService:  userService,
+	}
+}
+
+// loadCaller loads the signed-in user.
+func (h *StaffHandler) loadCaller(c *fiber.Ctx) (*models.User, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	caller, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	return caller, nil
+}
+
+// authorizeManager checks the caller may manage event's staff.
+func (h *StaffHandler) authorizeManager(c *fiber.Ctx, event *models.Event, caller *models.User) (bool, error) {
+	allowed, err := h.staffService.Authorize(event, caller.ID, services.PermManageStaff)
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check permissions"})
+	}
+	if !allowed {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage staff for this event"})
+	}
+	return true, nil
+}
+
+// loadManagedEvent loads the event in the :eventId param and checks the
+// caller may manage its staff.
+func (h *StaffHandler) loadManagedEvent(c *fiber.Ctx, caller *models.User) (*models.Event, error) {
+	eventID, err := uuid.Parse(c.Params("eventId"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+	event, err := h.eventService.GetEventByID(eventID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if allowed, err := h.authorizeManager(c, event, caller); !allowed {
+		return nil, err
+	}
+	return event, nil
+}
+
+// loadManagedStaff loads the staff member in the :id param and their event,
+// and checks the caller may manage the event's staff.
+func (h *StaffHandler) loadManagedStaff(c *fiber.Ctx, caller *models.User) (*models.EventStaff, *models.Event, error) {
+	staffID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid staff ID"})
+	}
+	staff, err := h.staffService.GetStaffMember(staffID)
+	if err != nil {
+		return nil, nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Staff member not found"})
+	}
+	event, err := h.eventService.GetEventByID(staff.EventID)
+	if err != nil {
+		return nil, nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if allowed, err := h.authorizeManager(c, event, caller); !allowed {
+		return nil, nil, err
+	}
+	return staff, event, nil
+}
+
+// staffError maps staff service errors to responses.
+func staffError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrStaffInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrStaffNotAllowed):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrStaffNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrStaffExists), errors.Is(err, services.ErrStaffNotPending), errors.Is(err, services.ErrStaffNotEditable):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
+
+// GET /api/v1/staff/events/:eventId/members
+func (h *StaffHandler) GetEventStaff(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+	event, err := h.loadManagedEvent(c, caller)
+	if event == nil {
+		return err
+	}
+
+	staff, err := h.staffService.GetEventStaff(event.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get event staff"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": staff,
+	})
+}
+
+// POST /api/v1/staff/events/:eventId/members
+func (h *StaffHandler) InviteStaff(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+	event, err := h.loadManagedEvent(c, caller)
+	if event == nil {
+		return err
+	}
+
+	var req models.InviteStaffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !isValidEmail(strings.TrimSpace(req.Email)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A valid email is required"})
+	}
+
+	staff, err := h.staffService.InviteStaff(event, caller, req.Email, models.StaffRole(req.Role))
+	if err != nil {
+		return staffError(c, err, "Failed to invite staff")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": staff,
+	})
+}
+
+// PUT /api/v1/staff/members/:id
+func (h *StaffHandler) UpdateStaffRole(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+	staff, event, err := h.loadManagedStaff(c, caller)
+	if staff == nil {
+		return err
+	}
+
+	var req models.StaffRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.staffService.UpdateRole(event, caller.ID, staff, models.StaffRole(req.Role)); err != nil {
+		return staffError(c, err, "Failed to update staff role")
+	}
+
+	return c.JSON(fiber.Map{
+		"data": staff,
+	})
+}
+
+// DELETE /api/v1/staff/members/:id
+func (h *StaffHandler) RemoveStaff(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+	staff, event, err := h.loadManagedStaff(c, caller)
+	if staff == nil {
+		return err
+	}
+
+	if err := h.staffService.RemoveStaff(event, caller.ID, staff); err != nil {
+		return staffError(c, err, "Failed to remove staff member")
+	}
+
+	return c.JSON(fiber.Map{"message": "Staff member removed successfully"})
+}
+
+// GET /api/v1/users/me/staff-invites
+func (h *StaffHandler) GetMyInvites(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+
+	invites, err := h.staffService.GetInvites(caller)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get staff invites"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": invites,
+	})
+}
+
+// POST /api/v1/users/me/staff-invites/:token/accept
+func (h *StaffHandler) AcceptInvite(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+
+	staff, err := h.staffService.AcceptInvite(c.Params("token"), caller)
+	if err != nil {
+		return staffError(c, err, "Failed to accept staff invite")
+	}
+
+	return c.JSON(fiber.Map{
+		"data": staff,
+	})
+}
+
+// POST /api/v1/users/me/staff-invites/:token/decline
+func (h *StaffHandler) DeclineInvite(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+
+	if err := h.staffService.DeclineInvite(c.Params("token"), caller); err != nil {
+		return staffError(c, err, "Failed to decline staff invite")
+	}
+
+	return c.JSON(fiber.Map{"message": "Staff invite declined"})
+}
+
+// GET /api/v1/users/me/staff-events
+func (h *StaffHandler) GetMyStaffedEvents(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	staff, err := h.staffService.GetStaffedEvents(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get staffed events"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": staff,
+	})
+}
@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
@@ -12,18 +16,24 @@ import (
 
 // TicketHandler handles ticket-related requests
 type TicketHandler struct {
-	ticketService services.TicketService
-	eventService  services.EventService
-	userService   services.UserService
-	emailService  services.EmailService
+	ticketService     services.TicketService
+	eventService      services.EventService
+	userService       services.UserService
+	emailService      services.EmailService
+	fulfilmentService services.FulfilmentService
+	accessCodeService services.AccessCodeService
+	waitlistService   services.WaitlistService
 }
 
-func NewTicketHandler(ticketService services.TicketService, eventService services.EventService, userService services.UserService, emailService services.EmailService) *TicketHandler {
+func NewTicketHandler(ticketService services.TicketService, eventService services.EventService, userService services.UserService, emailService services.EmailService, fulfilmentService services.FulfilmentService, accessCodeService services.AccessCodeService, waitlistService services.WaitlistService) *TicketHandler {
 	return &TicketHandler{
-		ticketService: ticketService,
-		eventService:  eventService,
-		userService:   userService,
-		emailService:  emailService,
+		ticketService:     ticketService,
+		eventService:      eventService,
+		userService:       userService,
+		emailService:      emailService,
+		fulfilmentService: fulfilmentService,
+		accessCodeService: accessCodeService,
+		waitlistService:   waitlistService,
 	}
 }
 
@@ -98,6 +108,7 @@ func (h *TicketHandler) RSVPFreeEvent(c *fiber.Ctx) error {
 		AttendeeFullName string `json:"attendeeFullName"`
 		AttendeeEmail    string `json:"attendeeEmail"`
 		AttendeePhone    string `json:"attendeePhone"`
+		AccessCode       string `json:"accessCode"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
@@ -155,33 +166,103 @@ func (h *TicketHandler) RSVPFreeEvent(c *fiber.Ctx) error {
 		}
 	}
 
-	// Find the free ticket type (price = 0)
+	// Find the free ticket type (price = 0) that's on sale now
+	tiers := make([]models.TicketType, len(ticketTypes))
+	for i, tt := range ticketTypes {
+		tiers[i] = *tt
+	}
+	services.SetSaleStates(tiers, nil, time.Now())
+
+	// Hidden free tiers, like a guest list, need a code that unlocks them,
+	// and are used ahead of public ones when it does. A tier the user has a
+	// waitlist offer on is used ahead of both, hidden or not.
+	access, err := h.accessCodeService.Unlock(eventID, request.AccessCode)
+	if err != nil {
+		log.Printf("❌ FREE RSVP ERROR: Access code %q rejected for event %s", request.AccessCode, eventID.String())
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	offers, err := h.waitlistService.GetUserOffers(userID, eventID)
+	if err != nil {
+		log.Printf("❌ FREE RSVP ERROR: Failed to load waitlist offers for user %s: %v", userID.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create RSVP"})
+	}
+	visible := make([]models.TicketType, 0, len(tiers))
+	for i := range tiers {
+		if offers[tiers[i].ID] != nil || services.TicketTypeVisible(&tiers[i], access) {
+			visible = append(visible, tiers[i])
+		}
+	}
+	tiers = visible
+	rank := func(tt *models.TicketType) int {
+		switch {
+		case offers[tt.ID] != nil:
+			return 0
+		case tt.Visibility == models.TicketHidden:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return rank(&tiers[i]) < rank(&tiers[j])
+	})
+
 	var freeTicketType *models.TicketType
-	for _, tt := range ticketTypes {
-		if tt.Price == 0 {
-			freeTicketType = tt
-			log.Printf("🆓 FREE TICKET TYPE FOUND: %s (ID: %s, Available: %d)", tt.Name, tt.ID.String(), tt.TotalQuantity-tt.SoldQuantity)
-			break
+	var closedState models.TicketSaleState
+	for i := range tiers {
+		tt := &tiers[i]
+		if tt.Price != 0 {
+			continue
 		}
+		if tt.SaleState != models.TicketOnSale {
+			closedState = tt.SaleState
+			continue
+		}
+		freeTicketType = tt
+		log.Printf("🆓 FREE TICKET TYPE FOUND: %s (ID: %s, Available: %d)", tt.Name, tt.ID.String(), tt.TotalQuantity-tt.SoldQuantity)
+		break
 	}
 
+	if freeTicketType == nil && closedState == models.TicketSoldOut {
+		log.Printf("❌ FREE RSVP ERROR: Event %s is full", eventID.String())
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This event is fully booked"})
+	}
+	if freeTicketType == nil && closedState != "" {
+		log.Printf("❌ FREE RSVP ERROR: Free tickets for event %s are not on sale (%s)", eventID.String(), closedState)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":      "RSVPs are not open for this event",
+			"sale_state": closedState,
+		})
+	}
 	if freeTicketType == nil {
 		log.Printf("❌ FREE RSVP ERROR: No free ticket type found for event %s", eventID.String())
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No free ticket type found for this event"})
 	}
 
 	// Check if user already has a ticket for this event
-	existingTickets, err := h.ticketService.GetTicketsByUserID(userID)
-	if err == nil {
-		for _, ticket := range existingTickets {
-			if ticket.EventID == eventID {
-				log.Printf("❌ FREE RSVP ERROR: User %s already has a ticket for event %s", userID.String(), eventID.String())
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "You already have a ticket for this event"})
-			}
-		}
+	existingTickets, err := h.ticketService.CountUserEventTickets(userID, eventID)
+	if err != nil {
+		log.Printf("❌ FREE RSVP ERROR: Failed to count tickets for user %s: %v", userID.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create RSVP"})
+	}
+	if existingTickets > 0 {
+		log.Printf("❌ FREE RSVP ERROR: User %s already has a ticket for event %s", userID.String(), eventID.String())
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "You already have a ticket for this event"})
 	}
 	log.Printf("✅ USER VERIFICATION: User %s doesn't have existing ticket for event %s", userID.String(), eventID.String())
 
+	attendee := models.PaymentAttendee{
+		FullName: request.AttendeeFullName,
+		Email:    request.AttendeeEmail,
+		Phone:    request.AttendeePhone,
+	}
+	if err := h.ticketService.CheckPurchaseLimits(freeTicketType, 1, []models.PaymentAttendee{attendee}); err != nil {
+		log.Printf("❌ FREE RSVP ERROR: %v", err)
+		if errors.Is(err, services.ErrPurchaseLimit) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create RSVP"})
+	}
+
 	// Create the free ticket
 	ticket := &models.Ticket{
 		EventID:          eventID,
@@ -196,21 +277,22 @@ func (h *TicketHandler) RSVPFreeEvent(c *fiber.Ctx) error {
 
 	log.Printf("🎫 CREATING FREE TICKET: Creating ticket for attendee %s", request.AttendeeFullName)
 
-	if err := h.ticketService.CreateTicketWithQR(ticket); err != nil {
+	// The ticket, attendee record and sold quantity commit together
+	if err := h.fulfilmentService.IssueFreeTicket(ticket); err != nil {
+		if errors.Is(err, services.ErrTicketsUnavailable) {
+			log.Printf("❌ FREE RSVP ERROR: Event %s is full: %v", eventID.String(), err)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This event is fully booked"})
+		}
+		if errors.Is(err, services.ErrPurchaseLimit) {
+			log.Printf("❌ FREE RSVP ERROR: %v", err)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		log.Printf("❌ FREE RSVP ERROR: Failed to create RSVP ticket: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create RSVP"})
 	}
 
 	log.Printf("✅ FREE TICKET CREATED: Successfully created free ticket %s for user %s", ticket.ID.String(), userID.String())
 
-	// Update sold quantity for the ticket type
-	if err := h.ticketService.UpdateSoldQuantity(freeTicketType.ID, 1); err != nil {
-		log.Printf("⚠️ FREE RSVP WARNING: Failed to update sold quantity: %v", err)
-		// Log the error but don't fail the request
-	} else {
-		log.Printf("📊 SALES UPDATED: Updated sold quantity for free ticket type %s", freeTicketType.ID.String())
-	}
-
 	// Get event details and host for email notifications
 	eventDetails, err := h.eventService.GetEventByID(eventID)
 	if err == nil {
@@ -253,3 +335,275 @@ func (h *TicketHandler) RSVPFreeEvent(c *fiber.Ctx) error {
 		"ticket":  ticket,
 	})
 }
+
+// PUT /api/v1/users/me/tickets/:id/attendee
+// AssignTicketAttendee lets a buyer name who an unnamed ticket is for, and
+// sends the ticket and its QR code to them.
+func (h *TicketHandler) AssignTicketAttendee(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	ticketID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ticket ID"})
+	}
+
+	var req models.AttendeeDataRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.FullName = strings.TrimSpace(req.FullName)
+	req.Email = strings.TrimSpace(req.Email)
+	req.Phone = strings.TrimSpace(req.Phone)
+	if req.FullName == "" || !isValidEmail(req.Email) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Attendee full name and a valid email are required"})
+	}
+
+	ticket, err := h.ticketService.GetTicketByID(ticketID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket not found"})
+	}
+	if ticket.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+	}
+
+	ticket, err = h.ticketService.AssignAttendee(ticketID, &req)
+	if errors.Is(err, services.ErrTicketAttendeeNamed) || errors.Is(err, services.ErrTicketCancelled) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		log.Printf("❌ TICKET ERROR: Failed to assign attendee to ticket %s: %v", ticketID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update ticket"})
+	}
+	log.Printf("👥 ATTENDEE ASSIGNED: Ticket %s is now for %s (%s)", ticket.ID, ticket.AttendeeFullName, ticket.AttendeeEmail)
+
+	buyer, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		log.Printf("⚠️ EMAIL WARNING: Failed to load buyer %s for ticket %s: %v", userID, ticket.ID, err)
+	} else if err := h.emailService.SendTicketConfirmation(ticket, &ticket.Event, buyer); err != nil {
+		log.Printf("❌ EMAIL ERROR: Failed to send ticket confirmation for ticket %s to %s: %v", ticket.ID, ticket.AttendeeEmail, err)
+	}
+
+	return c.JSON(ticket)
+}
+
+// hostTicketType loads the :id ticket type, checking the caller hosts its event.
+func (h *TicketHandler) hostTicketType(c *fiber.Ctx) (*models.TicketType, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	ticketTypeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ticket type ID"})
+	}
+
+	ticketType, err := h.ticketService.GetTicketTypeByID(ticketTypeID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket type not found"})
+	}
+	event, err := h.eventService.GetEventByID(ticketType.EventID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if event.HostID != hostID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this ticket type"})
+	}
+	return ticketType, nil
+}
+
+// PUT /api/v1/hosts/me/ticket-types/:id/limits
+func (h *TicketHandler) UpdateTicketTypeLimits(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	var req models.TicketTypeLimitsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.ticketService.UpdateTicketTypeLimits(ticketType, &req); err != nil {
+		if errors.Is(err, services.ErrTicketTypeLimitsInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update purchase limits"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": ticketType,
+	})
+}
+
+// PUT /api/v1/hosts/me/ticket-types/:id/visibility
+func (h *TicketHandler) UpdateTicketTypeVisibility(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	var req models.TicketTypeVisibilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.ticketService.UpdateTicketTypeVisibility(ticketType, req.Visibility); err != nil {
+		if errors.Is(err, services.ErrTicketVisibilityInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update visibility"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": ticketType,
+	})
+}
+
+// PUT /api/v1/hosts/me/ticket-types/:id/entry
+func (h *TicketHandler) UpdateTicketTypeEntry(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	var req models.TicketTypeEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.ticketService.UpdateTicketTypeEntry(ticketType, &req); err != nil {
+		if errors.Is(err, services.ErrTicketEntryInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update entry rules"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": ticketType,
+	})
+}
+
+// PUT /api/v1/hosts/me/ticket-types/:id/capacity
+func (h *TicketHandler) UpdateTicketTypeCapacity(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	var req models.TicketTypeCapacityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.ticketService.UpdateTicketTypeCapacity(ticketType, req.TotalQuantity); err != nil {
+		if errors.Is(err, services.ErrTicketCapacityInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update capacity"})
+	}
+
+	// Extra capacity goes to the waitlist first
+	if _, err := h.waitlistService.OfferFreedTickets(ticketType.ID); err != nil {
+		log.Printf("⚠️ WAITLIST WARNING: Failed to offer new capacity on %s: %v", ticketType.ID, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": ticketType,
+	})
+}
+
+// PUT /api/v1/hosts/me/ticket-types/:id/sales
+func (h *TicketHandler) UpdateTicketTypeSales(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	var req models.TicketTypeSalesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.ticketService.UpdateTicketTypeSales(ticketType, &req); err != nil {
+		if errors.Is(err, services.ErrTicketSalesInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update sale window"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": ticketType,
+	})
+}
+
+// GET /api/v1/hosts/me/ticket-types/:id/price-changes
+func (h *TicketHandler) GetPriceChanges(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	changes, err := h.ticketService.GetPriceChanges(ticketType.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get price changes"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": changes,
+	})
+}
+
+// POST /api/v1/hosts/me/ticket-types/:id/price-changes
+func (h *TicketHandler) SchedulePriceChange(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	var req models.TicketPriceChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	change, err := h.ticketService.SchedulePriceChange(ticketType, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrPriceChangeInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to schedule price change"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": change,
+	})
+}
+
+// DELETE /api/v1/hosts/me/ticket-types/:id/price-changes/:changeId
+func (h *TicketHandler) CancelPriceChange(c *fiber.Ctx) error {
+	ticketType, err := h.hostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	changeID, err := uuid.Parse(c.Params("changeId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid price change ID"})
+	}
+
+	if err := h.ticketService.CancelPriceChange(ticketType.ID, changeID); err != nil {
+		if errors.Is(err, services.ErrPriceChangeNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to cancel price change"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// TicketTransferHandler handles attendees passing their tickets on to
+// someone else, and recipients accepting them
+type TicketTransferHandler struct {
+	transferService services.TicketTransferService
+	ticketService   services.TicketService
+	userService     services.UserService
+	emailService    services.EmailService
+}
+
+func NewTicketTransferHandler(transferService services.TicketTransferService, ticketService services.TicketService, userService services.UserService, emailService services.EmailService) *TicketTransferHandler {
+	return &TicketTransferHandler{
+		transferService: transferService,
+		ticketService:   ticketService,
+		userService:     userService,
+		emailService:    emailService,
+	}
+}
+
+// loadCaller loads the signed-in user.
+func (h *TicketTransferHandler) loadCaller(c *fiber.Ctx) (*models.User, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	caller, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+	return caller, nil
+}
+
+// loadOwnTicket loads the ticket in the :id param and checks the caller holds it.
+func (h *TicketTransferHandler) loadOwnTicket(c *fiber.Ctx, caller *models.User) (*models.Ticket, error) {
+	ticketID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ticket ID"})
+	}
+
+	ticket, err := h.ticketService.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket not found"})
+	}
+	if ticket.UserID != caller.ID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+	}
+	return ticket, nil
+}
+
+// transferError maps a transfer service error to a response.
+func transferError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrTransferInvalid), errors.Is(err, services.ErrPurchaseLimit):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTransferNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTransferNotAllowed), errors.Is(err, services.ErrTicketCancelled),
+		errors.Is(err, services.ErrTransferPending), errors.Is(err, services.ErrTransferNotPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Printf("❌ TRANSFER ERROR: %s: %v", fallback, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
+
+// POST /api/v1/users/me/tickets/:id/transfer
+func (h *TicketTransferHandler) CreateTransfer(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+	ticket, err := h.loadOwnTicket(c, caller)
+	if ticket == nil {
+		return err
+	}
+
+	var req models.TicketTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !isValidEmail(req.Email) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A valid recipient email is required"})
+	}
+
+	transfer, err := h.transferService.CreateTransfer(ticket, caller, req.Email)
+	if err != nil {
+		return transferError(c, err, "Failed to create transfer")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": transfer,
+	})
+}
+
+// DELETE /api/v1/users/me/tickets/:id/transfer
+func (h *TicketTransferHandler) CancelTransfer(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+	ticket, err := h.loadOwnTicket(c, caller)
+	if ticket == nil {
+		return err
+	}
+
+	if err := h.transferService.CancelTransfer(ticket.ID); err != nil {
+		return transferError(c, err, "Failed to cancel transfer")
+	}
+
+	return c.JSON(fiber.Map{"message": "Transfer cancelled successfully"})
+}
+
+// GET /api/v1/users/me/tickets/:id/transfers
+func (h *TicketTransferHandler) GetTicketTransfers(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+	ticket, err := h.loadOwnTicket(c, caller)
+	if ticket == nil {
+		return err
+	}
+
+	transfers, err := h.transferService.GetTicketTransfers(ticket.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get transfers"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": transfers,
+	})
+}
+
+// GET /api/v1/users/me/transfers
+func (h *TicketTransferHandler) GetIncomingTransfers(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+
+	transfers, err := h.transferService.GetIncomingTransfers(caller)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get transfers"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": transfers,
+	})
+}
+
+// POST /api/v1/users/me/transfers/:token/accept
+func (h *TicketTransferHandler) AcceptTransfer(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+
+	var req models.AcceptTicketTransferRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	if req.Attendee != nil {
+		req.Attendee.FullName = strings.TrimSpace(req.Attendee.FullName)
+		req.Attendee.Email = strings.TrimSpace(req.Attendee.Email)
+		req.Attendee.Phone = strings.TrimSpace(req.Attendee.Phone)
+		if req.Attendee.FullName == "" || !isValidEmail(req.Attendee.Email) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Attendee full name and a valid email are required"})
+		}
+	}
+
+	ticket, err := h.transferService.AcceptTransfer(c.Params("token"), caller, req.Attendee)
+	if err != nil {
+		return transferError(c, err, "Failed to accept transfer")
+	}
+
+	if err := h.emailService.SendTicketConfirmation(ticket, &ticket.Event, caller); err != nil {
+		log.Printf("❌ EMAIL ERROR: Failed to send ticket confirmation for ticket %s to %s: %v", ticket.ID, ticket.AttendeeEmail, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": ticket,
+	})
+}
+
+// POST /api/v1/users/me/transfers/:token/decline
+func (h *TicketTransferHandler) DeclineTransfer(c *fiber.Ctx) error {
+	caller, err := h.loadCaller(c)
+	if caller == nil {
+		return err
+	}
+
+	if err := h.transferService.DeclineTransfer(c.Params("token"), caller); err != nil {
+		return transferError(c, err, "Failed to decline transfer")
+	}
+
+	return c.JSON(fiber.Map{"message": "Transfer declined"})
+}
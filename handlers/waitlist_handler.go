@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/services"
+)
+
+// WaitlistHandler handles buyers queueing for sold-out ticket types and
+// hosts managing those queues
+type WaitlistHandler struct {
+	waitlistService    services.WaitlistService
+	ticketService      services.TicketService
+	eventService       services.EventService
+	reservationService services.ReservationService
+	accessCodeService  services.AccessCodeService
+}
+
+func NewWaitlistHandler(waitlistService services.WaitlistService, ticketService services.TicketService, eventService services.EventService, reservationService services.ReservationService, accessCodeService services.AccessCodeService) *WaitlistHandler {
+	return &WaitlistHandler{
+		waitlistService:    waitlistService,
+		ticketService:      ticketService,
+		eventService:       eventService,
+		reservationService: reservationService,
+		accessCodeService:  accessCodeService,
+	}
+}
+
+// loadHostTicketType loads the ticket type in the :id param and checks the caller hosts its event.
+func (h *WaitlistHandler) loadHostTicketType(c *fiber.Ctx) (*models.TicketType, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	ticketTypeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ticket type ID"})
+	}
+
+	ticketType, err := h.ticketService.GetTicketTypeByID(ticketTypeID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket type not found"})
+	}
+	event, err := h.eventService.GetEventByID(ticketType.EventID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if event.HostID != hostID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this waitlist"})
+	}
+	return ticketType, nil
+}
+
+// loadHostEntry loads the waitlist entry in the :id param and checks the caller hosts its event.
+func (h *WaitlistHandler) loadHostEntry(c *fiber.Ctx) (*models.WaitlistEntry, error) {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	hostID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	entryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid waitlist entry ID"})
+	}
+
+	entry, err := h.waitlistService.GetEntry(entryID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Waitlist entry not found"})
+	}
+	event, err := h.eventService.GetEventByID(entry.EventID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if event.HostID != hostID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to manage this waitlist"})
+	}
+	return entry, nil
+}
+
+// POST /api/v1/tickets/waitlist
+func (h *WaitlistHandler) JoinWaitlist(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	var req models.JoinWaitlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	ticketTypeID, err := uuid.Parse(req.TicketTypeID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ticket type ID"})
+	}
+	ticketType, err := h.ticketService.GetTicketTypeByID(ticketTypeID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket type not found"})
+	}
+	event, err := h.eventService.GetEventByID(ticketType.EventID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	access, err := h.accessCodeService.Unlock(event.ID, req.AccessCode)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !services.TicketTypeVisible(ticketType, access) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Ticket type not found"})
+	}
+
+	// Only sold-out ticket types have a waitlist; anything else is bought directly
+	eventTypeIDs := make([]uuid.UUID, 0, len(event.TicketTypes))
+	for _, tt := range event.TicketTypes {
+		eventTypeIDs = append(eventTypeIDs, tt.ID)
+	}
+	held, err := h.reservationService.GetHeldQuantities(eventTypeIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check ticket availability"})
+	}
+	services.SetSaleStates(event.TicketTypes, held, time.Now())
+	for _, tt := range event.TicketTypes {
+		if tt.ID == ticketType.ID && tt.SaleState != models.TicketSoldOut {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":      fmt.Sprintf("%s is not sold out", ticketType.Name),
+				"sale_state": tt.SaleState,
+			})
+		}
+	}
+
+	entry, err := h.waitlistService.Join(userID, ticketType, req.Quantity)
+	if err != nil {
+		if errors.Is(err, services.ErrWaitlistInvalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrWaitlistJoined) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to join waitlist"})
+	}
+	log.Printf("📋 WAITLIST JOINED: User %s for %d %s, place %d", userID, entry.Quantity, ticketType.Name, entry.Place)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": entry,
+	})
+}
+
+// GET /api/v1/users/me/waitlist
+func (h *WaitlistHandler) GetMyWaitlist(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	entries, err := h.waitlistService.GetUserEntries(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get waitlist entries"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": entries,
+	})
+}
+
+// DELETE /api/v1/users/me/waitlist/:id
+func (h *WaitlistHandler) LeaveWaitlist(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	userID, err := uuid.Parse(claims["user_id"].(string))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to parse user ID"})
+	}
+
+	entryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid waitlist entry ID"})
+	}
+
+	if err := h.waitlistService.Leave(userID, entryID); err != nil {
+		if errors.Is(err, services.ErrWaitlistEntryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrWaitlistNotWaiting) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to leave waitlist"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Left waitlist successfully"})
+}
+
+// GET /api/v1/hosts/me/ticket-types/:id/waitlist
+func (h *WaitlistHandler) GetTicketTypeWaitlist(c *fiber.Ctx) error {
+	ticketType, err := h.loadHostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	entries, err := h.waitlistService.GetTicketTypeWaitlist(ticketType.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get waitlist"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": entries,
+	})
+}
+
+// POST /api/v1/hosts/me/ticket-types/:id/waitlist/offer
+func (h *WaitlistHandler) OfferFreedTickets(c *fiber.Ctx) error {
+	ticketType, err := h.loadHostTicketType(c)
+	if ticketType == nil {
+		return err
+	}
+
+	offered, err := h.waitlistService.OfferFreedTickets(ticketType.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to offer tickets to the waitlist"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{"offered": offered},
+	})
+}
+
+// PUT /api/v1/hosts/me/waitlist/:id/position
+func (h *WaitlistHandler) MoveWaitlistEntry(c *fiber.Ctx) error {
+	entry, err := h.loadHostEntry(c)
+	if entry == nil {
+		return err
+	}
+
+	var req models.WaitlistPositionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Position < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Position must be at least 1"})
+	}
+
+	if err := h.waitlistService.MoveEntry(entry, req.Position); err != nil {
+		if errors.Is(err, services.ErrWaitlistNotWaiting) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to move waitlist entry"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": entry,
+	})
+}
+
+// DELETE /api/v1/hosts/me/waitlist/:id
+func (h *WaitlistHandler) RemoveWaitlistEntry(c *fiber.Ctx) error {
+	entry, err := h.loadHostEntry(c)
+	if entry == nil {
+		return err
+	}
+
+	if err := h.waitlistService.RemoveEntry(entry); err != nil {
+		if errors.Is(err, services.ErrWaitlistNotWaiting) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to remove waitlist entry"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Waitlist entry removed successfully"})
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -36,32 +39,150 @@ func main() {
 	paymentRepo := repository.NewPaymentRepoPG(config.DB)
 	analyticsRepo := repository.NewAnalyticsRepoPG(config.DB)
 	attendeeRepo := repository.NewAttendeeRepoPG(config.DB)
+	webhookEventRepo := repository.NewWebhookEventRepoPG(config.DB)
+	reservationRepo := repository.NewReservationRepoPG(config.DB)
+	refundRepo := repository.NewRefundRepoPG(config.DB)
+	promoRepo := repository.NewPromoRepoPG(config.DB)
+	accessCodeRepo := repository.NewAccessCodeRepoPG(config.DB)
+	payoutRepo := repository.NewPayoutRepoPG(config.DB)
+	ledgerRepo := repository.NewLedgerRepoPG(config.DB)
+	feeRepo := repository.NewFeeRepoPG(config.DB)
+	reconciliationRepo := repository.NewReconciliationRepoPG(config.DB)
+	waitlistRepo := repository.NewWaitlistRepoPG(config.DB)
+	transferRepo := repository.NewTicketTransferRepoPG(config.DB)
+	checkInRepo := repository.NewCheckInEventRepoPG(config.DB)
+	attendeeImportRepo := repository.NewAttendeeImportRepoPG(config.DB)
+	staffRepo := repository.NewEventStaffRepoPG(config.DB)
+	unitOfWork := repository.NewUnitOfWorkPG(config.DB)
+
+	// Ticket QR codes are signed so they can't be forged
+	ticketSigner, err := services.NewTicketSignerFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid QR signing keys: %v", err)
+	}
+
+	// Per-day tickets get their entries back at midnight in CHECKIN_TIMEZONE
+	// (an IANA name, default UTC)
+	entryDays := time.UTC
+	if name := os.Getenv("CHECKIN_TIMEZONE"); name != "" {
+		entryDays, err = time.LoadLocation(name)
+		if err != nil {
+			log.Fatalf("Invalid CHECKIN_TIMEZONE: %v", err)
+		}
+	}
 
 	// Create services
 	userService := services.NewUserService(userRepo)
 	eventService := services.NewEventService(eventRepo)
-	ticketService := services.NewTicketService(ticketRepo, attendeeRepo)
+	ticketService := services.NewTicketService(ticketRepo, attendeeRepo, unitOfWork, ticketSigner)
 	wishlistService := services.NewWishlistService(wishlistRepo)
 	reviewService := services.NewReviewService(reviewRepo)
-	paymentService := services.NewPaymentService(paymentRepo, userRepo)
+	gateways := newGatewayRegistry()
+	paymentService := services.NewPaymentService(paymentRepo, userRepo, gateways)
 	analyticsService := services.NewAnalyticsService(analyticsRepo, paymentRepo, attendeeRepo, reviewRepo)
-	attendeeService := services.NewAttendeeService(attendeeRepo, ticketRepo)
+	// Check-ins are fanned out to live dashboards on this instance
+	checkInFeed := services.NewMemoryCheckInFeed()
+	attendeeService := services.NewAttendeeService(attendeeRepo, ticketRepo, checkInRepo, unitOfWork, ticketSigner, entryDays, checkInFeed)
+	offlineCheckInService := services.NewOfflineCheckInService(attendeeRepo, unitOfWork, ticketSigner, entryDays, checkInFeed)
+	checkInDashboardService := services.NewCheckInDashboardService(checkInRepo, checkInFeed)
+	webhookService := services.NewWebhookService(webhookEventRepo)
+	promoService := services.NewPromoService(promoRepo, unitOfWork)
+	accessCodeService := services.NewAccessCodeService(accessCodeRepo, os.Getenv("FRONTEND_URL"))
+	ledgerService := services.NewLedgerService(ledgerRepo, unitOfWork)
+
+	// Fees fall back to PLATFORM_FEE_PERCENT (default 1), with the platform
+	// absorbing gateway fees, until an admin sets the platform's schedule
+	feeDefaults := models.FeeSettings{Percent: 1, GatewayFeeBearer: models.GatewayFeePlatform}
+	if percent, err := strconv.ParseFloat(os.Getenv("PLATFORM_FEE_PERCENT"), 64); err == nil && percent >= 0 && percent <= 100 {
+		feeDefaults.Percent = percent
+	}
+	feeService := services.NewFeeService(feeRepo, feeDefaults)
+
+	// Checkout holds expire after TICKET_HOLD_TTL_MINUTES (default 15)
+	holdTTL := 15 * time.Minute
+	if minutes, err := strconv.Atoi(os.Getenv("TICKET_HOLD_TTL_MINUTES")); err == nil && minutes > 0 {
+		holdTTL = time.Duration(minutes) * time.Minute
+	}
+	reservationService := services.NewReservationService(reservationRepo, unitOfWork, holdTTL)
+	reservationService.StartSweeper(time.Minute)
+	ticketService.StartPriceScheduler(time.Minute)
+
+	payoutService := newPayoutService(payoutRepo, unitOfWork, gateways)
 
 	// Use Zoho email service
 	var emailService services.EmailService
 	log.Println("Using Zoho email service")
 	emailService = services.NewZohoEmailService()
+	refundService := services.NewRefundService(refundRepo, paymentRepo, unitOfWork, gateways, emailService)
+	fulfilmentService := services.NewFulfilmentService(unitOfWork, ticketSigner, refundService)
+	boxOfficeService := services.NewBoxOfficeService(ticketRepo, userRepo, unitOfWork, ticketSigner, emailService, entryDays, checkInFeed)
+
+	// Committed attendee imports too large to finish in the request are
+	// picked up every 15 seconds
+	attendeeImportService := services.NewAttendeeImportService(attendeeImportRepo, ticketRepo, reservationRepo, eventRepo, userRepo, unitOfWork, ticketSigner, emailService)
+	attendeeImportService.StartWorker(15 * time.Second)
+
+	// Tickets offered to the waitlist are held for WAITLIST_OFFER_TTL_MINUTES
+	// (default 120); freed tickets are offered every minute
+	offerTTL := 120 * time.Minute
+	if minutes, err := strconv.Atoi(os.Getenv("WAITLIST_OFFER_TTL_MINUTES")); err == nil && minutes > 0 {
+		offerTTL = time.Duration(minutes) * time.Minute
+	}
+	waitlistService := services.NewWaitlistService(waitlistRepo, reservationRepo, eventRepo, unitOfWork, emailService, offerTTL)
+	waitlistService.StartScheduler(time.Minute)
+
+	// Ticket transfer invites stay open for TICKET_TRANSFER_TTL_HOURS (default 72)
+	transferTTL := 72 * time.Hour
+	if hours, err := strconv.Atoi(os.Getenv("TICKET_TRANSFER_TTL_HOURS")); err == nil && hours > 0 {
+		transferTTL = time.Duration(hours) * time.Hour
+	}
+	transferService := services.NewTicketTransferService(transferRepo, attendeeRepo, unitOfWork, emailService, transferTTL, ticketSigner)
+
+	// Event staff invites stay open for STAFF_INVITE_TTL_HOURS (default a week)
+	staffInviteTTL := 7 * 24 * time.Hour
+	if hours, err := strconv.Atoi(os.Getenv("STAFF_INVITE_TTL_HOURS")); err == nil && hours > 0 {
+		staffInviteTTL = time.Duration(hours) * time.Hour
+	}
+	staffService := services.NewStaffService(staffRepo, emailService, staffInviteTTL)
+
+	// Payments pending past their ticket hold are checked with the gateway
+	// every RECONCILE_INTERVAL_MINUTES (default 15), and expired once unpaid
+	// for RECONCILE_EXPIRE_AFTER_HOURS (default 24)
+	reconcileInterval := 15 * time.Minute
+	if minutes, err := strconv.Atoi(os.Getenv("RECONCILE_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		reconcileInterval = time.Duration(minutes) * time.Minute
+	}
+	expireAfter := 24 * time.Hour
+	if hours, err := strconv.Atoi(os.Getenv("RECONCILE_EXPIRE_AFTER_HOURS")); err == nil && hours > 0 {
+		expireAfter = time.Duration(hours) * time.Hour
+	}
+	reconciliationService := services.NewReconciliationService(reconciliationRepo, paymentRepo, userRepo, gateways, fulfilmentService, reservationService, promoService, emailService, holdTTL, expireAfter)
+	reconciliationService.StartScheduler(reconcileInterval)
 
 	// Create handlers
 	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
 	authHandler := handlers.NewAuthHandler(userService, emailService, jwtSecret)
 	userHandler := handlers.NewUserHandler(userService, wishlistService, ticketService)
-	eventHandler := handlers.NewEventHandler(eventService, ticketService)
-	ticketHandler := handlers.NewTicketHandler(ticketService, eventService, userService, emailService)
+	eventHandler := handlers.NewEventHandler(eventService, ticketService, reservationService, accessCodeService, gateways)
+	ticketHandler := handlers.NewTicketHandler(ticketService, eventService, userService, emailService, fulfilmentService, accessCodeService, waitlistService)
 	reviewHandler := handlers.NewReviewHandler(reviewService)
-	paymentHandler := handlers.NewPaymentHandler(paymentService, ticketService, eventService, userService, emailService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, ticketService, eventService, userService, emailService, webhookService, fulfilmentService, reservationService, refundService, gateways, promoService, payoutService, feeService, accessCodeService, waitlistService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
-	attendeeHandler := handlers.NewAttendeeHandler(attendeeService, eventService)
+	attendeeHandler := handlers.NewAttendeeHandler(attendeeService, eventService, staffService)
+	refundHandler := handlers.NewRefundHandler(refundService, paymentService)
+	promoHandler := handlers.NewPromoHandler(promoService, eventService, analyticsService)
+	accessCodeHandler := handlers.NewAccessCodeHandler(accessCodeService, eventService)
+	transferHandler := handlers.NewTicketTransferHandler(transferService, ticketService, userService, emailService)
+	waitlistHandler := handlers.NewWaitlistHandler(waitlistService, ticketService, eventService, reservationService, accessCodeService)
+	offlineCheckInHandler := handlers.NewOfflineCheckInHandler(offlineCheckInService, eventService, staffService)
+	checkInDashboardHandler := handlers.NewCheckInDashboardHandler(checkInDashboardService, eventService, staffService)
+	staffHandler := handlers.NewStaffHandler(staffService, eventService, userService)
+	boxOfficeHandler := handlers.NewBoxOfficeHandler(boxOfficeService, eventService, staffService)
+	attendeeImportHandler := handlers.NewAttendeeImportHandler(attendeeImportService, eventService)
+	payoutHandler := handlers.NewPayoutHandler(payoutService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	feeHandler := handlers.NewFeeHandler(feeService, eventService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
 
 	// Create Fiber app
 	app := fiber.New()
@@ -103,10 +224,23 @@ func main() {
 	user.Get("/me/tickets", userHandler.GetMyTickets)
 	user.Get("/me/tickets/:id", userHandler.GetMyTicket)
 	user.Get("/me/tickets/debug", userHandler.GetMyTicketsDebug)
+	user.Put("/me/tickets/:id/attendee", ticketHandler.AssignTicketAttendee)
+	user.Get("/me/tickets/:id/transfers", transferHandler.GetTicketTransfers)
+	user.Post("/me/tickets/:id/transfer", transferHandler.CreateTransfer)
+	user.Delete("/me/tickets/:id/transfer", transferHandler.CancelTransfer)
+	user.Get("/me/transfers", transferHandler.GetIncomingTransfers)
+	user.Post("/me/transfers/:token/accept", transferHandler.AcceptTransfer)
+	user.Post("/me/transfers/:token/decline", transferHandler.DeclineTransfer)
 	user.Get("/me/wishlist", userHandler.GetMyWishlist)
 	user.Get("/me/wishlist/check", userHandler.CheckWishlistStatus)
 	user.Post("/me/wishlist", userHandler.AddToMyWishlist)
 	user.Delete("/me/wishlist", userHandler.RemoveFromMyWishlist)
+	user.Get("/me/waitlist", waitlistHandler.GetMyWaitlist)
+	user.Delete("/me/waitlist/:id", waitlistHandler.LeaveWaitlist)
+	user.Get("/me/staff-invites", staffHandler.GetMyInvites)
+	user.Post("/me/staff-invites/:token/accept", staffHandler.AcceptInvite)
+	user.Post("/me/staff-invites/:token/decline", staffHandler.DeclineInvite)
+	user.Get("/me/staff-events", staffHandler.GetMyStaffedEvents)
 
 	// Event routes
 	event := api.Group("/events")
@@ -140,12 +274,82 @@ func main() {
 	host.Get("/me/payments/earnings", paymentHandler.GetHostEarnings)
 	host.Get("/me/payments/payouts", paymentHandler.GetHostPayouts)
 	host.Get("/me/payments/pending", paymentHandler.GetPendingPayouts)
+	host.Get("/me/payments/:id/refunds", refundHandler.GetPaymentRefunds)
+	host.Post("/me/payments/:id/refunds", refundHandler.CreateRefund)
+	host.Get("/me/refunds", refundHandler.GetHostRefunds)
+	host.Get("/me/bank-account", payoutHandler.GetBankAccount)
+	host.Put("/me/bank-account", payoutHandler.SaveBankAccount)
+	host.Get("/me/fees", feeHandler.GetHostFees)
+	host.Put("/me/fees", feeHandler.SetPassFeesToBuyer)
+	host.Get("/me/events/:eventId/fees", feeHandler.GetHostFees)
+	host.Put("/me/events/:eventId/fees", feeHandler.SetPassFeesToBuyer)
+
+	// Host ticket types
+	host.Put("/me/ticket-types/:id/limits", ticketHandler.UpdateTicketTypeLimits)
+	host.Put("/me/ticket-types/:id/sales", ticketHandler.UpdateTicketTypeSales)
+	host.Put("/me/ticket-types/:id/visibility", ticketHandler.UpdateTicketTypeVisibility)
+	host.Put("/me/ticket-types/:id/entry", ticketHandler.UpdateTicketTypeEntry)
+	host.Put("/me/ticket-types/:id/capacity", ticketHandler.UpdateTicketTypeCapacity)
+	host.Get("/me/ticket-types/:id/price-changes", ticketHandler.GetPriceChanges)
+	host.Post("/me/ticket-types/:id/price-changes", ticketHandler.SchedulePriceChange)
+	host.Delete("/me/ticket-types/:id/price-changes/:changeId", ticketHandler.CancelPriceChange)
+
+	// Host promo codes
+	host.Get("/me/events/:eventId/promo-codes", promoHandler.GetEventPromoCodes)
+	host.Post("/me/events/:eventId/promo-codes", promoHandler.CreatePromoCode)
+	host.Get("/me/events/:eventId/promo-codes/stats", promoHandler.GetPromoCodeStats)
+	host.Put("/me/promo-codes/:id", promoHandler.UpdatePromoCode)
+	host.Delete("/me/promo-codes/:id", promoHandler.DeletePromoCode)
+
+	// Host access codes
+	host.Get("/me/events/:eventId/access-codes", accessCodeHandler.GetEventAccessCodes)
+	host.Post("/me/events/:eventId/access-codes", accessCodeHandler.CreateAccessCode)
+	host.Put("/me/access-codes/:id", accessCodeHandler.UpdateAccessCode)
+	host.Delete("/me/access-codes/:id", accessCodeHandler.DeleteAccessCode)
+
+	// Host waitlists
+	host.Get("/me/ticket-types/:id/waitlist", waitlistHandler.GetTicketTypeWaitlist)
+	host.Post("/me/ticket-types/:id/waitlist/offer", waitlistHandler.OfferFreedTickets)
+	host.Put("/me/waitlist/:id/position", waitlistHandler.MoveWaitlistEntry)
+	host.Delete("/me/waitlist/:id", waitlistHandler.RemoveWaitlistEntry)
 
 	// Host attendees
 	host.Get("/me/attendees", attendeeHandler.GetHostAttendees)
 	host.Get("/me/attendees/export", attendeeHandler.ExportHostAttendees)
+	host.Post("/me/events/:eventId/attendee-imports", attendeeImportHandler.UploadImport)
+	host.Get("/me/events/:eventId/attendee-imports", attendeeImportHandler.GetEventImports)
+	host.Get("/me/attendee-imports/:id", attendeeImportHandler.GetImport)
+	host.Get("/me/attendee-imports/:id/rows", attendeeImportHandler.GetImportRows)
+	host.Post("/me/attendee-imports/:id/commit", attendeeImportHandler.CommitImport)
 	host.Get("/me/events/:eventId/attendees", attendeeHandler.GetEventAttendees)
 	host.Post("/me/attendees/checkin", attendeeHandler.CheckInAttendee)
+	host.Get("/me/events/:eventId/check-ins", attendeeHandler.GetCheckInLog)
+	host.Get("/me/events/:eventId/check-ins/live", checkInDashboardHandler.StreamDashboard)
+	host.Post("/me/check-ins/:id/undo", attendeeHandler.UndoCheckIn)
+
+	// Host offline check-in for door scanners; manifests are checked with the public keys
+	api.Get("/checkin-manifest/keys", offlineCheckInHandler.GetManifestKeys)
+	host.Get("/me/events/:eventId/checkin-manifest", offlineCheckInHandler.GetManifest)
+	host.Post("/me/events/:eventId/checkin-sync", offlineCheckInHandler.SyncCheckIns)
+
+	// Host box office: tickets sold or comped at the door
+	host.Post("/me/events/:eventId/box-office", boxOfficeHandler.Sell)
+
+	// Event staff routes; any signed-in user, checked against their role at the event
+	staff := api.Group("/staff")
+	staff.Use(middleware.AuthRequired(jwtSecret))
+	staff.Get("/events/:eventId/attendees", attendeeHandler.GetEventAttendees)
+	staff.Post("/attendees/checkin", attendeeHandler.CheckInAttendee)
+	staff.Get("/events/:eventId/checkin-manifest", offlineCheckInHandler.GetManifest)
+	staff.Post("/events/:eventId/checkin-sync", offlineCheckInHandler.SyncCheckIns)
+	staff.Get("/events/:eventId/check-ins", attendeeHandler.GetCheckInLog)
+	staff.Get("/events/:eventId/check-ins/live", checkInDashboardHandler.StreamDashboard)
+	staff.Post("/check-ins/:id/undo", attendeeHandler.UndoCheckIn)
+	staff.Post("/events/:eventId/box-office", boxOfficeHandler.Sell)
+	staff.Get("/events/:eventId/members", staffHandler.GetEventStaff)
+	staff.Post("/events/:eventId/members", staffHandler.InviteStaff)
+	staff.Put("/members/:id", staffHandler.UpdateStaffRole)
+	staff.Delete("/members/:id", staffHandler.RemoveStaff)
 
 	// Review routes
 	review := api.Group("/reviews")
@@ -158,8 +362,9 @@ func main() {
 	// Payment routes
 	payment := api.Group("/payments")
 	payment.Post("/initiate", middleware.AuthRequired(jwtSecret), paymentHandler.InitiatePayment)
-	payment.Post("/webhook", paymentHandler.PaymentWebhook)  // No auth required for webhook
-	payment.Get("/webhook/test", paymentHandler.TestWebhook) // Test endpoint to verify webhook is reachable
+	payment.Post("/webhook", paymentHandler.PaymentWebhook)           // Paystack; kept for existing dashboard config
+	payment.Post("/webhook/:provider", paymentHandler.PaymentWebhook) // No auth required for webhooks
+	payment.Get("/webhook/test", paymentHandler.TestWebhook)          // Test endpoint to verify webhook is reachable
 	// payment.Post("/simulate-success", middleware.AuthRequired(jwtSecret), paymentHandler.SimulatePaymentSuccess) // For testing without webhooks - DISABLED for production
 
 	// Ticket routes
@@ -167,7 +372,107 @@ func main() {
 	ticket.Use(middleware.AuthRequired(jwtSecret))
 	ticket.Post("/purchase", ticketHandler.PurchaseTicket)
 	ticket.Post("/rsvp", ticketHandler.RSVPFreeEvent)
+	ticket.Post("/waitlist", waitlistHandler.JoinWaitlist)
+
+	// Admin routes
+	admin := api.Group("/admin")
+	admin.Use(middleware.AuthRequired(jwtSecret))
+	admin.Use(middleware.RoleRequired(models.AdminRole))
+	admin.Get("/webhooks", paymentHandler.ListWebhookEvents)
+	admin.Get("/webhooks/:id", paymentHandler.GetWebhookEvent)
+	admin.Post("/webhooks/:id/replay", paymentHandler.ReplayWebhookEvent)
+	admin.Get("/payments/:id/refunds", refundHandler.GetPaymentRefunds)
+	admin.Post("/payments/:id/refunds", refundHandler.CreateRefund)
+	admin.Post("/payouts/run", payoutHandler.RunSettlement)
+	admin.Post("/events/:id/settle", payoutHandler.SettleEvent)
+	admin.Get("/ledger/balances", ledgerHandler.GetAccountBalances)
+	admin.Post("/reconciliation/run", reconciliationHandler.RunReconciliation)
+	admin.Get("/reconciliation/report", reconciliationHandler.GetReport)
+	admin.Get("/fees", feeHandler.GetPlatformFees)
+	admin.Put("/fees", feeHandler.SavePlatformFees)
+	admin.Get("/hosts/:id/fees", feeHandler.GetHostFeeSchedule)
+	admin.Put("/hosts/:id/fees", feeHandler.SaveHostFeeSchedule)
+	admin.Get("/events/:id/fees", feeHandler.GetEventFeeSchedule)
+	admin.Put("/events/:id/fees", feeHandler.SaveEventFeeSchedule)
 
 	// Start server
 	log.Fatal(app.Listen(":8080"))
 }
+
+// newGatewayRegistry registers Paystack, plus Flutterwave when its keys are
+// set. PAYMENT_GATEWAY_CURRENCIES maps currencies to gateways, e.g.
+// "KES=flutterwave,USD=flutterwave"; anything unmapped uses
+// PAYMENT_DEFAULT_GATEWAY (default paystack).
+func newGatewayRegistry() services.GatewayRegistry {
+	callbackURL := os.Getenv("PAYMENT_CALLBACK_URL")
+	if callbackURL == "" {
+		callbackURL = os.Getenv("FRONTEND_URL") + "/payments/callback"
+	}
+
+	gateways := []services.PaymentGateway{
+		services.NewPaystackGateway(os.Getenv("PAYSTACK_BASE_URL"), os.Getenv("PAYSTACK_SECRET_KEY"), os.Getenv("PAYSTACK_PUBLIC_KEY"), callbackURL),
+	}
+	if os.Getenv("FLUTTERWAVE_SECRET_KEY") != "" {
+		gateways = append(gateways, services.NewFlutterwaveGateway(
+			os.Getenv("FLUTTERWAVE_BASE_URL"),
+			os.Getenv("FLUTTERWAVE_SECRET_KEY"),
+			os.Getenv("FLUTTERWAVE_PUBLIC_KEY"),
+			os.Getenv("FLUTTERWAVE_SECRET_HASH"),
+			callbackURL,
+		))
+	}
+
+	byCurrency := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("PAYMENT_GATEWAY_CURRENCIES"), ",") {
+		currency, gateway, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok {
+			byCurrency[strings.TrimSpace(currency)] = strings.ToLower(strings.TrimSpace(gateway))
+		}
+	}
+
+	defaultGateway := os.Getenv("PAYMENT_DEFAULT_GATEWAY")
+	if defaultGateway == "" {
+		defaultGateway = services.PaystackGateway
+	}
+
+	registry := services.NewGatewayRegistry(defaultGateway, byCurrency, gateways...)
+	log.Printf("Payment gateways: %s (default %s)", strings.Join(registry.Names(), ", "), defaultGateway)
+	return registry
+}
+
+// newPayoutService settles host earnings through PAYOUT_TRANSFER_GATEWAY
+// (default paystack; "fake" settles without moving money), paying out
+// PAYOUT_DELAY_HOURS (default 72) after each event ends. Settlement runs
+// every PAYOUT_SCHEDULE_INTERVAL_MINUTES (default 60).
+func newPayoutService(payoutRepo repository.PayoutRepository, uow repository.UnitOfWork, gateways services.GatewayRegistry) services.PayoutService {
+	transferGateway := os.Getenv("PAYOUT_TRANSFER_GATEWAY")
+	if transferGateway == "" {
+		transferGateway = services.PaystackGateway
+	}
+
+	var transfers services.TransferGateway
+	if transferGateway == services.FakeTransferGateway {
+		log.Println("⚠️ Using fake payout transfers; no money will be sent")
+		transfers = services.NewFakeTransferGateway()
+	} else {
+		var err error
+		transfers, err = gateways.Transfers(transferGateway)
+		if err != nil {
+			log.Fatalf("Invalid PAYOUT_TRANSFER_GATEWAY: %v", err)
+		}
+	}
+
+	delay := 72 * time.Hour
+	if hours, err := strconv.Atoi(os.Getenv("PAYOUT_DELAY_HOURS")); err == nil && hours >= 0 {
+		delay = time.Duration(hours) * time.Hour
+	}
+	interval := time.Hour
+	if minutes, err := strconv.Atoi(os.Getenv("PAYOUT_SCHEDULE_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		interval = time.Duration(minutes) * time.Minute
+	}
+
+	payoutService := services.NewPayoutService(payoutRepo, uow, transfers, delay)
+	payoutService.StartScheduler(interval)
+	log.Printf("Payouts: %s transfers, paid %s after events end", transfers.Name(), delay)
+	return payoutService
+}
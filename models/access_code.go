@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type AccessCodeKind string
+
+const (
+	AccessCodeTyped AccessCodeKind = "code" // Chosen by the host and shared as text
+	AccessCodeLink  AccessCodeKind = "link" // Generated and shared in a magic link
+)
+
+// AccessCode unlocks hidden ticket types for one of a host's events. Codes
+// are stored upper-case and matched case-insensitively; link tokens are
+// generated the same way, so either can be supplied wherever a code is taken.
+type AccessCode struct {
+	gorm.Model
+	ID            uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	EventID       uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_access_codes_event_code,where:deleted_at IS NULL" json:"event_id"`
+	HostID        uuid.UUID      `gorm:"type:uuid;not null;index" json:"host_id"`
+	Code          string         `gorm:"not null;uniqueIndex:idx_access_codes_event_code,where:deleted_at IS NULL" json:"code"`
+	Kind          AccessCodeKind `gorm:"type:varchar(10);not null" json:"kind"`
+	Label         string         `json:"label"`                              // What the host calls it, e.g. "Press"
+	TicketTypeIDs pq.StringArray `gorm:"type:text[]" json:"ticket_type_ids"` // Empty unlocks every hidden ticket type
+	ExpiresAt     *time.Time     `json:"expires_at"`
+	Active        bool           `gorm:"not null" json:"active"`
+	Link          string         `gorm:"-" json:"link,omitempty"` // For link codes, the event page URL carrying the token
+}
+
+func (a *AccessCode) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AttendeeImportStatus string
+
+const (
+	ImportPending    AttendeeImportStatus = "pending" // Validated; waiting for the host to commit it
+	ImportQueued     AttendeeImportStatus = "queued"  // Committed; waiting for the import worker
+	ImportProcessing AttendeeImportStatus = "processing"
+	ImportCompleted  AttendeeImportStatus = "completed" // Every queued row was tried
+	ImportFailed     AttendeeImportStatus = "failed"    // Stopped early; Error says why
+)
+
+type AttendeeImportRowStatus string
+
+const (
+	ImportRowValid     AttendeeImportRowStatus = "valid"
+	ImportRowInvalid   AttendeeImportRowStatus = "invalid"   // Never imported; Error says why
+	ImportRowDuplicate AttendeeImportRowStatus = "duplicate" // Email already has a ticket, or is on an earlier row
+	ImportRowImported  AttendeeImportRowStatus = "imported"
+	ImportRowFailed    AttendeeImportRowStatus = "failed" // Valid in the preview but couldn't be issued, e.g. sold out
+)
+
+// AttendeeImport is a CSV of attendees a host uploaded for an event. Rows
+// are validated on upload for the host to preview; committing it issues a
+// ticket to each valid row, in the background for large files.
+type AttendeeImport struct {
+	gorm.Model
+	ID               uuid.UUID            `gorm:"type:uuid;primary_key;" json:"id"`
+	EventID          uuid.UUID            `gorm:"type:uuid;not null;index" json:"event_id"`
+	HostID           uuid.UUID            `gorm:"type:uuid;not null;index" json:"host_id"`
+	FileName         string               `json:"file_name"`
+	Status           AttendeeImportStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	TotalRows        int                  `gorm:"not null" json:"total_rows"`
+	QueuedRows       int                  `gorm:"not null;default:0" json:"queued_rows"` // Rows the commit set out to import
+	ImportDuplicates bool                 `gorm:"not null;default:false" json:"import_duplicates"`
+	SendInvites      bool                 `gorm:"not null;default:false" json:"send_invites"`
+	StartedAt        *time.Time           `json:"started_at,omitempty"`
+	CompletedAt      *time.Time           `json:"completed_at,omitempty"`
+	Error            string               `json:"error,omitempty"`
+
+	RowCounts map[AttendeeImportRowStatus]int64 `gorm:"-" json:"row_counts"`
+	Progress  float64                           `gorm:"-" json:"progress"` // Share of queued rows tried so far, 0 to 1
+}
+
+// AttendeeImportRow is one attendee from an import's CSV, as uploaded, and
+// what became of it.
+type AttendeeImportRow struct {
+	gorm.Model
+	ID             uuid.UUID               `gorm:"type:uuid;primary_key;" json:"id"`
+	ImportID       uuid.UUID               `gorm:"type:uuid;not null;index" json:"import_id"`
+	Line           int                     `gorm:"not null" json:"line"` // In the CSV, counting the header
+	FullName       string                  `json:"full_name"`
+	Email          string                  `json:"email"`
+	Phone          string                  `json:"phone"`
+	TicketTypeName string                  `json:"ticket_type_name"`
+	TicketTypeID   *uuid.UUID              `gorm:"type:uuid" json:"ticket_type_id,omitempty"`
+	Status         AttendeeImportRowStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Error          string                  `json:"error,omitempty"`
+	TicketID       *uuid.UUID              `gorm:"type:uuid" json:"ticket_id,omitempty"`
+}
+
+// AttendeeImportRowCount is how many of an import's rows are in a status.
+type AttendeeImportRowCount struct {
+	Status AttendeeImportRowStatus
+	Count  int64
+}
+
+func (i *AttendeeImport) BeforeCreate(tx *gorm.DB) (err error) {
+	i.ID = uuid.New()
+	return
+}
+
+func (r *AttendeeImportRow) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// HostBankAccount is where a host's payouts are sent. Each host has one.
+type HostBankAccount struct {
+	gorm.Model
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	HostID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_host_bank_accounts_host,where:deleted_at IS NULL" json:"host_id"`
+	BankCode      string    `gorm:"not null" json:"bank_code"`
+	BankName      string    `json:"bank_name"`
+	AccountNumber string    `gorm:"not null" json:"account_number"`
+	AccountName   string    `gorm:"not null" json:"account_name"`
+	Currency      string    `gorm:"type:varchar(3);not null;default:'NGN'" json:"currency"`
+	Gateway       string    `gorm:"type:varchar(20)" json:"gateway"` // Transfer gateway RecipientCode belongs to
+	RecipientCode string    `json:"-"`
+}
+
+func (a *HostBankAccount) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
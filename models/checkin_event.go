@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CheckInSource string
+
+const (
+	CheckInOnline  CheckInSource = "online"  // Scanned against the server
+	CheckInOffline CheckInSource = "offline" // Scanned by a door scanner without a connection and synced later
+	CheckInLegacy  CheckInSource = "legacy"  // A check-in made before the log existed
+)
+
+type CheckInOutcome string
+
+const (
+	CheckInAdmitted  CheckInOutcome = "admitted"
+	CheckInDuplicate CheckInOutcome = "duplicate" // The ticket had no entries left when scanned
+	CheckInRejected  CheckInOutcome = "rejected"  // Not a genuine ticket, wrong event, replaced code or cancelled ticket
+)
+
+// CheckInEvent is one scan of a ticket at the door. Whether an admitted or
+// duplicate scan counts as an entry is worked out from all of the ticket's
+// scans in the order they were made, so a late-synced offline scan or an undo
+// can change it. Undone scans stay in the log but don't count.
+type CheckInEvent struct {
+	gorm.Model
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	EventID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"event_id"`
+	TicketID   *uuid.UUID     `gorm:"type:uuid;index" json:"ticket_id,omitempty"` // Nil when the code isn't a genuine ticket
+	StaffID    uuid.UUID      `gorm:"type:uuid;not null" json:"staff_id"`         // Host or staff member who scanned or synced it
+	Source     CheckInSource  `gorm:"type:varchar(20);not null" json:"source"`
+	DeviceID   string         `gorm:"index" json:"device_id,omitempty"` // Offline scans only
+	Gate       string         `json:"gate,omitempty"`
+	ScannedAt  time.Time      `gorm:"not null;index" json:"scanned_at"` // The device clock for offline scans
+	Outcome    CheckInOutcome `gorm:"type:varchar(20);not null" json:"outcome"`
+	Reason     string         `json:"reason,omitempty"`
+	UndoneAt   *time.Time     `json:"undone_at,omitempty"`
+	UndoneBy   *uuid.UUID     `gorm:"type:uuid" json:"undone_by,omitempty"`
+	UndoReason string         `json:"undo_reason,omitempty"`
+}
+
+func (e *CheckInEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	e.ID = uuid.New()
+	return
+}
+
+// TicketTypeCheckIns is how many of a ticket type's attendees have arrived.
+type TicketTypeCheckIns struct {
+	TicketTypeID uuid.UUID `json:"ticket_type_id"`
+	Name         string    `json:"name"`
+	Attendees    int64     `json:"attendees"` // Not cancelled
+	CheckedIn    int64     `json:"checked_in"`
+}
+
+// ArrivalBucket counts the entries in the interval starting at StartsAt.
+type ArrivalBucket struct {
+	StartsAt time.Time `json:"starts_at"`
+	Entries  int64     `json:"entries"`
+}
+
+// GateCheckIns breaks an event's scans down by the gate they were made at.
+// Admitted scans that were undone aren't counted.
+type GateCheckIns struct {
+	Gate       string `json:"gate"` // Empty for scans that didn't name one
+	Admitted   int64  `json:"admitted"`
+	Duplicates int64  `json:"duplicates"`
+	Rejected   int64  `json:"rejected"`
+}
+
+// CheckInManifestTicket is one ticket in an offline check-in manifest.
+// Scanners match a scanned code by the hex SHA-256 of its payload.
+type CheckInManifestTicket struct {
+	TicketID     uuid.UUID      `json:"ticket_id"`
+	QRCodeHash   string         `json:"qr_code_hash"`
+	TicketType   string         `json:"ticket_type"`
+	AttendeeName string         `json:"attendee_name"`
+	Status       AttendeeStatus `json:"status"`
+	CheckedInAt  *time.Time     `json:"checked_in_at,omitempty"`
+	EntryLimit   int            `json:"entry_limit"` // 0 for unlimited re-entry
+	EntryPerDay  bool           `json:"entry_per_day"`
+}
+
+// CheckInManifest is everything a door scanner needs to check an event's
+// tickets without a connection. Signature covers the manifest with the
+// signature left empty.
+type CheckInManifest struct {
+	EventID     uuid.UUID               `json:"event_id"`
+	EventTitle  string                  `json:"event_title"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	Tickets     []CheckInManifestTicket `json:"tickets"`
+	KeyID       string                  `json:"key_id"`
+	Signature   string                  `json:"signature"`
+}
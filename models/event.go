@@ -36,6 +36,9 @@ type Event struct {
 	Host                User           `gorm:"foreignKey:HostID" json:"host"`
 	TicketTypes         []TicketType   `gorm:"foreignKey:EventID" json:"ticket_types,omitempty"`
 	Status              EventStatus    `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	Currency            string         `gorm:"type:varchar(3);not null;default:'NGN'" json:"currency"`
+	PaymentGateway      string         `gorm:"type:varchar(20)" json:"payment_gateway,omitempty"` // Empty picks the gateway for the currency
+	TransfersDisabled   bool           `gorm:"not null;default:false" json:"transfers_disabled"`  // Stops attendees passing tickets on
 }
 
 func (e *Event) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StaffRole string
+
+const (
+	StaffScanner   StaffRole = "scanner"    // Checks tickets in at the door
+	StaffBoxOffice StaffRole = "box_office" // Sells tickets, checks them in and sorts out problems at the desk
+	StaffCoManager StaffRole = "co_manager" // Runs the door and its staff alongside the host
+)
+
+type StaffStatus string
+
+const (
+	StaffInvited  StaffStatus = "invited"
+	StaffActive   StaffStatus = "active"
+	StaffDeclined StaffStatus = "declined"
+	StaffRemoved  StaffStatus = "removed"
+)
+
+// EventStaff gives someone other than the host a role at one event. Staff
+// never see revenue or edit the event; what else they can do depends on
+// their role.
+type EventStaff struct {
+	gorm.Model
+	ID         uuid.UUID   `gorm:"type:uuid;primary_key;" json:"id"`
+	EventID    uuid.UUID   `gorm:"type:uuid;not null;index" json:"event_id"`
+	Event      *Event      `gorm:"foreignKey:EventID" json:"event,omitempty"`
+	Email      string      `gorm:"not null;index" json:"email"`
+	UserID     *uuid.UUID  `gorm:"type:uuid;index" json:"user_id,omitempty"` // Set once accepted
+	User       *User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Role       StaffRole   `gorm:"type:varchar(20);not null" json:"role"`
+	Status     StaffStatus `gorm:"type:varchar(20);not null;default:'invited';index" json:"status"`
+	Token      string      `gorm:"not null;uniqueIndex" json:"-"` // Carried by the invite link
+	InvitedBy  uuid.UUID   `gorm:"type:uuid;not null" json:"invited_by"`
+	ExpiresAt  time.Time   `gorm:"not null" json:"expires_at"` // When an unanswered invite lapses
+	AcceptedAt *time.Time  `json:"accepted_at,omitempty"`
+}
+
+func (s *EventStaff) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
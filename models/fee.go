@@ -0,0 +1,63 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FeeScope string
+
+const (
+	FeeScopePlatform FeeScope = "platform"
+	FeeScopeHost     FeeScope = "host"
+	FeeScopeEvent    FeeScope = "event"
+)
+
+type GatewayFeeBearer string
+
+const (
+	GatewayFeePlatform GatewayFeeBearer = "platform" // Paid out of the platform fee
+	GatewayFeeHost     GatewayFeeBearer = "host"     // Charged to the host on top of the platform fee
+)
+
+// FeeSchedule sets fees for the platform, a host or an event; there is at
+// most one per scope, enforced by idx_fee_schedules_scope. Nil fields inherit: an event's schedule from its
+// host's, a host's from the platform's.
+type FeeSchedule struct {
+	gorm.Model
+	ID               uuid.UUID         `gorm:"type:uuid;primary_key;" json:"id"`
+	Scope            FeeScope          `gorm:"type:varchar(10);not null" json:"scope"`
+	HostID           *uuid.UUID        `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	EventID          *uuid.UUID        `gorm:"type:uuid;index" json:"event_id,omitempty"`
+	Percent          *float64          `json:"percent"`                                    // Of each order's ticket subtotal
+	Flat             *float64          `json:"flat"`                                       // Per order, in the event's currency
+	Cap              *float64          `json:"cap"`                                        // Most the platform fee can be per order; 0 is uncapped
+	GatewayFeeBearer *GatewayFeeBearer `gorm:"type:varchar(10)" json:"gateway_fee_bearer"` // Who absorbs the payment gateway's fee
+	PassToBuyer      *bool             `json:"pass_fees_to_buyer"`                         // Add the host's fees to the buyer's total instead
+}
+
+// FeeSettings are the fees that apply to an event once schedules are merged.
+type FeeSettings struct {
+	Percent          float64          `json:"percent"`
+	Flat             float64          `json:"flat"`
+	Cap              float64          `json:"cap"`
+	GatewayFeeBearer GatewayFeeBearer `json:"gateway_fee_bearer"`
+	PassToBuyer      bool             `json:"pass_fees_to_buyer"`
+}
+
+// FeeBreakdown splits one order between the buyer, the host, the platform and
+// the payment gateway. Fees are not refunded.
+type FeeBreakdown struct {
+	Subtotal    float64 `json:"subtotal"`     // Tickets, after any promo discount
+	PlatformFee float64 `json:"platform_fee"` // Percentage plus flat amount, capped
+	GatewayFee  float64 `json:"gateway_fee"`  // Estimated from the gateway's pricing
+	BuyerFee    float64 `json:"buyer_fee"`    // Fees passed to the buyer, added to Total
+	HostFee     float64 `json:"host_fee"`     // Fees taken from the host's share at payout
+	Total       float64 `json:"total"`        // What the buyer pays
+	HostNet     float64 `json:"host_net"`     // What the host is paid
+}
+
+func (f *FeeSchedule) BeforeCreate(tx *gorm.DB) (err error) {
+	f.ID = uuid.New()
+	return
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LedgerAccount string
+
+const (
+	LedgerBuyer          LedgerAccount = "buyer"           // Buyer money held at the gateway
+	LedgerHostBalance    LedgerAccount = "host_balance"    // Owed to hosts
+	LedgerPlatformFees   LedgerAccount = "platform_fees"   // Kept by the platform
+	LedgerPayoutClearing LedgerAccount = "payout_clearing" // Sent to hosts, awaiting the transfer outcome
+	LedgerRefunds        LedgerAccount = "refunds"         // Owed back to buyers, awaiting the gateway
+	LedgerHostCollected  LedgerAccount = "host_collected"  // Taken by hosts at the box office; never held by the platform
+	LedgerUnallocated    LedgerAccount = "unallocated"     // Paid for orders that couldn't be issued; owed back to the buyer
+	LedgerGatewayFees    LedgerAccount = "gateway_fees"    // Taken by the payment gateway from what it holds
+	// Memo of box office revenue, against host_collected; kept apart from what hosts are owed
+	LedgerBoxOfficeRevenue LedgerAccount = "box_office_revenue"
+)
+
+type LedgerTransactionType string
+
+const (
+	LedgerCharge         LedgerTransactionType = "charge"
+	LedgerUnfulfilled    LedgerTransactionType = "unfulfilled"     // Paid, but no tickets could be issued; refunded in full
+	LedgerRefund         LedgerTransactionType = "refund"          // Initiated; taken off the host's balance
+	LedgerRefundSettled  LedgerTransactionType = "refund_settled"  // Gateway returned the money
+	LedgerRefundReversed LedgerTransactionType = "refund_reversed" // Gateway refused; owed to the host again
+	LedgerPayout         LedgerTransactionType = "payout"          // Includes the platform fee
+	LedgerPayoutSettled  LedgerTransactionType = "payout_settled"
+	LedgerPayoutReversed LedgerTransactionType = "payout_reversed" // Transfer failed or was reversed
+	LedgerBoxOfficeSale  LedgerTransactionType = "box_office_sale" // Collected by the host at the door; never paid out
+	LedgerGatewayFee     LedgerTransactionType = "gateway_fee"     // The gateway's fee on a payment; the platform bears it
+)
+
+// LedgerTransaction is one balanced posting. Transactions are append-only: a
+// mistake is corrected by posting another transaction, never by editing.
+type LedgerTransaction struct {
+	gorm.Model
+	ID          uuid.UUID             `gorm:"type:uuid;primary_key;" json:"id"`
+	Type        LedgerTransactionType `gorm:"type:varchar(30);not null" json:"type"`
+	Reference   string                `gorm:"not null;uniqueIndex" json:"reference"` // e.g. "charge:<payment reference>"; posting it again is a no-op
+	Description string                `json:"description"`
+	EventID     uuid.UUID             `gorm:"type:uuid;not null;index" json:"event_id"`
+	HostID      uuid.UUID             `gorm:"type:uuid;not null;index" json:"host_id"`
+	PaymentID   *uuid.UUID            `gorm:"type:uuid;index" json:"payment_id,omitempty"` // The payment it, or its refund, is for; nil for payouts
+	Currency    string                `gorm:"type:varchar(3);not null" json:"currency"`
+	EffectiveAt time.Time             `gorm:"not null;index" json:"effective_at"` // When the money moved, which may predate posting
+	Entries     []LedgerEntry         `gorm:"foreignKey:TransactionID" json:"entries,omitempty"`
+}
+
+// LedgerEntry debits or credits one account. The transaction's fields are
+// copied on so reports can filter entries without a join.
+type LedgerEntry struct {
+	gorm.Model
+	ID              uuid.UUID             `gorm:"type:uuid;primary_key;" json:"id"`
+	TransactionID   uuid.UUID             `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	TransactionType LedgerTransactionType `gorm:"type:varchar(30);not null;index" json:"transaction_type"`
+	Account         LedgerAccount         `gorm:"type:varchar(30);not null;index" json:"account"`
+	EventID         uuid.UUID             `gorm:"type:uuid;not null;index" json:"event_id"`
+	HostID          uuid.UUID             `gorm:"type:uuid;not null;index" json:"host_id"`
+	PaymentID       *uuid.UUID            `gorm:"type:uuid;index" json:"payment_id,omitempty"`
+	Currency        string                `gorm:"type:varchar(3);not null" json:"currency"`
+	Debit           float64               `gorm:"not null;default:0" json:"debit"`
+	Credit          float64               `gorm:"not null;default:0" json:"credit"`
+	EffectiveAt     time.Time             `gorm:"not null;index" json:"effective_at"`
+}
+
+// LedgerAccountBalance totals one account in one currency.
+type LedgerAccountBalance struct {
+	Account  LedgerAccount `json:"account"`
+	Currency string        `json:"currency"`
+	Debit    float64       `json:"debit"`
+	Credit   float64       `json:"credit"`
+	Balance  float64       `json:"balance"` // Debit minus credit
+}
+
+func (t *LedgerTransaction) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = uuid.New()
+	return
+}
+
+func (e *LedgerEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	e.ID = uuid.New()
+	return
+}
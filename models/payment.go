@@ -14,6 +14,8 @@ const (
 	PaymentCompleted PaymentStatus = "completed"
 	PaymentFailed    PaymentStatus = "failed"
 	PaymentRefunded  PaymentStatus = "refunded"
+	PaymentDisputed  PaymentStatus = "disputed" // Gateway record didn't match ours; held for review
+	PaymentExpired   PaymentStatus = "expired"  // Abandoned checkout, never paid
 )
 
 type PaymentMethod string
@@ -22,92 +24,130 @@ const (
 	BankTransfer PaymentMethod = "bank_transfer"
 	Card         PaymentMethod = "card"
 	Wallet       PaymentMethod = "wallet"
+
+	// Taken at the event's box office rather than through a gateway
+	Cash PaymentMethod = "cash"
+	POS  PaymentMethod = "pos"
+	Comp PaymentMethod = "comp" // Given away; nothing collected
 )
 
-// PaystackWebhookEvent represents the webhook payload from Paystack
-type PaystackWebhookEvent struct {
-	Event string `json:"event"`
-	Data  struct {
-		ID                int64     `json:"id"`
-		Domain            string    `json:"domain"`
-		Status            string    `json:"status"`
-		Reference         string    `json:"reference"`
-		Amount            int64     `json:"amount"` // Amount in kobo
-		Message           string    `json:"message"`
-		GatewayResponse   string    `json:"gateway_response"`
-		PaidAt            time.Time `json:"paid_at"`
-		CreatedAt         time.Time `json:"created_at"`
-		Channel           string    `json:"channel"`
-		Currency          string    `json:"currency"`
-		IPAddress         string    `json:"ip_address"`
-		Metadata          struct {
-			EventID      string `json:"eventId"`
-			EventTitle   string `json:"eventTitle"`
-			AttendeeData struct {
-				FullName string `json:"fullName"`
-				Email    string `json:"email"`
-				Phone    string `json:"phone"`
-			} `json:"attendeeData"`
-			TicketDetails []struct {
-				TicketTypeID   string  `json:"ticketTypeId"`
-				TicketTypeName string  `json:"ticketTypeName"`
-				Quantity       int     `json:"quantity"`
-				Price          float64 `json:"price"`
-			} `json:"ticketDetails"`
-		} `json:"metadata"`
-		Customer struct {
-			ID           int64  `json:"id"`
-			FirstName    string `json:"first_name"`
-			LastName     string `json:"last_name"`
-			Email        string `json:"email"`
-			CustomerCode string `json:"customer_code"`
-			Phone        string `json:"phone"`
-		} `json:"customer"`
-		Authorization struct {
-			AuthorizationCode string `json:"authorization_code"`
-			Bin               string `json:"bin"`
-			Last4             string `json:"last4"`
-			ExpMonth          string `json:"exp_month"`
-			ExpYear           string `json:"exp_year"`
-			Channel           string `json:"channel"`
-			CardType          string `json:"card_type"`
-			Bank              string `json:"bank"`
-			CountryCode       string `json:"country_code"`
-			Brand             string `json:"brand"`
-		} `json:"authorization"`
-	} `json:"data"`
-}
+// BoxOfficeMethods are the payment methods sold at the door.
+var BoxOfficeMethods = []PaymentMethod{Cash, POS, Comp}
 
 type Payment struct {
 	gorm.Model
-	ID            uuid.UUID     `gorm:"type:uuid;primary_key;" json:"id"`
-	EventID       uuid.UUID     `gorm:"type:uuid;not null" json:"event_id"`
-	Event         Event         `gorm:"foreignKey:EventID" json:"event"`
-	UserID        uuid.UUID     `gorm:"type:uuid;not null" json:"user_id"`
-	User          User          `gorm:"foreignKey:UserID" json:"user"`
-	Amount        float64       `gorm:"not null" json:"amount"`
-	Currency      string        `gorm:"default:'NGN'" json:"currency"`
-	Status        PaymentStatus `gorm:"type:payment_status;not null" json:"status"`
-	Method        PaymentMethod `gorm:"type:payment_method;not null" json:"method"`
-	Reference     string        `gorm:"unique;not null" json:"reference"`
-	ProcessedAt   *time.Time    `json:"processed_at"`
-	FailureReason string        `json:"failure_reason"`
+	ID             uuid.UUID         `gorm:"type:uuid;primary_key;" json:"id"`
+	EventID        uuid.UUID         `gorm:"type:uuid;not null" json:"event_id"`
+	Event          Event             `gorm:"foreignKey:EventID" json:"event"`
+	UserID         uuid.UUID         `gorm:"type:uuid;not null" json:"user_id"`
+	User           User              `gorm:"foreignKey:UserID" json:"user"`
+	Amount         float64           `gorm:"not null" json:"amount"`
+	Currency       string            `gorm:"default:'NGN'" json:"currency"`
+	Status         PaymentStatus     `gorm:"type:payment_status;not null" json:"status"`
+	Method         PaymentMethod     `gorm:"type:payment_method;not null" json:"method"`
+	Reference      string            `gorm:"unique;not null" json:"reference"`
+	ProcessedAt    *time.Time        `json:"processed_at"`
+	FailureReason  string            `json:"failure_reason"`
+	LineItems      []PaymentLineItem `gorm:"foreignKey:PaymentID" json:"line_items,omitempty"`
+	RefundedAmount float64           `gorm:"not null;default:0" json:"refunded_amount"` // Includes refunds still pending at the gateway
+	Gateway        string            `gorm:"not null;default:'paystack'" json:"gateway"`
+	PromoCodeID    *uuid.UUID        `gorm:"type:uuid" json:"promo_code_id,omitempty"`
+	DiscountAmount float64           `gorm:"not null;default:0" json:"discount_amount"` // Already taken off Amount
+	PlatformFee    float64           `gorm:"not null;default:0" json:"platform_fee"`
+	GatewayFee     float64           `gorm:"not null;default:0" json:"gateway_fee"` // Estimated at checkout
+	BuyerFee       float64           `gorm:"not null;default:0" json:"buyer_fee"`   // Included in Amount; kept by the platform
+	HostFee        float64           `gorm:"not null;default:0" json:"host_fee"`    // Taken from the host's payout
+	Attendees      []PaymentAttendee `gorm:"foreignKey:PaymentID" json:"attendees,omitempty"`
+	IssuedBy       *uuid.UUID        `gorm:"type:uuid" json:"issued_by,omitempty"` // Host or staff member who sold it at the box office
+	ReconciledAt   *time.Time        `json:"-"`                                    // Last checked against the gateway while pending
+}
+
+// PaymentLineItem snapshots what was bought and at what price when the payment
+// was initiated, so fulfilment, receipts and refunds never depend on the
+// current ticket type price or on client-supplied metadata.
+type PaymentLineItem struct {
+	gorm.Model
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	PaymentID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"payment_id"`
+	TicketTypeID   uuid.UUID  `gorm:"type:uuid;not null" json:"ticket_type_id"`
+	TicketType     TicketType `gorm:"foreignKey:TicketTypeID" json:"-"`
+	TicketTypeName string     `gorm:"not null" json:"ticket_type_name"`
+	UnitPrice      float64    `gorm:"not null" json:"unit_price"`
+	Quantity       int        `gorm:"not null" json:"quantity"`
+	Subtotal       float64    `gorm:"not null" json:"subtotal"`
+	Discount       float64    `gorm:"not null;default:0" json:"discount"` // Promo discount across the line; Subtotal is before it
+}
+
+// PaymentAttendee is one named attendee given at checkout. Fulfilment issues
+// the order's ticket at Position, counting across line items, to them; tickets
+// with no attendee go to the buyer with no name, for them to fill in later.
+type PaymentAttendee struct {
+	gorm.Model
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	PaymentID uuid.UUID `gorm:"type:uuid;not null;index" json:"payment_id"`
+	Position  int       `gorm:"not null" json:"position"`
+	FullName  string    `gorm:"not null" json:"full_name"`
+	Email     string    `gorm:"not null" json:"email"`
+	Phone     string    `json:"phone"`
 }
 
+type PayoutStatus string
+
+const (
+	PayoutPending    PayoutStatus = "pending"    // Created, not yet sent to the transfer gateway
+	PayoutProcessing PayoutStatus = "processing" // Sent, or possibly sent; awaiting the gateway's webhook or a lookup
+	PayoutCompleted  PayoutStatus = "completed"
+	PayoutFailed     PayoutStatus = "failed" // Its amount is owed to the host again
+)
+
+// Payout settles part of an event's earnings to its host. GrossAmount is the
+// revenue it covers net of refunds; Amount is what is sent after the host
+// fees recorded on the event's payments.
 type Payout struct {
 	gorm.Model
-	ID          uuid.UUID     `gorm:"type:uuid;primary_key;" json:"id"`
-	HostID      uuid.UUID     `gorm:"type:uuid;not null" json:"host_id"`
-	Host        User          `gorm:"foreignKey:HostID" json:"host"`
-	EventID     uuid.UUID     `gorm:"type:uuid;not null" json:"event_id"`
-	Event       Event         `gorm:"foreignKey:EventID" json:"event"`
-	Amount      float64       `gorm:"not null" json:"amount"`
-	Currency    string        `gorm:"default:'NGN'" json:"currency"`
-	Status      PaymentStatus `gorm:"type:payment_status;not null" json:"status"`
-	Method      PaymentMethod `gorm:"type:payment_method;not null" json:"method"`
-	Reference   string        `gorm:"unique;not null" json:"reference"`
-	ProcessedAt *time.Time    `json:"processed_at"`
-	PayoutDate  time.Time     `gorm:"not null" json:"payout_date"`
+	ID              uuid.UUID     `gorm:"type:uuid;primary_key;" json:"id"`
+	HostID          uuid.UUID     `gorm:"type:uuid;not null" json:"host_id"`
+	Host            User          `gorm:"foreignKey:HostID" json:"host"`
+	EventID         uuid.UUID     `gorm:"type:uuid;not null" json:"event_id"`
+	Event           Event         `gorm:"foreignKey:EventID" json:"event"`
+	Amount          float64       `gorm:"not null" json:"amount"`
+	Currency        string        `gorm:"default:'NGN'" json:"currency"`
+	Status          PayoutStatus  `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Method          PaymentMethod `gorm:"type:payment_method;not null" json:"method"`
+	Reference       string        `gorm:"unique;not null" json:"reference"`
+	ProcessedAt     *time.Time    `json:"processed_at"`
+	PayoutDate      time.Time     `gorm:"not null" json:"payout_date"`
+	GrossAmount     float64       `gorm:"not null;default:0" json:"gross_amount"`
+	FeeAmount       float64       `gorm:"not null;default:0" json:"fee_amount"`
+	BankAccountID   *uuid.UUID    `gorm:"type:uuid" json:"bank_account_id,omitempty"`
+	TransferGateway string        `gorm:"type:varchar(20)" json:"transfer_gateway"`
+	TransferCode    string        `gorm:"index" json:"transfer_code,omitempty"` // The gateway's ID for the transfer
+	FailureReason   string        `json:"failure_reason,omitempty"`
+}
+
+// ChannelRevenue is what an event or host has sold through one channel:
+// "online" through a gateway, or a box office payment method.
+type ChannelRevenue struct {
+	Channel string  `json:"channel"`
+	Orders  int64   `json:"orders"`
+	Tickets int64   `json:"tickets"` // Refunded ones included
+	Revenue float64 `json:"revenue"` // Net of refunds and buyer fees
+}
+
+// EventBalance is an event's revenue net of refunds and the fees owed on it,
+// and how much of each is already covered by payouts that haven't failed.
+type EventBalance struct {
+	EventID      uuid.UUID
+	HostID       uuid.UUID
+	Currency     string
+	StartDate    time.Time
+	StartTime    string
+	EndTime      string
+	Gross        float64
+	Settled      float64
+	Fees         float64 // Host fees on the event's payments
+	FeesSettled  float64
+	LastFailedAt *time.Time // Most recent failed payout, if any
 }
 
 func (p *Payment) BeforeCreate(tx *gorm.DB) (err error) {
@@ -115,7 +155,17 @@ func (p *Payment) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+func (li *PaymentLineItem) BeforeCreate(tx *gorm.DB) (err error) {
+	li.ID = uuid.New()
+	return
+}
+
+func (a *PaymentAttendee) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
+
 func (p *Payout) BeforeCreate(tx *gorm.DB) (err error) {
 	p.ID = uuid.New()
 	return
-}
\ No newline at end of file
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage" // DiscountValue percent off each eligible ticket
+	DiscountFixed      DiscountType = "fixed"      // DiscountValue off each eligible ticket, never below zero
+)
+
+// PromoCode is a host's discount code for one of their events. Codes are
+// stored upper-case and matched case-insensitively.
+type PromoCode struct {
+	gorm.Model
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	EventID        uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_promo_codes_event_code,where:deleted_at IS NULL" json:"event_id"`
+	Event          Event          `gorm:"foreignKey:EventID" json:"-"`
+	HostID         uuid.UUID      `gorm:"type:uuid;not null;index" json:"host_id"`
+	Code           string         `gorm:"not null;uniqueIndex:idx_promo_codes_event_code,where:deleted_at IS NULL" json:"code"`
+	Description    string         `json:"description"`
+	DiscountType   DiscountType   `gorm:"type:varchar(20);not null" json:"discount_type"`
+	DiscountValue  float64        `gorm:"not null" json:"discount_value"`
+	TicketTypeIDs  pq.StringArray `gorm:"type:text[]" json:"ticket_type_ids"` // Empty applies to every ticket type
+	MaxUses        *int           `json:"max_uses"`                           // Nil is unlimited
+	MaxUsesPerUser *int           `json:"max_uses_per_user"`                  // Nil is unlimited
+	UsedCount      int            `gorm:"not null;default:0" json:"used_count"`
+	StartsAt       *time.Time     `json:"starts_at"`
+	EndsAt         *time.Time     `json:"ends_at"`
+	Active         bool           `gorm:"not null" json:"active"`
+}
+
+type PromoRedemptionStatus string
+
+const (
+	RedemptionPending   PromoRedemptionStatus = "pending" // Checkout started, awaiting payment
+	RedemptionConfirmed PromoRedemptionStatus = "confirmed"
+	RedemptionReleased  PromoRedemptionStatus = "released" // Payment failed; the use was given back
+)
+
+// PromoRedemption records a promo code applied to a payment.
+type PromoRedemption struct {
+	gorm.Model
+	ID               uuid.UUID             `gorm:"type:uuid;primary_key;" json:"id"`
+	PromoCodeID      uuid.UUID             `gorm:"type:uuid;not null;index" json:"promo_code_id"`
+	PaymentID        uuid.UUID             `gorm:"type:uuid;not null;uniqueIndex" json:"payment_id"`
+	PaymentReference string                `gorm:"not null;index" json:"payment_reference"`
+	UserID           uuid.UUID             `gorm:"type:uuid;not null;index" json:"user_id"`
+	DiscountAmount   float64               `gorm:"not null" json:"discount_amount"`
+	Status           PromoRedemptionStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+}
+
+// PromoCodeStats is how a promo code has performed. Only confirmed
+// redemptions are counted.
+type PromoCodeStats struct {
+	PromoCodeID   uuid.UUID `json:"promo_code_id"`
+	Code          string    `json:"code"`
+	Redemptions   int64     `json:"redemptions"`
+	TicketsSold   int64     `json:"tickets_sold"`
+	Revenue       float64   `json:"revenue"` // As posted to the ledger: net of refunds and buyer fees
+	DiscountTotal float64   `json:"discount_total"`
+}
+
+func (p *PromoCode) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	return
+}
+
+func (r *PromoRedemption) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
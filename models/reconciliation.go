@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReconciliationAction string
+
+const (
+	ReconcileCompleted ReconciliationAction = "completed" // Gateway took the money; tickets were issued
+	ReconcileFailed    ReconciliationAction = "failed"    // Gateway reports the charge failed
+	ReconcileExpired   ReconciliationAction = "expired"   // Never paid within the expiry window
+	ReconcileDisputed  ReconciliationAction = "disputed"  // Gateway's record didn't match ours
+	ReconcileError     ReconciliationAction = "error"     // Couldn't verify or act; retried next run
+)
+
+// PaymentReconciliation records one action the reconciliation job took on a
+// stale pending payment. Discrepancy is set when the gateway knew something
+// we didn't, such as a charge whose webhook never arrived.
+type PaymentReconciliation struct {
+	gorm.Model
+	ID             uuid.UUID            `gorm:"type:uuid;primary_key;" json:"id"`
+	RunID          uuid.UUID            `gorm:"type:uuid;not null;index" json:"run_id"`
+	PaymentID      uuid.UUID            `gorm:"type:uuid;not null;index" json:"payment_id"`
+	Reference      string               `gorm:"not null;index" json:"reference"`
+	Gateway        string               `gorm:"type:varchar(20)" json:"gateway"`
+	Action         ReconciliationAction `gorm:"type:varchar(20);not null;index" json:"action"`
+	GatewayStatus  string               `json:"gateway_status,omitempty"`
+	Discrepancy    string               `json:"discrepancy,omitempty"`
+	Detail         string               `json:"detail,omitempty"`
+	PaymentAmount  float64              `json:"payment_amount"`
+	Currency       string               `gorm:"type:varchar(3)" json:"currency"`
+	PaymentCreated time.Time            `json:"payment_created_at"`
+}
+
+// ReconciliationSummary counts a run's, or a period's, actions.
+type ReconciliationSummary struct {
+	Action        ReconciliationAction `json:"action"`
+	Count         int64                `json:"count"`
+	Discrepancies int64                `json:"discrepancies"`
+}
+
+func (r *PaymentReconciliation) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending" // Recorded and sent to the gateway, awaiting its webhook
+	RefundProcessed RefundStatus = "processed"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// Refund is a full or partial refund of a payment. Tickets listed in
+// TicketIDs were cancelled and returned to inventory when it was initiated.
+type Refund struct {
+	gorm.Model
+	ID               uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	PaymentID        uuid.UUID      `gorm:"type:uuid;not null;index" json:"payment_id"`
+	Payment          Payment        `gorm:"foreignKey:PaymentID" json:"-"`
+	PaymentReference string         `gorm:"not null;index" json:"payment_reference"`
+	Amount           float64        `gorm:"not null" json:"amount"`
+	Currency         string         `gorm:"default:'NGN'" json:"currency"`
+	Reason           string         `json:"reason"`
+	Status           RefundStatus   `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	TicketIDs        pq.StringArray `gorm:"type:text[]" json:"ticket_ids"`
+	InitiatedBy      uuid.UUID      `gorm:"type:uuid;not null" json:"initiated_by"`
+	GatewayRefundID  string         `gorm:"index" json:"gateway_refund_id"`
+	FailureReason    string         `json:"failure_reason,omitempty"`
+	ProcessedAt      *time.Time     `json:"processed_at"`
+	Unfulfilled      bool           `gorm:"not null;default:false" json:"unfulfilled"` // Returns a payment none of whose tickets could be issued; never owed to the host
+}
+
+func (r *Refund) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
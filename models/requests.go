@@ -31,6 +31,13 @@ type CreateEventRequest struct {
 
 	// Tickets (only for ticketed events)
 	TicketTypes []CreateTicketTypeRequest `json:"ticketTypes"`
+
+	// Payments (only for ticketed events)
+	Currency       string `json:"currency,omitempty"`       // Defaults to NGN
+	PaymentGateway string `json:"paymentGateway,omitempty"` // Defaults to the gateway for the currency
+
+	// Ticket transfers; omitted leaves them as they are, allowed for new events
+	TransfersDisabled *bool `json:"transfersDisabled,omitempty"`
 }
 
 // LocationDataRequest represents location data with coordinates
@@ -49,44 +56,98 @@ type CoordinatesRequest struct {
 
 // CreateTicketTypeRequest represents a ticket type in the creation request
 type CreateTicketTypeRequest struct {
-	Name          string  `json:"name" validate:"required"`
-	Price         float64 `json:"price" validate:"min=0"`
-	Description   string  `json:"description"`
-	TotalQuantity int     `json:"totalQuantity" validate:"min=1"`
+	Name          string     `json:"name" validate:"required"`
+	Price         float64    `json:"price" validate:"min=0"`
+	Description   string     `json:"description"`
+	TotalQuantity int        `json:"totalQuantity" validate:"min=1"`
+	SalesStart    *time.Time `json:"salesStart,omitempty"`
+	SalesEnd      *time.Time `json:"salesEnd,omitempty"`
+	OpensAfter    *int       `json:"opensAfter,omitempty"` // Index in ticketTypes of the tier this one follows
+	Visibility    string     `json:"visibility,omitempty"` // "public" (default) or "hidden"
+	TicketTypeLimitsRequest
+}
+
+// TicketTypeLimitsRequest sets a ticket type's purchase limits; 0 means no limit
+type TicketTypeLimitsRequest struct {
+	MinPerOrder int  `json:"minPerOrder" validate:"min=0"`
+	MaxPerOrder int  `json:"maxPerOrder" validate:"min=0"`
+	MaxPerUser  int  `json:"maxPerUser" validate:"min=0"` // Across all of a buyer's orders
+	OnePerEmail bool `json:"onePerEmail"`                 // Each buyer account and attendee email may hold only one
+	OnePerPhone bool `json:"onePerPhone"`                 // Each attendee phone number may hold only one
+}
+
+// TicketTypeSalesRequest sets when a ticket type is on sale. Nil times leave
+// that side of the window open; PreviousTierID keeps it closed until that tier
+// sells out or ends.
+type TicketTypeSalesRequest struct {
+	SalesStart     *time.Time `json:"salesStart"`
+	SalesEnd       *time.Time `json:"salesEnd"`
+	PreviousTierID *string    `json:"previousTierId"`
+}
+
+// TicketTypeVisibilityRequest sets whether a ticket type is listed publicly
+type TicketTypeVisibilityRequest struct {
+	Visibility string `json:"visibility" validate:"required"` // "public" or "hidden"
+}
+
+// TicketTypeCapacityRequest sets how many tickets of a type there are
+type TicketTypeCapacityRequest struct {
+	TotalQuantity int `json:"totalQuantity" validate:"required"`
+}
+
+// TicketPriceChangeRequest schedules a ticket type's price to change
+type TicketPriceChangeRequest struct {
+	Price       float64   `json:"price" validate:"min=0"`
+	EffectiveAt time.Time `json:"effectiveAt" validate:"required"`
 }
 
 // EventResponse represents the response structure for events
 type EventResponse struct {
-	ID             uuid.UUID            `json:"id"`
-	Title          string               `json:"title"`
-	Description    string               `json:"description"`
-	StartDate      time.Time            `json:"start_date"`
-	StartTime      string               `json:"start_time"`
-	EndTime        string               `json:"end_time"`
-	Location       string               `json:"location"`
-	Latitude       *float64             `json:"latitude,omitempty"`
-	Longitude      *float64             `json:"longitude,omitempty"`
-	PlaceID        *string              `json:"place_id,omitempty"`
-	Tags           []string             `json:"tags"`
-	BannerImageURL string               `json:"banner_image_url"`
-	EventType      string               `json:"event_type"`
-	HostID         uuid.UUID            `json:"host_id"`
-	Host           UserResponse         `json:"host"`
-	TicketTypes    []TicketTypeResponse `json:"ticket_types,omitempty"`
-	Status         EventStatus          `json:"status"`
-	CreatedAt      time.Time            `json:"created_at"`
-	UpdatedAt      time.Time            `json:"updated_at"`
+	ID                uuid.UUID            `json:"id"`
+	Title             string               `json:"title"`
+	Description       string               `json:"description"`
+	StartDate         time.Time            `json:"start_date"`
+	StartTime         string               `json:"start_time"`
+	EndTime           string               `json:"end_time"`
+	Location          string               `json:"location"`
+	ManualDescription string               `json:"manual_description,omitempty"`
+	Latitude          *float64             `json:"latitude,omitempty"`
+	Longitude         *float64             `json:"longitude,omitempty"`
+	PlaceID           *string              `json:"place_id,omitempty"`
+	Tags              []string             `json:"tags"`
+	BannerImageURL    string               `json:"banner_image_url"`
+	EventType         string               `json:"event_type"`
+	HostID            uuid.UUID            `json:"host_id"`
+	Host              UserResponse         `json:"host"`
+	TicketTypes       []TicketTypeResponse `json:"ticket_types,omitempty"`
+	Currency          string               `json:"currency"`
+	TransfersDisabled bool                 `json:"transfers_disabled"`
+	Status            EventStatus          `json:"status"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
 }
 
 // TicketTypeResponse represents the response structure for ticket types
 type TicketTypeResponse struct {
-	ID                uuid.UUID `json:"id"`
-	Name              string    `json:"name"`
-	Price             float64   `json:"price"`
-	Description       string    `json:"description"`
-	TotalQuantity     int       `json:"total_quantity"`
-	SoldQuantity      int       `json:"sold_quantity"`
-	AvailableQuantity int       `json:"available_quantity"`
+	ID                uuid.UUID        `json:"id"`
+	Name              string           `json:"name"`
+	Price             float64          `json:"price"`
+	Description       string           `json:"description"`
+	TotalQuantity     int              `json:"total_quantity"`
+	SoldQuantity      int              `json:"sold_quantity"`
+	AvailableQuantity int              `json:"available_quantity"`
+	MinPerOrder       int              `json:"min_per_order,omitempty"`
+	MaxPerOrder       int              `json:"max_per_order,omitempty"`
+	MaxPerUser        int              `json:"max_per_user,omitempty"`
+	OnePerEmail       bool             `json:"one_per_email,omitempty"`
+	OnePerPhone       bool             `json:"one_per_phone,omitempty"`
+	SalesStart        *time.Time       `json:"sales_start,omitempty"`
+	SalesEnd          *time.Time       `json:"sales_end,omitempty"`
+	PreviousTierID    *uuid.UUID       `json:"previous_tier_id,omitempty"`
+	SaleState         TicketSaleState  `json:"sale_state"`
+	Visibility        TicketVisibility `json:"visibility"`
+	EntryLimit        int              `json:"entry_limit"` // 0 for unlimited re-entry
+	EntryPerDay       bool             `json:"entry_per_day"`
 }
 
 // SignupRequest represents the request payload for user registration
@@ -137,6 +198,8 @@ type PaymentInitiationRequest struct {
 	AttendeeData  AttendeeDataRequest   `json:"attendeeData" validate:"required"` // Primary attendee for payment
 	Attendees     []AttendeeDataRequest `json:"attendees,omitempty"`              // All attendees (optional for backward compatibility)
 	TicketDetails []TicketDetailRequest `json:"ticketDetails" validate:"required,min=1"`
+	PromoCode     string                `json:"promoCode,omitempty"`
+	AccessCode    string                `json:"accessCode,omitempty"` // Code or link token unlocking hidden ticket types
 }
 
 // AttendeeDataRequest represents attendee information
@@ -151,22 +214,175 @@ type MultipleAttendeesRequest struct {
 	Attendees []AttendeeDataRequest `json:"attendees" validate:"required,min=1"`
 }
 
-// TicketDetailRequest represents ticket purchase details
+// TicketDetailRequest represents ticket purchase details.
+// TicketTypeName and Price are informational only; the server prices the
+// order from the ticket type.
 type TicketDetailRequest struct {
 	TicketTypeID   string  `json:"ticketTypeId" validate:"required"`
-	TicketTypeName string  `json:"ticketTypeName" validate:"required"`
+	TicketTypeName string  `json:"ticketTypeName"`
 	Quantity       int     `json:"quantity" validate:"required,min=1"`
-	Price          float64 `json:"price" validate:"required,min=0"`
+	Price          float64 `json:"price,omitempty"`
 }
 
 // PaymentInitiationResponse represents the response for payment initiation
+
 type PaymentInitiationResponse struct {
-	Reference   string `json:"reference"`
-	Amount      int64  `json:"amount"` // Amount in kobo
-	PaystackURL string `json:"paystackUrl"`
-	PublicKey   string `json:"publicKey"`
-	Email       string `json:"email"`
-	Currency    string `json:"currency"`
+	Reference     string        `json:"reference"`
+	Amount        int64         `json:"amount"`             // Amount in the currency's minor unit (kobo, cents)
+	Discount      float64       `json:"discount,omitempty"` // Promo discount already taken off Amount, in major units
+	Gateway       string        `json:"gateway"`
+	CheckoutURL   string        `json:"checkoutUrl"`
+	AccessCode    string        `json:"accessCode,omitempty"`  // Paystack inline checkout only
+	PaystackURL   string        `json:"paystackUrl,omitempty"` // Deprecated: use checkoutUrl
+	PublicKey     string        `json:"publicKey"`
+	Email         string        `json:"email"`
+	Currency      string        `json:"currency"`
+	HoldExpiresAt time.Time     `json:"holdExpiresAt"` // Tickets are held for this payment until then
+	Fees          *FeeBreakdown `json:"fees"`          // Amount is Fees.Total
+}
+
+// CreateRefundRequest represents a host or admin refunding a payment.
+// Leave both Amount and TicketIDs empty to refund everything that's left.
+type CreateRefundRequest struct {
+	Amount    float64  `json:"amount,omitempty"`    // Defaults to the price of TicketIDs
+	TicketIDs []string `json:"ticketIds,omitempty"` // Tickets to cancel and return to inventory
+	Reason    string   `json:"reason"`
+}
+
+// PromoCodeRequest represents a host creating or updating a promo code.
+// On update, omitted fields are left unchanged.
+type PromoCodeRequest struct {
+	Code           string     `json:"code"`
+	Description    *string    `json:"description,omitempty"`
+	DiscountType   string     `json:"discountType"` // "percentage" or "fixed"
+	DiscountValue  *float64   `json:"discountValue"`
+	TicketTypeIDs  []string   `json:"ticketTypeIds"`  // Empty applies to every ticket type
+	MaxUses        *int       `json:"maxUses"`        // 0 makes it unlimited
+	MaxUsesPerUser *int       `json:"maxUsesPerUser"` // 0 makes it unlimited
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	Active         *bool      `json:"active"`
+}
+
+// AccessCodeRequest represents a host creating or updating an access code.
+// On update, omitted fields are left unchanged.
+type AccessCodeRequest struct {
+	Code          string     `json:"code"` // Typed codes only; links are given a generated token
+	Kind          string     `json:"kind"` // "code" (default) or "link"; can't be changed
+	Label         *string    `json:"label,omitempty"`
+	TicketTypeIDs []string   `json:"ticketTypeIds"` // Hidden ticket types; empty unlocks all of them
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	Active        *bool      `json:"active"`
+}
+
+// TicketTransferRequest represents a ticket holder inviting someone to take their ticket
+type TicketTransferRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// AcceptTicketTransferRequest represents a recipient accepting a ticket.
+// Without an attendee the ticket is for the recipient themselves.
+type AcceptTicketTransferRequest struct {
+	Attendee *AttendeeDataRequest `json:"attendee,omitempty"`
+}
+
+// JoinWaitlistRequest represents a buyer queueing for a sold-out ticket type
+type JoinWaitlistRequest struct {
+	TicketTypeID string `json:"ticketTypeId" validate:"required"`
+	Quantity     int    `json:"quantity"`   // Defaults to 1
+	AccessCode   string `json:"accessCode"` // Needed for hidden ticket types
+}
+
+// WaitlistPositionRequest moves a waiting entry to a place in line, 1 being next
+type WaitlistPositionRequest struct {
+	Position int `json:"position" validate:"required"`
+}
+
+// OfflineCheckInSyncRequest represents a door scanner uploading the
+// check-ins it made while offline
+type OfflineCheckInSyncRequest struct {
+	DeviceID string                  `json:"deviceId" validate:"required"`
+	CheckIns []OfflineCheckInRequest `json:"checkIns" validate:"required"`
+}
+
+// OfflineCheckInRequest is one scan made offline, timed by the device clock
+type OfflineCheckInRequest struct {
+	QRCode    string    `json:"qrCode" validate:"required"`
+	ScannedAt time.Time `json:"scannedAt" validate:"required"`
+	Gate      string    `json:"gate"`
+}
+
+// InviteStaffRequest represents a host inviting someone to work an event
+type InviteStaffRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required"` // scanner, box_office or co_manager
+}
+
+// StaffRoleRequest represents changing a staff member's role
+type StaffRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// UndoCheckInRequest represents taking back a check-in made by mistake
+type UndoCheckInRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// TicketTypeEntryRequest represents setting how many times a ticket type gets in
+type TicketTypeEntryRequest struct {
+	EntryLimit  int  `json:"entryLimit" validate:"min=0"` // 0 for unlimited re-entry
+	EntryPerDay bool `json:"entryPerDay"`
+}
+
+// BoxOfficeSaleRequest represents selling or comping tickets at the door,
+// all for one attendee
+
+type BoxOfficeSaleRequest struct {
+	TicketTypeID  string            `json:"ticketTypeId" validate:"required"`
+	Quantity      int               `json:"quantity" validate:"required,min=1"`
+	PaymentMethod string            `json:"paymentMethod" validate:"required"` // "cash", "pos" or "comp"
+	Attendee      BoxOfficeAttendee `json:"attendee" validate:"required"`
+	CheckIn       bool              `json:"checkIn"` // Check the tickets in straight away
+	Gate          string            `json:"gate"`
+}
+
+// BoxOfficeAttendee is who box office tickets are for. Email is optional;
+// with it, the tickets are emailed and go to the account using it, if any.
+type BoxOfficeAttendee struct {
+	FullName string `json:"fullName" validate:"required"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+}
+
+// AttendeeImportCommitRequest represents a host going ahead with a previewed
+// attendee import
+type AttendeeImportCommitRequest struct {
+	ImportDuplicates bool `json:"importDuplicates"` // Also import rows whose email already has a ticket
+	SendInvites      bool `json:"sendInvites"`      // Email each attendee their ticket
+}
+
+// BankAccountRequest represents a host setting the account payouts are sent to
+type BankAccountRequest struct {
+	BankCode      string `json:"bankCode" validate:"required"`
+	BankName      string `json:"bankName"`
+	AccountNumber string `json:"accountNumber" validate:"required"`
+	AccountName   string `json:"accountName" validate:"required"`
+	Currency      string `json:"currency"` // Defaults to NGN
+}
+
+// FeeScheduleRequest represents an admin setting fees for the platform, a
+// host or an event. Omitted fields inherit from the wider scope.
+type FeeScheduleRequest struct {
+	Percent          *float64 `json:"percent"`
+	Flat             *float64 `json:"flat"`
+	Cap              *float64 `json:"cap"`              // 0 is uncapped
+	GatewayFeeBearer *string  `json:"gatewayFeeBearer"` // "platform" or "host"
+	PassFeesToBuyer  *bool    `json:"passFeesToBuyer"`
+}
+
+// PassFeesRequest represents a host choosing who pays their fees
+type PassFeesRequest struct {
+	PassFeesToBuyer bool `json:"passFeesToBuyer"`
 }
 
 // TicketResponse represents a purchased ticket
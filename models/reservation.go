@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationConverted ReservationStatus = "converted" // Payment succeeded; counted in SoldQuantity
+	ReservationReleased  ReservationStatus = "released"  // Expired or payment failed
+)
+
+// TicketReservation holds inventory for a pending checkout so the same seats
+// can't be sold twice while the buyer is paying.
+type TicketReservation struct {
+	gorm.Model
+	ID               uuid.UUID         `gorm:"type:uuid;primary_key;" json:"id"`
+	TicketTypeID     uuid.UUID         `gorm:"type:uuid;not null;index" json:"ticket_type_id"`
+	EventID          uuid.UUID         `gorm:"type:uuid;not null" json:"event_id"`
+	UserID           uuid.UUID         `gorm:"type:uuid;not null" json:"user_id"`
+	PaymentReference string            `gorm:"not null;index" json:"payment_reference"`
+	Quantity         int               `gorm:"not null" json:"quantity"`
+	Status           ReservationStatus `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	ExpiresAt        time.Time         `gorm:"not null;index" json:"expires_at"`
+	// The held tickets' attendees, normalised, so one-per-contact limits count them
+	AttendeeEmails pq.StringArray `gorm:"type:text[]" json:"-"`
+	AttendeePhones pq.StringArray `gorm:"type:text[]" json:"-"`
+}
+
+func (r *TicketReservation) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
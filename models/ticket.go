@@ -2,6 +2,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -24,6 +26,23 @@ type Ticket struct {
 	Quantity         int    `gorm:"not null;default:1" json:"quantity"`
 }
 
+type TicketSaleState string
+
+const (
+	TicketOnSale         TicketSaleState = "on_sale"
+	TicketSaleNotStarted TicketSaleState = "not_started"
+	TicketSaleEnded      TicketSaleState = "ended"
+	TicketSoldOut        TicketSaleState = "sold_out"
+	TicketAwaitingTier   TicketSaleState = "awaiting_previous_tier" // Opens once the previous tier sells out or ends
+)
+
+type TicketVisibility string
+
+const (
+	TicketPublic TicketVisibility = "public"
+	TicketHidden TicketVisibility = "hidden" // Listed and sold only with an access code that unlocks it
+)
+
 type TicketType struct {
 	gorm.Model
 	ID            uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
@@ -34,6 +53,34 @@ type TicketType struct {
 	Description   string    `json:"description"`
 	TotalQuantity int       `gorm:"not null;default:100" json:"total_quantity"`
 	SoldQuantity  int       `gorm:"not null;default:0" json:"sold_quantity"`
+	// Purchase limits; 0 means no limit
+	MinPerOrder int  `gorm:"not null;default:0" json:"min_per_order"`
+	MaxPerOrder int  `gorm:"not null;default:0" json:"max_per_order"`
+	MaxPerUser  int  `gorm:"not null;default:0" json:"max_per_user"`      // Across all of a buyer's orders
+	OnePerEmail bool `gorm:"not null;default:false" json:"one_per_email"` // Each buyer account and attendee email may hold only one
+	OnePerPhone bool `gorm:"not null;default:false" json:"one_per_phone"` // Each attendee phone number may hold only one
+	// Sale window and tiers; nil times leave that side open
+	SalesStart     *time.Time      `json:"sales_start"`
+	SalesEnd       *time.Time      `json:"sales_end"`
+	PreviousTierID *uuid.UUID      `gorm:"type:uuid" json:"previous_tier_id,omitempty"` // Stays closed until this tier sells out or ends
+	SaleState      TicketSaleState `gorm:"-" json:"sale_state,omitempty"`               // Worked out when listed, not stored
+	// Hidden types are listed and sold only with an access code that unlocks them
+	Visibility TicketVisibility `gorm:"type:varchar(10);not null;default:'public'" json:"visibility"`
+	// Entry at the door: how many times a ticket gets in, 0 for unlimited
+	// re-entry, and whether that resets each day for multi-day passes
+	EntryLimit  int  `gorm:"not null;default:1" json:"entry_limit"`
+	EntryPerDay bool `gorm:"not null;default:false" json:"entry_per_day"`
+}
+
+// TicketPriceChange schedules a ticket type's price to change at EffectiveAt.
+// Orders already started keep the price they were quoted.
+type TicketPriceChange struct {
+	gorm.Model
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	TicketTypeID uuid.UUID  `gorm:"type:uuid;not null;index" json:"ticket_type_id"`
+	Price        float64    `gorm:"not null" json:"price"`
+	EffectiveAt  time.Time  `gorm:"not null;index" json:"effective_at"`
+	AppliedAt    *time.Time `json:"applied_at"`
 }
 
 func (t *Ticket) BeforeCreate(tx *gorm.DB) (err error) {
@@ -45,3 +92,8 @@ func (tt *TicketType) BeforeCreate(tx *gorm.DB) (err error) {
 	tt.ID = uuid.New()
 	return
 }
+
+func (pc *TicketPriceChange) BeforeCreate(tx *gorm.DB) (err error) {
+	pc.ID = uuid.New()
+	return
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TicketTransferStatus string
+
+const (
+	TransferPending   TicketTransferStatus = "pending" // Invite sent, awaiting the recipient
+	TransferAccepted  TicketTransferStatus = "accepted"
+	TransferDeclined  TicketTransferStatus = "declined"
+	TransferCancelled TicketTransferStatus = "cancelled" // Withdrawn by the sender
+	TransferExpired   TicketTransferStatus = "expired"
+)
+
+// TicketTransfer is an invite to hand a ticket to someone else. Accepting it
+// re-issues the ticket to the recipient with a new QR code. A ticket's
+// transfers, accepted or not, make up its transfer history.
+type TicketTransfer struct {
+	gorm.Model
+	ID         uuid.UUID            `gorm:"type:uuid;primary_key;" json:"id"`
+	TicketID   uuid.UUID            `gorm:"type:uuid;not null;index" json:"ticket_id"`
+	Ticket     Ticket               `gorm:"foreignKey:TicketID" json:"ticket,omitempty"`
+	EventID    uuid.UUID            `gorm:"type:uuid;not null;index" json:"event_id"`
+	FromUserID uuid.UUID            `gorm:"type:uuid;not null;index" json:"from_user_id"`
+	ToEmail    string               `gorm:"not null;index" json:"to_email"`
+	ToUserID   *uuid.UUID           `gorm:"type:uuid" json:"to_user_id,omitempty"` // Set once accepted
+	Token      string               `gorm:"not null;uniqueIndex" json:"-"`         // Carried by the invite link
+	Status     TicketTransferStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ExpiresAt  time.Time            `gorm:"not null" json:"expires_at"`
+	// Who the ticket was for before and after the transfer
+	FromAttendeeName  string     `json:"from_attendee_name"`
+	FromAttendeeEmail string     `json:"from_attendee_email"`
+	ToAttendeeName    string     `json:"to_attendee_name,omitempty"`
+	ToAttendeeEmail   string     `json:"to_attendee_email,omitempty"`
+	RespondedAt       *time.Time `json:"responded_at,omitempty"`
+}
+
+func (t *TicketTransfer) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = uuid.New()
+	return
+}
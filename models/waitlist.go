@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting WaitlistStatus = "waiting"
+	WaitlistOffered WaitlistStatus = "offered" // Tickets are held for them until OfferExpiresAt
+	WaitlistClaimed WaitlistStatus = "claimed" // They started a checkout or RSVP with the offer
+	WaitlistExpired WaitlistStatus = "expired" // The offer lapsed unused
+	WaitlistRemoved WaitlistStatus = "removed" // They left, or the host removed them
+)
+
+// WaitlistEntry queues a buyer for a sold-out ticket type. As tickets free
+// up, waiting entries are offered them in Position order, each offer backed
+// by a hold of its own.
+type WaitlistEntry struct {
+	gorm.Model
+	ID               uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	TicketTypeID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"ticket_type_id"`
+	TicketType       TicketType     `gorm:"foreignKey:TicketTypeID" json:"ticket_type"`
+	EventID          uuid.UUID      `gorm:"type:uuid;not null;index" json:"event_id"`
+	UserID           uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	User             User           `gorm:"foreignKey:UserID" json:"user"`
+	Quantity         int            `gorm:"not null;default:1" json:"quantity"`
+	Position         int            `gorm:"not null" json:"position"` // Lower is offered first
+	Status           WaitlistStatus `gorm:"type:varchar(20);not null;default:'waiting';index" json:"status"`
+	OfferReference   string         `gorm:"index" json:"-"` // Reference of the hold backing the offer
+	OfferedQuantity  int            `json:"offered_quantity,omitempty"`
+	OfferedAt        *time.Time     `json:"offered_at,omitempty"`
+	OfferExpiresAt   *time.Time     `json:"offer_expires_at,omitempty"`
+	ClaimedReference string         `json:"claimed_reference,omitempty"` // Payment reference of the checkout that used the offer
+	Place            int            `gorm:"-" json:"place,omitempty"`    // 1 for the next waiting entry to be offered; worked out when listed
+}
+
+func (w *WaitlistEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	w.ID = uuid.New()
+	return
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookEventStatus string
+
+const (
+	WebhookReceived   WebhookEventStatus = "received"
+	WebhookProcessing WebhookEventStatus = "processing"
+	WebhookProcessed  WebhookEventStatus = "processed"
+	WebhookFailed     WebhookEventStatus = "failed"
+	WebhookIgnored    WebhookEventStatus = "ignored"  // Valid delivery for an event type we don't handle
+	WebhookRejected   WebhookEventStatus = "rejected" // Signature check failed; never processed
+)
+
+// WebhookEvent is a stored webhook delivery from a payment provider. Deliveries
+// are keyed by provider and provider event ID so retries can be recognised.
+type WebhookEvent struct {
+	gorm.Model
+	ID              uuid.UUID          `gorm:"type:uuid;primary_key;" json:"id"`
+	Provider        string             `gorm:"type:varchar(20);not null;uniqueIndex:idx_webhook_provider_event" json:"provider"`
+	ProviderEventID *string            `gorm:"uniqueIndex:idx_webhook_provider_event" json:"provider_event_id"` // nil for rejected deliveries
+	EventType       string             `json:"event_type"`
+	Reference       string             `gorm:"index" json:"reference"`
+	RawBody         string             `gorm:"type:text;not null" json:"raw_body"`
+	SignatureValid  bool               `gorm:"not null;default:false" json:"signature_valid"`
+	Status          WebhookEventStatus `gorm:"type:varchar(20);not null;default:'received'" json:"status"`
+	Error           string             `gorm:"type:text" json:"error,omitempty"`
+	Attempts        int                `gorm:"not null;default:0" json:"attempts"`
+	ProcessedAt     *time.Time         `json:"processed_at"`
+}
+
+func (w *WebhookEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	w.ID = uuid.New()
+	return
+}
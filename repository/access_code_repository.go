@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type AccessCodeRepository interface {
+	CreateAccessCode(code *models.AccessCode) error
+	GetAccessCodeByID(id uuid.UUID) (*models.AccessCode, error)
+	// GetAccessCodeByCode looks up an event's code; code must already be upper-case.
+	GetAccessCodeByCode(eventID uuid.UUID, code string) (*models.AccessCode, error)
+	GetAccessCodesByEventID(eventID uuid.UUID) ([]models.AccessCode, error)
+	UpdateAccessCode(code *models.AccessCode) error
+	DeleteAccessCode(id uuid.UUID) error
+}
+
+type accessCodeRepoPG struct {
+	db *gorm.DB
+}
+
+func NewAccessCodeRepoPG(db *gorm.DB) AccessCodeRepository {
+	return &accessCodeRepoPG{db: db}
+}
+
+func (r *accessCodeRepoPG) CreateAccessCode(code *models.AccessCode) error {
+	return r.db.Create(code).Error
+}
+
+func (r *accessCodeRepoPG) GetAccessCodeByID(id uuid.UUID) (*models.AccessCode, error) {
+	var code models.AccessCode
+	err := r.db.First(&code, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *accessCodeRepoPG) GetAccessCodeByCode(eventID uuid.UUID, code string) (*models.AccessCode, error) {
+	var accessCode models.AccessCode
+	err := r.db.Where("event_id = ? AND code = ?", eventID, code).First(&accessCode).Error
+	if err != nil {
+		return nil, err
+	}
+	return &accessCode, nil
+}
+
+func (r *accessCodeRepoPG) GetAccessCodesByEventID(eventID uuid.UUID) ([]models.AccessCode, error) {
+	var codes []models.AccessCode
+	err := r.db.Where("event_id = ?", eventID).Order("created_at DESC").Find(&codes).Error
+	return codes, err
+}
+
+func (r *accessCodeRepoPG) UpdateAccessCode(code *models.AccessCode) error {
+	return r.db.Save(code).Error
+}
+
+func (r *accessCodeRepoPG) DeleteAccessCode(id uuid.UUID) error {
+	return r.db.Delete(&models.AccessCode{}, "id = ?", id).Error
+}
@@ -25,6 +25,9 @@ type AnalyticsRepository interface {
 	GetHostDashboardStats(hostID uuid.UUID) (map[string]interface{}, error)
 	GetEventPerformanceStats(eventID uuid.UUID) (map[string]interface{}, error)
 	GetMonthlyRevenueStats(hostID uuid.UUID, year int) ([]map[string]interface{}, error)
+	GetPromoCodeStats(eventID uuid.UUID) ([]models.PromoCodeStats, error)
+	GetEventRevenueByChannel(eventID uuid.UUID) ([]models.ChannelRevenue, error)
+	GetHostRevenueByChannel(hostID uuid.UUID) ([]models.ChannelRevenue, error)
 }
 
 type analyticsRepoPG struct {
@@ -92,9 +95,9 @@ func (a *analyticsRepoPG) GetHostDashboardStats(hostID uuid.UUID) (map[string]in
 
 	// Total revenue
 	var totalRevenue float64
-	a.db.Model(&models.Payment{}).
-		Where("event_id IN (SELECT id FROM events WHERE host_id = ?) AND status = ?", hostID, models.PaymentCompleted).
-		Select("COALESCE(SUM(amount), 0)").
+	ledgerRevenue(a.db).
+		Where("host_id = ?", hostID).
+		Select("COALESCE(SUM(credit - debit), 0)").
 		Scan(&totalRevenue)
 	stats["total_revenue"] = totalRevenue
 
@@ -110,13 +113,19 @@ func (a *analyticsRepoPG) GetHostDashboardStats(hostID uuid.UUID) (map[string]in
 	now := time.Now()
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	var monthlyRevenue float64
-	a.db.Model(&models.Payment{}).
-		Where("event_id IN (SELECT id FROM events WHERE host_id = ?) AND status = ? AND created_at >= ?",
-			hostID, models.PaymentCompleted, startOfMonth).
-		Select("COALESCE(SUM(amount), 0)").
+	ledgerRevenue(a.db).
+		Where("host_id = ? AND effective_at >= ?", hostID, startOfMonth).
+		Select("COALESCE(SUM(credit - debit), 0)").
 		Scan(&monthlyRevenue)
 	stats["monthly_revenue"] = monthlyRevenue
 
+	// Revenue by sales channel
+	channels, err := a.GetHostRevenueByChannel(hostID)
+	if err != nil {
+		return nil, err
+	}
+	stats["revenue_by_channel"] = channels
+
 	return stats, nil
 }
 
@@ -143,12 +152,19 @@ func (a *analyticsRepoPG) GetEventPerformanceStats(eventID uuid.UUID) (map[strin
 
 	// Revenue
 	var revenue float64
-	a.db.Model(&models.Payment{}).
-		Where("event_id = ? AND status = ?", eventID, models.PaymentCompleted).
-		Select("COALESCE(SUM(amount), 0)").
+	ledgerRevenue(a.db).
+		Where("event_id = ?", eventID).
+		Select("COALESCE(SUM(credit - debit), 0)").
 		Scan(&revenue)
 	stats["revenue"] = revenue
 
+	// Revenue by sales channel
+	channels, err := a.GetEventRevenueByChannel(eventID)
+	if err != nil {
+		return nil, err
+	}
+	stats["revenue_by_channel"] = channels
+
 	// Conversion rate
 	var conversionRate float64
 	if uniqueViews > 0 {
@@ -166,11 +182,12 @@ func (a *analyticsRepoPG) GetMonthlyRevenueStats(hostID uuid.UUID, year int) ([]
 		Count   int64
 	}
 
-	err := a.db.Model(&models.Payment{}).
-		Select("EXTRACT(MONTH FROM created_at) as month, COALESCE(SUM(amount), 0) as revenue, COUNT(*) as count").
-		Where("event_id IN (SELECT id FROM events WHERE host_id = ?) AND status = ? AND EXTRACT(YEAR FROM created_at) = ?",
-			hostID, models.PaymentCompleted, year).
-		Group("EXTRACT(MONTH FROM created_at)").
+	// count is the number of sales; refunds only reduce revenue
+	err := ledgerRevenue(a.db).
+		Select("EXTRACT(MONTH FROM effective_at) as month, COALESCE(SUM(credit - debit), 0) as revenue, COUNT(*) FILTER (WHERE transaction_type IN ?) as count",
+			[]models.LedgerTransactionType{models.LedgerCharge, models.LedgerBoxOfficeSale}).
+		Where("host_id = ? AND EXTRACT(YEAR FROM effective_at) = ?", hostID, year).
+		Group("EXTRACT(MONTH FROM effective_at)").
 		Order("month").
 		Find(&results).Error
 
@@ -190,3 +207,72 @@ func (a *analyticsRepoPG) GetMonthlyRevenueStats(hostID uuid.UUID, year int) ([]
 
 	return monthlyStats, nil
 }
+
+// paymentRevenue totals each payment's revenue as posted to the ledger, net
+// of refunds, for joining onto payments.
+func (a *analyticsRepoPG) paymentRevenue() *gorm.DB {
+	return ledgerRevenue(a.db).
+		Select("payment_id, SUM(credit - debit) AS revenue").
+		Where("payment_id IS NOT NULL").
+		Group("payment_id")
+}
+
+func (a *analyticsRepoPG) GetPromoCodeStats(eventID uuid.UUID) ([]models.PromoCodeStats, error) {
+	var stats []models.PromoCodeStats
+	err := a.db.Raw(`
+		SELECT pc.id AS promo_code_id, pc.code,
+			COUNT(pr.id) AS redemptions,
+			COALESCE(SUM(li.quantity), 0) AS tickets_sold,
+			COALESCE(SUM(rev.revenue), 0) AS revenue,
+			COALESCE(SUM(pr.discount_amount), 0) AS discount_total
+		FROM promo_codes pc
+		LEFT JOIN promo_redemptions pr
+			ON pr.promo_code_id = pc.id AND pr.status = ? AND pr.deleted_at IS NULL
+		LEFT JOIN payments p ON p.id = pr.payment_id
+		LEFT JOIN (
+			SELECT payment_id, SUM(quantity) AS quantity
+			FROM payment_line_items
+			WHERE deleted_at IS NULL
+			GROUP BY payment_id
+		) li ON li.payment_id = p.id
+		LEFT JOIN (?) rev ON rev.payment_id = p.id
+		WHERE pc.event_id = ? AND pc.deleted_at IS NULL
+		GROUP BY pc.id, pc.code
+		ORDER BY revenue DESC`, models.RedemptionConfirmed, a.paymentRevenue(), eventID).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// revenueByChannel totals completed and refunded payments matching where by
+// channel: box office sales under their payment method, everything else
+// under "online". Revenue is as posted to the ledger.
+func (a *analyticsRepoPG) revenueByChannel(where string, args ...interface{}) ([]models.ChannelRevenue, error) {
+	var channels []models.ChannelRevenue
+	err := a.db.Raw(`
+		SELECT CASE WHEN p.method IN ? THEN p.method::text ELSE 'online' END AS channel,
+			COUNT(p.id) AS orders,
+			COALESCE(SUM(li.quantity), 0) AS tickets,
+			COALESCE(SUM(rev.revenue), 0) AS revenue
+		FROM payments p
+		JOIN events e ON e.id = p.event_id
+		LEFT JOIN (
+			SELECT payment_id, SUM(quantity) AS quantity
+			FROM payment_line_items
+			WHERE deleted_at IS NULL
+			GROUP BY payment_id
+		) li ON li.payment_id = p.id
+		LEFT JOIN (?) rev ON rev.payment_id = p.id
+		WHERE p.status IN ? AND p.deleted_at IS NULL AND `+where+`
+		GROUP BY channel
+		ORDER BY channel`, append([]interface{}{models.BoxOfficeMethods, a.paymentRevenue(), revenueStatuses}, args...)...).
+		Scan(&channels).Error
+	return channels, err
+}
+
+func (a *analyticsRepoPG) GetEventRevenueByChannel(eventID uuid.UUID) ([]models.ChannelRevenue, error) {
+	return a.revenueByChannel("p.event_id = ?", eventID)
+}
+
+func (a *analyticsRepoPG) GetHostRevenueByChannel(hostID uuid.UUID) ([]models.ChannelRevenue, error) {
+	return a.revenueByChannel("e.host_id = ?", hostID)
+}
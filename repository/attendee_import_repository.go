@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type AttendeeImportRepository interface {
+	Create(attendeeImport *models.AttendeeImport) error
+	CreateRows(rows []models.AttendeeImportRow) error
+	GetByID(id uuid.UUID) (*models.AttendeeImport, error)
+	GetByEventID(eventID uuid.UUID) ([]models.AttendeeImport, error)
+	// GetRows returns an import's rows in CSV order, optionally only those
+	// in status, and how many there are in all.
+	GetRows(importID uuid.UUID, status models.AttendeeImportRowStatus, limit, offset int) ([]models.AttendeeImportRow, int64, error)
+	CountRows(importID uuid.UUID) (map[models.AttendeeImportRowStatus]int64, error)
+
+	// Queue moves a pending import to the queue, with the options it was
+	// committed with. It reports false if the import wasn't pending.
+	Queue(id uuid.UUID, importDuplicates, sendInvites bool, queuedRows int) (bool, error)
+	// Claim starts processing a queued import, reporting false if it wasn't
+	// queued.
+	Claim(id uuid.UUID) (bool, error)
+	// ClaimNext starts processing the oldest queued import, or one left
+	// processing without a heartbeat since staleBefore, e.g. by a worker that
+	// died. It returns nil when there is none.
+	ClaimNext(staleBefore time.Time) (*models.AttendeeImport, error)
+	// Heartbeat marks a processing import as still being worked on.
+	Heartbeat(id uuid.UUID) error
+	Finish(id uuid.UUID, status models.AttendeeImportStatus, reason string) error
+
+	// GetRowsToImport returns up to limit of an import's rows still in one
+	// of statuses, in CSV order.
+	GetRowsToImport(importID uuid.UUID, statuses []models.AttendeeImportRowStatus, limit int) ([]models.AttendeeImportRow, error)
+	// FinishRow records what became of a row still in one of from, reporting
+	// false if it had already been dealt with.
+	FinishRow(id uuid.UUID, from []models.AttendeeImportRowStatus, status models.AttendeeImportRowStatus, ticketID *uuid.UUID, reason string) (bool, error)
+}
+
+type attendeeImportRepoPG struct {
+	db *gorm.DB
+}
+
+func NewAttendeeImportRepoPG(db *gorm.DB) AttendeeImportRepository {
+	return &attendeeImportRepoPG{db: db}
+}
+
+// importRowBatchSize is how many rows are inserted per statement.
+const importRowBatchSize = 500
+
+func (r *attendeeImportRepoPG) Create(attendeeImport *models.AttendeeImport) error {
+	return r.db.Create(attendeeImport).Error
+}
+
+func (r *attendeeImportRepoPG) CreateRows(rows []models.AttendeeImportRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(rows, importRowBatchSize).Error
+}
+
+func (r *attendeeImportRepoPG) GetByID(id uuid.UUID) (*models.AttendeeImport, error) {
+	var attendeeImport models.AttendeeImport
+	err := r.db.Where("id = ?", id).First(&attendeeImport).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attendeeImport, nil
+}
+
+func (r *attendeeImportRepoPG) GetByEventID(eventID uuid.UUID) ([]models.AttendeeImport, error) {
+	var imports []models.AttendeeImport
+	err := r.db.Where("event_id = ?", eventID).Order("created_at DESC").Find(&imports).Error
+	return imports, err
+}
+
+func (r *attendeeImportRepoPG) GetRows(importID uuid.UUID, status models.AttendeeImportRowStatus, limit, offset int) ([]models.AttendeeImportRow, int64, error) {
+	query := r.db.Model(&models.AttendeeImportRow{}).Where("import_id = ?", importID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.AttendeeImportRow
+	err := query.Order("line").Limit(limit).Offset(offset).Find(&rows).Error
+	return rows, total, err
+}
+
+func (r *attendeeImportRepoPG) CountRows(importID uuid.UUID) (map[models.AttendeeImportRowStatus]int64, error) {
+	var counts []models.AttendeeImportRowCount
+	err := r.db.Model(&models.AttendeeImportRow{}).
+		Select("status, COUNT(*) AS count").
+		Where("import_id = ?", importID).
+		Group("status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byStatus := make(map[models.AttendeeImportRowStatus]int64, len(counts))
+	for _, count := range counts {
+		byStatus[count.Status] = count.Count
+	}
+	return byStatus, nil
+}
+
+func (r *attendeeImportRepoPG) Queue(id uuid.UUID, importDuplicates, sendInvites bool, queuedRows int) (bool, error) {
+	result := r.db.Model(&models.AttendeeImport{}).
+		Where("id = ? AND status = ?", id, models.ImportPending).
+		Updates(map[string]interface{}{
+			"status":            models.ImportQueued,
+			"import_duplicates": importDuplicates,
+			"send_invites":      sendInvites,
+			"queued_rows":       queuedRows,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *attendeeImportRepoPG) Claim(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.AttendeeImport{}).
+		Where("id = ? AND status = ?", id, models.ImportQueued).
+		Updates(map[string]interface{}{
+			"status":     models.ImportProcessing,
+			"started_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *attendeeImportRepoPG) ClaimNext(staleBefore time.Time) (*models.AttendeeImport, error) {
+	var imports []models.AttendeeImport
+	err := r.db.Raw(`
+		UPDATE attendee_imports
+		SET status = ?, started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM attendee_imports
+			WHERE deleted_at IS NULL AND (status = ? OR (status = ? AND updated_at < ?))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.ImportProcessing, models.ImportQueued, models.ImportProcessing, staleBefore).
+		Scan(&imports).Error
+	if err != nil || len(imports) == 0 {
+		return nil, err
+	}
+	return &imports[0], nil
+}
+
+func (r *attendeeImportRepoPG) Heartbeat(id uuid.UUID) error {
+	return r.db.Model(&models.AttendeeImport{}).
+		Where("id = ? AND status = ?", id, models.ImportProcessing).
+		Update("updated_at", time.Now()).Error
+}
+
+func (r *attendeeImportRepoPG) Finish(id uuid.UUID, status models.AttendeeImportStatus, reason string) error {
+	return r.db.Model(&models.AttendeeImport{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"error":        reason,
+			"completed_at": time.Now(),
+		}).Error
+}
+
+func (r *attendeeImportRepoPG) GetRowsToImport(importID uuid.UUID, statuses []models.AttendeeImportRowStatus, limit int) ([]models.AttendeeImportRow, error) {
+	var rows []models.AttendeeImportRow
+	err := r.db.Where("import_id = ? AND status IN ?", importID, statuses).
+		Order("line").
+		Limit(limit).
+		Find(&rows).Error
+	return rows, err
+}
+
+func (r *attendeeImportRepoPG) FinishRow(id uuid.UUID, from []models.AttendeeImportRowStatus, status models.AttendeeImportRowStatus, ticketID *uuid.UUID, reason string) (bool, error) {
+	result := r.db.Model(&models.AttendeeImportRow{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(map[string]interface{}{
+			"status":    status,
+			"ticket_id": ticketID,
+			"error":     reason,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
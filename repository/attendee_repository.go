@@ -2,16 +2,30 @@ package repository
 
 import (
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hidenkeys/motiv-backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AttendeeRepository interface {
 	Create(attendee *models.Attendee) error
 	GetByID(id uuid.UUID) (*models.Attendee, error)
+	GetByTicketIDs(ticketIDs []uuid.UUID) ([]models.Attendee, error)
+	// CancelByTicketIDs cancels the active attendees holding these tickets.
+	CancelByTicketIDs(ticketIDs []uuid.UUID) (int64, error)
+	// ReinstateByTicketIDs makes the cancelled attendees holding these
+	// tickets active again.
+	ReinstateByTicketIDs(ticketIDs []uuid.UUID) (int64, error)
+	// ReassignTicket moves the active, not checked-in attendee holding a
+	// ticket to userID, and reports whether it did.
+	ReassignTicket(ticketID, userID uuid.UUID) (bool, error)
 	GetByEventID(eventID uuid.UUID, limit, offset int) ([]models.Attendee, error)
+	// GetByEventIDAfter returns up to limit of an event's attendees with IDs
+	// after afterID, in ID order, for paging through every one of them.
+	GetByEventIDAfter(eventID, afterID uuid.UUID, limit int) ([]models.Attendee, error)
 	GetEventAttendeesTotalCount(eventID uuid.UUID) (int64, error)
 	GetByHostID(hostID uuid.UUID, limit, offset int) ([]models.Attendee, error)
 	GetHostAttendeesTotalCount(hostID uuid.UUID) (int64, error)
@@ -20,6 +34,15 @@ type AttendeeRepository interface {
 	Update(attendee *models.Attendee) error
 	Delete(id uuid.UUID) error
 	CheckInAttendee(attendeeID, checkedInBy uuid.UUID) error
+	// GetByTicketIDForUpdate row-locks the attendee holding a ticket; only
+	// meaningful inside a transaction.
+	GetByTicketIDForUpdate(ticketID uuid.UUID) (*models.Attendee, error)
+	// CheckInAttendeeAt checks an attendee in as of checkedInAt, e.g. when an
+	// offline scan is synced.
+	CheckInAttendeeAt(attendeeID, checkedInBy uuid.UUID, checkedInAt time.Time) error
+	// ClearCheckIn puts a checked-in attendee back to active, e.g. when their
+	// only check-in is undone.
+	ClearCheckIn(attendeeID uuid.UUID) error
 	GetEventAttendeeStats(eventID uuid.UUID) (map[string]int64, error)
 	GetHostAttendeeStats(hostID uuid.UUID) (map[string]int64, error)
 }
@@ -43,6 +66,42 @@ func (a *attendeeRepoPG) GetByID(id uuid.UUID) (*models.Attendee, error) {
 	return &attendee, err
 }
 
+func (a *attendeeRepoPG) GetByTicketIDs(ticketIDs []uuid.UUID) ([]models.Attendee, error) {
+	var attendees []models.Attendee
+	if len(ticketIDs) == 0 {
+		return attendees, nil
+	}
+	err := a.db.Where("ticket_id IN ?", ticketIDs).Find(&attendees).Error
+	return attendees, err
+}
+
+func (a *attendeeRepoPG) CancelByTicketIDs(ticketIDs []uuid.UUID) (int64, error) {
+	if len(ticketIDs) == 0 {
+		return 0, nil
+	}
+	result := a.db.Model(&models.Attendee{}).
+		Where("ticket_id IN ? AND status = ?", ticketIDs, models.AttendeeActive).
+		Update("status", models.AttendeeCancelled)
+	return result.RowsAffected, result.Error
+}
+
+func (a *attendeeRepoPG) ReinstateByTicketIDs(ticketIDs []uuid.UUID) (int64, error) {
+	if len(ticketIDs) == 0 {
+		return 0, nil
+	}
+	result := a.db.Model(&models.Attendee{}).
+		Where("ticket_id IN ? AND status = ?", ticketIDs, models.AttendeeCancelled).
+		Update("status", models.AttendeeActive)
+	return result.RowsAffected, result.Error
+}
+
+func (a *attendeeRepoPG) ReassignTicket(ticketID, userID uuid.UUID) (bool, error) {
+	result := a.db.Model(&models.Attendee{}).
+		Where("ticket_id = ? AND status = ?", ticketID, models.AttendeeActive).
+		Update("user_id", userID)
+	return result.RowsAffected == 1, result.Error
+}
+
 func (a *attendeeRepoPG) GetByEventID(eventID uuid.UUID, limit, offset int) ([]models.Attendee, error) {
 	var attendees []models.Attendee
 	err := a.db.Preload("User").Preload("Ticket").Preload("Ticket.TicketType").
@@ -53,6 +112,16 @@ func (a *attendeeRepoPG) GetByEventID(eventID uuid.UUID, limit, offset int) ([]m
 	return attendees, err
 }
 
+func (a *attendeeRepoPG) GetByEventIDAfter(eventID, afterID uuid.UUID, limit int) ([]models.Attendee, error) {
+	var attendees []models.Attendee
+	err := a.db.Preload("User").Preload("Ticket").Preload("Ticket.TicketType").
+		Where("event_id = ? AND id > ?", eventID, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&attendees).Error
+	return attendees, err
+}
+
 func (a *attendeeRepoPG) GetEventAttendeesTotalCount(eventID uuid.UUID) (int64, error) {
 	var count int64
 	err := a.db.Model(&models.Attendee{}).
@@ -99,6 +168,35 @@ func (a *attendeeRepoPG) CheckInAttendee(attendeeID, checkedInBy uuid.UUID) erro
 		}).Error
 }
 
+func (a *attendeeRepoPG) GetByTicketIDForUpdate(ticketID uuid.UUID) (*models.Attendee, error) {
+	var attendee models.Attendee
+	err := a.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("ticket_id = ?", ticketID).First(&attendee).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attendee, nil
+}
+
+func (a *attendeeRepoPG) CheckInAttendeeAt(attendeeID, checkedInBy uuid.UUID, checkedInAt time.Time) error {
+	return a.db.Model(&models.Attendee{}).
+		Where("id = ?", attendeeID).
+		Updates(map[string]interface{}{
+			"status":        models.AttendeeCheckedIn,
+			"checked_in_at": checkedInAt,
+			"checked_in_by": checkedInBy,
+		}).Error
+}
+
+func (a *attendeeRepoPG) ClearCheckIn(attendeeID uuid.UUID) error {
+	return a.db.Model(&models.Attendee{}).
+		Where("id = ? AND status = ?", attendeeID, models.AttendeeCheckedIn).
+		Updates(map[string]interface{}{
+			"status":        models.AttendeeActive,
+			"checked_in_at": nil,
+			"checked_in_by": nil,
+		}).Error
+}
+
 func (a *attendeeRepoPG) GetEventAttendeeStats(eventID uuid.UUID) (map[string]int64, error) {
 	stats := make(map[string]int64)
 
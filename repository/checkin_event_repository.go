@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type CheckInEventRepository interface {
+	Create(checkIn *models.CheckInEvent) error
+	GetByID(id uuid.UUID) (*models.CheckInEvent, error)
+	// GetDeviceScan returns a device's scan at an event of a ticket, or of a
+	// code that isn't one if ticketID is nil, at scannedAt, if it has already
+	// been synced.
+	GetDeviceScan(eventID uuid.UUID, ticketID *uuid.UUID, deviceID string, scannedAt time.Time) (*models.CheckInEvent, error)
+	// CountEntryScans counts a ticket's admitted and duplicate scans, undone or not.
+	CountEntryScans(ticketID uuid.UUID) (int64, error)
+	// GetCountable returns a ticket's admitted and duplicate scans that
+	// haven't been undone, in the order they were made. Ties go to online
+	// scans, then the lowest device ID.
+	GetCountable(ticketID uuid.UUID) ([]models.CheckInEvent, error)
+	// GetByEventID lists an event's scans, or one ticket's if ticketID is
+	// set, most recent scan first.
+	GetByEventID(eventID uuid.UUID, ticketID *uuid.UUID, limit, offset int) ([]models.CheckInEvent, int64, error)
+	SetOutcome(id uuid.UUID, outcome models.CheckInOutcome, reason string) error
+	// MarkUndone undoes a scan that hasn't been undone yet, and reports whether it did.
+	MarkUndone(id, undoneBy uuid.UUID, reason string) (bool, error)
+
+	// Live dashboard totals
+	GetTicketTypeCheckIns(eventID uuid.UUID) ([]models.TicketTypeCheckIns, error)
+	// GetArrivals counts an event's entries that haven't been undone in
+	// interval-long buckets, oldest first. Empty buckets are left out.
+	GetArrivals(eventID uuid.UUID, interval time.Duration) ([]models.ArrivalBucket, error)
+	GetGateCheckIns(eventID uuid.UUID) ([]models.GateCheckIns, error)
+}
+
+type checkInEventRepoPG struct {
+	db *gorm.DB
+}
+
+func NewCheckInEventRepoPG(db *gorm.DB) CheckInEventRepository {
+	return &checkInEventRepoPG{db: db}
+}
+
+func (r *checkInEventRepoPG) Create(checkIn *models.CheckInEvent) error {
+	return r.db.Create(checkIn).Error
+}
+
+func (r *checkInEventRepoPG) GetByID(id uuid.UUID) (*models.CheckInEvent, error) {
+	var checkIn models.CheckInEvent
+	err := r.db.First(&checkIn, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &checkIn, nil
+}
+
+func (r *checkInEventRepoPG) GetDeviceScan(eventID uuid.UUID, ticketID *uuid.UUID, deviceID string, scannedAt time.Time) (*models.CheckInEvent, error) {
+	query := r.db.Where("event_id = ? AND device_id = ? AND scanned_at = ?", eventID, deviceID, scannedAt)
+	if ticketID != nil {
+		query = query.Where("ticket_id = ?", *ticketID)
+	} else {
+		query = query.Where("ticket_id IS NULL")
+	}
+
+	var checkIn models.CheckInEvent
+	err := query.First(&checkIn).Error
+	if err != nil {
+		return nil, err
+	}
+	return &checkIn, nil
+}
+
+func (r *checkInEventRepoPG) CountEntryScans(ticketID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CheckInEvent{}).
+		Where("ticket_id = ? AND outcome IN ?", ticketID,
+			[]models.CheckInOutcome{models.CheckInAdmitted, models.CheckInDuplicate}).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *checkInEventRepoPG) GetCountable(ticketID uuid.UUID) ([]models.CheckInEvent, error) {
+	var checkIns []models.CheckInEvent
+	err := r.db.Where("ticket_id = ? AND undone_at IS NULL AND outcome IN ?", ticketID,
+		[]models.CheckInOutcome{models.CheckInAdmitted, models.CheckInDuplicate}).
+		Order("scanned_at, device_id, created_at").
+		Find(&checkIns).Error
+	return checkIns, err
+}
+
+func (r *checkInEventRepoPG) GetByEventID(eventID uuid.UUID, ticketID *uuid.UUID, limit, offset int) ([]models.CheckInEvent, int64, error) {
+	query := r.db.Model(&models.CheckInEvent{}).Where("event_id = ?", eventID)
+	if ticketID != nil {
+		query = query.Where("ticket_id = ?", *ticketID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var checkIns []models.CheckInEvent
+	err := query.Order("scanned_at DESC, device_id").Limit(limit).Offset(offset).Find(&checkIns).Error
+	return checkIns, total, err
+}
+
+func (r *checkInEventRepoPG) SetOutcome(id uuid.UUID, outcome models.CheckInOutcome, reason string) error {
+	return r.db.Model(&models.CheckInEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"outcome": outcome,
+			"reason":  reason,
+		}).Error
+}
+
+func (r *checkInEventRepoPG) MarkUndone(id, undoneBy uuid.UUID, reason string) (bool, error) {
+	result := r.db.Model(&models.CheckInEvent{}).
+		Where("id = ? AND undone_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"undone_at":   time.Now(),
+			"undone_by":   undoneBy,
+			"undo_reason": reason,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *checkInEventRepoPG) GetTicketTypeCheckIns(eventID uuid.UUID) ([]models.TicketTypeCheckIns, error) {
+	var counts []models.TicketTypeCheckIns
+	err := r.db.Raw(`
+		SELECT tt.id AS ticket_type_id, tt.name,
+			COUNT(a.id) FILTER (WHERE a.status <> ?) AS attendees,
+			COUNT(a.id) FILTER (WHERE a.status = ?) AS checked_in
+		FROM ticket_types tt
+		LEFT JOIN tickets t ON t.ticket_type_id = tt.id AND t.deleted_at IS NULL
+		LEFT JOIN attendees a ON a.ticket_id = t.id AND a.deleted_at IS NULL
+		WHERE tt.event_id = ? AND tt.deleted_at IS NULL
+		GROUP BY tt.id, tt.name
+		ORDER BY tt.name`, models.AttendeeCancelled, models.AttendeeCheckedIn, eventID).
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *checkInEventRepoPG) GetArrivals(eventID uuid.UUID, interval time.Duration) ([]models.ArrivalBucket, error) {
+	seconds := int64(interval / time.Second)
+	var buckets []models.ArrivalBucket
+	err := r.db.Raw(`
+		SELECT TO_TIMESTAMP(FLOOR(EXTRACT(EPOCH FROM scanned_at) / ?) * ?) AS starts_at,
+			COUNT(*) AS entries
+		FROM check_in_events
+		WHERE event_id = ? AND outcome = ? AND undone_at IS NULL AND deleted_at IS NULL
+		GROUP BY 1
+		ORDER BY 1`, seconds, seconds, eventID, models.CheckInAdmitted).
+		Scan(&buckets).Error
+	return buckets, err
+}
+
+func (r *checkInEventRepoPG) GetGateCheckIns(eventID uuid.UUID) ([]models.GateCheckIns, error) {
+	var gates []models.GateCheckIns
+	err := r.db.Raw(`
+		SELECT gate,
+			COUNT(*) FILTER (WHERE outcome = ? AND undone_at IS NULL) AS admitted,
+			COUNT(*) FILTER (WHERE outcome = ?) AS duplicates,
+			COUNT(*) FILTER (WHERE outcome = ?) AS rejected
+		FROM check_in_events
+		WHERE event_id = ? AND deleted_at IS NULL
+		GROUP BY gate
+		ORDER BY gate`, models.CheckInAdmitted, models.CheckInDuplicate, models.CheckInRejected, eventID).
+		Scan(&gates).Error
+	return gates, err
+}
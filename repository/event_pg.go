@@ -109,7 +109,8 @@ func (r *eventRepoPG) UpdateEvent(event *models.Event) error {
 	// Use Select to only update specific fields, avoiding issues with host_id
 	return r.db.Model(event).Select(
 		"title", "description", "start_date", "start_time", "end_time", 
-		"location", "latitude", "longitude", "place_id", "tags", "banner_image_url", "event_type", "status", "updated_at",
+		"location", "latitude", "longitude", "place_id", "tags", "banner_image_url", "event_type", "status",
+		"currency", "payment_gateway", "transfers_disabled", "updated_at",
 	).Updates(event).Error
 }
 
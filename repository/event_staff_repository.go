@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type EventStaffRepository interface {
+	Create(staff *models.EventStaff) error
+	GetByID(id uuid.UUID) (*models.EventStaff, error)
+	GetByToken(token string) (*models.EventStaff, error)
+	// GetByEventID lists an event's invited and active staff, oldest first.
+	GetByEventID(eventID uuid.UUID) ([]models.EventStaff, error)
+	// GetOpenByEmail returns the active staff record or unexpired invite for
+	// an email at an event, matched case-insensitively.
+	GetOpenByEmail(eventID uuid.UUID, email string) (*models.EventStaff, error)
+	// GetActive returns a user's active staff record for an event.
+	GetActive(eventID, userID uuid.UUID) (*models.EventStaff, error)
+	// GetActiveByUserID lists the events a user is active staff at.
+	GetActiveByUserID(userID uuid.UUID) ([]models.EventStaff, error)
+	// GetPendingByEmail lists unexpired invites sent to an email.
+	GetPendingByEmail(email string) ([]models.EventStaff, error)
+	// MarkAccepted activates an unexpired invite for userID, and reports whether it did.
+	MarkAccepted(id, userID uuid.UUID) (bool, error)
+	UpdateRole(id uuid.UUID, role models.StaffRole) error
+	// Transition moves a record out of one of from, and reports whether it did.
+	Transition(id uuid.UUID, from []models.StaffStatus, to models.StaffStatus) (bool, error)
+}
+
+type eventStaffRepoPG struct {
+	db *gorm.DB
+}
+
+func NewEventStaffRepoPG(db *gorm.DB) EventStaffRepository {
+	return &eventStaffRepoPG{db: db}
+}
+
+func (r *eventStaffRepoPG) Create(staff *models.EventStaff) error {
+	return r.db.Create(staff).Error
+}
+
+func (r *eventStaffRepoPG) GetByID(id uuid.UUID) (*models.EventStaff, error) {
+	var staff models.EventStaff
+	err := r.db.Preload("User").First(&staff, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+func (r *eventStaffRepoPG) GetByToken(token string) (*models.EventStaff, error) {
+	var staff models.EventStaff
+	err := r.db.Preload("Event").Where("token = ?", token).First(&staff).Error
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+func (r *eventStaffRepoPG) GetByEventID(eventID uuid.UUID) ([]models.EventStaff, error) {
+	var staff []models.EventStaff
+	err := r.db.Preload("User").
+		Where("event_id = ? AND status IN ?", eventID, []models.StaffStatus{models.StaffInvited, models.StaffActive}).
+		Order("created_at").
+		Find(&staff).Error
+	return staff, err
+}
+
+func (r *eventStaffRepoPG) GetOpenByEmail(eventID uuid.UUID, email string) (*models.EventStaff, error) {
+	var staff models.EventStaff
+	err := r.db.Where("event_id = ? AND LOWER(email) = LOWER(?)", eventID, email).
+		Where("status = ? OR (status = ? AND expires_at > ?)", models.StaffActive, models.StaffInvited, time.Now()).
+		First(&staff).Error
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+func (r *eventStaffRepoPG) GetActive(eventID, userID uuid.UUID) (*models.EventStaff, error) {
+	var staff models.EventStaff
+	err := r.db.Where("event_id = ? AND user_id = ? AND status = ?", eventID, userID, models.StaffActive).
+		First(&staff).Error
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+func (r *eventStaffRepoPG) GetActiveByUserID(userID uuid.UUID) ([]models.EventStaff, error) {
+	var staff []models.EventStaff
+	err := r.db.Preload("Event").
+		Where("user_id = ? AND status = ?", userID, models.StaffActive).
+		Order("created_at DESC").
+		Find(&staff).Error
+	return staff, err
+}
+
+func (r *eventStaffRepoPG) GetPendingByEmail(email string) ([]models.EventStaff, error) {
+	var staff []models.EventStaff
+	err := r.db.Preload("Event").
+		Where("LOWER(email) = LOWER(?) AND status = ? AND expires_at > ?", email, models.StaffInvited, time.Now()).
+		Order("created_at DESC").
+		Find(&staff).Error
+	return staff, err
+}
+
+func (r *eventStaffRepoPG) MarkAccepted(id, userID uuid.UUID) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.EventStaff{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, models.StaffInvited, now).
+		Updates(map[string]interface{}{
+			"status":      models.StaffActive,
+			"user_id":     userID,
+			"accepted_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *eventStaffRepoPG) UpdateRole(id uuid.UUID, role models.StaffRole) error {
+	return r.db.Model(&models.EventStaff{}).Where("id = ?", id).Update("role", role).Error
+}
+
+func (r *eventStaffRepoPG) Transition(id uuid.UUID, from []models.StaffStatus, to models.StaffStatus) (bool, error) {
+	result := r.db.Model(&models.EventStaff{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type FeeRepository interface {
+	// GetSchedule returns the schedule for a scope. hostID is used for the
+	// host scope and eventID for the event scope; both are ignored otherwise.
+	GetSchedule(scope models.FeeScope, hostID, eventID uuid.UUID) (*models.FeeSchedule, error)
+	SaveSchedule(schedule *models.FeeSchedule) error
+}
+
+type feeRepoPG struct {
+	db *gorm.DB
+}
+
+func NewFeeRepoPG(db *gorm.DB) FeeRepository {
+	return &feeRepoPG{db: db}
+}
+
+func (r *feeRepoPG) GetSchedule(scope models.FeeScope, hostID, eventID uuid.UUID) (*models.FeeSchedule, error) {
+	query := r.db.Where("scope = ?", scope)
+	switch scope {
+	case models.FeeScopeHost:
+		query = query.Where("host_id = ?", hostID)
+	case models.FeeScopeEvent:
+		query = query.Where("event_id = ?", eventID)
+	}
+
+	var schedule models.FeeSchedule
+	if err := query.First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *feeRepoPG) SaveSchedule(schedule *models.FeeSchedule) error {
+	return r.db.Save(schedule).Error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerRepository interface {
+	// Post writes a transaction and its entries. If the transaction's
+	// reference has already been posted nothing is written and it reports
+	// false. Call it inside a unit of work so the entries commit together.
+	Post(txn *models.LedgerTransaction) (bool, error)
+	// GetAccountBalances totals every account per currency.
+	GetAccountBalances() ([]models.LedgerAccountBalance, error)
+
+	// Records from before the ledger existed, or otherwise never posted.
+	// Each is returned if the posting for its current status is missing.
+	// Box office payments are posted when sold, so are never returned.
+	GetUnpostedPayments() ([]models.Payment, error)
+	GetUnpostedRefunds() ([]models.Refund, error)
+	GetUnpostedPayouts() ([]models.Payout, error)
+}
+
+type ledgerRepoPG struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepoPG(db *gorm.DB) LedgerRepository {
+	return &ledgerRepoPG{db: db}
+}
+
+// revenueTransactionTypes are the postings that move sales money into or out
+// of host revenue. Fees and payouts are not revenue.
+var revenueTransactionTypes = []models.LedgerTransactionType{models.LedgerCharge, models.LedgerRefund, models.LedgerRefundReversed, models.LedgerBoxOfficeSale}
+
+// revenueAccounts hold host revenue: what hosts are owed, and the memo of
+// what they took at the box office.
+var revenueAccounts = []models.LedgerAccount{models.LedgerHostBalance, models.LedgerBoxOfficeRevenue}
+
+// payableTransactionTypes are the revenue postings paid out to hosts. Box
+// office sales are left out; the host collected those at the door.
+var payableTransactionTypes = []models.LedgerTransactionType{models.LedgerCharge, models.LedgerRefund, models.LedgerRefundReversed}
+
+// ledgerRevenue selects the entries that make up host revenue net of
+// refunds; callers add filters and SUM(credit - debit).
+func ledgerRevenue(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.LedgerEntry{}).
+		Where("account IN ? AND transaction_type IN ?", revenueAccounts, revenueTransactionTypes)
+}
+
+func (r *ledgerRepoPG) Post(txn *models.LedgerTransaction) (bool, error) {
+	entries := txn.Entries
+	txn.Entries = nil
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(txn)
+	txn.Entries = entries
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	for i := range txn.Entries {
+		txn.Entries[i].TransactionID = txn.ID
+	}
+	return true, r.db.Create(&txn.Entries).Error
+}
+
+func (r *ledgerRepoPG) GetAccountBalances() ([]models.LedgerAccountBalance, error) {
+	var balances []models.LedgerAccountBalance
+	err := r.db.Model(&models.LedgerEntry{}).
+		Select("account, currency, SUM(debit) AS debit, SUM(credit) AS credit, SUM(debit - credit) AS balance").
+		Group("account, currency").
+		Order("currency, account").
+		Scan(&balances).Error
+	return balances, err
+}
+
+func (r *ledgerRepoPG) GetUnpostedPayments() ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.Preload("Event").
+		Where("status IN ? AND method NOT IN ?", revenueStatuses, models.BoxOfficeMethods).
+		Where(`NOT EXISTS (SELECT 1 FROM ledger_transactions lt WHERE lt.reference = 'charge:' || payments.reference AND lt.deleted_at IS NULL)
+			OR (gateway_fee > 0 AND NOT EXISTS (SELECT 1 FROM ledger_transactions lt WHERE lt.reference = 'gateway_fee:' || payments.reference AND lt.deleted_at IS NULL))`).
+		Order("created_at").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *ledgerRepoPG) GetUnpostedRefunds() ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.Preload("Payment.Event").
+		Where(`NOT EXISTS (SELECT 1 FROM ledger_transactions lt WHERE lt.deleted_at IS NULL AND lt.reference =
+			CASE refunds.status WHEN ? THEN 'refund_settled:' WHEN ? THEN 'refund_reversed:' ELSE 'refund:' END || refunds.id::text)`,
+			models.RefundProcessed, models.RefundFailed).
+		Order("created_at").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *ledgerRepoPG) GetUnpostedPayouts() ([]models.Payout, error) {
+	var payouts []models.Payout
+	err := r.db.
+		Where(`NOT EXISTS (SELECT 1 FROM ledger_transactions lt WHERE lt.deleted_at IS NULL AND lt.reference =
+			CASE payouts.status WHEN ? THEN 'payout_settled:' WHEN ? THEN 'payout_reversed:' ELSE 'payout:' END || payouts.reference)`,
+			models.PayoutCompleted, models.PayoutFailed).
+		Order("created_at").
+		Find(&payouts).Error
+	return payouts, err
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/hidenkeys/motiv-backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository interface {
@@ -11,15 +14,35 @@ type PaymentRepository interface {
 	CreatePayment(payment *models.Payment) error
 	GetPaymentByID(id uuid.UUID) (*models.Payment, error)
 	GetPaymentByReference(reference string) (*models.Payment, error)
+	// GetPaymentForUpdate row-locks the payment, without its associations;
+	// only meaningful inside a transaction.
+	GetPaymentForUpdate(id uuid.UUID) (*models.Payment, error)
 	UpdatePayment(payment *models.Payment) error
+	// CompletePayment marks a pending, failed or expired payment completed and
+	// reports whether it did; a payment already completed is left untouched.
+	// A failed or expired payment's hold has been released, so the caller
+	// must check its tickets are still there before issuing them.
+	CompletePayment(reference string) (bool, error)
+	// TransitionPayment moves a payment out of one of from, and reports
+	// whether it did. failureReason is stored when moving to failed, expired
+	// or disputed.
+	TransitionPayment(reference string, from []models.PaymentStatus, to models.PaymentStatus, failureReason string) (bool, error)
+	// ReserveRefund adds amount to a completed payment's refunded total, unless
+	// that would refund more than was paid. Fees passed to the buyer are not
+	// refundable. It reports whether it did.
+	ReserveRefund(paymentID uuid.UUID, amount float64) (bool, error)
+	// MarkUnfulfillable moves a payment out of one of from to disputed, with
+	// its whole amount counted as refunded, for a payment none of whose
+	// tickets could be issued. It reports whether it did.
+	MarkUnfulfillable(reference string, from []models.PaymentStatus, reason string) (bool, error)
+	// ReleaseRefund takes a failed refund's amount back off the refunded total.
+	ReleaseRefund(paymentID uuid.UUID, amount float64) error
 	GetPaymentsByTicketID(ticketID uuid.UUID) ([]models.Payment, error)
 	GetPaymentsByEventID(eventID uuid.UUID) ([]models.Payment, error)
 
 	// Payouts
-	CreatePayout(payout *models.Payout) error
-	GetPayoutByID(id uuid.UUID) (*models.Payout, error)
 	GetPayoutsByHostID(hostID uuid.UUID, limit, offset int) ([]models.Payout, error)
-	UpdatePayout(payout *models.Payout) error
+	// GetPendingPayouts returns payouts not yet sent or still in flight.
 	GetPendingPayouts(hostID uuid.UUID) ([]models.Payout, error)
 
 	// Financial Stats
@@ -43,21 +66,81 @@ func (p *paymentRepoPG) CreatePayment(payment *models.Payment) error {
 
 func (p *paymentRepoPG) GetPaymentByID(id uuid.UUID) (*models.Payment, error) {
 	var payment models.Payment
-	err := p.db.Preload("Event").Preload("User").First(&payment, "id = ?", id).Error
+	err := p.db.Preload("Event").Preload("User").Preload("LineItems").Preload("Attendees", attendeesInOrder).First(&payment, "id = ?", id).Error
 	return &payment, err
 }
 
 func (p *paymentRepoPG) GetPaymentByReference(reference string) (*models.Payment, error) {
 	var payment models.Payment
-	err := p.db.Preload("Event").Preload("User").First(&payment, "reference = ?", reference).Error
+	err := p.db.Preload("Event").Preload("User").Preload("LineItems").Preload("Attendees", attendeesInOrder).First(&payment, "reference = ?", reference).Error
 	return &payment, err
 }
 
+func (p *paymentRepoPG) GetPaymentForUpdate(id uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	err := p.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// attendeesInOrder preloads a payment's attendees in the order given at checkout.
+func attendeesInOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
 func (p *paymentRepoPG) UpdatePayment(payment *models.Payment) error {
 	// Only update specific fields to avoid foreign key constraint issues
 	return p.db.Model(payment).Select("status", "processed_at", "failure_reason", "updated_at").Updates(payment).Error
 }
 
+func (p *paymentRepoPG) CompletePayment(reference string) (bool, error) {
+	result := p.db.Model(&models.Payment{}).
+		Where("reference = ? AND status IN ?", reference, []models.PaymentStatus{models.PaymentPending, models.PaymentFailed, models.PaymentExpired}).
+		Updates(map[string]interface{}{
+			"status":       models.PaymentCompleted,
+			"processed_at": time.Now(),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (p *paymentRepoPG) TransitionPayment(reference string, from []models.PaymentStatus, to models.PaymentStatus, failureReason string) (bool, error) {
+	updates := map[string]interface{}{"status": to}
+	if to != models.PaymentCompleted {
+		updates["failure_reason"] = failureReason
+	}
+
+	result := p.db.Model(&models.Payment{}).
+		Where("reference = ? AND status IN ?", reference, from).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (p *paymentRepoPG) ReserveRefund(paymentID uuid.UUID, amount float64) (bool, error) {
+	result := p.db.Model(&models.Payment{}).
+		Where("id = ? AND status = ? AND refunded_amount + ? <= amount - buyer_fee + 0.005", paymentID, models.PaymentCompleted, amount).
+		Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount))
+	return result.RowsAffected == 1, result.Error
+}
+
+func (p *paymentRepoPG) MarkUnfulfillable(reference string, from []models.PaymentStatus, reason string) (bool, error) {
+	result := p.db.Model(&models.Payment{}).
+		Where("reference = ? AND status IN ?", reference, from).
+		Updates(map[string]interface{}{
+			"status":          models.PaymentDisputed,
+			"failure_reason":  reason,
+			"refunded_amount": gorm.Expr("amount"),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (p *paymentRepoPG) ReleaseRefund(paymentID uuid.UUID, amount float64) error {
+	return p.db.Model(&models.Payment{}).
+		Where("id = ?", paymentID).
+		Update("refunded_amount", gorm.Expr("GREATEST(refunded_amount - ?, 0)", amount)).Error
+}
+
 func (p *paymentRepoPG) GetPaymentsByTicketID(ticketID uuid.UUID) ([]models.Payment, error) {
 	// Since we changed the model, we'll get payments by finding tickets with this ID
 	// and then finding payments with those references
@@ -83,16 +166,6 @@ func (p *paymentRepoPG) GetPaymentsByEventID(eventID uuid.UUID) ([]models.Paymen
 }
 
 // Payout methods
-func (p *paymentRepoPG) CreatePayout(payout *models.Payout) error {
-	return p.db.Create(payout).Error
-}
-
-func (p *paymentRepoPG) GetPayoutByID(id uuid.UUID) (*models.Payout, error) {
-	var payout models.Payout
-	err := p.db.Preload("Host").Preload("Event").First(&payout, "id = ?", id).Error
-	return &payout, err
-}
-
 func (p *paymentRepoPG) GetPayoutsByHostID(hostID uuid.UUID, limit, offset int) ([]models.Payout, error) {
 	var payouts []models.Payout
 	err := p.db.Preload("Event").Where("host_id = ?", hostID).
@@ -102,43 +175,42 @@ func (p *paymentRepoPG) GetPayoutsByHostID(hostID uuid.UUID, limit, offset int)
 	return payouts, err
 }
 
-func (p *paymentRepoPG) UpdatePayout(payout *models.Payout) error {
-	return p.db.Save(payout).Error
-}
-
 func (p *paymentRepoPG) GetPendingPayouts(hostID uuid.UUID) ([]models.Payout, error) {
 	var payouts []models.Payout
 	err := p.db.Preload("Event").
-		Where("host_id = ? AND status = ?", hostID, models.PaymentPending).
+		Where("host_id = ? AND status IN ?", hostID, []models.PayoutStatus{models.PayoutPending, models.PayoutProcessing}).
 		Find(&payouts).Error
 	return payouts, err
 }
 
-// Financial Stats methods
+// Financial Stats methods, read from the ledger so they always reconcile
+
+// revenueStatuses are the payment statuses that earned money at some point.
+var revenueStatuses = []models.PaymentStatus{models.PaymentCompleted, models.PaymentRefunded}
+
 func (p *paymentRepoPG) GetHostEarnings(hostID uuid.UUID) (float64, error) {
 	var totalEarnings float64
-	err := p.db.Model(&models.Payment{}).
-		Where("event_id IN (SELECT id FROM events WHERE host_id = ?) AND status = ?", hostID, models.PaymentCompleted).
-		Select("COALESCE(SUM(amount), 0)").
+	err := ledgerRevenue(p.db).
+		Where("host_id = ?", hostID).
+		Select("COALESCE(SUM(credit - debit), 0)").
 		Scan(&totalEarnings).Error
 	return totalEarnings, err
 }
 
 func (p *paymentRepoPG) GetHostMonthlyEarnings(hostID uuid.UUID, year, month int) (float64, error) {
 	var monthlyEarnings float64
-	err := p.db.Model(&models.Payment{}).
-		Where("event_id IN (SELECT id FROM events WHERE host_id = ?) AND status = ? AND EXTRACT(YEAR FROM created_at) = ? AND EXTRACT(MONTH FROM created_at) = ?",
-			hostID, models.PaymentCompleted, year, month).
-		Select("COALESCE(SUM(amount), 0)").
+	err := ledgerRevenue(p.db).
+		Where("host_id = ? AND EXTRACT(YEAR FROM effective_at) = ? AND EXTRACT(MONTH FROM effective_at) = ?", hostID, year, month).
+		Select("COALESCE(SUM(credit - debit), 0)").
 		Scan(&monthlyEarnings).Error
 	return monthlyEarnings, err
 }
 
 func (p *paymentRepoPG) GetEventRevenue(eventID uuid.UUID) (float64, error) {
 	var revenue float64
-	err := p.db.Model(&models.Payment{}).
-		Where("event_id = ? AND status = ?", eventID, models.PaymentCompleted).
-		Select("COALESCE(SUM(amount), 0)").
+	err := ledgerRevenue(p.db).
+		Where("event_id = ?", eventID).
+		Select("COALESCE(SUM(credit - debit), 0)").
 		Scan(&revenue).Error
 	return revenue, err
 }
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayoutRepository interface {
+	CreatePayout(payout *models.Payout) error
+	GetPayoutByID(id uuid.UUID) (*models.Payout, error)
+	GetPayoutByReference(reference string) (*models.Payout, error)
+	// MarkSent moves a pending payout to processing once the transfer
+	// gateway has accepted it, and reports whether it did.
+	MarkSent(id uuid.UUID, transferCode string) (bool, error)
+	// TransitionPayout moves a payout out of one of from, and reports whether
+	// it did. failureReason is stored when moving to failed.
+	TransitionPayout(id uuid.UUID, from []models.PayoutStatus, to models.PayoutStatus, failureReason string) (bool, error)
+	// GetUnresolvedPayouts returns up to limit pending or processing payouts
+	// not updated since before, least recently updated first.
+	GetUnresolvedPayouts(before time.Time, limit int) ([]models.Payout, error)
+	// TouchPayout marks a payout as just checked.
+	TouchPayout(id uuid.UUID) error
+
+	// LockEvent locks an event's row until the transaction ends, so two
+	// settlements of the same event can't both see it unpaid.
+	LockEvent(eventID uuid.UUID) error
+	GetEventBalance(eventID uuid.UUID) (*models.EventBalance, error)
+	// GetEventBalancesStartedBefore returns balances of events that started
+	// before t and haven't been cancelled.
+	GetEventBalancesStartedBefore(t time.Time) ([]models.EventBalance, error)
+	GetHostEventBalances(hostID uuid.UUID) ([]models.EventBalance, error)
+
+	// Bank accounts
+	GetBankAccountByHostID(hostID uuid.UUID) (*models.HostBankAccount, error)
+	SaveBankAccount(account *models.HostBankAccount) error
+}
+
+type payoutRepoPG struct {
+	db *gorm.DB
+}
+
+func NewPayoutRepoPG(db *gorm.DB) PayoutRepository {
+	return &payoutRepoPG{db: db}
+}
+
+func (r *payoutRepoPG) CreatePayout(payout *models.Payout) error {
+	return r.db.Create(payout).Error
+}
+
+func (r *payoutRepoPG) GetPayoutByID(id uuid.UUID) (*models.Payout, error) {
+	var payout models.Payout
+	err := r.db.Preload("Event").First(&payout, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+func (r *payoutRepoPG) GetPayoutByReference(reference string) (*models.Payout, error) {
+	var payout models.Payout
+	err := r.db.Preload("Event").First(&payout, "reference = ?", reference).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+func (r *payoutRepoPG) MarkSent(id uuid.UUID, transferCode string) (bool, error) {
+	result := r.db.Model(&models.Payout{}).
+		Where("id = ? AND status = ?", id, models.PayoutPending).
+		Updates(map[string]interface{}{
+			"status":        models.PayoutProcessing,
+			"transfer_code": transferCode,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *payoutRepoPG) TransitionPayout(id uuid.UUID, from []models.PayoutStatus, to models.PayoutStatus, failureReason string) (bool, error) {
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case models.PayoutCompleted:
+		updates["processed_at"] = time.Now()
+	case models.PayoutFailed:
+		updates["failure_reason"] = failureReason
+	}
+
+	result := r.db.Model(&models.Payout{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *payoutRepoPG) GetUnresolvedPayouts(before time.Time, limit int) ([]models.Payout, error) {
+	var payouts []models.Payout
+	err := r.db.Where("status IN ? AND updated_at < ?", []models.PayoutStatus{models.PayoutPending, models.PayoutProcessing}, before).
+		Order("updated_at").
+		Limit(limit).
+		Find(&payouts).Error
+	return payouts, err
+}
+
+func (r *payoutRepoPG) TouchPayout(id uuid.UUID) error {
+	return r.db.Model(&models.Payout{}).Where("id = ?", id).Update("updated_at", time.Now()).Error
+}
+
+func (r *payoutRepoPG) LockEvent(eventID uuid.UUID) error {
+	var event models.Event
+	return r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", eventID).First(&event).Error
+}
+
+// eventBalances sums each event's payable revenue net of refunds, from the
+// ledger, and the host fees on its payments, against the amounts covered by
+// its payouts. Failed payouts cover nothing.
+func (r *payoutRepoPG) eventBalances() *gorm.DB {
+	return r.db.Table("events e").
+		Select(`e.id AS event_id, e.host_id, e.currency, e.start_date, e.start_time, e.end_time,
+			p.gross, COALESCE(o.settled, 0) AS settled, COALESCE(f.fees, 0) AS fees,
+			COALESCE(o.fees_settled, 0) AS fees_settled, o.last_failed_at`).
+		Joins(`JOIN (SELECT event_id, SUM(credit - debit) AS gross FROM ledger_entries
+			WHERE account = ? AND transaction_type IN ? AND deleted_at IS NULL GROUP BY event_id) p ON p.event_id = e.id`,
+			models.LedgerHostBalance, payableTransactionTypes).
+		Joins(`LEFT JOIN (SELECT event_id, SUM(host_fee) AS fees FROM payments
+			WHERE status IN ? AND deleted_at IS NULL GROUP BY event_id) f ON f.event_id = e.id`,
+			revenueStatuses).
+		Joins(`LEFT JOIN (SELECT event_id,
+				SUM(CASE WHEN status <> ? THEN gross_amount ELSE 0 END) AS settled,
+				SUM(CASE WHEN status <> ? THEN fee_amount ELSE 0 END) AS fees_settled,
+				MAX(CASE WHEN status = ? THEN updated_at END) AS last_failed_at
+			FROM payouts WHERE deleted_at IS NULL GROUP BY event_id) o ON o.event_id = e.id`,
+			models.PayoutFailed, models.PayoutFailed, models.PayoutFailed).
+		Where("e.deleted_at IS NULL AND e.status <> ?", models.CancelledEvent)
+}
+
+func (r *payoutRepoPG) GetEventBalance(eventID uuid.UUID) (*models.EventBalance, error) {
+	var balances []models.EventBalance
+	if err := r.eventBalances().Where("e.id = ?", eventID).Scan(&balances).Error; err != nil {
+		return nil, err
+	}
+	if len(balances) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &balances[0], nil
+}
+
+func (r *payoutRepoPG) GetEventBalancesStartedBefore(t time.Time) ([]models.EventBalance, error) {
+	var balances []models.EventBalance
+	err := r.eventBalances().Where("e.start_date < ?", t).Order("e.start_date").Scan(&balances).Error
+	return balances, err
+}
+
+func (r *payoutRepoPG) GetHostEventBalances(hostID uuid.UUID) ([]models.EventBalance, error) {
+	var balances []models.EventBalance
+	err := r.eventBalances().Where("e.host_id = ?", hostID).Order("e.start_date").Scan(&balances).Error
+	return balances, err
+}
+
+func (r *payoutRepoPG) GetBankAccountByHostID(hostID uuid.UUID) (*models.HostBankAccount, error) {
+	var account models.HostBankAccount
+	err := r.db.Where("host_id = ?", hostID).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *payoutRepoPG) SaveBankAccount(account *models.HostBankAccount) error {
+	return r.db.Save(account).Error
+}
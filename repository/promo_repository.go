@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type PromoRepository interface {
+	CreatePromoCode(promo *models.PromoCode) error
+	GetPromoCodeByID(id uuid.UUID) (*models.PromoCode, error)
+	// GetPromoCodeByCode looks up an event's code; code must already be upper-case.
+	GetPromoCodeByCode(eventID uuid.UUID, code string) (*models.PromoCode, error)
+	GetPromoCodesByEventID(eventID uuid.UUID) ([]models.PromoCode, error)
+	// UpdatePromoCode saves the host-editable fields only, so uses counted
+	// since the code was loaded are kept.
+	UpdatePromoCode(promo *models.PromoCode) error
+	DeletePromoCode(id uuid.UUID) error
+
+	// ClaimUse counts one use against the code's cap, and reports false once
+	// the cap is reached. ReturnUse gives a use back.
+	ClaimUse(promoID uuid.UUID) (bool, error)
+	ReturnUse(promoID uuid.UUID) error
+	// ReclaimUse counts a use even past the cap, for a payment that completed
+	// after its redemption had been released.
+	ReclaimUse(promoID uuid.UUID) error
+
+	CreateRedemption(redemption *models.PromoRedemption) error
+	GetRedemptionByReference(reference string) (*models.PromoRedemption, error)
+	// CountUserRedemptions counts a user's pending and confirmed redemptions of a code.
+	CountUserRedemptions(promoID, userID uuid.UUID) (int64, error)
+	// TransitionRedemption moves a redemption out of one of from, and reports whether it did.
+	TransitionRedemption(id uuid.UUID, from []models.PromoRedemptionStatus, to models.PromoRedemptionStatus) (bool, error)
+}
+
+type promoRepoPG struct {
+	db *gorm.DB
+}
+
+func NewPromoRepoPG(db *gorm.DB) PromoRepository {
+	return &promoRepoPG{db: db}
+}
+
+func (r *promoRepoPG) CreatePromoCode(promo *models.PromoCode) error {
+	return r.db.Create(promo).Error
+}
+
+func (r *promoRepoPG) GetPromoCodeByID(id uuid.UUID) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	err := r.db.First(&promo, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepoPG) GetPromoCodeByCode(eventID uuid.UUID, code string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	err := r.db.Where("event_id = ? AND code = ?", eventID, code).First(&promo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepoPG) GetPromoCodesByEventID(eventID uuid.UUID) ([]models.PromoCode, error) {
+	var promos []models.PromoCode
+	err := r.db.Where("event_id = ?", eventID).Order("created_at DESC").Find(&promos).Error
+	return promos, err
+}
+
+func (r *promoRepoPG) UpdatePromoCode(promo *models.PromoCode) error {
+	return r.db.Model(promo).
+		Select("code", "description", "discount_type", "discount_value", "ticket_type_ids",
+			"max_uses", "max_uses_per_user", "starts_at", "ends_at", "active", "updated_at").
+		Updates(promo).Error
+}
+
+func (r *promoRepoPG) DeletePromoCode(id uuid.UUID) error {
+	return r.db.Delete(&models.PromoCode{}, "id = ?", id).Error
+}
+
+func (r *promoRepoPG) ClaimUse(promoID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.PromoCode{}).
+		Where("id = ? AND (max_uses IS NULL OR used_count < max_uses)", promoID).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *promoRepoPG) ReturnUse(promoID uuid.UUID) error {
+	return r.db.Model(&models.PromoCode{}).
+		Where("id = ? AND used_count > 0", promoID).
+		Update("used_count", gorm.Expr("used_count - 1")).Error
+}
+
+func (r *promoRepoPG) ReclaimUse(promoID uuid.UUID) error {
+	return r.db.Model(&models.PromoCode{}).
+		Where("id = ?", promoID).
+		Update("used_count", gorm.Expr("used_count + 1")).Error
+}
+
+func (r *promoRepoPG) CreateRedemption(redemption *models.PromoRedemption) error {
+	return r.db.Create(redemption).Error
+}
+
+func (r *promoRepoPG) GetRedemptionByReference(reference string) (*models.PromoRedemption, error) {
+	var redemption models.PromoRedemption
+	err := r.db.Where("payment_reference = ?", reference).First(&redemption).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *promoRepoPG) CountUserRedemptions(promoID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.PromoRedemption{}).
+		Where("promo_code_id = ? AND user_id = ? AND status IN ?", promoID, userID,
+			[]models.PromoRedemptionStatus{models.RedemptionPending, models.RedemptionConfirmed}).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *promoRepoPG) TransitionRedemption(id uuid.UUID, from []models.PromoRedemptionStatus, to models.PromoRedemptionStatus) (bool, error) {
+	result := r.db.Model(&models.PromoRedemption{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	// GetStalePendingPayments returns up to limit payments still pending that
+	// were created before t, those never reconciled first, then those
+	// reconciled longest ago, so each run moves on to different payments.
+	GetStalePendingPayments(before time.Time, limit int) ([]models.Payment, error)
+	// MarkReconciled records that payments were just checked against their gateway.
+	MarkReconciled(paymentIDs []uuid.UUID) error
+	Record(reconciliation *models.PaymentReconciliation) error
+	// GetDiscrepancies lists reconciliations since t where the gateway
+	// disagreed with us, newest first.
+	GetDiscrepancies(since time.Time, limit, offset int) ([]models.PaymentReconciliation, int64, error)
+	// GetSummary counts reconciliations since t by action.
+	GetSummary(since time.Time) ([]models.ReconciliationSummary, error)
+}
+
+type reconciliationRepoPG struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepoPG(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepoPG{db: db}
+}
+
+func (r *reconciliationRepoPG) GetStalePendingPayments(before time.Time, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.Preload("Event").Preload("User").Preload("LineItems").Preload("Attendees", attendeesInOrder).
+		Where("status = ? AND created_at < ?", models.PaymentPending, before).
+		Order("reconciled_at NULLS FIRST, created_at").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *reconciliationRepoPG) MarkReconciled(paymentIDs []uuid.UUID) error {
+	if len(paymentIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Payment{}).Where("id IN ?", paymentIDs).Update("reconciled_at", time.Now()).Error
+}
+
+func (r *reconciliationRepoPG) Record(reconciliation *models.PaymentReconciliation) error {
+	return r.db.Create(reconciliation).Error
+}
+
+func (r *reconciliationRepoPG) GetDiscrepancies(since time.Time, limit, offset int) ([]models.PaymentReconciliation, int64, error) {
+	var reconciliations []models.PaymentReconciliation
+	var total int64
+
+	query := r.db.Model(&models.PaymentReconciliation{}).Where("created_at >= ? AND discrepancy <> ''", since)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&reconciliations).Error
+	return reconciliations, total, err
+}
+
+func (r *reconciliationRepoPG) GetSummary(since time.Time) ([]models.ReconciliationSummary, error) {
+	var summary []models.ReconciliationSummary
+	err := r.db.Model(&models.PaymentReconciliation{}).
+		Select("action, COUNT(*) AS count, COUNT(*) FILTER (WHERE discrepancy <> '') AS discrepancies").
+		Where("created_at >= ?", since).
+		Group("action").
+		Order("action").
+		Scan(&summary).Error
+	return summary, err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type RefundRepository interface {
+	Create(refund *models.Refund) error
+	GetByID(id uuid.UUID) (*models.Refund, error)
+	GetByGatewayRefundID(gatewayRefundID string) (*models.Refund, error)
+	// GetOldestPendingByReference finds the earliest pending refund for a
+	// payment, for gateway webhooks that don't carry our refund ID.
+	GetOldestPendingByReference(reference string) (*models.Refund, error)
+	GetByPaymentID(paymentID uuid.UUID) ([]models.Refund, error)
+	GetByHostID(hostID uuid.UUID, limit, offset int) ([]models.Refund, error)
+	// SetGatewayRefundID records the provider's ID for a refund it accepted.
+	SetGatewayRefundID(id uuid.UUID, gatewayRefundID string) error
+	// MarkProcessed and MarkFailed only move pending refunds, and report whether they did.
+	MarkProcessed(id uuid.UUID) (bool, error)
+	MarkFailed(id uuid.UUID, reason string) (bool, error)
+}
+
+type refundRepoPG struct {
+	db *gorm.DB
+}
+
+func NewRefundRepoPG(db *gorm.DB) RefundRepository {
+	return &refundRepoPG{db: db}
+}
+
+func (r *refundRepoPG) Create(refund *models.Refund) error {
+	return r.db.Create(refund).Error
+}
+
+func (r *refundRepoPG) GetByID(id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.First(&refund, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepoPG) GetByGatewayRefundID(gatewayRefundID string) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Where("gateway_refund_id = ?", gatewayRefundID).First(&refund).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepoPG) GetOldestPendingByReference(reference string) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Where("payment_reference = ? AND status = ?", reference, models.RefundPending).
+		Order("created_at ASC").
+		First(&refund).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepoPG) GetByPaymentID(paymentID uuid.UUID) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.Where("payment_id = ?", paymentID).Order("created_at DESC").Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepoPG) GetByHostID(hostID uuid.UUID, limit, offset int) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.Joins("JOIN payments ON payments.id = refunds.payment_id").
+		Where("payments.event_id IN (SELECT id FROM events WHERE host_id = ?)", hostID).
+		Order("refunds.created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepoPG) SetGatewayRefundID(id uuid.UUID, gatewayRefundID string) error {
+	return r.db.Model(&models.Refund{}).Where("id = ?", id).Update("gateway_refund_id", gatewayRefundID).Error
+}
+
+func (r *refundRepoPG) MarkProcessed(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Refund{}).
+		Where("id = ? AND status = ?", id, models.RefundPending).
+		Updates(map[string]interface{}{
+			"status":       models.RefundProcessed,
+			"processed_at": time.Now(),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *refundRepoPG) MarkFailed(id uuid.UUID, reason string) (bool, error) {
+	result := r.db.Model(&models.Refund{}).
+		Where("id = ? AND status = ?", id, models.RefundPending).
+		Updates(map[string]interface{}{
+			"status":         models.RefundFailed,
+			"failure_reason": reason,
+		})
+	return result.RowsAffected == 1, result.Error
+}
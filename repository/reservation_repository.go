@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type ReservationRepository interface {
+	Create(reservation *models.TicketReservation) error
+	// GetHeldQuantity sums the active, unexpired holds on a ticket type.
+	GetHeldQuantity(ticketTypeID uuid.UUID) (int, error)
+	GetHeldQuantities(ticketTypeIDs []uuid.UUID) (map[uuid.UUID]int, error)
+	// GetUserHeldQuantity sums one buyer's active, unexpired holds on a ticket type.
+	GetUserHeldQuantity(userID, ticketTypeID uuid.UUID) (int, error)
+	// CountHeldContacts counts the active, unexpired holds on a ticket type
+	// for any of the given attendee emails or phone digits.
+	CountHeldContacts(ticketTypeID uuid.UUID, emails, phones []string) (int64, error)
+	// ConvertByReference turns a payment's unexpired holds into a sale and
+	// releases any that have expired, in one statement, so a lapsed hold is
+	// never converted. It returns how many holds it converted and released.
+	ConvertByReference(reference string) (int64, int64, error)
+	ReleaseByReference(reference string) (int64, error)
+	ReleaseExpired(now time.Time) (int64, error)
+}
+
+type reservationRepoPG struct {
+	db *gorm.DB
+}
+
+func NewReservationRepoPG(db *gorm.DB) ReservationRepository {
+	return &reservationRepoPG{db: db}
+}
+
+func (r *reservationRepoPG) Create(reservation *models.TicketReservation) error {
+	return r.db.Create(reservation).Error
+}
+
+func (r *reservationRepoPG) GetHeldQuantity(ticketTypeID uuid.UUID) (int, error) {
+	var held int
+	err := r.db.Model(&models.TicketReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("ticket_type_id = ? AND status = ? AND expires_at > ?", ticketTypeID, models.ReservationActive, time.Now()).
+		Scan(&held).Error
+	return held, err
+}
+
+func (r *reservationRepoPG) GetUserHeldQuantity(userID, ticketTypeID uuid.UUID) (int, error) {
+	var held int
+	err := r.db.Model(&models.TicketReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("user_id = ? AND ticket_type_id = ? AND status = ? AND expires_at > ?", userID, ticketTypeID, models.ReservationActive, time.Now()).
+		Scan(&held).Error
+	return held, err
+}
+
+func (r *reservationRepoPG) CountHeldContacts(ticketTypeID uuid.UUID, emails, phones []string) (int64, error) {
+	var count int64
+	if len(emails) == 0 && len(phones) == 0 {
+		return count, nil
+	}
+	err := r.db.Model(&models.TicketReservation{}).
+		Where("ticket_type_id = ? AND status = ? AND expires_at > ?", ticketTypeID, models.ReservationActive, time.Now()).
+		Where("attendee_emails && ? OR attendee_phones && ?", pq.StringArray(emails), pq.StringArray(phones)).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *reservationRepoPG) GetHeldQuantities(ticketTypeIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	held := make(map[uuid.UUID]int)
+	if len(ticketTypeIDs) == 0 {
+		return held, nil
+	}
+
+	var rows []struct {
+		TicketTypeID uuid.UUID
+		Held         int
+	}
+	err := r.db.Model(&models.TicketReservation{}).
+		Select("ticket_type_id, COALESCE(SUM(quantity), 0) as held").
+		Where("ticket_type_id IN ? AND status = ? AND expires_at > ?", ticketTypeIDs, models.ReservationActive, time.Now()).
+		Group("ticket_type_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		held[row.TicketTypeID] = row.Held
+	}
+	return held, nil
+}
+
+func (r *reservationRepoPG) ConvertByReference(reference string) (int64, int64, error) {
+	now := time.Now()
+	var statuses []models.ReservationStatus
+	err := r.db.Raw(`
+		UPDATE ticket_reservations
+		SET status = CASE WHEN expires_at > ? THEN ? ELSE ? END, updated_at = ?
+		WHERE payment_reference = ? AND status = ? AND deleted_at IS NULL
+		RETURNING status`,
+		now, models.ReservationConverted, models.ReservationReleased, now, reference, models.ReservationActive).
+		Scan(&statuses).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var converted, released int64
+	for _, status := range statuses {
+		if status == models.ReservationConverted {
+			converted++
+		} else {
+			released++
+		}
+	}
+	return converted, released, nil
+}
+
+func (r *reservationRepoPG) ReleaseByReference(reference string) (int64, error) {
+	result := r.db.Model(&models.TicketReservation{}).
+		Where("payment_reference = ? AND status = ?", reference, models.ReservationActive).
+		Update("status", models.ReservationReleased)
+	return result.RowsAffected, result.Error
+}
+
+func (r *reservationRepoPG) ReleaseExpired(now time.Time) (int64, error) {
+	result := r.db.Model(&models.TicketReservation{}).
+		Where("status = ? AND expires_at <= ?", models.ReservationActive, now).
+		Update("status", models.ReservationReleased)
+	return result.RowsAffected, result.Error
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hidenkeys/motiv-backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ticketRepoPG struct {
@@ -90,6 +92,15 @@ func (r *ticketRepoPG) GetTicketTypeByID(id uuid.UUID) (*models.TicketType, erro
 	return &ticketType, nil
 }
 
+func (r *ticketRepoPG) GetTicketTypeForUpdate(id uuid.UUID) (*models.TicketType, error) {
+	var ticketType models.TicketType
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&ticketType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ticketType, nil
+}
+
 func (r *ticketRepoPG) UpdateSoldQuantity(ticketTypeID uuid.UUID, quantity int) error {
 	return r.db.Model(&models.TicketType{}).
 		Where("id = ?", ticketTypeID).
@@ -104,3 +115,185 @@ func (r *ticketRepoPG) GetByQRCode(qrCode string) (*models.Ticket, error) {
 	}
 	return &ticket, nil
 }
+
+func (r *ticketRepoPG) GetTicketsByPaymentReference(reference string) ([]*models.Ticket, error) {
+	var tickets []*models.Ticket
+	err := r.db.Where("payment_reference = ?", reference).Find(&tickets).Error
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (r *ticketRepoPG) AssignAttendee(ticketID uuid.UUID, fullName, email, phone string) (bool, error) {
+	result := r.db.Model(&models.Ticket{}).
+		Where("id = ? AND attendee_full_name = ''", ticketID).
+		Updates(map[string]interface{}{
+			"attendee_full_name": fullName,
+			"attendee_email":     email,
+			"attendee_phone":     phone,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *ticketRepoPG) Reissue(ticketID, fromUserID, toUserID uuid.UUID, fullName, email, phone, qrCode string) (bool, error) {
+	result := r.db.Model(&models.Ticket{}).
+		Where("id = ? AND user_id = ?", ticketID, fromUserID).
+		Updates(map[string]interface{}{
+			"user_id":            toUserID,
+			"attendee_full_name": fullName,
+			"attendee_email":     email,
+			"attendee_phone":     phone,
+			"qr_code":            qrCode,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *ticketRepoPG) GetTicketsAfter(afterID uuid.UUID, limit int) ([]*models.Ticket, error) {
+	var tickets []*models.Ticket
+	err := r.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&tickets).Error
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (r *ticketRepoPG) ReplaceQRCode(ticketID uuid.UUID, oldQRCode, qrCode string) (bool, error) {
+	result := r.db.Model(&models.Ticket{}).
+		Where("id = ? AND qr_code = ?", ticketID, oldQRCode).
+		Update("qr_code", qrCode)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// activeTickets limits a ticket query to tickets whose attendee record hasn't
+// been cancelled by a refund.
+func activeTickets(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Ticket{}).
+		Joins("JOIN attendees ON attendees.ticket_id = tickets.id AND attendees.deleted_at IS NULL").
+		Where("attendees.status <> ?", models.AttendeeCancelled)
+}
+
+func (r *ticketRepoPG) CountUserTickets(userID, ticketTypeID uuid.UUID) (int64, error) {
+	var count int64
+	err := activeTickets(r.db).
+		Where("tickets.user_id = ? AND tickets.ticket_type_id = ?", userID, ticketTypeID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *ticketRepoPG) CountUserEventTickets(userID, eventID uuid.UUID) (int64, error) {
+	var count int64
+	err := activeTickets(r.db).
+		Where("tickets.user_id = ? AND tickets.event_id = ?", userID, eventID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *ticketRepoPG) GetTicketsByAttendeeContact(ticketTypeID uuid.UUID, emails, phones []string) ([]*models.Ticket, error) {
+	var tickets []*models.Ticket
+	if len(emails) == 0 && len(phones) == 0 {
+		return tickets, nil
+	}
+
+	const phoneDigits = "REGEXP_REPLACE(tickets.attendee_phone, '[^0-9]', '', 'g')"
+	query := activeTickets(r.db).Where("tickets.ticket_type_id = ?", ticketTypeID)
+	switch {
+	case len(emails) > 0 && len(phones) > 0:
+		query = query.Where("LOWER(tickets.attendee_email) IN ? OR "+phoneDigits+" IN ?", emails, phones)
+	case len(emails) > 0:
+		query = query.Where("LOWER(tickets.attendee_email) IN ?", emails)
+	default:
+		query = query.Where(phoneDigits+" IN ?", phones)
+	}
+	err := query.Find(&tickets).Error
+	return tickets, err
+}
+
+func (r *ticketRepoPG) GetAttendeeEmails(eventID uuid.UUID, emails []string) ([]string, error) {
+	var found []string
+	if len(emails) == 0 {
+		return found, nil
+	}
+	err := activeTickets(r.db).
+		Where("tickets.event_id = ? AND LOWER(tickets.attendee_email) IN ?", eventID, emails).
+		Distinct().
+		Pluck("LOWER(tickets.attendee_email)", &found).Error
+	return found, err
+}
+
+func (r *ticketRepoPG) UpdateTicketTypeLimits(ticketType *models.TicketType) error {
+	return r.db.Model(ticketType).
+		Select("min_per_order", "max_per_order", "max_per_user", "one_per_email", "one_per_phone").
+		Updates(ticketType).Error
+}
+
+func (r *ticketRepoPG) UpdateTicketTypeSales(ticketType *models.TicketType) error {
+	return r.db.Model(ticketType).
+		Select("sales_start", "sales_end", "previous_tier_id").
+		Updates(ticketType).Error
+}
+
+func (r *ticketRepoPG) UpdateTicketTypeVisibility(ticketType *models.TicketType) error {
+	return r.db.Model(ticketType).Update("visibility", ticketType.Visibility).Error
+}
+
+func (r *ticketRepoPG) UpdateTicketTypeEntry(ticketType *models.TicketType) error {
+	return r.db.Model(ticketType).
+		Select("entry_limit", "entry_per_day").
+		Updates(ticketType).Error
+}
+
+func (r *ticketRepoPG) UpdateTicketTypeCapacity(ticketTypeID uuid.UUID, total int) error {
+	return r.db.Model(&models.TicketType{}).Where("id = ?", ticketTypeID).Update("total_quantity", total).Error
+}
+
+func (r *ticketRepoPG) CreatePriceChange(change *models.TicketPriceChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *ticketRepoPG) GetPriceChanges(ticketTypeID uuid.UUID) ([]models.TicketPriceChange, error) {
+	var changes []models.TicketPriceChange
+	err := r.db.Where("ticket_type_id = ?", ticketTypeID).Order("effective_at").Find(&changes).Error
+	return changes, err
+}
+
+func (r *ticketRepoPG) DeletePendingPriceChange(id, ticketTypeID uuid.UUID) (bool, error) {
+	result := r.db.Where("id = ? AND ticket_type_id = ? AND applied_at IS NULL", id, ticketTypeID).
+		Delete(&models.TicketPriceChange{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *ticketRepoPG) GetDuePriceChanges(now time.Time) ([]models.TicketPriceChange, error) {
+	var changes []models.TicketPriceChange
+	err := r.db.Where("applied_at IS NULL AND effective_at <= ?", now).Order("effective_at").Find(&changes).Error
+	return changes, err
+}
+
+func (r *ticketRepoPG) ApplyPriceChange(change *models.TicketPriceChange) (bool, error) {
+	result := r.db.Model(&models.TicketPriceChange{}).
+		Where("id = ? AND applied_at IS NULL", change.ID).
+		Update("applied_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+
+	err := r.db.Model(&models.TicketType{}).
+		Where("id = ?", change.TicketTypeID).
+		Update("price", change.Price).Error
+	return err == nil, err
+}
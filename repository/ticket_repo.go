@@ -2,6 +2,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/hidenkeys/motiv-backend/models"
 )
@@ -12,10 +14,54 @@ type TicketRepository interface {
 	GetTicketsByUserID(userID uuid.UUID) ([]*models.Ticket, error)
 	GetTicketByID(id uuid.UUID) (*models.Ticket, error)
 	GetByQRCode(qrCode string) (*models.Ticket, error)
+	GetTicketsByPaymentReference(reference string) ([]*models.Ticket, error)
+	// AssignAttendee names the attendee on a ticket issued without one, and
+	// reports whether it did; a ticket already named is left untouched.
+	AssignAttendee(ticketID uuid.UUID, fullName, email, phone string) (bool, error)
+	// Reissue moves a ticket from fromUserID to toUserID for the named
+	// attendee with a new QR code, and reports whether it did; a ticket no
+	// longer held by fromUserID is left untouched.
+	Reissue(ticketID, fromUserID, toUserID uuid.UUID, fullName, email, phone, qrCode string) (bool, error)
+	// GetTicketsAfter pages through every ticket in ID order, returning up to
+	// limit tickets with IDs after afterID.
+	GetTicketsAfter(afterID uuid.UUID, limit int) ([]*models.Ticket, error)
+	// ReplaceQRCode swaps a ticket's QR code for qrCode if it is still
+	// oldQRCode, and reports whether it did.
+	ReplaceQRCode(ticketID uuid.UUID, oldQRCode, qrCode string) (bool, error)
+	// CountUserTickets counts a buyer's tickets of a type that haven't been cancelled.
+	CountUserTickets(userID, ticketTypeID uuid.UUID) (int64, error)
+	// CountUserEventTickets counts a buyer's tickets for an event that haven't been cancelled.
+	CountUserEventTickets(userID, eventID uuid.UUID) (int64, error)
+	// GetTicketsByAttendeeContact returns the uncancelled tickets of a type held
+	// by any of the attendee emails, matched case-insensitively, or phone
+	// numbers, matched on their digits.
+	GetTicketsByAttendeeContact(ticketTypeID uuid.UUID, emails, phones []string) ([]*models.Ticket, error)
+	// GetAttendeeEmails returns which of emails, lowercased, already have an
+	// uncancelled ticket for the event.
+	GetAttendeeEmails(eventID uuid.UUID, emails []string) ([]string, error)
 	
 	// Ticket Type methods
 	CreateTicketType(ticketType *models.TicketType) error
 	GetTicketTypesByEventID(eventID uuid.UUID) ([]*models.TicketType, error)
 	GetTicketTypeByID(id uuid.UUID) (*models.TicketType, error)
+	// GetTicketTypeForUpdate row-locks the ticket type; only meaningful inside a transaction.
+	GetTicketTypeForUpdate(id uuid.UUID) (*models.TicketType, error)
 	UpdateSoldQuantity(ticketTypeID uuid.UUID, quantity int) error
+	UpdateTicketTypeLimits(ticketType *models.TicketType) error
+	UpdateTicketTypeSales(ticketType *models.TicketType) error
+	UpdateTicketTypeVisibility(ticketType *models.TicketType) error
+	UpdateTicketTypeEntry(ticketType *models.TicketType) error
+	UpdateTicketTypeCapacity(ticketTypeID uuid.UUID, total int) error
+
+	// Scheduled price changes
+	CreatePriceChange(change *models.TicketPriceChange) error
+	GetPriceChanges(ticketTypeID uuid.UUID) ([]models.TicketPriceChange, error)
+	// DeletePendingPriceChange deletes a price change that hasn't been applied
+	// yet, and reports whether it did.
+	DeletePendingPriceChange(id, ticketTypeID uuid.UUID) (bool, error)
+	// GetDuePriceChanges returns unapplied price changes effective by now, oldest first.
+	GetDuePriceChanges(now time.Time) ([]models.TicketPriceChange, error)
+	// ApplyPriceChange sets the ticket type's price and marks the change
+	// applied, unless it already was, and reports whether it did.
+	ApplyPriceChange(change *models.TicketPriceChange) (bool, error)
 }
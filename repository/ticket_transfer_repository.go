@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type TicketTransferRepository interface {
+	Create(transfer *models.TicketTransfer) error
+	GetByToken(token string) (*models.TicketTransfer, error)
+	// GetPendingByTicketID returns a ticket's unexpired pending transfer.
+	GetPendingByTicketID(ticketID uuid.UUID) (*models.TicketTransfer, error)
+	// GetByTicketID returns a ticket's transfer history, newest first.
+	GetByTicketID(ticketID uuid.UUID) ([]models.TicketTransfer, error)
+	// GetPendingByEmail returns the unexpired transfers waiting on email,
+	// matched case-insensitively.
+	GetPendingByEmail(email string) ([]models.TicketTransfer, error)
+	// ExpireLapsed marks a ticket's pending transfers past their expiry expired.
+	ExpireLapsed(ticketID uuid.UUID) error
+	// MarkAccepted records an unexpired pending transfer as accepted by
+	// toUserID for the named attendee, and reports whether it did.
+	MarkAccepted(id, toUserID uuid.UUID, attendeeName, attendeeEmail string) (bool, error)
+	// Transition moves a pending transfer to status, and reports whether it did.
+	Transition(id uuid.UUID, status models.TicketTransferStatus) (bool, error)
+}
+
+type ticketTransferRepoPG struct {
+	db *gorm.DB
+}
+
+func NewTicketTransferRepoPG(db *gorm.DB) TicketTransferRepository {
+	return &ticketTransferRepoPG{db: db}
+}
+
+func (r *ticketTransferRepoPG) Create(transfer *models.TicketTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+func (r *ticketTransferRepoPG) GetByToken(token string) (*models.TicketTransfer, error) {
+	var transfer models.TicketTransfer
+	err := r.db.Preload("Ticket").Preload("Ticket.Event").Preload("Ticket.TicketType").
+		First(&transfer, "token = ?", token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *ticketTransferRepoPG) GetPendingByTicketID(ticketID uuid.UUID) (*models.TicketTransfer, error) {
+	var transfer models.TicketTransfer
+	err := r.db.Where("ticket_id = ? AND status = ? AND expires_at > ?", ticketID, models.TransferPending, time.Now()).
+		First(&transfer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *ticketTransferRepoPG) GetByTicketID(ticketID uuid.UUID) ([]models.TicketTransfer, error) {
+	var transfers []models.TicketTransfer
+	err := r.db.Where("ticket_id = ?", ticketID).Order("created_at DESC").Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *ticketTransferRepoPG) GetPendingByEmail(email string) ([]models.TicketTransfer, error) {
+	var transfers []models.TicketTransfer
+	err := r.db.Preload("Ticket").Preload("Ticket.Event").Preload("Ticket.TicketType").
+		Where("LOWER(to_email) = LOWER(?) AND status = ? AND expires_at > ?", email, models.TransferPending, time.Now()).
+		Order("created_at DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *ticketTransferRepoPG) ExpireLapsed(ticketID uuid.UUID) error {
+	return r.db.Model(&models.TicketTransfer{}).
+		Where("ticket_id = ? AND status = ? AND expires_at <= ?", ticketID, models.TransferPending, time.Now()).
+		Update("status", models.TransferExpired).Error
+}
+
+func (r *ticketTransferRepoPG) MarkAccepted(id, toUserID uuid.UUID, attendeeName, attendeeEmail string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.TicketTransfer{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, models.TransferPending, now).
+		Updates(map[string]interface{}{
+			"status":            models.TransferAccepted,
+			"to_user_id":        toUserID,
+			"to_attendee_name":  attendeeName,
+			"to_attendee_email": attendeeEmail,
+			"responded_at":      now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *ticketTransferRepoPG) Transition(id uuid.UUID, status models.TicketTransferStatus) (bool, error) {
+	result := r.db.Model(&models.TicketTransfer{}).
+		Where("id = ? AND status = ?", id, models.TransferPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": time.Now(),
+		})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// TxRepositories are repositories bound to a single database transaction.
+type TxRepositories struct {
+	Tickets      TicketRepository
+	Attendees    AttendeeRepository
+	Payments     PaymentRepository
+	Reservations ReservationRepository
+	Refunds      RefundRepository
+	Promos       PromoRepository
+	Payouts      PayoutRepository
+	Ledger       LedgerRepository
+	Waitlist     WaitlistRepository
+	Transfers    TicketTransferRepository
+	CheckIns     CheckInEventRepository
+	Imports      AttendeeImportRepository
+}
+
+// UnitOfWork runs a set of repository calls atomically.
+type UnitOfWork interface {
+	// Do runs fn inside a transaction. If fn returns an error, everything it
+	// wrote through repos is rolled back.
+	Do(fn func(repos TxRepositories) error) error
+}
+
+type unitOfWorkPG struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWorkPG(db *gorm.DB) UnitOfWork {
+	return &unitOfWorkPG{db: db}
+}
+
+func (u *unitOfWorkPG) Do(fn func(repos TxRepositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(TxRepositories{
+			Tickets:      NewTicketRepoPG(tx),
+			Attendees:    NewAttendeeRepoPG(tx),
+			Payments:     NewPaymentRepoPG(tx),
+			Reservations: NewReservationRepoPG(tx),
+			Refunds:      NewRefundRepoPG(tx),
+			Promos:       NewPromoRepoPG(tx),
+			Payouts:      NewPayoutRepoPG(tx),
+			Ledger:       NewLedgerRepoPG(tx),
+			Waitlist:     NewWaitlistRepoPG(tx),
+			Transfers:    NewTicketTransferRepoPG(tx),
+			CheckIns:     NewCheckInEventRepoPG(tx),
+			Imports:      NewAttendeeImportRepoPG(tx),
+		})
+	})
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type WaitlistRepository interface {
+	Create(entry *models.WaitlistEntry) error
+	GetByID(id uuid.UUID) (*models.WaitlistEntry, error)
+	// GetActiveEntry returns a buyer's waiting or offered entry for a ticket type.
+	GetActiveEntry(userID, ticketTypeID uuid.UUID) (*models.WaitlistEntry, error)
+	GetUserEntries(userID uuid.UUID) ([]models.WaitlistEntry, error)
+	// GetTicketTypeEntries lists a ticket type's entries in the given
+	// statuses, or all of them if none are given, in position order.
+	GetTicketTypeEntries(ticketTypeID uuid.UUID, statuses ...models.WaitlistStatus) ([]models.WaitlistEntry, error)
+	GetNextPosition(ticketTypeID uuid.UUID) (int, error)
+	// CountAhead counts the waiting entries ahead of position.
+	CountAhead(ticketTypeID uuid.UUID, position int) (int64, error)
+	SetPosition(id uuid.UUID, position int) error
+
+	// GetWaitingQuantity sums the quantities wanted by a ticket type's waiting
+	// entries, leaving out exceptUserID's own.
+	GetWaitingQuantity(ticketTypeID, exceptUserID uuid.UUID) (int, error)
+	// GetWaitingTicketTypeIDs lists ticket types with anyone waiting.
+	GetWaitingTicketTypeIDs() ([]uuid.UUID, error)
+	// GetLapsedOffers returns offers that expired at or before now.
+	GetLapsedOffers(now time.Time) ([]models.WaitlistEntry, error)
+	// GetUserOffers returns a buyer's unexpired offers for an event.
+	GetUserOffers(userID, eventID uuid.UUID) ([]models.WaitlistEntry, error)
+	// MarkOffered moves a waiting entry to offered, and reports whether it did.
+	MarkOffered(id uuid.UUID, reference string, quantity int, expiresAt time.Time) (bool, error)
+	// MarkClaimed moves an unexpired offer to claimed by the checkout with
+	// reference, and reports whether it did.
+	MarkClaimed(id uuid.UUID, reference string) (bool, error)
+	// Transition moves an entry out of one of from, and reports whether it did.
+	Transition(id uuid.UUID, from []models.WaitlistStatus, to models.WaitlistStatus) (bool, error)
+}
+
+type waitlistRepoPG struct {
+	db *gorm.DB
+}
+
+func NewWaitlistRepoPG(db *gorm.DB) WaitlistRepository {
+	return &waitlistRepoPG{db: db}
+}
+
+func (r *waitlistRepoPG) Create(entry *models.WaitlistEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *waitlistRepoPG) GetByID(id uuid.UUID) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := r.db.Preload("TicketType").Preload("User").First(&entry, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepoPG) GetActiveEntry(userID, ticketTypeID uuid.UUID) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := r.db.Where("user_id = ? AND ticket_type_id = ? AND status IN ?", userID, ticketTypeID,
+		[]models.WaitlistStatus{models.WaitlistWaiting, models.WaitlistOffered}).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepoPG) GetUserEntries(userID uuid.UUID) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.db.Preload("TicketType").Preload("TicketType.Event").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepoPG) GetTicketTypeEntries(ticketTypeID uuid.UUID, statuses ...models.WaitlistStatus) ([]models.WaitlistEntry, error) {
+	query := r.db.Preload("User").Where("ticket_type_id = ?", ticketTypeID)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+
+	var entries []models.WaitlistEntry
+	err := query.Order("position, created_at").Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepoPG) GetNextPosition(ticketTypeID uuid.UUID) (int, error) {
+	var position int
+	err := r.db.Model(&models.WaitlistEntry{}).
+		Select("COALESCE(MAX(position), 0) + 1").
+		Where("ticket_type_id = ?", ticketTypeID).
+		Scan(&position).Error
+	return position, err
+}
+
+func (r *waitlistRepoPG) CountAhead(ticketTypeID uuid.UUID, position int) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.WaitlistEntry{}).
+		Where("ticket_type_id = ? AND status = ? AND position < ?", ticketTypeID, models.WaitlistWaiting, position).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *waitlistRepoPG) SetPosition(id uuid.UUID, position int) error {
+	return r.db.Model(&models.WaitlistEntry{}).Where("id = ?", id).Update("position", position).Error
+}
+
+func (r *waitlistRepoPG) GetWaitingQuantity(ticketTypeID, exceptUserID uuid.UUID) (int, error) {
+	var waiting int
+	err := r.db.Model(&models.WaitlistEntry{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("ticket_type_id = ? AND status = ? AND user_id <> ?", ticketTypeID, models.WaitlistWaiting, exceptUserID).
+		Scan(&waiting).Error
+	return waiting, err
+}
+
+func (r *waitlistRepoPG) GetWaitingTicketTypeIDs() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.WaitlistEntry{}).
+		Distinct("ticket_type_id").
+		Where("status = ?", models.WaitlistWaiting).
+		Pluck("ticket_type_id", &ids).Error
+	return ids, err
+}
+
+func (r *waitlistRepoPG) GetLapsedOffers(now time.Time) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.db.Where("status = ? AND offer_expires_at <= ?", models.WaitlistOffered, now).Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepoPG) GetUserOffers(userID, eventID uuid.UUID) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.db.Where("user_id = ? AND event_id = ? AND status = ? AND offer_expires_at > ?",
+		userID, eventID, models.WaitlistOffered, time.Now()).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepoPG) MarkOffered(id uuid.UUID, reference string, quantity int, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ?", id, models.WaitlistWaiting).
+		Updates(map[string]interface{}{
+			"status":           models.WaitlistOffered,
+			"offer_reference":  reference,
+			"offered_quantity": quantity,
+			"offered_at":       now,
+			"offer_expires_at": expiresAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *waitlistRepoPG) MarkClaimed(id uuid.UUID, reference string) (bool, error) {
+	result := r.db.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ? AND offer_expires_at > ?", id, models.WaitlistOffered, time.Now()).
+		Updates(map[string]interface{}{
+			"status":            models.WaitlistClaimed,
+			"claimed_reference": reference,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *waitlistRepoPG) Transition(id uuid.UUID, from []models.WaitlistStatus, to models.WaitlistStatus) (bool, error) {
+	result := r.db.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"gorm.io/gorm"
+)
+
+type WebhookEventRepository interface {
+	Create(event *models.WebhookEvent) error
+	GetByID(id uuid.UUID) (*models.WebhookEvent, error)
+	GetByProviderEventID(provider, providerEventID string) (*models.WebhookEvent, error)
+	List(provider, status string, limit, offset int) ([]models.WebhookEvent, int64, error)
+	// Claim moves an event to processing if it is in one of the from statuses,
+	// or was left processing without an update since staleBefore, e.g. by a
+	// crash mid-run, and reports whether it did.
+	Claim(id uuid.UUID, from []models.WebhookEventStatus, staleBefore time.Time) (bool, error)
+	UpdateResult(id uuid.UUID, status models.WebhookEventStatus, errorMessage string) error
+}
+
+type webhookEventRepoPG struct {
+	db *gorm.DB
+}
+
+func NewWebhookEventRepoPG(db *gorm.DB) WebhookEventRepository {
+	return &webhookEventRepoPG{db: db}
+}
+
+func (w *webhookEventRepoPG) Create(event *models.WebhookEvent) error {
+	return w.db.Create(event).Error
+}
+
+func (w *webhookEventRepoPG) GetByID(id uuid.UUID) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	err := w.db.First(&event, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (w *webhookEventRepoPG) GetByProviderEventID(provider, providerEventID string) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	err := w.db.Where("provider = ? AND provider_event_id = ?", provider, providerEventID).First(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (w *webhookEventRepoPG) List(provider, status string, limit, offset int) ([]models.WebhookEvent, int64, error) {
+	var events []models.WebhookEvent
+	var total int64
+
+	query := w.db.Model(&models.WebhookEvent{})
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, total, err
+}
+
+func (w *webhookEventRepoPG) Claim(id uuid.UUID, from []models.WebhookEventStatus, staleBefore time.Time) (bool, error) {
+	result := w.db.Model(&models.WebhookEvent{}).
+		Where("id = ? AND (status IN ? OR (status = ? AND updated_at < ?))", id, from, models.WebhookProcessing, staleBefore).
+		Updates(map[string]interface{}{
+			"status":   models.WebhookProcessing,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (w *webhookEventRepoPG) UpdateResult(id uuid.UUID, status models.WebhookEventStatus, errorMessage string) error {
+	updates := map[string]interface{}{
+		"status": status,
+		"error":  errorMessage,
+	}
+	if status == models.WebhookProcessed || status == models.WebhookIgnored {
+		updates["processed_at"] = time.Now()
+	}
+	return w.db.Model(&models.WebhookEvent{}).Where("id = ?", id).Updates(updates).Error
+}
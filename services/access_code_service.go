@@ -0,0 +1,187 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type AccessCodeService interface {
+	// CreateAccessCode validates and stores a new code. Typed codes are
+	// upper-cased; link codes are given a generated token.
+	CreateAccessCode(code *models.AccessCode) error
+	GetAccessCode(id uuid.UUID) (*models.AccessCode, error)
+	GetEventAccessCodes(eventID uuid.UUID) ([]models.AccessCode, error)
+	UpdateAccessCode(code *models.AccessCode) error
+	DeleteAccessCode(id uuid.UUID) error
+
+	// Unlock looks up a code or link token supplied for eventID. An empty
+	// code returns nil; an unknown, inactive or expired one returns
+	// ErrAccessCodeInvalid.
+	Unlock(eventID uuid.UUID, code string) (*models.AccessCode, error)
+}
+
+var (
+	ErrAccessCodeInvalid = errors.New("access code is not valid for this event")
+	ErrAccessCodeSetup   = errors.New("invalid access code")
+)
+
+type accessCodeService struct {
+	accessCodeRepo repository.AccessCodeRepository
+	frontendURL    string
+}
+
+// NewAccessCodeService stores hosts' access codes; link codes point at the
+// event's page under frontendURL.
+func NewAccessCodeService(accessCodeRepo repository.AccessCodeRepository, frontendURL string) AccessCodeService {
+	return &accessCodeService{
+		accessCodeRepo: accessCodeRepo,
+		frontendURL:    strings.TrimRight(frontendURL, "/"),
+	}
+}
+
+// TicketTypeVisible reports whether a ticket type can be seen and bought
+// with access, which is nil when no code was supplied.
+func TicketTypeVisible(ticketType *models.TicketType, access *models.AccessCode) bool {
+	if ticketType.Visibility != models.TicketHidden {
+		return true
+	}
+	if access == nil || access.EventID != ticketType.EventID {
+		return false
+	}
+	if len(access.TicketTypeIDs) == 0 {
+		return true
+	}
+	for _, id := range access.TicketTypeIDs {
+		if id == ticketType.ID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibleTicketTypes drops the hidden ticket types access doesn't unlock.
+func VisibleTicketTypes(ticketTypes []models.TicketType, access *models.AccessCode) []models.TicketType {
+	visible := make([]models.TicketType, 0, len(ticketTypes))
+	for i := range ticketTypes {
+		if TicketTypeVisible(&ticketTypes[i], access) {
+			visible = append(visible, ticketTypes[i])
+		}
+	}
+	return visible
+}
+
+// validateAccessCode checks the settings a host controls.
+func validateAccessCode(code *models.AccessCode) error {
+	switch code.Kind {
+	case models.AccessCodeTyped:
+		code.Code = strings.ToUpper(strings.TrimSpace(code.Code))
+		if code.Code == "" || strings.ContainsAny(code.Code, " \t\n") {
+			return fmt.Errorf("%w: code must be a single word", ErrAccessCodeSetup)
+		}
+	case models.AccessCodeLink:
+		if code.Code == "" {
+			return fmt.Errorf("%w: link has no token", ErrAccessCodeSetup)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be 'code' or 'link'", ErrAccessCodeSetup)
+	}
+	return nil
+}
+
+// newLinkToken generates the token carried by a magic link.
+func newLinkToken() (string, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate link token: %w", err)
+	}
+	return strings.ToUpper(hex.EncodeToString(tokenBytes)), nil
+}
+
+func (s *accessCodeService) setLink(code *models.AccessCode) {
+	if code.Kind == models.AccessCodeLink {
+		code.Link = fmt.Sprintf("%s/events/%s?access_code=%s", s.frontendURL, code.EventID, code.Code)
+	}
+}
+
+func (s *accessCodeService) CreateAccessCode(code *models.AccessCode) error {
+	if code.Kind == models.AccessCodeLink {
+		token, err := newLinkToken()
+		if err != nil {
+			return err
+		}
+		code.Code = token
+	}
+	if err := validateAccessCode(code); err != nil {
+		return err
+	}
+	if _, err := s.accessCodeRepo.GetAccessCodeByCode(code.EventID, code.Code); err == nil {
+		return fmt.Errorf("%w: %s already exists for this event", ErrAccessCodeSetup, code.Code)
+	}
+	if err := s.accessCodeRepo.CreateAccessCode(code); err != nil {
+		return err
+	}
+	s.setLink(code)
+	return nil
+}
+
+func (s *accessCodeService) GetAccessCode(id uuid.UUID) (*models.AccessCode, error) {
+	code, err := s.accessCodeRepo.GetAccessCodeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.setLink(code)
+	return code, nil
+}
+
+func (s *accessCodeService) GetEventAccessCodes(eventID uuid.UUID) ([]models.AccessCode, error) {
+	codes, err := s.accessCodeRepo.GetAccessCodesByEventID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range codes {
+		s.setLink(&codes[i])
+	}
+	return codes, nil
+}
+
+func (s *accessCodeService) UpdateAccessCode(code *models.AccessCode) error {
+	if err := validateAccessCode(code); err != nil {
+		return err
+	}
+	if existing, err := s.accessCodeRepo.GetAccessCodeByCode(code.EventID, code.Code); err == nil && existing.ID != code.ID {
+		return fmt.Errorf("%w: %s already exists for this event", ErrAccessCodeSetup, code.Code)
+	}
+	if err := s.accessCodeRepo.UpdateAccessCode(code); err != nil {
+		return err
+	}
+	s.setLink(code)
+	return nil
+}
+
+func (s *accessCodeService) DeleteAccessCode(id uuid.UUID) error {
+	return s.accessCodeRepo.DeleteAccessCode(id)
+}
+
+func (s *accessCodeService) Unlock(eventID uuid.UUID, code string) (*models.AccessCode, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, nil
+	}
+
+	access, err := s.accessCodeRepo.GetAccessCodeByCode(eventID, code)
+	if err != nil || !access.Active {
+		return nil, ErrAccessCodeInvalid
+	}
+	if access.ExpiresAt != nil && time.Now().After(*access.ExpiresAt) {
+		return nil, ErrAccessCodeInvalid
+	}
+	return access, nil
+}
@@ -20,6 +20,7 @@ type AnalyticsService interface {
 	// Performance Stats
 	GetMonthlyRevenueStats(hostID uuid.UUID, year int) ([]map[string]interface{}, error)
 	GetEventPerformanceStats(eventID uuid.UUID) (map[string]interface{}, error)
+	GetPromoCodeStats(eventID uuid.UUID) ([]models.PromoCodeStats, error)
 	
 	// Update Analytics
 	UpdateEventAnalytics(eventID uuid.UUID) error
@@ -79,6 +80,10 @@ func (s *analyticsService) GetEventPerformanceStats(eventID uuid.UUID) (map[stri
 	return s.analyticsRepo.GetEventPerformanceStats(eventID)
 }
 
+func (s *analyticsService) GetPromoCodeStats(eventID uuid.UUID) ([]models.PromoCodeStats, error) {
+	return s.analyticsRepo.GetPromoCodeStats(eventID)
+}
+
 func (s *analyticsService) UpdateEventAnalytics(eventID uuid.UUID) error {
 	analytics, err := s.analyticsRepo.GetEventAnalytics(eventID)
 	if err != nil {
@@ -0,0 +1,551 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+const (
+	// maxImportRows is the most attendees one CSV can hold.
+	maxImportRows = 5000
+	// inlineImportRows is the most rows a commit imports before returning;
+	// larger imports are left to the background worker.
+	inlineImportRows = 100
+	// importBatchSize is how many rows are loaded at a time while importing.
+	importBatchSize = 100
+	// importStaleAfter is how long a processing import can go without a
+	// heartbeat before another worker takes it over.
+	importStaleAfter = 5 * time.Minute
+)
+
+// importEmailPattern matches the addresses accepted at signup.
+var importEmailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+type AttendeeImportService interface {
+	// Preview reads a CSV of attendees for event and validates each row,
+	// checking its ticket type, what's left of it, and whether the email
+	// already has a ticket or is on an earlier row. Nothing is issued until
+	// the import is committed.
+	Preview(event *models.Event, hostID uuid.UUID, fileName string, file io.Reader) (*models.AttendeeImport, error)
+	// GetImport returns an import with its row counts and progress.
+	GetImport(id uuid.UUID) (*models.AttendeeImport, error)
+	GetEventImports(eventID uuid.UUID) ([]models.AttendeeImport, error)
+	GetRows(importID uuid.UUID, status models.AttendeeImportRowStatus, limit, offset int) ([]models.AttendeeImportRow, int64, error)
+	// Commit issues a ticket and QR code to each valid row, and to
+	// duplicates if importDuplicates is set, optionally emailing each
+	// attendee their ticket. Small imports are done before it returns;
+	// larger ones are queued for the background worker.
+	Commit(attendeeImport *models.AttendeeImport, importDuplicates, sendInvites bool) (*models.AttendeeImport, error)
+	// StartWorker processes queued imports every interval.
+	StartWorker(interval time.Duration)
+}
+
+var (
+	ErrImportInvalid    = errors.New("invalid attendee import")
+	ErrImportNotPending = errors.New("attendee import has already been committed")
+)
+
+// errImportRowTaken rolls back a row another worker has already imported.
+var errImportRowTaken = errors.New("import row has already been dealt with")
+
+type attendeeImportService struct {
+	importRepo      repository.AttendeeImportRepository
+	ticketRepo      repository.TicketRepository
+	reservationRepo repository.ReservationRepository
+	eventRepo       repository.EventRepository
+	userRepo        repository.UserRepository
+	uow             repository.UnitOfWork
+	signer          TicketSigner
+	emailService    EmailService
+}
+
+func NewAttendeeImportService(importRepo repository.AttendeeImportRepository, ticketRepo repository.TicketRepository, reservationRepo repository.ReservationRepository, eventRepo repository.EventRepository, userRepo repository.UserRepository, uow repository.UnitOfWork, signer TicketSigner, emailService EmailService) AttendeeImportService {
+	return &attendeeImportService{
+		importRepo:      importRepo,
+		ticketRepo:      ticketRepo,
+		reservationRepo: reservationRepo,
+		eventRepo:       eventRepo,
+		userRepo:        userRepo,
+		uow:             uow,
+		signer:          signer,
+		emailService:    emailService,
+	}
+}
+
+// importColumns are the CSV columns holding each attendee field, or -1.
+type importColumns struct {
+	name, email, phone, ticketType int
+}
+
+// parseImportHeader finds the attendee fields in a CSV header. It accepts
+// the headers ExportHostAttendees writes, so an export can be imported.
+func parseImportHeader(header []string) (importColumns, error) {
+	columns := importColumns{name: -1, email: -1, phone: -1, ticketType: -1}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "name", "full name", "fullname", "attendee name":
+			columns.name = i
+		case "email", "email address", "attendee email":
+			columns.email = i
+		case "phone", "phone number", "attendee phone":
+			columns.phone = i
+		case "ticket type", "ticket_type", "tickettype", "ticket":
+			columns.ticketType = i
+		}
+	}
+	if columns.name < 0 || columns.email < 0 {
+		return columns, fmt.Errorf("%w: the header row needs Name and Email columns", ErrImportInvalid)
+	}
+	return columns, nil
+}
+
+func (c importColumns) field(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+func (s *attendeeImportService) Preview(event *models.Event, hostID uuid.UUID, fileName string, file io.Reader) (*models.AttendeeImport, error) {
+	if event.Status == models.CancelledEvent {
+		return nil, fmt.Errorf("%w: event is cancelled", ErrImportInvalid)
+	}
+	ticketTypes, err := s.ticketRepo.GetTicketTypesByEventID(event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ticket types: %w", err)
+	}
+	if len(ticketTypes) == 0 {
+		return nil, fmt.Errorf("%w: event has no ticket types", ErrImportInvalid)
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrImportInvalid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportInvalid, err)
+	}
+	columns, err := parseImportHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.AttendeeImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportInvalid, err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("%w: at most %d attendees per file", ErrImportInvalid, maxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, models.AttendeeImportRow{
+			Line:           line,
+			FullName:       columns.field(record, columns.name),
+			Email:          strings.ToLower(columns.field(record, columns.email)),
+			Phone:          columns.field(record, columns.phone),
+			TicketTypeName: columns.field(record, columns.ticketType),
+		})
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: file has no attendees", ErrImportInvalid)
+	}
+
+	if err := s.validateRows(event, ticketTypes, rows); err != nil {
+		return nil, err
+	}
+
+	attendeeImport := &models.AttendeeImport{
+		EventID:   event.ID,
+		HostID:    hostID,
+		FileName:  fileName,
+		Status:    models.ImportPending,
+		TotalRows: len(rows),
+	}
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		if err := repos.Imports.Create(attendeeImport); err != nil {
+			return fmt.Errorf("failed to save import: %w", err)
+		}
+		for i := range rows {
+			rows[i].ImportID = attendeeImport.ID
+		}
+		if err := repos.Imports.CreateRows(rows); err != nil {
+			return fmt.Errorf("failed to save import rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withProgress(attendeeImport)
+}
+
+// validateRows sets each row's status, with the reasons it can't be
+// imported, and resolves its ticket type.
+func (s *attendeeImportService) validateRows(event *models.Event, ticketTypes []*models.TicketType, rows []models.AttendeeImportRow) error {
+	byName := make(map[string]*models.TicketType, len(ticketTypes))
+	ticketTypeIDs := make([]uuid.UUID, 0, len(ticketTypes))
+	for _, ticketType := range ticketTypes {
+		byName[strings.ToLower(strings.TrimSpace(ticketType.Name))] = ticketType
+		ticketTypeIDs = append(ticketTypeIDs, ticketType.ID)
+	}
+
+	var emails []string
+	for i := range rows {
+		row := &rows[i]
+		var problems []string
+		if row.FullName == "" {
+			problems = append(problems, "Name is required")
+		}
+		switch {
+		case row.Email == "":
+			problems = append(problems, "Email is required")
+		case !importEmailPattern.MatchString(row.Email):
+			problems = append(problems, "Email is not valid")
+		}
+
+		// With only one ticket type the column can be left out
+		ticketType := byName[strings.ToLower(row.TicketTypeName)]
+		switch {
+		case row.TicketTypeName == "" && len(ticketTypes) == 1:
+			ticketType = ticketTypes[0]
+		case row.TicketTypeName == "":
+			problems = append(problems, "Ticket type is required")
+		case ticketType == nil:
+			problems = append(problems, fmt.Sprintf("No ticket type named %q", row.TicketTypeName))
+		}
+		if ticketType != nil {
+			row.TicketTypeID = &ticketType.ID
+			row.TicketTypeName = ticketType.Name
+		}
+
+		if len(problems) > 0 {
+			row.Status = models.ImportRowInvalid
+			row.Error = strings.Join(problems, "; ")
+			continue
+		}
+		row.Status = models.ImportRowValid
+		emails = append(emails, row.Email)
+	}
+
+	existing, err := s.ticketRepo.GetAttendeeEmails(event.ID, emails)
+	if err != nil {
+		return fmt.Errorf("failed to check for existing tickets: %w", err)
+	}
+	hasTicket := make(map[string]bool, len(existing))
+	for _, email := range existing {
+		hasTicket[email] = true
+	}
+
+	held, err := s.reservationRepo.GetHeldQuantities(ticketTypeIDs)
+	if err != nil {
+		return fmt.Errorf("failed to load holds: %w", err)
+	}
+	left := make(map[uuid.UUID]int, len(ticketTypes))
+	for _, ticketType := range ticketTypes {
+		left[ticketType.ID] = max(ticketType.TotalQuantity-ticketType.SoldQuantity-held[ticketType.ID], 0)
+	}
+	available := make(map[uuid.UUID]int, len(left))
+	for id, count := range left {
+		available[id] = count
+	}
+
+	firstLine := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != models.ImportRowValid {
+			continue
+		}
+		if hasTicket[row.Email] {
+			row.Status = models.ImportRowDuplicate
+			row.Error = "Already has a ticket for this event"
+			continue
+		}
+		if line, seen := firstLine[row.Email]; seen {
+			row.Status = models.ImportRowDuplicate
+			row.Error = fmt.Sprintf("Also on line %d", line)
+			continue
+		}
+		firstLine[row.Email] = row.Line
+
+		// Rows past what's left of their ticket type can't be issued
+		if left[*row.TicketTypeID] == 0 {
+			row.Status = models.ImportRowInvalid
+			row.Error = fmt.Sprintf("Only %d %s left", available[*row.TicketTypeID], row.TicketTypeName)
+			continue
+		}
+		left[*row.TicketTypeID]--
+	}
+	return nil
+}
+
+// importableStatuses are the rows a commit imports.
+func importableStatuses(importDuplicates bool) []models.AttendeeImportRowStatus {
+	if importDuplicates {
+		return []models.AttendeeImportRowStatus{models.ImportRowValid, models.ImportRowDuplicate}
+	}
+	return []models.AttendeeImportRowStatus{models.ImportRowValid}
+}
+
+// withProgress fills in an import's row counts and how far it has got.
+func (s *attendeeImportService) withProgress(attendeeImport *models.AttendeeImport) (*models.AttendeeImport, error) {
+	counts, err := s.importRepo.CountRows(attendeeImport.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count import rows: %w", err)
+	}
+	attendeeImport.RowCounts = counts
+
+	switch attendeeImport.Status {
+	case models.ImportCompleted:
+		attendeeImport.Progress = 1
+	case models.ImportQueued, models.ImportProcessing, models.ImportFailed:
+		if attendeeImport.QueuedRows > 0 {
+			var remaining int64
+			for _, status := range importableStatuses(attendeeImport.ImportDuplicates) {
+				remaining += counts[status]
+			}
+			attendeeImport.Progress = max(float64(int64(attendeeImport.QueuedRows)-remaining), 0) / float64(attendeeImport.QueuedRows)
+		}
+	}
+	return attendeeImport, nil
+}
+
+func (s *attendeeImportService) GetImport(id uuid.UUID) (*models.AttendeeImport, error) {
+	attendeeImport, err := s.importRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withProgress(attendeeImport)
+}
+
+func (s *attendeeImportService) GetEventImports(eventID uuid.UUID) ([]models.AttendeeImport, error) {
+	return s.importRepo.GetByEventID(eventID)
+}
+
+func (s *attendeeImportService) GetRows(importID uuid.UUID, status models.AttendeeImportRowStatus, limit, offset int) ([]models.AttendeeImportRow, int64, error) {
+	return s.importRepo.GetRows(importID, status, limit, offset)
+}
+
+func (s *attendeeImportService) Commit(attendeeImport *models.AttendeeImport, importDuplicates, sendInvites bool) (*models.AttendeeImport, error) {
+	if attendeeImport.Status != models.ImportPending {
+		return nil, ErrImportNotPending
+	}
+
+	counts, err := s.importRepo.CountRows(attendeeImport.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count import rows: %w", err)
+	}
+	queued := 0
+	for _, status := range importableStatuses(importDuplicates) {
+		queued += int(counts[status])
+	}
+	if queued == 0 {
+		return nil, fmt.Errorf("%w: no rows to import", ErrImportInvalid)
+	}
+
+	ok, err := s.importRepo.Queue(attendeeImport.ID, importDuplicates, sendInvites, queued)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue import: %w", err)
+	}
+	if !ok {
+		return nil, ErrImportNotPending
+	}
+
+	// Small imports are done now; the worker may already have claimed it
+	if queued <= inlineImportRows {
+		claimed, err := s.importRepo.Claim(attendeeImport.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start import: %w", err)
+		}
+		if claimed {
+			claimedImport, err := s.importRepo.GetByID(attendeeImport.ID)
+			if err != nil {
+				return nil, err
+			}
+			s.process(claimedImport)
+		}
+	}
+
+	return s.GetImport(attendeeImport.ID)
+}
+
+// process imports a claimed import's queued rows. If it stops part way,
+// the import is left processing for a worker to take over once stale.
+func (s *attendeeImportService) process(attendeeImport *models.AttendeeImport) {
+	event, err := s.eventRepo.GetEventByID(attendeeImport.EventID)
+	if err != nil {
+		log.Printf("❌ IMPORT ERROR: Failed to load event for import %s: %v", attendeeImport.ID, err)
+		return
+	}
+	if event.Status == models.CancelledEvent {
+		if err := s.importRepo.Finish(attendeeImport.ID, models.ImportFailed, "Event was cancelled"); err != nil {
+			log.Printf("❌ IMPORT ERROR: Failed to stop import %s: %v", attendeeImport.ID, err)
+		}
+		return
+	}
+
+	statuses := importableStatuses(attendeeImport.ImportDuplicates)
+	for {
+		rows, err := s.importRepo.GetRowsToImport(attendeeImport.ID, statuses, importBatchSize)
+		if err != nil {
+			log.Printf("❌ IMPORT ERROR: Failed to load rows for import %s: %v", attendeeImport.ID, err)
+			return
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for i := range rows {
+			if err := s.importRow(attendeeImport, event, &rows[i]); err != nil {
+				log.Printf("❌ IMPORT ERROR: Failed to record line %d of import %s: %v", rows[i].Line, attendeeImport.ID, err)
+				return
+			}
+		}
+		if err := s.importRepo.Heartbeat(attendeeImport.ID); err != nil {
+			log.Printf("⚠️ IMPORT WARNING: Failed to record progress on import %s: %v", attendeeImport.ID, err)
+		}
+	}
+
+	if err := s.importRepo.Finish(attendeeImport.ID, models.ImportCompleted, ""); err != nil {
+		log.Printf("❌ IMPORT ERROR: Failed to complete import %s: %v", attendeeImport.ID, err)
+		return
+	}
+	log.Printf("📥 IMPORT COMPLETED: Import %s for event %s", attendeeImport.ID, event.ID)
+}
+
+// finishImportRow records what became of row inside a unit of work.
+func finishImportRow(repos repository.TxRepositories, row *models.AttendeeImportRow, from []models.AttendeeImportRowStatus, status models.AttendeeImportRowStatus, ticketID *uuid.UUID, reason string) error {
+	finished, err := repos.Imports.FinishRow(row.ID, from, status, ticketID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to record import row: %w", err)
+	}
+	if !finished {
+		return errImportRowTaken
+	}
+	return nil
+}
+
+// importRow issues row its ticket, or records why it couldn't be. Tickets go
+// to the account using the row's email, or are held by the host. It only
+// returns an error if what became of the row couldn't be recorded.
+func (s *attendeeImportService) importRow(attendeeImport *models.AttendeeImport, event *models.Event, row *models.AttendeeImportRow) error {
+	from := importableStatuses(attendeeImport.ImportDuplicates)
+
+	ownerID := event.HostID
+	recipient := &models.User{Name: row.FullName, Email: row.Email}
+	user, err := attendeeAccount(s.userRepo, row.Email)
+	if err != nil {
+		return fmt.Errorf("failed to look up attendee account: %w", err)
+	}
+	if user != nil {
+		ownerID = user.ID
+		recipient = user
+	}
+
+	var ticket *models.Ticket
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		ticket = nil
+
+		// Someone may have got a ticket since the preview
+		if !attendeeImport.ImportDuplicates {
+			existing, err := repos.Tickets.GetAttendeeEmails(event.ID, []string{row.Email})
+			if err != nil {
+				return fmt.Errorf("failed to check for existing tickets: %w", err)
+			}
+			if len(existing) > 0 {
+				return finishImportRow(repos, row, from, models.ImportRowDuplicate, nil, "Already has a ticket for this event")
+			}
+		}
+
+		if _, err := checkCapacity(repos, uuid.Nil, *row.TicketTypeID, 1); err != nil {
+			if errors.Is(err, ErrTicketsUnavailable) {
+				return finishImportRow(repos, row, from, models.ImportRowFailed, nil, err.Error())
+			}
+			return err
+		}
+
+		issued := &models.Ticket{
+			EventID:          event.ID,
+			UserID:           ownerID,
+			TicketTypeID:     *row.TicketTypeID,
+			PaymentReference: "IMPORT-" + attendeeImport.ID.String(),
+			AttendeeFullName: row.FullName,
+			AttendeeEmail:    row.Email,
+			AttendeePhone:    row.Phone,
+			Quantity:         1,
+		}
+		if err := issueTicket(repos.Tickets, repos.Attendees, s.signer, issued); err != nil {
+			return err
+		}
+		if err := repos.Tickets.UpdateSoldQuantity(issued.TicketTypeID, 1); err != nil {
+			return fmt.Errorf("failed to update sold quantity: %w", err)
+		}
+		if err := finishImportRow(repos, row, from, models.ImportRowImported, &issued.ID, ""); err != nil {
+			return err
+		}
+		ticket = issued
+		return nil
+	})
+	if errors.Is(err, errImportRowTaken) {
+		return nil
+	}
+	if err != nil {
+		// Nothing was issued; record the failure on its own
+		log.Printf("❌ IMPORT ERROR: Failed to import line %d of import %s: %v", row.Line, attendeeImport.ID, err)
+		if _, err := s.importRepo.FinishRow(row.ID, from, models.ImportRowFailed, nil, "Failed to issue ticket"); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if ticket != nil && attendeeImport.SendInvites {
+		if err := s.emailService.SendTicketConfirmation(ticket, event, recipient); err != nil {
+			log.Printf("❌ EMAIL ERROR: Failed to send imported ticket %s to %s: %v", ticket.ID, row.Email, err)
+		}
+	}
+	return nil
+}
+
+func (s *attendeeImportService) StartWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			for {
+				attendeeImport, err := s.importRepo.ClaimNext(time.Now().Add(-importStaleAfter))
+				if err != nil {
+					log.Printf("❌ IMPORT WORKER ERROR: %v", err)
+					break
+				}
+				if attendeeImport == nil {
+					break
+				}
+				log.Printf("📥 IMPORT WORKER: Processing import %s (%d rows)", attendeeImport.ID, attendeeImport.QueuedRows)
+				s.process(attendeeImport)
+			}
+		}
+	}()
+}
@@ -1,6 +1,10 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,7 +21,16 @@ type AttendeeService interface {
 	GetHostAttendeesTotalCount(hostID uuid.UUID) (int64, error)
 	GetHostAttendeesWithFilters(hostID uuid.UUID, limit, offset int, eventID *uuid.UUID, ticketType, status, search string) ([]AttendeeResponse, error)
 	GetHostAttendeesTotalCountWithFilters(hostID uuid.UUID, eventID *uuid.UUID, ticketType, status, search string) (int64, error)
-	CheckInByQRCode(qrCode string, eventID, checkedInBy uuid.UUID) (*CheckInResult, error)
+	// CheckInByQRCode scans a ticket in at the door and logs the scan, whatever
+	// its outcome, at gate if the scanner named one. A ticket gets in as many
+	// times as its type allows.
+	CheckInByQRCode(qrCode, gate string, eventID, checkedInBy uuid.UUID) (*CheckInResult, error)
+	// GetCheckInLog lists an event's scans, or one ticket's, most recent first.
+	GetCheckInLog(eventID uuid.UUID, ticketID *uuid.UUID, limit, offset int) ([]models.CheckInEvent, int64, error)
+	GetCheckIn(id uuid.UUID) (*models.CheckInEvent, error)
+	// UndoCheckIn takes back an admitted scan, e.g. one made by mistake. The
+	// scan stays in the log, and the ticket's entries are counted again.
+	UndoCheckIn(checkIn *models.CheckInEvent, undoneBy uuid.UUID, reason string) error
 	GetEventAttendeeStats(eventID uuid.UUID) (map[string]int64, error)
 	GetHostAttendeeStats(hostID uuid.UUID) (map[string]int64, error)
 }
@@ -26,6 +39,7 @@ type CheckInResult struct {
 	Success   bool              `json:"success"`
 	Message   string            `json:"message"`
 	Attendee  *AttendeeResponse `json:"attendee,omitempty"`
+	CheckInID *uuid.UUID        `json:"check_in_id,omitempty"` // The logged scan, for undoing it
 	Timestamp time.Time         `json:"timestamp"`
 }
 
@@ -41,19 +55,34 @@ type AttendeeResponse struct {
 	Amount       float64    `json:"amount"`
 	Status       string     `json:"status"`
 	CheckInTime  *time.Time `json:"check_in_time,omitempty"`
+	CheckedInBy  *uuid.UUID `json:"checked_in_by,omitempty"` // Host or staff member who checked them in
 	CreatedAt    string     `json:"created_at"`
 	UpdatedAt    string     `json:"updated_at"`
 }
 
+var ErrCheckInNotUndoable = errors.New("only an admitted check-in that hasn't been undone can be undone")
+
 type attendeeService struct {
 	attendeeRepo repository.AttendeeRepository
 	ticketRepo   repository.TicketRepository
+	checkInRepo  repository.CheckInEventRepository
+	uow          repository.UnitOfWork
+	signer       TicketSigner
+	entryDays    *time.Location
+	feed         CheckInFeed
 }
 
-func NewAttendeeService(attendeeRepo repository.AttendeeRepository, ticketRepo repository.TicketRepository) AttendeeService {
+// NewAttendeeService counts per-day entries by calendar days in entryDays,
+// and publishes every check-in it logs or undoes to feed.
+func NewAttendeeService(attendeeRepo repository.AttendeeRepository, ticketRepo repository.TicketRepository, checkInRepo repository.CheckInEventRepository, uow repository.UnitOfWork, signer TicketSigner, entryDays *time.Location, feed CheckInFeed) AttendeeService {
 	return &attendeeService{
 		attendeeRepo: attendeeRepo,
 		ticketRepo:   ticketRepo,
+		checkInRepo:  checkInRepo,
+		uow:          uow,
+		signer:       signer,
+		entryDays:    entryDays,
+		feed:         feed,
 	}
 }
 
@@ -117,6 +146,7 @@ func (s *attendeeService) transformAttendeesToResponse(attendees []models.Attend
 			Amount:       attendee.Ticket.TicketType.Price,
 			Status:       string(attendee.Status),
 			CheckInTime:  attendee.CheckedInAt,
+			CheckedInBy:  attendee.CheckedInBy,
 			CreatedAt:    attendee.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:    attendee.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
@@ -124,111 +154,163 @@ func (s *attendeeService) transformAttendeesToResponse(attendees []models.Attend
 	return responses
 }
 
-func (s *attendeeService) CheckInByQRCode(qrCode string, eventID, checkedInBy uuid.UUID) (*CheckInResult, error) {
-	// Find ticket by QR code
-	ticket, err := s.ticketRepo.GetByQRCode(qrCode)
+func (s *attendeeService) CheckInByQRCode(qrCode, gate string, eventID, checkedInBy uuid.UUID) (*CheckInResult, error) {
+	qrCode = strings.TrimSpace(qrCode)
+	checkIn := &models.CheckInEvent{
+		EventID:   eventID,
+		StaffID:   checkedInBy,
+		Source:    models.CheckInOnline,
+		Gate:      strings.TrimSpace(gate),
+		ScannedAt: time.Now(),
+		Outcome:   models.CheckInRejected,
+	}
+
+	// Check the signature before looking the ticket up, so forged codes never
+	// reach the tickets table. The rejected scan is still written to the
+	// check-in log; only the event's staff can scan, which bounds those writes.
+	qrClaims, err := s.signer.Verify(qrCode)
 	if err != nil {
-		return &CheckInResult{
-			Success:   false,
-			Message:   "Invalid QR Code - Ticket not found",
-			Timestamp: time.Now(),
-		}, nil
+		checkIn.Reason = "Invalid QR Code - This is not a genuine ticket"
+		return s.rejectCheckIn(checkIn)
 	}
+	checkIn.TicketID = &qrClaims.TicketID
 
 	// Verify ticket belongs to the correct event
-	if ticket.EventID != eventID {
-		return &CheckInResult{
-			Success:   false,
-			Message:   "Wrong event - This ticket is for a different event",
-			Timestamp: time.Now(),
-		}, nil
+	if qrClaims.EventID != eventID {
+		checkIn.Reason = "Wrong event - This ticket is for a different event"
+		return s.rejectCheckIn(checkIn)
 	}
 
-	// Find attendee by ticket ID
-	attendees, err := s.attendeeRepo.GetByEventID(eventID, 1000, 0) // Get all attendees for the event
-	if err != nil {
-		return nil, err
+	// Find ticket by QR code. A genuine code that's been replaced, e.g. by a
+	// transfer or re-issue, no longer matches its ticket.
+	ticket, err := s.ticketRepo.GetByQRCode(qrCode)
+	if err != nil || ticket.ID != qrClaims.TicketID {
+		checkIn.Reason = "Invalid QR Code - This code has been replaced or the ticket no longer exists"
+		return s.rejectCheckIn(checkIn)
 	}
 
-	var targetAttendee *models.Attendee
-	for _, attendee := range attendees {
-		if attendee.TicketID == ticket.ID {
-			targetAttendee = &attendee
-			break
+	var attendeeID uuid.UUID
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		// Locking the attendee serialises every scan of this ticket
+		attendee, err := repos.Attendees.GetByTicketIDForUpdate(ticket.ID)
+		if err != nil {
+			checkIn.Reason = "Attendee not found for this ticket"
+			return repos.CheckIns.Create(checkIn)
 		}
-	}
+		attendeeID = attendee.ID
 
-	if targetAttendee == nil {
-		return &CheckInResult{
-			Success:   false,
-			Message:   "Attendee not found for this ticket",
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	// Check if already checked in
-	if targetAttendee.Status == models.AttendeeCheckedIn {
-		return &CheckInResult{
-			Success: false,
-			Message: "Already checked in",
-			Attendee: &AttendeeResponse{
-				ID:          targetAttendee.ID,
-				Name:        targetAttendee.User.Name,
-				Email:       targetAttendee.User.Email,
-				EventTitle:  targetAttendee.Event.Title,
-				TicketType:  ticket.TicketType.Name,
-				Status:      string(targetAttendee.Status),
-				CheckInTime: targetAttendee.CheckedInAt,
-			},
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	// Check if cancelled
-	if targetAttendee.Status == models.AttendeeCancelled {
-		return &CheckInResult{
-			Success: false,
-			Message: "Ticket has been cancelled",
-			Attendee: &AttendeeResponse{
-				ID:         targetAttendee.ID,
-				Name:       targetAttendee.User.Name,
-				Email:      targetAttendee.User.Email,
-				EventTitle: targetAttendee.Event.Title,
-				TicketType: ticket.TicketType.Name,
-				Status:     string(targetAttendee.Status),
-			},
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	// Check in the attendee
-	err = s.attendeeRepo.CheckInAttendee(targetAttendee.ID, checkedInBy)
+		if attendee.Status == models.AttendeeCancelled {
+			checkIn.Reason = "Ticket has been cancelled"
+			return repos.CheckIns.Create(checkIn)
+		}
+		_, _, err = recordScan(repos, &ticket.TicketType, attendee, checkIn, s.entryDays)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.feed.Publish(*checkIn)
+
+	result := &CheckInResult{
+		Success:   checkIn.Outcome == models.CheckInAdmitted,
+		Message:   checkIn.Reason,
+		CheckInID: &checkIn.ID,
+		Timestamp: time.Now(),
+	}
+	if result.Success {
+		result.Message = "Successfully checked in!"
+	}
+	if attendeeID == uuid.Nil {
+		return result, nil
+	}
 
 	// Get updated attendee
-	updatedAttendee, err := s.attendeeRepo.GetByID(targetAttendee.ID)
+	updatedAttendee, err := s.attendeeRepo.GetByID(attendeeID)
 	if err != nil {
 		return nil, err
 	}
+	result.Attendee = &AttendeeResponse{
+		ID:          updatedAttendee.ID,
+		Name:        updatedAttendee.User.Name,
+		Email:       updatedAttendee.User.Email,
+		EventTitle:  updatedAttendee.Event.Title,
+		TicketType:  ticket.TicketType.Name,
+		Status:      string(updatedAttendee.Status),
+		CheckInTime: updatedAttendee.CheckedInAt,
+		CheckedInBy: updatedAttendee.CheckedInBy,
+	}
+	return result, nil
+}
 
+// rejectCheckIn logs a scan that was turned away before reaching its attendee.
+func (s *attendeeService) rejectCheckIn(checkIn *models.CheckInEvent) (*CheckInResult, error) {
+	if err := s.checkInRepo.Create(checkIn); err != nil {
+		return nil, err
+	}
+	s.feed.Publish(*checkIn)
 	return &CheckInResult{
-		Success: true,
-		Message: "Successfully checked in!",
-		Attendee: &AttendeeResponse{
-			ID:          updatedAttendee.ID,
-			Name:        updatedAttendee.User.Name,
-			Email:       updatedAttendee.User.Email,
-			EventTitle:  updatedAttendee.Event.Title,
-			TicketType:  ticket.TicketType.Name,
-			Status:      string(updatedAttendee.Status),
-			CheckInTime: updatedAttendee.CheckedInAt,
-		},
+		Success:   false,
+		Message:   checkIn.Reason,
+		CheckInID: &checkIn.ID,
 		Timestamp: time.Now(),
 	}, nil
 }
 
+func (s *attendeeService) GetCheckInLog(eventID uuid.UUID, ticketID *uuid.UUID, limit, offset int) ([]models.CheckInEvent, int64, error) {
+	return s.checkInRepo.GetByEventID(eventID, ticketID, limit, offset)
+}
+
+func (s *attendeeService) GetCheckIn(id uuid.UUID) (*models.CheckInEvent, error) {
+	return s.checkInRepo.GetByID(id)
+}
+
+func (s *attendeeService) UndoCheckIn(checkIn *models.CheckInEvent, undoneBy uuid.UUID, reason string) error {
+	if checkIn.UndoneAt != nil || checkIn.Outcome != models.CheckInAdmitted || checkIn.TicketID == nil {
+		return ErrCheckInNotUndoable
+	}
+
+	err := s.uow.Do(func(repos repository.TxRepositories) error {
+		attendee, err := repos.Attendees.GetByTicketIDForUpdate(*checkIn.TicketID)
+		if err != nil {
+			return fmt.Errorf("failed to load attendee: %w", err)
+		}
+		ticket, err := repos.Tickets.GetTicketByID(*checkIn.TicketID)
+		if err != nil {
+			return fmt.Errorf("failed to load ticket: %w", err)
+		}
+
+		// A sync may have recounted the ticket's entries since checkIn was loaded
+		current, err := repos.CheckIns.GetByID(checkIn.ID)
+		if err != nil {
+			return err
+		}
+		if current.Outcome != models.CheckInAdmitted {
+			return ErrCheckInNotUndoable
+		}
+		undone, err := repos.CheckIns.MarkUndone(checkIn.ID, undoneBy, reason)
+		if err != nil {
+			return err
+		}
+		if !undone {
+			return ErrCheckInNotUndoable
+		}
+		// A later duplicate may now count as the entry instead
+		_, _, err = countEntries(repos, &ticket.TicketType, attendee, s.entryDays)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	checkIn.UndoneAt = &now
+	checkIn.UndoneBy = &undoneBy
+	checkIn.UndoReason = reason
+	s.feed.Publish(*checkIn)
+	log.Printf("↩️ CHECK-IN UNDONE: %s for ticket %s by %s", checkIn.ID, *checkIn.TicketID, undoneBy)
+	return nil
+}
+
 func (s *attendeeService) GetEventAttendeeStats(eventID uuid.UUID) (map[string]int64, error) {
 	return s.attendeeRepo.GetEventAttendeeStats(eventID)
 }
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+// BoxOfficeGateway marks payments taken at an event's door rather than
+// through a payment gateway.
+const BoxOfficeGateway = "box_office"
+
+// maxBoxOfficeQuantity is the most tickets one box office sale can issue.
+const maxBoxOfficeQuantity = 20
+
+type BoxOfficeService interface {
+	// Sell issues tickets sold for cash or POS, or given away, at event's
+	// door by issuerID. The sale counts against the ticket type's inventory
+	// but not its sale window or per-person limits. With CheckIn set the
+	// tickets are checked in as part of the sale.
+	Sell(event *models.Event, issuerID uuid.UUID, req *models.BoxOfficeSaleRequest) (*BoxOfficeSale, error)
+}
+
+// BoxOfficeSale is a box office payment and what it issued.
+type BoxOfficeSale struct {
+	Payment  *models.Payment       `json:"payment"`
+	Tickets  []*models.Ticket      `json:"tickets"`
+	CheckIns []models.CheckInEvent `json:"check_ins,omitempty"`
+}
+
+var (
+	ErrBoxOfficeInvalid = errors.New("invalid box office sale")
+	ErrBoxOfficeClosed  = errors.New("event is not open for sales")
+)
+
+type boxOfficeService struct {
+	ticketRepo   repository.TicketRepository
+	userRepo     repository.UserRepository
+	uow          repository.UnitOfWork
+	signer       TicketSigner
+	emailService EmailService
+	entryDays    *time.Location
+	feed         CheckInFeed
+}
+
+func NewBoxOfficeService(ticketRepo repository.TicketRepository, userRepo repository.UserRepository, uow repository.UnitOfWork, signer TicketSigner, emailService EmailService, entryDays *time.Location, feed CheckInFeed) BoxOfficeService {
+	return &boxOfficeService{
+		ticketRepo:   ticketRepo,
+		userRepo:     userRepo,
+		uow:          uow,
+		signer:       signer,
+		emailService: emailService,
+		entryDays:    entryDays,
+		feed:         feed,
+	}
+}
+
+func (s *boxOfficeService) Sell(event *models.Event, issuerID uuid.UUID, req *models.BoxOfficeSaleRequest) (*BoxOfficeSale, error) {
+	method := models.PaymentMethod(strings.ToLower(strings.TrimSpace(req.PaymentMethod)))
+	if method != models.Cash && method != models.POS && method != models.Comp {
+		return nil, fmt.Errorf("%w: payment method must be cash, pos or comp", ErrBoxOfficeInvalid)
+	}
+	if req.Quantity < 1 || req.Quantity > maxBoxOfficeQuantity {
+		return nil, fmt.Errorf("%w: quantity must be between 1 and %d", ErrBoxOfficeInvalid, maxBoxOfficeQuantity)
+	}
+	fullName := strings.TrimSpace(req.Attendee.FullName)
+	if fullName == "" {
+		return nil, fmt.Errorf("%w: attendee name is required", ErrBoxOfficeInvalid)
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Attendee.Email))
+	phone := strings.TrimSpace(req.Attendee.Phone)
+
+	if event.Status != models.ActiveEvent {
+		return nil, ErrBoxOfficeClosed
+	}
+
+	ticketTypeID, err := uuid.Parse(req.TicketTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ticket type ID", ErrBoxOfficeInvalid)
+	}
+	ticketType, err := s.ticketRepo.GetTicketTypeByID(ticketTypeID)
+	if err != nil || ticketType.EventID != event.ID {
+		return nil, fmt.Errorf("%w: ticket type not found for this event", ErrBoxOfficeInvalid)
+	}
+
+	// Tickets go to the attendee's account if they have one; otherwise the
+	// host holds them, and can transfer them on later
+	ownerID := event.HostID
+	recipient := &models.User{Name: fullName, Email: email}
+	if email != "" {
+		user, err := attendeeAccount(s.userRepo, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up attendee account: %w", err)
+		}
+		if user != nil {
+			ownerID = user.ID
+			recipient = user
+		}
+	}
+
+	now := time.Now()
+	payment := &models.Payment{
+		EventID:     event.ID,
+		UserID:      ownerID,
+		Currency:    event.Currency,
+		Status:      models.PaymentCompleted,
+		Method:      method,
+		Reference:   fmt.Sprintf("BOX-%s-%s-%d", event.ID.String()[:8], issuerID.String()[:8], now.UnixNano()),
+		ProcessedAt: &now,
+		Gateway:     BoxOfficeGateway,
+		IssuedBy:    &issuerID,
+	}
+	sale := &BoxOfficeSale{Payment: payment}
+
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		ticketType, err := checkCapacity(repos, uuid.Nil, ticketTypeID, req.Quantity)
+		if err != nil {
+			return err
+		}
+
+		// Sold at the price the ticket type has now; comps are free
+		unitPrice := ticketType.Price
+		if method == models.Comp {
+			unitPrice = 0
+		}
+		subtotal := math.Round(unitPrice*float64(req.Quantity)*100) / 100
+		payment.Amount = subtotal
+		payment.LineItems = []models.PaymentLineItem{{
+			TicketTypeID:   ticketType.ID,
+			TicketTypeName: ticketType.Name,
+			UnitPrice:      unitPrice,
+			Quantity:       req.Quantity,
+			Subtotal:       subtotal,
+		}}
+		if err := repos.Payments.CreatePayment(payment); err != nil {
+			return fmt.Errorf("failed to record box office payment: %w", err)
+		}
+
+		payment.Event = *event
+		if err := postBoxOfficeSale(repos.Ledger, payment, now); err != nil {
+			return err
+		}
+
+		for i := 0; i < req.Quantity; i++ {
+			ticket := &models.Ticket{
+				EventID:          event.ID,
+				UserID:           ownerID,
+				TicketTypeID:     ticketType.ID,
+				PaymentReference: payment.Reference,
+				AttendeeFullName: fullName,
+				AttendeeEmail:    email,
+				AttendeePhone:    phone,
+				Quantity:         1,
+			}
+			if err := issueTicket(repos.Tickets, repos.Attendees, s.signer, ticket); err != nil {
+				return fmt.Errorf("failed to issue ticket %d: %w", i+1, err)
+			}
+			sale.Tickets = append(sale.Tickets, ticket)
+
+			if !req.CheckIn {
+				continue
+			}
+			attendee, err := repos.Attendees.GetByTicketIDForUpdate(ticket.ID)
+			if err != nil {
+				return fmt.Errorf("failed to load attendee for ticket %d: %w", i+1, err)
+			}
+			ticketID := ticket.ID
+			checkIn := &models.CheckInEvent{
+				EventID:   event.ID,
+				TicketID:  &ticketID,
+				StaffID:   issuerID,
+				Source:    models.CheckInOnline,
+				Gate:      strings.TrimSpace(req.Gate),
+				ScannedAt: now,
+			}
+			if _, _, err := recordScan(repos, ticketType, attendee, checkIn, s.entryDays); err != nil {
+				return err
+			}
+			sale.CheckIns = append(sale.CheckIns, *checkIn)
+		}
+
+		return repos.Tickets.UpdateSoldQuantity(ticketType.ID, req.Quantity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🎟️ BOX OFFICE: %s issued %d %s (%s, %.2f %s) for event %s",
+		issuerID, req.Quantity, ticketType.Name, method, payment.Amount, payment.Currency, event.ID)
+
+	for _, checkIn := range sale.CheckIns {
+		s.feed.Publish(checkIn)
+	}
+
+	if email != "" {
+		for _, ticket := range sale.Tickets {
+			if err := s.emailService.SendTicketConfirmation(ticket, event, recipient); err != nil {
+				log.Printf("❌ EMAIL ERROR: Failed to send box office ticket %s to %s: %v", ticket.ID, email, err)
+			}
+		}
+	}
+
+	return sale, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type CheckInDashboardService interface {
+	// GetDashboard totals up an event's check-ins so far.
+	GetDashboard(eventID uuid.UUID) (*CheckInDashboard, error)
+	// Watch subscribes to an event's check-ins as they're logged. Call stop
+	// once done watching.
+	Watch(eventID uuid.UUID) (checkIns <-chan models.CheckInEvent, stop func())
+}
+
+// CheckInDashboard is an event's arrivals so far.
+type CheckInDashboard struct {
+	EventID     uuid.UUID                   `json:"event_id"`
+	GeneratedAt time.Time                   `json:"generated_at"`
+	Attendees   int64                       `json:"attendees"` // Not cancelled
+	CheckedIn   int64                       `json:"checked_in"`
+	TicketTypes []models.TicketTypeCheckIns `json:"ticket_types"`
+	// Arrivals counts entries, re-entries included, every ArrivalInterval
+	Arrivals        []models.ArrivalBucket `json:"arrivals"`
+	ArrivalInterval int                    `json:"arrival_interval_minutes"`
+	Gates           []models.GateCheckIns  `json:"gates"`
+}
+
+// arrivalInterval is how finely the dashboard buckets arrivals.
+const arrivalInterval = 5 * time.Minute
+
+type checkInDashboardService struct {
+	checkInRepo repository.CheckInEventRepository
+	feed        CheckInFeed
+}
+
+func NewCheckInDashboardService(checkInRepo repository.CheckInEventRepository, feed CheckInFeed) CheckInDashboardService {
+	return &checkInDashboardService{
+		checkInRepo: checkInRepo,
+		feed:        feed,
+	}
+}
+
+func (s *checkInDashboardService) GetDashboard(eventID uuid.UUID) (*CheckInDashboard, error) {
+	ticketTypes, err := s.checkInRepo.GetTicketTypeCheckIns(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count check-ins by ticket type: %w", err)
+	}
+	arrivals, err := s.checkInRepo.GetArrivals(eventID, arrivalInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count arrivals: %w", err)
+	}
+	gates, err := s.checkInRepo.GetGateCheckIns(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count check-ins by gate: %w", err)
+	}
+
+	dashboard := &CheckInDashboard{
+		EventID:         eventID,
+		GeneratedAt:     time.Now().UTC(),
+		TicketTypes:     ticketTypes,
+		Arrivals:        arrivals,
+		ArrivalInterval: int(arrivalInterval / time.Minute),
+		Gates:           gates,
+	}
+	for _, ticketType := range ticketTypes {
+		dashboard.Attendees += ticketType.Attendees
+		dashboard.CheckedIn += ticketType.CheckedIn
+	}
+	return dashboard, nil
+}
+
+func (s *checkInDashboardService) Watch(eventID uuid.UUID) (<-chan models.CheckInEvent, func()) {
+	return s.feed.Subscribe(eventID)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+// entryWindow is what a ticket's entries are counted against: the calendar
+// day in loc for per-day tickets, and the whole event for the rest.
+func entryWindow(ticketType *models.TicketType, scannedAt time.Time, loc *time.Location) string {
+	if !ticketType.EntryPerDay {
+		return ""
+	}
+	return scannedAt.In(loc).Format("2006-01-02")
+}
+
+// backfillLegacyCheckIn logs an attendee's check-in from before the check-in
+// log existed, so it keeps counting as an entry.
+func backfillLegacyCheckIn(repos repository.TxRepositories, attendee *models.Attendee) error {
+	if attendee.Status != models.AttendeeCheckedIn || attendee.CheckedInAt == nil {
+		return nil
+	}
+	logged, err := repos.CheckIns.CountEntryScans(attendee.TicketID)
+	if err != nil || logged > 0 {
+		return err
+	}
+
+	ticketID := attendee.TicketID
+	checkIn := &models.CheckInEvent{
+		EventID:   attendee.EventID,
+		TicketID:  &ticketID,
+		Source:    models.CheckInLegacy,
+		ScannedAt: *attendee.CheckedInAt,
+		Outcome:   models.CheckInAdmitted,
+	}
+	if attendee.CheckedInBy != nil {
+		checkIn.StaffID = *attendee.CheckedInBy
+	}
+	return repos.CheckIns.Create(checkIn)
+}
+
+// countEntries goes through a ticket's scans in the order they were made and
+// admits each while its ticket type has entries left in that scan's window;
+// the rest are duplicates. It saves any outcome that changed, checks the
+// attendee in as of their latest entry, or back out if they have none, and
+// returns the scans along with any that were entries and no longer are.
+func countEntries(repos repository.TxRepositories, ticketType *models.TicketType, attendee *models.Attendee, loc *time.Location) ([]models.CheckInEvent, []models.CheckInEvent, error) {
+	checkIns, err := repos.CheckIns.GetCountable(attendee.TicketID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load check-ins: %w", err)
+	}
+
+	used := make(map[string]int)
+	lastEntry := make(map[string]*models.CheckInEvent)
+	var latest *models.CheckInEvent
+	var superseded []models.CheckInEvent
+	for i := range checkIns {
+		checkIn := &checkIns[i]
+		window := entryWindow(ticketType, checkIn.ScannedAt, loc)
+
+		outcome, reason := models.CheckInAdmitted, ""
+		if ticketType.EntryLimit > 0 && used[window] >= ticketType.EntryLimit {
+			outcome = models.CheckInDuplicate
+			switch {
+			case ticketType.EntryLimit > 1:
+				reason = fmt.Sprintf("All %d entries used", ticketType.EntryLimit)
+			default:
+				reason = fmt.Sprintf("Already checked in at %s", lastEntry[window].ScannedAt.Format(time.RFC3339))
+			}
+			if ticketType.EntryPerDay {
+				reason += " for the day"
+			}
+		} else {
+			used[window]++
+			lastEntry[window] = checkIn
+			latest = checkIn
+		}
+
+		if outcome != checkIn.Outcome || reason != checkIn.Reason {
+			if err := repos.CheckIns.SetOutcome(checkIn.ID, outcome, reason); err != nil {
+				return nil, nil, fmt.Errorf("failed to update check-in: %w", err)
+			}
+			if checkIn.Outcome == models.CheckInAdmitted && outcome == models.CheckInDuplicate {
+				superseded = append(superseded, *checkIn)
+			}
+			checkIn.Outcome, checkIn.Reason = outcome, reason
+		}
+	}
+
+	switch {
+	case attendee.Status == models.AttendeeCancelled:
+	case latest == nil:
+		if attendee.Status == models.AttendeeCheckedIn {
+			if err := repos.Attendees.ClearCheckIn(attendee.ID); err != nil {
+				return nil, nil, fmt.Errorf("failed to clear check-in: %w", err)
+			}
+		}
+	case attendee.Status != models.AttendeeCheckedIn || attendee.CheckedInAt == nil || !attendee.CheckedInAt.Equal(latest.ScannedAt):
+		if err := repos.Attendees.CheckInAttendeeAt(attendee.ID, latest.StaffID, latest.ScannedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to check attendee in: %w", err)
+		}
+	}
+	return checkIns, superseded, nil
+}
+
+// recordScan logs a scan of a ticket that hasn't been cancelled and works out
+// whether it gets in, setting its outcome. Call it in a unit of work holding
+// the attendee's row lock. Like countEntries, it returns the ticket's
+// countable scans and any entries the scan displaced.
+func recordScan(repos repository.TxRepositories, ticketType *models.TicketType, attendee *models.Attendee, checkIn *models.CheckInEvent, loc *time.Location) ([]models.CheckInEvent, []models.CheckInEvent, error) {
+	if err := backfillLegacyCheckIn(repos, attendee); err != nil {
+		return nil, nil, fmt.Errorf("failed to log earlier check-in: %w", err)
+	}
+
+	checkIn.Outcome = models.CheckInAdmitted
+	if err := repos.CheckIns.Create(checkIn); err != nil {
+		return nil, nil, fmt.Errorf("failed to log check-in: %w", err)
+	}
+	checkIns, superseded, err := countEntries(repos, ticketType, attendee, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, counted := range checkIns {
+		if counted.ID == checkIn.ID {
+			checkIn.Outcome, checkIn.Reason = counted.Outcome, counted.Reason
+		}
+	}
+	return checkIns, superseded, nil
+}
+
+// lastEntryBefore returns the latest of checkIns admitted in the same window
+// as checkIn and no later than it, if any.
+func lastEntryBefore(checkIns []models.CheckInEvent, checkIn *models.CheckInEvent, ticketType *models.TicketType, loc *time.Location) *models.CheckInEvent {
+	window := entryWindow(ticketType, checkIn.ScannedAt, loc)
+	var last *models.CheckInEvent
+	for i := range checkIns {
+		counted := &checkIns[i]
+		if counted.ID == checkIn.ID || counted.ScannedAt.After(checkIn.ScannedAt) {
+			continue
+		}
+		if counted.Outcome == models.CheckInAdmitted && entryWindow(ticketType, counted.ScannedAt, loc) == window {
+			last = counted
+		}
+	}
+	return last
+}
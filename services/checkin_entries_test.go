@@ -0,0 +1,202 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+// fakeCheckIns serves a ticket's countable scans and records outcome changes.
+type fakeCheckIns struct {
+	repository.CheckInEventRepository
+	scans    []models.CheckInEvent
+	outcomes map[uuid.UUID]models.CheckInOutcome
+}
+
+func (f *fakeCheckIns) GetCountable(ticketID uuid.UUID) ([]models.CheckInEvent, error) {
+	return append([]models.CheckInEvent(nil), f.scans...), nil
+}
+
+func (f *fakeCheckIns) SetOutcome(id uuid.UUID, outcome models.CheckInOutcome, reason string) error {
+	f.outcomes[id] = outcome
+	return nil
+}
+
+// fakeAttendees records check-ins made and cleared.
+type fakeAttendees struct {
+	repository.AttendeeRepository
+	checkedInAt *time.Time
+	cleared     bool
+}
+
+func (f *fakeAttendees) CheckInAttendeeAt(attendeeID, checkedInBy uuid.UUID, checkedInAt time.Time) error {
+	f.checkedInAt = &checkedInAt
+	return nil
+}
+
+func (f *fakeAttendees) ClearCheckIn(attendeeID uuid.UUID) error {
+	f.cleared = true
+	return nil
+}
+
+func TestEntryWindow(t *testing.T) {
+	lagos := time.FixedZone("WAT", 60*60)
+	lateNight := time.Date(2026, 6, 1, 23, 30, 0, 0, time.UTC) // Already 2 June in Lagos
+
+	tests := []struct {
+		name       string
+		ticketType models.TicketType
+		want       string
+	}{
+		{name: "whole event", ticketType: models.TicketType{EntryLimit: 1}, want: ""},
+		{name: "per day, in the event's time zone", ticketType: models.TicketType{EntryLimit: 1, EntryPerDay: true}, want: "2026-06-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entryWindow(&tt.ticketType, lateNight, lagos); got != tt.want {
+				t.Fatalf("entryWindow() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountEntries(t *testing.T) {
+	lagos := time.FixedZone("WAT", 60*60)
+	day1 := time.Date(2026, 6, 1, 18, 0, 0, 0, lagos)
+	day2 := day1.AddDate(0, 0, 1)
+	admitted, duplicate := models.CheckInAdmitted, models.CheckInDuplicate
+
+	type scan struct {
+		at      time.Time
+		outcome models.CheckInOutcome // As saved before counting
+	}
+	tests := []struct {
+		name           string
+		ticketType     models.TicketType
+		status         models.AttendeeStatus
+		scans          []scan
+		want           []models.CheckInOutcome
+		wantReasons    []string
+		wantSuperseded int
+		wantCheckedIn  *time.Time // Check-in time set on the attendee, if any
+		wantCleared    bool
+	}{
+		{
+			name:          "single entry, scanned twice",
+			ticketType:    models.TicketType{EntryLimit: 1},
+			status:        models.AttendeeActive,
+			scans:         []scan{{day1, admitted}, {day1.Add(time.Minute), duplicate}},
+			want:          []models.CheckInOutcome{admitted, duplicate},
+			wantReasons:   []string{"", "Already checked in at " + day1.Format(time.RFC3339)},
+			wantCheckedIn: &day1,
+		},
+		{
+			name:          "multiple entries run out",
+			ticketType:    models.TicketType{EntryLimit: 2},
+			status:        models.AttendeeActive,
+			scans:         []scan{{day1, admitted}, {day1.Add(time.Hour), admitted}, {day1.Add(2 * time.Hour), duplicate}},
+			want:          []models.CheckInOutcome{admitted, admitted, duplicate},
+			wantReasons:   []string{"", "", "All 2 entries used"},
+			wantCheckedIn: ptrTime(day1.Add(time.Hour)),
+		},
+		{
+			name:          "per-day entries reset each day",
+			ticketType:    models.TicketType{EntryLimit: 1, EntryPerDay: true},
+			status:        models.AttendeeActive,
+			scans:         []scan{{day1, admitted}, {day1.Add(time.Hour), duplicate}, {day2, admitted}},
+			want:          []models.CheckInOutcome{admitted, duplicate, admitted},
+			wantReasons:   []string{"", "Already checked in at " + day1.Format(time.RFC3339) + " for the day", ""},
+			wantCheckedIn: &day2,
+		},
+		{
+			name:          "no entry limit",
+			ticketType:    models.TicketType{EntryLimit: 0},
+			status:        models.AttendeeActive,
+			scans:         []scan{{day1, admitted}, {day1.Add(time.Minute), duplicate}},
+			want:          []models.CheckInOutcome{admitted, admitted},
+			wantReasons:   []string{"", ""},
+			wantCheckedIn: ptrTime(day1.Add(time.Minute)),
+		},
+		{
+			name:           "earlier offline scan displaces an online entry",
+			ticketType:     models.TicketType{EntryLimit: 1},
+			status:         models.AttendeeCheckedIn,
+			scans:          []scan{{day1, admitted}, {day1.Add(time.Minute), admitted}},
+			want:           []models.CheckInOutcome{admitted, duplicate},
+			wantReasons:    []string{"", "Already checked in at " + day1.Format(time.RFC3339)},
+			wantSuperseded: 1,
+			wantCheckedIn:  &day1,
+		},
+		{
+			name:        "no entries left checks the attendee back out",
+			ticketType:  models.TicketType{EntryLimit: 1},
+			status:      models.AttendeeCheckedIn,
+			want:        []models.CheckInOutcome{},
+			wantReasons: []string{},
+			wantCleared: true,
+		},
+		{
+			name:        "cancelled attendees are left alone",
+			ticketType:  models.TicketType{EntryLimit: 1},
+			status:      models.AttendeeCancelled,
+			scans:       []scan{{day1, admitted}},
+			want:        []models.CheckInOutcome{admitted},
+			wantReasons: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkIns := &fakeCheckIns{outcomes: make(map[uuid.UUID]models.CheckInOutcome)}
+			for _, s := range tt.scans {
+				checkIns.scans = append(checkIns.scans, models.CheckInEvent{ID: uuid.New(), ScannedAt: s.at, Outcome: s.outcome})
+			}
+			attendees := &fakeAttendees{}
+			repos := repository.TxRepositories{CheckIns: checkIns, Attendees: attendees}
+			attendee := &models.Attendee{ID: uuid.New(), TicketID: uuid.New(), Status: tt.status}
+			if tt.status == models.AttendeeCheckedIn {
+				// Checked in as of the last scan, as an online scan would have left it
+				checkedInAt := day1.Add(time.Minute)
+				attendee.CheckedInAt = &checkedInAt
+			}
+
+			counted, superseded, err := countEntries(repos, &tt.ticketType, attendee, lagos)
+			if err != nil {
+				t.Fatalf("countEntries() error = %v", err)
+			}
+			if len(counted) != len(tt.want) {
+				t.Fatalf("countEntries() returned %d scans, want %d", len(counted), len(tt.want))
+			}
+			for i, checkIn := range counted {
+				if checkIn.Outcome != tt.want[i] || checkIn.Reason != tt.wantReasons[i] {
+					t.Errorf("scan %d = %s %q, want %s %q", i, checkIn.Outcome, checkIn.Reason, tt.want[i], tt.wantReasons[i])
+				}
+				if saved, ok := checkIns.outcomes[checkIn.ID]; ok && saved != checkIn.Outcome {
+					t.Errorf("scan %d saved as %s, returned as %s", i, saved, checkIn.Outcome)
+				}
+			}
+			if len(superseded) != tt.wantSuperseded {
+				t.Errorf("superseded %d entries, want %d", len(superseded), tt.wantSuperseded)
+			}
+			switch {
+			case tt.wantCheckedIn == nil && attendees.checkedInAt != nil:
+				t.Errorf("attendee checked in at %s, want no change", attendees.checkedInAt)
+			case tt.wantCheckedIn != nil && attendee.CheckedInAt != nil && attendee.CheckedInAt.Equal(*tt.wantCheckedIn):
+				// Already checked in as of the latest entry; nothing to save
+			case tt.wantCheckedIn != nil && (attendees.checkedInAt == nil || !attendees.checkedInAt.Equal(*tt.wantCheckedIn)):
+				t.Errorf("attendee checked in at %v, want %s", attendees.checkedInAt, tt.wantCheckedIn)
+			}
+			if attendees.cleared != tt.wantCleared {
+				t.Errorf("check-in cleared = %v, want %v", attendees.cleared, tt.wantCleared)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+// CheckInFeed fans logged check-ins out to whoever is watching their event,
+// e.g. live dashboards. Delivery is best effort: a subscriber that falls
+// behind misses check-ins rather than holding up the door.
+//
+// The in-process feed only reaches subscribers on the same instance. A
+// multi-instance deployment can put Postgres LISTEN/NOTIFY behind this
+// interface, publishing with NOTIFY and handing what each instance LISTENs
+// for to its local subscribers.
+type CheckInFeed interface {
+	// Publish sends a check-in to its event's subscribers. Call it once the
+	// check-in has been committed.
+	Publish(checkIn models.CheckInEvent)
+	// Subscribe returns a channel of an event's check-ins from now on, and a
+	// func to call once done with it, which closes the channel.
+	Subscribe(eventID uuid.UUID) (<-chan models.CheckInEvent, func())
+}
+
+// checkInFeedBuffer is how many check-ins a subscriber can fall behind by
+// before it starts missing them.
+const checkInFeedBuffer = 64
+
+type memoryCheckInFeed struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan models.CheckInEvent]struct{}
+}
+
+// NewMemoryCheckInFeed returns a feed that reaches subscribers in this process.
+func NewMemoryCheckInFeed() CheckInFeed {
+	return &memoryCheckInFeed{
+		subscribers: make(map[uuid.UUID]map[chan models.CheckInEvent]struct{}),
+	}
+}
+
+func (f *memoryCheckInFeed) Publish(checkIn models.CheckInEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers[checkIn.EventID] {
+		select {
+		case ch <- checkIn:
+		default:
+			// Subscriber is behind; it misses this one
+		}
+	}
+}
+
+func (f *memoryCheckInFeed) Subscribe(eventID uuid.UUID) (<-chan models.CheckInEvent, func()) {
+	ch := make(chan models.CheckInEvent, checkInFeedBuffer)
+
+	f.mu.Lock()
+	if f.subscribers[eventID] == nil {
+		f.subscribers[eventID] = make(map[chan models.CheckInEvent]struct{})
+	}
+	f.subscribers[eventID][ch] = struct{}{}
+	f.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.subscribers[eventID], ch)
+			if len(f.subscribers[eventID]) == 0 {
+				delete(f.subscribers, eventID)
+			}
+			close(ch)
+		})
+	}
+}
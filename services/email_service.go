@@ -2,13 +2,16 @@ package services
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"html/template"
 	"log"
 	"net/smtp"
 	"os"
+	"time"
 
 	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/skip2/go-qrcode"
 )
 
 // Helper function for safe string truncation
@@ -24,6 +27,11 @@ type EmailService interface {
 	SendHostNotification(ticket *models.Ticket, event *models.Event, user *models.User, host *models.User) error
 	SendPasswordResetEmail(user *models.User, resetToken string) error
 	SendWelcomeEmail(user *models.User) error
+	SendRefundNotification(refund *models.Refund, payment *models.Payment, event *models.Event, user *models.User) error
+	SendPaymentReceipt(payment *models.Payment, event *models.Event, user *models.User) error
+	SendWaitlistOffer(entry *models.WaitlistEntry, event *models.Event, user *models.User) error
+	SendTicketTransferInvite(transfer *models.TicketTransfer, ticket *models.Ticket, event *models.Event, from *models.User) error
+	SendStaffInvite(staff *models.EventStaff, event *models.Event, inviter *models.User) error
 }
 
 type ZohoEmailService struct {
@@ -146,6 +154,121 @@ func (e *ZohoEmailService) SendWelcomeEmail(user *models.User) error {
 	return nil
 }
 
+func (e *ZohoEmailService) SendRefundNotification(refund *models.Refund, payment *models.Payment, event *models.Event, user *models.User) error {
+	log.Printf("=== SENDING REFUND NOTIFICATION EMAIL ===")
+	log.Printf("User: %s (%s)", user.Name, user.Email)
+	log.Printf("Refund: %.2f %s on payment %s", refund.Amount, refund.Currency, payment.Reference)
+
+	subject := fmt.Sprintf("Your Refund for %s", event.Title)
+
+	htmlContent, _, err := e.generateRefundNotificationContent(refund, payment, event, user)
+	if err != nil {
+		log.Printf("❌ Failed to generate refund notification content: %v", err)
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	err = e.sendEmail(user.Email, subject, htmlContent)
+	if err != nil {
+		log.Printf("❌ Refund notification failed: %v", err)
+		return err
+	}
+	log.Printf("✅ REFUND NOTIFICATION EMAIL SENT SUCCESSFULLY!")
+	log.Printf("==============================")
+	return nil
+}
+
+func (e *ZohoEmailService) SendPaymentReceipt(payment *models.Payment, event *models.Event, user *models.User) error {
+	log.Printf("=== SENDING PAYMENT RECEIPT EMAIL ===")
+	log.Printf("User: %s (%s)", user.Name, user.Email)
+	log.Printf("Payment: %.2f %s, reference %s", payment.Amount, payment.Currency, payment.Reference)
+
+	subject := fmt.Sprintf("Your Receipt for %s", event.Title)
+
+	htmlContent, _, err := e.generatePaymentReceiptContent(payment, event, user)
+	if err != nil {
+		log.Printf("❌ Failed to generate payment receipt content: %v", err)
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	err = e.sendEmail(user.Email, subject, htmlContent)
+	if err != nil {
+		log.Printf("❌ Payment receipt failed: %v", err)
+		return err
+	}
+	log.Printf("✅ PAYMENT RECEIPT EMAIL SENT SUCCESSFULLY!")
+	log.Printf("==============================")
+	return nil
+}
+
+func (e *ZohoEmailService) SendWaitlistOffer(entry *models.WaitlistEntry, event *models.Event, user *models.User) error {
+	log.Printf("=== SENDING WAITLIST OFFER EMAIL ===")
+	log.Printf("User: %s (%s)", user.Name, user.Email)
+	log.Printf("Offer: %d %s until %s", entry.OfferedQuantity, entry.TicketType.Name, entry.OfferExpiresAt.Format(time.RFC3339))
+
+	subject := fmt.Sprintf("Tickets Are Available for %s", event.Title)
+
+	htmlContent, _, err := e.generateWaitlistOfferContent(entry, event, user)
+	if err != nil {
+		log.Printf("❌ Failed to generate waitlist offer content: %v", err)
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	err = e.sendEmail(user.Email, subject, htmlContent)
+	if err != nil {
+		log.Printf("❌ Waitlist offer failed: %v", err)
+		return err
+	}
+	log.Printf("✅ WAITLIST OFFER EMAIL SENT SUCCESSFULLY!")
+	log.Printf("==============================")
+	return nil
+}
+
+func (e *ZohoEmailService) SendTicketTransferInvite(transfer *models.TicketTransfer, ticket *models.Ticket, event *models.Event, from *models.User) error {
+	log.Printf("=== SENDING TICKET TRANSFER INVITE EMAIL ===")
+	log.Printf("To: %s, from %s (%s)", transfer.ToEmail, from.Name, from.Email)
+	log.Printf("Ticket: %s for %s", ticket.ID, event.Title)
+
+	subject := fmt.Sprintf("%s Sent You a Ticket to %s", from.Name, event.Title)
+
+	htmlContent, _, err := e.generateTicketTransferInviteContent(transfer, ticket, event, from)
+	if err != nil {
+		log.Printf("❌ Failed to generate ticket transfer invite content: %v", err)
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	err = e.sendEmail(transfer.ToEmail, subject, htmlContent)
+	if err != nil {
+		log.Printf("❌ Ticket transfer invite failed: %v", err)
+		return err
+	}
+	log.Printf("✅ TICKET TRANSFER INVITE EMAIL SENT SUCCESSFULLY!")
+	log.Printf("==============================")
+	return nil
+}
+
+func (e *ZohoEmailService) SendStaffInvite(staff *models.EventStaff, event *models.Event, inviter *models.User) error {
+	log.Printf("=== SENDING STAFF INVITE EMAIL ===")
+	log.Printf("To: %s as %s, from %s (%s)", staff.Email, staff.Role, inviter.Name, inviter.Email)
+	log.Printf("Event: %s", event.Title)
+
+	subject := fmt.Sprintf("You're Invited to Work %s", event.Title)
+
+	htmlContent, _, err := e.generateStaffInviteContent(staff, event, inviter)
+	if err != nil {
+		log.Printf("❌ Failed to generate staff invite content: %v", err)
+		return fmt.Errorf("failed to generate email content: %w", err)
+	}
+
+	err = e.sendEmail(staff.Email, subject, htmlContent)
+	if err != nil {
+		log.Printf("❌ Staff invite failed: %v", err)
+		return err
+	}
+	log.Printf("✅ STAFF INVITE EMAIL SENT SUCCESSFULLY!")
+	log.Printf("==============================")
+	return nil
+}
+
 func (e *ZohoEmailService) sendEmail(to, subject, body string) error {
 	log.Printf("=== ZOHO SMTP EMAIL SENDING ===")
 	log.Printf("To: %s", to)
@@ -200,7 +323,7 @@ func (e *ZohoEmailService) generateTicketConfirmationContent(ticket *models.Tick
             <p>Your ticket for {{.Event.Title}} has been confirmed</p>
         </div>
         
-        <h2>Hi {{.Ticket.AttendeeFullName}},</h2>
+        <h2>Hi {{if .Ticket.AttendeeFullName}}{{.Ticket.AttendeeFullName}}{{else}}{{.User.Name}}{{end}},</h2>
         <p>Thank you for your purchase! Here are your ticket details:</p>
         
         <div class="ticket-info">
@@ -223,7 +346,11 @@ func (e *ZohoEmailService) generateTicketConfirmationContent(ticket *models.Tick
         <div class="ticket-info">
             <h3>🎫 Ticket Information</h3>
             <p><strong>Ticket ID:</strong> {{.Ticket.ID}}</p>
+            {{if .Ticket.AttendeeFullName}}
             <p><strong>Attendee:</strong> {{.Ticket.AttendeeFullName}}</p>
+            {{else}}
+            <p><strong>Attendee:</strong> Not named yet. Add who this ticket is for in My Tickets and we'll send it to them.</p>
+            {{end}}
             <p><strong>Email:</strong> {{.Ticket.AttendeeEmail}}</p>
             {{if .Ticket.AttendeePhone}}
             <p><strong>Phone:</strong> {{.Ticket.AttendeePhone}}</p>
@@ -233,11 +360,11 @@ func (e *ZohoEmailService) generateTicketConfirmationContent(ticket *models.Tick
             {{end}}
         </div>
         
-        {{if .Ticket.QRCodeData}}
+        {{if .QRCodeData}}
         <div class="qr-code">
             <h3>📱 Your QR Code</h3>
             <p>Show this QR code at the event entrance:</p>
-            <img src="data:image/png;base64,{{.Ticket.QRCodeData}}" alt="QR Code" style="max-width: 200px;">
+            <img src="data:image/png;base64,{{.QRCodeData}}" alt="QR Code" style="max-width: 200px;">
         </div>
         {{end}}
         
@@ -257,7 +384,7 @@ func (e *ZohoEmailService) generateTicketConfirmationContent(ticket *models.Tick
 	textTemplate := `
 Ticket Confirmation - {{.Event.Title}}
 
-Hi {{.Ticket.AttendeeFullName}},
+Hi {{if .Ticket.AttendeeFullName}}{{.Ticket.AttendeeFullName}}{{else}}{{.User.Name}}{{end}},
 
 Thank you for your purchase! Here are your ticket details:
 
@@ -275,7 +402,7 @@ Location: {{.Event.Location}}
 
 TICKET INFORMATION
 Ticket ID: {{.Ticket.ID}}
-Attendee: {{.Ticket.AttendeeFullName}}
+Attendee: {{if .Ticket.AttendeeFullName}}{{.Ticket.AttendeeFullName}}{{else}}Not named yet. Add who this ticket is for in My Tickets and we'll send it to them.{{end}}
 Email: {{.Ticket.AttendeeEmail}}
 {{if .Ticket.AttendeePhone}}Phone: {{.Ticket.AttendeePhone}}{{end}}
 {{if .Ticket.PaymentReference}}Payment Reference: {{.Ticket.PaymentReference}}{{end}}
@@ -287,16 +414,28 @@ Need help? Contact us at support@motivevents.com
 © 2025 Motiv Events. All rights reserved.
 `
 
+	// Each ticket's own QR code, embedded so it shows without loading images
+	var qrCodeData string
+	if ticket.QRCode != "" {
+		png, err := qrcode.Encode(ticket.QRCode, qrcode.Medium, 256)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate QR code: %w", err)
+		}
+		qrCodeData = base64.StdEncoding.EncodeToString(png)
+	}
+
 	data := struct {
-		Ticket *models.Ticket
-		Event  *models.Event
-		User   *models.User
-		AppURL string
+		Ticket     *models.Ticket
+		Event      *models.Event
+		User       *models.User
+		AppURL     string
+		QRCodeData string
 	}{
-		Ticket: ticket,
-		Event:  event,
-		User:   user,
-		AppURL: os.Getenv("FRONTEND_URL"),
+		Ticket:     ticket,
+		Event:      event,
+		User:       user,
+		AppURL:     os.Getenv("FRONTEND_URL"),
+		QRCodeData: qrCodeData,
 	}
 
 	// Generate HTML content
@@ -692,3 +831,577 @@ If you have any questions, feel free to reach out to our support team.
 
 	return htmlBuf.String(), textBuf.String(), nil
 }
+
+func (e *ZohoEmailService) generateRefundNotificationContent(refund *models.Refund, payment *models.Payment, event *models.Event, user *models.User) (string, string, error) {
+	// HTML Template for refund notification
+	htmlTemplate := `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Your Refund</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; margin: 0; padding: 20px; background-color: #f4f4f4; }
+        .container { max-width: 600px; margin: 0 auto; background: white; padding: 20px; border-radius: 10px; box-shadow: 0 0 10px rgba(0,0,0,0.1); }
+        .header { background: #D72638; color: white; padding: 20px; text-align: center; border-radius: 10px 10px 0 0; margin: -20px -20px 20px -20px; }
+        .content { padding: 20px 0; }
+        .refund-info { background: #f8f9fa; padding: 15px; border-radius: 5px; margin: 20px 0; border-left: 4px solid #D72638; }
+        .footer { margin-top: 30px; padding-top: 20px; border-top: 1px solid #eee; text-align: center; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>💸 Refund Processed</h1>
+            <p>{{.Event.Title}}</p>
+        </div>
+
+        <div class="content">
+            <h2>Hi {{.User.Name}},</h2>
+            <p>Your refund for <strong>{{.Event.Title}}</strong> has been processed.</p>
+
+            <div class="refund-info">
+                <p><strong>Amount refunded:</strong> {{.Amount}} {{.Refund.Currency}}</p>
+                <p><strong>Payment reference:</strong> {{.Payment.Reference}}</p>
+                {{if .TicketCount}}<p><strong>Tickets cancelled:</strong> {{.TicketCount}}</p>{{end}}
+                {{if .Refund.Reason}}<p><strong>Reason:</strong> {{.Refund.Reason}}</p>{{end}}
+            </div>
+
+            <p>Depending on your bank, it can take a few business days for the money to appear in your account.</p>
+        </div>
+
+        <div class="footer">
+            <p>If you have any questions, contact us at support@motivevents.com</p>
+            <p>© 2025 Motiv Events. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	// Text Template for refund notification
+	textTemplate := `
+Refund Processed - {{.Event.Title}}
+
+Hi {{.User.Name}},
+
+Your refund for {{.Event.Title}} has been processed.
+
+Amount refunded: {{.Amount}} {{.Refund.Currency}}
+Payment reference: {{.Payment.Reference}}
+{{if .TicketCount}}Tickets cancelled: {{.TicketCount}}
+{{end}}{{if .Refund.Reason}}Reason: {{.Refund.Reason}}
+{{end}}
+Depending on your bank, it can take a few business days for the money to appear in your account.
+
+If you have any questions, contact us at support@motivevents.com
+
+© 2025 Motiv Events. All rights reserved.
+`
+
+	data := struct {
+		Refund      *models.Refund
+		Payment     *models.Payment
+		Event       *models.Event
+		User        *models.User
+		Amount      string
+		TicketCount int
+	}{
+		Refund:      refund,
+		Payment:     payment,
+		Event:       event,
+		User:        user,
+		Amount:      fmt.Sprintf("%.2f", refund.Amount),
+		TicketCount: len(refund.TicketIDs),
+	}
+
+	// Generate HTML content
+	htmlTmpl, err := template.New("html").Parse(htmlTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var htmlBuf bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBuf, data); err != nil {
+		return "", "", err
+	}
+
+	// Generate text content
+	textTmpl, err := template.New("text").Parse(textTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var textBuf bytes.Buffer
+	if err := textTmpl.Execute(&textBuf, data); err != nil {
+		return "", "", err
+	}
+
+	return htmlBuf.String(), textBuf.String(), nil
+}
+
+func (e *ZohoEmailService) generatePaymentReceiptContent(payment *models.Payment, event *models.Event, user *models.User) (string, string, error) {
+	// HTML Template for payment receipt
+	htmlTemplate := `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Your Receipt</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; margin: 0; padding: 20px; background-color: #f4f4f4; }
+        .container { max-width: 600px; margin: 0 auto; background: white; padding: 20px; border-radius: 10px; box-shadow: 0 0 10px rgba(0,0,0,0.1); }
+        .header { background: #667eea; color: white; padding: 20px; text-align: center; border-radius: 10px 10px 0 0; margin: -20px -20px 20px -20px; }
+        table { width: 100%; border-collapse: collapse; margin: 20px 0; }
+        td { padding: 8px 0; border-bottom: 1px solid #eee; }
+        .amount { text-align: right; }
+        .total td { font-weight: bold; border-bottom: none; }
+        .footer { margin-top: 30px; padding-top: 20px; border-top: 1px solid #eee; text-align: center; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🧾 Receipt</h1>
+            <p>{{.Event.Title}}</p>
+        </div>
+
+        <h2>Hi {{.User.Name}},</h2>
+        <p>Thanks for your order. Your tickets are in a separate email.</p>
+
+        <table>
+            {{range .Lines}}
+            <tr><td>{{.Name}} x {{.Quantity}}</td><td class="amount">{{.Amount}} {{$.Payment.Currency}}</td></tr>
+            {{end}}
+            {{if .Discount}}
+            <tr><td>Discount</td><td class="amount">-{{.Discount}} {{.Payment.Currency}}</td></tr>
+            {{end}}
+            {{if .ServiceFee}}
+            <tr><td>Service fee</td><td class="amount">{{.ServiceFee}} {{.Payment.Currency}}</td></tr>
+            {{end}}
+            <tr class="total"><td>Total paid</td><td class="amount">{{.Total}} {{.Payment.Currency}}</td></tr>
+        </table>
+
+        <p><strong>Payment reference:</strong> {{.Payment.Reference}}</p>
+        {{if .ServiceFee}}<p>Service fees are not refundable.</p>{{end}}
+
+        <div class="footer">
+            <p>Need help? Contact us at support@motivevents.com</p>
+            <p>© 2025 Motiv Events. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	// Text Template for payment receipt
+	textTemplate := `
+Receipt - {{.Event.Title}}
+
+Hi {{.User.Name}},
+
+Thanks for your order. Your tickets are in a separate email.
+
+{{range .Lines}}{{.Name}} x {{.Quantity}}: {{.Amount}} {{$.Payment.Currency}}
+{{end}}{{if .Discount}}Discount: -{{.Discount}} {{.Payment.Currency}}
+{{end}}{{if .ServiceFee}}Service fee: {{.ServiceFee}} {{.Payment.Currency}}
+{{end}}Total paid: {{.Total}} {{.Payment.Currency}}
+
+Payment reference: {{.Payment.Reference}}
+{{if .ServiceFee}}Service fees are not refundable.
+{{end}}
+Need help? Contact us at support@motivevents.com
+
+© 2025 Motiv Events. All rights reserved.
+`
+
+	type receiptLine struct {
+		Name     string
+		Quantity int
+		Amount   string
+	}
+	lines := make([]receiptLine, 0, len(payment.LineItems))
+	for _, lineItem := range payment.LineItems {
+		lines = append(lines, receiptLine{
+			Name:     lineItem.TicketTypeName,
+			Quantity: lineItem.Quantity,
+			Amount:   fmt.Sprintf("%.2f", lineItem.Subtotal),
+		})
+	}
+
+	// Amounts come from the breakdown stored at checkout, not recalculated
+	data := struct {
+		Payment    *models.Payment
+		Event      *models.Event
+		User       *models.User
+		Lines      []receiptLine
+		Discount   string
+		ServiceFee string
+		Total      string
+	}{
+		Payment: payment,
+		Event:   event,
+		User:    user,
+		Lines:   lines,
+		Total:   fmt.Sprintf("%.2f", payment.Amount),
+	}
+	if payment.DiscountAmount > 0 {
+		data.Discount = fmt.Sprintf("%.2f", payment.DiscountAmount)
+	}
+	if payment.BuyerFee > 0 {
+		data.ServiceFee = fmt.Sprintf("%.2f", payment.BuyerFee)
+	}
+
+	// Generate HTML content
+	htmlTmpl, err := template.New("html").Parse(htmlTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var htmlBuf bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBuf, data); err != nil {
+		return "", "", err
+	}
+
+	// Generate text content
+	textTmpl, err := template.New("text").Parse(textTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var textBuf bytes.Buffer
+	if err := textTmpl.Execute(&textBuf, data); err != nil {
+		return "", "", err
+	}
+
+	return htmlBuf.String(), textBuf.String(), nil
+}
+
+func (e *ZohoEmailService) generateWaitlistOfferContent(entry *models.WaitlistEntry, event *models.Event, user *models.User) (string, string, error) {
+	// HTML Template for waitlist offer
+	htmlTemplate := `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Tickets Are Available</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; margin: 0; padding: 20px; background-color: #f4f4f4; }
+        .container { max-width: 600px; margin: 0 auto; background: white; padding: 20px; border-radius: 10px; box-shadow: 0 0 10px rgba(0,0,0,0.1); }
+        .header { background: #D72638; color: white; padding: 20px; text-align: center; border-radius: 10px 10px 0 0; margin: -20px -20px 20px -20px; }
+        .content { padding: 20px 0; }
+        .offer-info { background: #f8f9fa; padding: 15px; border-radius: 5px; margin: 20px 0; border-left: 4px solid #D72638; }
+        .btn { display: inline-block; background: #D72638; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; margin: 10px 0; }
+        .footer { margin-top: 30px; padding-top: 20px; border-top: 1px solid #eee; text-align: center; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🎟️ You're Off the Waitlist</h1>
+            <p>{{.Event.Title}}</p>
+        </div>
+
+        <div class="content">
+            <h2>Hi {{.User.Name}},</h2>
+            <p>Tickets for <strong>{{.Event.Title}}</strong> have become available and we're holding them for you.</p>
+
+            <div class="offer-info">
+                <p><strong>Ticket type:</strong> {{.Entry.TicketType.Name}}</p>
+                <p><strong>Quantity held:</strong> {{.Entry.OfferedQuantity}}</p>
+                <p><strong>Offer expires:</strong> {{.ExpiresAt}}</p>
+            </div>
+
+            <p>Complete your purchase before the offer expires, or the tickets will be offered to the next person on the waitlist.</p>
+
+            <a href="{{.AppURL}}/events/{{.Event.ID}}" class="btn">Get My Tickets</a>
+        </div>
+
+        <div class="footer">
+            <p>If you have any questions, contact us at support@motivevents.com</p>
+            <p>© 2025 Motiv Events. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	// Text Template for waitlist offer
+	textTemplate := `
+You're Off the Waitlist - {{.Event.Title}}
+
+Hi {{.User.Name}},
+
+Tickets for {{.Event.Title}} have become available and we're holding them for you.
+
+Ticket type: {{.Entry.TicketType.Name}}
+Quantity held: {{.Entry.OfferedQuantity}}
+Offer expires: {{.ExpiresAt}}
+
+Complete your purchase before the offer expires, or the tickets will be offered to the next person on the waitlist.
+
+Get your tickets: {{.AppURL}}/events/{{.Event.ID}}
+
+If you have any questions, contact us at support@motivevents.com
+
+© 2025 Motiv Events. All rights reserved.
+`
+
+	data := struct {
+		Entry     *models.WaitlistEntry
+		Event     *models.Event
+		User      *models.User
+		ExpiresAt string
+		AppURL    string
+	}{
+		Entry:     entry,
+		Event:     event,
+		User:      user,
+		ExpiresAt: entry.OfferExpiresAt.Format("Monday, January 2, 2006 at 3:04 PM MST"),
+		AppURL:    os.Getenv("FRONTEND_URL"),
+	}
+
+	// Generate HTML content
+	htmlTmpl, err := template.New("html").Parse(htmlTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var htmlBuf bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBuf, data); err != nil {
+		return "", "", err
+	}
+
+	// Generate text content
+	textTmpl, err := template.New("text").Parse(textTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var textBuf bytes.Buffer
+	if err := textTmpl.Execute(&textBuf, data); err != nil {
+		return "", "", err
+	}
+
+	return htmlBuf.String(), textBuf.String(), nil
+}
+
+func (e *ZohoEmailService) generateTicketTransferInviteContent(transfer *models.TicketTransfer, ticket *models.Ticket, event *models.Event, from *models.User) (string, string, error) {
+	// HTML Template for ticket transfer invite
+	htmlTemplate := `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>You've Been Sent a Ticket</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; margin: 0; padding: 20px; background-color: #f4f4f4; }
+        .container { max-width: 600px; margin: 0 auto; background: white; padding: 20px; border-radius: 10px; box-shadow: 0 0 10px rgba(0,0,0,0.1); }
+        .header { background: #D72638; color: white; padding: 20px; text-align: center; border-radius: 10px 10px 0 0; margin: -20px -20px 20px -20px; }
+        .content { padding: 20px 0; }
+        .ticket-info { background: #f8f9fa; padding: 15px; border-radius: 5px; margin: 20px 0; border-left: 4px solid #D72638; }
+        .btn { display: inline-block; background: #D72638; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; margin: 10px 0; }
+        .footer { margin-top: 30px; padding-top: 20px; border-top: 1px solid #eee; text-align: center; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🎁 You've Been Sent a Ticket</h1>
+            <p>{{.Event.Title}}</p>
+        </div>
+
+        <div class="content">
+            <h2>Hi there,</h2>
+            <p><strong>{{.From.Name}}</strong> wants to give you their ticket to <strong>{{.Event.Title}}</strong>.</p>
+
+            <div class="ticket-info">
+                <p><strong>Ticket type:</strong> {{.Ticket.TicketType.Name}}</p>
+                <p><strong>Date:</strong> {{.Event.StartDate.Format "Monday, January 2, 2006"}}</p>
+                <p><strong>Location:</strong> {{.Event.Location}}</p>
+                <p><strong>Accept by:</strong> {{.ExpiresAt}}</p>
+            </div>
+
+            <p>Sign in to Motiv with this email address to accept it. Once you do, the ticket and a new QR code are yours, and the old one stops working.</p>
+
+            <a href="{{.AppURL}}/tickets/transfers/{{.Transfer.Token}}" class="btn">Accept Ticket</a>
+        </div>
+
+        <div class="footer">
+            <p>If you have any questions, contact us at support@motivevents.com</p>
+            <p>© 2025 Motiv Events. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	// Text Template for ticket transfer invite
+	textTemplate := `
+You've Been Sent a Ticket - {{.Event.Title}}
+
+Hi there,
+
+{{.From.Name}} wants to give you their ticket to {{.Event.Title}}.
+
+Ticket type: {{.Ticket.TicketType.Name}}
+Date: {{.Event.StartDate.Format "Monday, January 2, 2006"}}
+Location: {{.Event.Location}}
+Accept by: {{.ExpiresAt}}
+
+Sign in to Motiv with this email address to accept it. Once you do, the ticket and a new QR code are yours, and the old one stops working.
+
+Accept the ticket: {{.AppURL}}/tickets/transfers/{{.Transfer.Token}}
+
+If you have any questions, contact us at support@motivevents.com
+
+© 2025 Motiv Events. All rights reserved.
+`
+
+	data := struct {
+		Transfer  *models.TicketTransfer
+		Ticket    *models.Ticket
+		Event     *models.Event
+		From      *models.User
+		ExpiresAt string
+		AppURL    string
+	}{
+		Transfer:  transfer,
+		Ticket:    ticket,
+		Event:     event,
+		From:      from,
+		ExpiresAt: transfer.ExpiresAt.Format("Monday, January 2, 2006 at 3:04 PM MST"),
+		AppURL:    os.Getenv("FRONTEND_URL"),
+	}
+
+	// Generate HTML content
+	htmlTmpl, err := template.New("html").Parse(htmlTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var htmlBuf bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBuf, data); err != nil {
+		return "", "", err
+	}
+
+	// Generate text content
+	textTmpl, err := template.New("text").Parse(textTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var textBuf bytes.Buffer
+	if err := textTmpl.Execute(&textBuf, data); err != nil {
+		return "", "", err
+	}
+
+	return htmlBuf.String(), textBuf.String(), nil
+}
+
+func (e *ZohoEmailService) generateStaffInviteContent(staff *models.EventStaff, event *models.Event, inviter *models.User) (string, string, error) {
+	// HTML Template for staff invite
+	htmlTemplate := `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>You're Invited to Join the Event Staff</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; margin: 0; padding: 20px; background-color: #f4f4f4; }
+        .container { max-width: 600px; margin: 0 auto; background: white; padding: 20px; border-radius: 10px; box-shadow: 0 0 10px rgba(0,0,0,0.1); }
+        .header { background: #D72638; color: white; padding: 20px; text-align: center; border-radius: 10px 10px 0 0; margin: -20px -20px 20px -20px; }
+        .content { padding: 20px 0; }
+        .event-info { background: #f8f9fa; padding: 15px; border-radius: 5px; margin: 20px 0; border-left: 4px solid #D72638; }
+        .btn { display: inline-block; background: #D72638; color: white; padding: 12px 24px; text-decoration: none; border-radius: 5px; margin: 10px 0; }
+        .footer { margin-top: 30px; padding-top: 20px; border-top: 1px solid #eee; text-align: center; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🪪 You're on the Team</h1>
+            <p>{{.Event.Title}}</p>
+        </div>
+
+        <div class="content">
+            <h2>Hi there,</h2>
+            <p><strong>{{.Inviter.Name}}</strong> has invited you to help run <strong>{{.Event.Title}}</strong> as <strong>{{.Role}}</strong>.</p>
+
+            <div class="event-info">
+                <p><strong>Date:</strong> {{.Event.StartDate.Format "Monday, January 2, 2006"}}</p>
+                <p><strong>Location:</strong> {{.Event.Location}}</p>
+                <p><strong>Accept by:</strong> {{.ExpiresAt}}</p>
+            </div>
+
+            <p>Sign in to Motiv with this email address to accept. You'll then be able to check guests in and look up attendees for this event.</p>
+
+            <a href="{{.AppURL}}/staff/invites/{{.Staff.Token}}" class="btn">Accept Invite</a>
+        </div>
+
+        <div class="footer">
+            <p>If you have any questions, contact us at support@motivevents.com</p>
+            <p>© 2025 Motiv Events. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>`
+
+	// Text Template for staff invite
+	textTemplate := `
+You're Invited to Join the Event Staff - {{.Event.Title}}
+
+Hi there,
+
+{{.Inviter.Name}} has invited you to help run {{.Event.Title}} as {{.Role}}.
+
+Date: {{.Event.StartDate.Format "Monday, January 2, 2006"}}
+Location: {{.Event.Location}}
+Accept by: {{.ExpiresAt}}
+
+Sign in to Motiv with this email address to accept. You'll then be able to check guests in and look up attendees for this event.
+
+Accept the invite: {{.AppURL}}/staff/invites/{{.Staff.Token}}
+
+If you have any questions, contact us at support@motivevents.com
+
+© 2025 Motiv Events. All rights reserved.
+`
+
+	roleNames := map[models.StaffRole]string{
+		models.StaffScanner:   "a scanner",
+		models.StaffBoxOffice: "box office staff",
+		models.StaffCoManager: "a co-manager",
+	}
+
+	data := struct {
+		Staff     *models.EventStaff
+		Event     *models.Event
+		Inviter   *models.User
+		Role      string
+		ExpiresAt string
+		AppURL    string
+	}{
+		Staff:     staff,
+		Event:     event,
+		Inviter:   inviter,
+		Role:      roleNames[staff.Role],
+		ExpiresAt: staff.ExpiresAt.Format("Monday, January 2, 2006 at 3:04 PM MST"),
+		AppURL:    os.Getenv("FRONTEND_URL"),
+	}
+
+	// Generate HTML content
+	htmlTmpl, err := template.New("html").Parse(htmlTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var htmlBuf bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBuf, data); err != nil {
+		return "", "", err
+	}
+
+	// Generate text content
+	textTmpl, err := template.New("text").Parse(textTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var textBuf bytes.Buffer
+	if err := textTmpl.Execute(&textBuf, data); err != nil {
+		return "", "", err
+	}
+
+	return htmlBuf.String(), textBuf.String(), nil
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+	"gorm.io/gorm"
+)
+
+type FeeService interface {
+	// GetSchedule returns the schedule set for a scope, or an empty one if
+	// nothing is set there yet. hostID and eventID are as in SaveSchedule.
+	GetSchedule(scope models.FeeScope, hostID, eventID uuid.UUID) (*models.FeeSchedule, error)
+	// SaveSchedule replaces a scope's schedule. hostID is used for the host
+	// scope and eventID for the event scope.
+	SaveSchedule(scope models.FeeScope, hostID, eventID uuid.UUID, req *models.FeeScheduleRequest) (*models.FeeSchedule, error)
+	// SetPassToBuyer records whether a host's fees are added to what buyers
+	// pay, for all their events or, with an eventID, for one of them.
+	SetPassToBuyer(hostID, eventID uuid.UUID, pass bool) (*models.FeeSettings, error)
+
+	// GetSettings merges the platform's, the host's and, unless eventID is
+	// uuid.Nil, the event's schedules.
+	GetSettings(hostID, eventID uuid.UUID) (*models.FeeSettings, error)
+	// Quote splits an order of subtotal, after discounts, for event paid
+	// through gateway. Checkout, payouts and receipts all use it.
+	Quote(event *models.Event, gateway PaymentGateway, subtotal float64) (*models.FeeBreakdown, error)
+}
+
+var ErrFeeScheduleInvalid = errors.New("invalid fee schedule")
+
+// maxFeeGrossUps bounds how many times a passed-on gateway fee is
+// recalculated on the total it's added to; it settles within a cent in a few.
+const maxFeeGrossUps = 10
+
+type feeService struct {
+	feeRepo  repository.FeeRepository
+	defaults models.FeeSettings
+}
+
+// NewFeeService calculates fees from stored schedules, falling back to
+// defaults for anything the platform schedule doesn't set.
+func NewFeeService(feeRepo repository.FeeRepository, defaults models.FeeSettings) FeeService {
+	return &feeService{
+		feeRepo:  feeRepo,
+		defaults: defaults,
+	}
+}
+
+func (s *feeService) GetSchedule(scope models.FeeScope, hostID, eventID uuid.UUID) (*models.FeeSchedule, error) {
+	schedule, err := s.feeRepo.GetSchedule(scope, hostID, eventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return newFeeSchedule(scope, hostID, eventID), nil
+	}
+	return schedule, err
+}
+
+func newFeeSchedule(scope models.FeeScope, hostID, eventID uuid.UUID) *models.FeeSchedule {
+	schedule := &models.FeeSchedule{Scope: scope}
+	switch scope {
+	case models.FeeScopeHost:
+		schedule.HostID = &hostID
+	case models.FeeScopeEvent:
+		schedule.HostID = &hostID
+		schedule.EventID = &eventID
+	}
+	return schedule
+}
+
+func (s *feeService) SaveSchedule(scope models.FeeScope, hostID, eventID uuid.UUID, req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	for _, value := range []*float64{req.Percent, req.Flat, req.Cap} {
+		if value != nil && *value < 0 {
+			return nil, fmt.Errorf("%w: percent, flat and cap cannot be negative", ErrFeeScheduleInvalid)
+		}
+	}
+	if req.Percent != nil && *req.Percent > 100 {
+		return nil, fmt.Errorf("%w: percent cannot be over 100", ErrFeeScheduleInvalid)
+	}
+	var bearer *models.GatewayFeeBearer
+	if req.GatewayFeeBearer != nil {
+		value := models.GatewayFeeBearer(*req.GatewayFeeBearer)
+		if value != models.GatewayFeePlatform && value != models.GatewayFeeHost {
+			return nil, fmt.Errorf("%w: gateway fee bearer must be platform or host", ErrFeeScheduleInvalid)
+		}
+		bearer = &value
+	}
+
+	schedule, err := s.GetSchedule(scope, hostID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Percent = req.Percent
+	schedule.Flat = req.Flat
+	schedule.Cap = req.Cap
+	schedule.GatewayFeeBearer = bearer
+	schedule.PassToBuyer = req.PassFeesToBuyer
+
+	if err := s.feeRepo.SaveSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *feeService) SetPassToBuyer(hostID, eventID uuid.UUID, pass bool) (*models.FeeSettings, error) {
+	scope := models.FeeScopeHost
+	if eventID != uuid.Nil {
+		scope = models.FeeScopeEvent
+	}
+
+	schedule, err := s.GetSchedule(scope, hostID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	schedule.PassToBuyer = &pass
+	if err := s.feeRepo.SaveSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(hostID, eventID)
+}
+
+func (s *feeService) GetSettings(hostID, eventID uuid.UUID) (*models.FeeSettings, error) {
+	settings := s.defaults
+	scopes := []models.FeeScope{models.FeeScopePlatform, models.FeeScopeHost}
+	if eventID != uuid.Nil {
+		scopes = append(scopes, models.FeeScopeEvent)
+	}
+
+	for _, scope := range scopes {
+		schedule, err := s.feeRepo.GetSchedule(scope, hostID, eventID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s fee schedule: %w", scope, err)
+		}
+		if schedule.Percent != nil {
+			settings.Percent = *schedule.Percent
+		}
+		if schedule.Flat != nil {
+			settings.Flat = *schedule.Flat
+		}
+		if schedule.Cap != nil {
+			settings.Cap = *schedule.Cap
+		}
+		if schedule.GatewayFeeBearer != nil {
+			settings.GatewayFeeBearer = *schedule.GatewayFeeBearer
+		}
+		if schedule.PassToBuyer != nil {
+			settings.PassToBuyer = *schedule.PassToBuyer
+		}
+	}
+	return &settings, nil
+}
+
+func (s *feeService) Quote(event *models.Event, gateway PaymentGateway, subtotal float64) (*models.FeeBreakdown, error) {
+	settings, err := s.GetSettings(event.HostID, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	return calculateFees(settings, subtotal, func(amount float64) float64 {
+		return fromMinorUnits(gateway.EstimateFee(toMinorUnits(amount), event.Currency))
+	}), nil
+}
+
+// calculateFees splits an order. The platform fee is the percentage plus the
+// flat amount, capped; the host also pays the gateway's fee if they bear it.
+// Passed to the buyer, the host's fees are added to the total; otherwise they
+// come out of the host's share. Free orders have no fees.
+func calculateFees(settings *models.FeeSettings, subtotal float64, gatewayFee func(amount float64) float64) *models.FeeBreakdown {
+	fees := &models.FeeBreakdown{Subtotal: subtotal, Total: subtotal, HostNet: subtotal}
+	if subtotal <= 0 {
+		return fees
+	}
+
+	fees.PlatformFee = math.Round((subtotal*settings.Percent/100+settings.Flat)*100) / 100
+	if settings.Cap > 0 && fees.PlatformFee > settings.Cap {
+		fees.PlatformFee = settings.Cap
+	}
+
+	charged := subtotal
+	if settings.PassToBuyer {
+		charged += fees.PlatformFee
+	}
+	fees.GatewayFee = math.Round(gatewayFee(charged)*100) / 100
+
+	// A gateway fee the buyer pays is itself charged through the gateway, so
+	// gross it up until the fee on the whole total is covered. Fees only grow
+	// with the amount, so stopping once they stop growing never undercharges.
+	if settings.PassToBuyer && settings.GatewayFeeBearer == models.GatewayFeeHost {
+		for i := 0; i < maxFeeGrossUps; i++ {
+			next := math.Round(gatewayFee(charged+fees.GatewayFee)*100) / 100
+			if next <= fees.GatewayFee {
+				break
+			}
+			fees.GatewayFee = next
+		}
+	}
+
+	hostFees := fees.PlatformFee
+	if settings.GatewayFeeBearer == models.GatewayFeeHost {
+		hostFees += fees.GatewayFee
+	}
+
+	if settings.PassToBuyer {
+		fees.BuyerFee = hostFees
+		fees.Total = math.Round((subtotal+hostFees)*100) / 100
+		return fees
+	}
+	fees.HostFee = math.Min(hostFees, subtotal)
+	fees.HostNet = math.Round((subtotal-fees.HostFee)*100) / 100
+	return fees
+}
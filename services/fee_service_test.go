@@ -0,0 +1,98 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+func TestCalculateFees(t *testing.T) {
+	// 1.5% of whatever goes through the gateway
+	gatewayFee := func(amount float64) float64 { return amount * 0.015 }
+
+	tests := []struct {
+		name     string
+		settings models.FeeSettings
+		subtotal float64
+		want     models.FeeBreakdown
+	}{
+		{
+			name:     "free orders have no fees",
+			settings: models.FeeSettings{Percent: 5, Flat: 100, GatewayFeeBearer: models.GatewayFeeHost},
+			subtotal: 0,
+			want:     models.FeeBreakdown{},
+		},
+		{
+			name:     "platform bears the gateway fee",
+			settings: models.FeeSettings{Percent: 5, Flat: 100, GatewayFeeBearer: models.GatewayFeePlatform},
+			subtotal: 10000,
+			want:     models.FeeBreakdown{Subtotal: 10000, PlatformFee: 600, GatewayFee: 150, HostFee: 600, Total: 10000, HostNet: 9400},
+		},
+		{
+			name:     "host bears the gateway fee",
+			settings: models.FeeSettings{Percent: 5, Flat: 100, GatewayFeeBearer: models.GatewayFeeHost},
+			subtotal: 10000,
+			want:     models.FeeBreakdown{Subtotal: 10000, PlatformFee: 600, GatewayFee: 150, HostFee: 750, Total: 10000, HostNet: 9250},
+		},
+		{
+			name:     "platform fee is capped",
+			settings: models.FeeSettings{Percent: 5, Flat: 100, Cap: 500, GatewayFeeBearer: models.GatewayFeePlatform},
+			subtotal: 10000,
+			want:     models.FeeBreakdown{Subtotal: 10000, PlatformFee: 500, GatewayFee: 150, HostFee: 500, Total: 10000, HostNet: 9500},
+		},
+		{
+			name:     "platform fee passed to the buyer",
+			settings: models.FeeSettings{Percent: 5, Flat: 100, GatewayFeeBearer: models.GatewayFeePlatform, PassToBuyer: true},
+			subtotal: 10000,
+			want:     models.FeeBreakdown{Subtotal: 10000, PlatformFee: 600, GatewayFee: 159, BuyerFee: 600, Total: 10600, HostNet: 10000},
+		},
+		{
+			name:     "gateway fee passed to the buyer is grossed up",
+			settings: models.FeeSettings{Percent: 5, Flat: 100, GatewayFeeBearer: models.GatewayFeeHost, PassToBuyer: true},
+			subtotal: 10000,
+			want:     models.FeeBreakdown{Subtotal: 10000, PlatformFee: 600, GatewayFee: 161.42, BuyerFee: 761.42, Total: 10761.42, HostNet: 10000},
+		},
+		{
+			name:     "host fees never exceed the subtotal",
+			settings: models.FeeSettings{Percent: 5, Flat: 100, GatewayFeeBearer: models.GatewayFeeHost},
+			subtotal: 50,
+			want:     models.FeeBreakdown{Subtotal: 50, PlatformFee: 102.5, GatewayFee: 0.75, HostFee: 50, Total: 50, HostNet: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateFees(&tt.settings, tt.subtotal, gatewayFee)
+			fields := []struct {
+				name      string
+				got, want float64
+			}{
+				{"Subtotal", got.Subtotal, tt.want.Subtotal},
+				{"PlatformFee", got.PlatformFee, tt.want.PlatformFee},
+				{"GatewayFee", got.GatewayFee, tt.want.GatewayFee},
+				{"BuyerFee", got.BuyerFee, tt.want.BuyerFee},
+				{"HostFee", got.HostFee, tt.want.HostFee},
+				{"Total", got.Total, tt.want.Total},
+				{"HostNet", got.HostNet, tt.want.HostNet},
+			}
+			for _, field := range fields {
+				if math.Abs(field.got-field.want) > 0.001 {
+					t.Errorf("%s = %.2f, want %.2f", field.name, field.got, field.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateFeesGrossUpCoversTotal(t *testing.T) {
+	gatewayFee := func(amount float64) float64 { return math.Min(amount*0.015+100, 2000) }
+	settings := &models.FeeSettings{Percent: 5, GatewayFeeBearer: models.GatewayFeeHost, PassToBuyer: true}
+
+	for _, subtotal := range []float64{500, 2500, 9999.99, 150000} {
+		fees := calculateFees(settings, subtotal, gatewayFee)
+		if onTotal := math.Round(gatewayFee(fees.Total)*100) / 100; onTotal > fees.GatewayFee {
+			t.Errorf("subtotal %.2f: charged gateway fee %.2f, but the fee on the total is %.2f", subtotal, fees.GatewayFee, onTotal)
+		}
+	}
+}
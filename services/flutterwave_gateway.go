@@ -0,0 +1,397 @@
+package services
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+const (
+	FlutterwaveGateway        = "flutterwave"
+	defaultFlutterwaveBaseURL = "https://api.flutterwave.com/v3"
+)
+
+var flutterwaveCurrencies = map[string]bool{
+	"NGN": true, "GHS": true, "KES": true, "UGX": true, "TZS": true, "RWF": true,
+	"ZAR": true, "ZMW": true, "XAF": true, "XOF": true, "USD": true, "EUR": true, "GBP": true,
+}
+
+// flutterwaveMeta is the flat metadata we attach to a Flutterwave checkout.
+type flutterwaveMeta struct {
+	EventID          string `json:"eventId"`
+	EventTitle       string `json:"eventTitle"`
+	AttendeeFullName string `json:"attendeeFullName"`
+	AttendeeEmail    string `json:"attendeeEmail"`
+	AttendeePhone    string `json:"attendeePhone"`
+}
+
+// flutterwaveWebhookPayload is the subset of a Flutterwave webhook we read.
+type flutterwaveWebhookPayload struct {
+	Event string `json:"event"`
+	Data  struct {
+		ID                int64           `json:"id"`
+		TxRef             string          `json:"tx_ref"`
+		Reference         string          `json:"reference"` // Transfer events
+		Status            string          `json:"status"`
+		Amount            float64         `json:"amount"` // Major units
+		AmountRefunded    float64         `json:"amount_refunded"`
+		Currency          string          `json:"currency"`
+		ProcessorResponse string          `json:"processor_response"`
+		Comments          string          `json:"comments"`
+		CompleteMessage   string          `json:"complete_message"`
+		Meta              flutterwaveMeta `json:"meta"`
+	} `json:"data"`
+	MetaData flutterwaveMeta `json:"meta_data"`
+}
+
+type flutterwaveGateway struct {
+	baseURL     string
+	secretKey   string
+	publicKey   string
+	secretHash  string
+	redirectURL string
+	httpClient  *http.Client
+}
+
+// NewFlutterwaveGateway creates a gateway backed by the Flutterwave v3 API.
+// secretHash is the value Flutterwave sends in the verif-hash header of each
+// webhook, as set on the Flutterwave dashboard.
+func NewFlutterwaveGateway(baseURL, secretKey, publicKey, secretHash, redirectURL string) PaymentGateway {
+	if baseURL == "" {
+		baseURL = defaultFlutterwaveBaseURL
+	}
+
+	return &flutterwaveGateway{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		secretKey:   secretKey,
+		publicKey:   publicKey,
+		secretHash:  secretHash,
+		redirectURL: redirectURL,
+		httpClient:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (f *flutterwaveGateway) Name() string {
+	return FlutterwaveGateway
+}
+
+func (f *flutterwaveGateway) SupportsCurrency(currency string) bool {
+	return flutterwaveCurrencies[strings.ToUpper(currency)]
+}
+
+// Local NGN cards are 1.4%, capped at ₦2,000. Other currencies are estimated
+// at the international rate.
+func (f *flutterwaveGateway) EstimateFee(amount int64, currency string) int64 {
+	if !strings.EqualFold(currency, "NGN") {
+		return int64(math.Round(float64(amount) * 0.038))
+	}
+	fee := int64(math.Round(float64(amount) * 0.014))
+	if fee > 200000 {
+		fee = 200000
+	}
+	return fee
+}
+
+// Flutterwave amounts are in major units; everything else here is in minor units
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+// call sends a request to Flutterwave and decodes the envelope's data into out.
+func (f *flutterwaveGateway) call(method, path string, payload interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode flutterwave request: %w", err)
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, f.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to build flutterwave request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+f.secretKey)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call flutterwave %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode flutterwave %s response: %w", path, err)
+	}
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("flutterwave %s failed (HTTP %d): %s", path, resp.StatusCode, body.Message)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w: flutterwave %s failed (HTTP %d): %s", ErrGatewayRejected, ErrGatewayRecordNotFound, path, resp.StatusCode, body.Message)
+	}
+	if resp.StatusCode >= 300 || body.Status != "success" {
+		return fmt.Errorf("%w: flutterwave %s failed (HTTP %d): %s", ErrGatewayRejected, path, resp.StatusCode, body.Message)
+	}
+
+	if err := json.Unmarshal(body.Data, out); err != nil {
+		return fmt.Errorf("failed to decode flutterwave %s data: %w", path, err)
+	}
+	return nil
+}
+
+func (f *flutterwaveGateway) InitializeTransaction(req GatewayCheckoutRequest) (*GatewayCheckout, error) {
+	var data struct {
+		Link string `json:"link"`
+	}
+	err := f.call(http.MethodPost, "/payments", map[string]interface{}{
+		"tx_ref":       req.Reference,
+		"amount":       fromMinorUnits(req.Amount),
+		"currency":     req.Currency,
+		"redirect_url": f.redirectURL,
+		"customer": map[string]string{
+			"email":       req.Email,
+			"name":        req.Attendee.FullName,
+			"phonenumber": req.Attendee.Phone,
+		},
+		"meta": flutterwaveMeta{
+			EventID:          req.EventID,
+			EventTitle:       req.EventTitle,
+			AttendeeFullName: req.Attendee.FullName,
+			AttendeeEmail:    req.Attendee.Email,
+			AttendeePhone:    req.Attendee.Phone,
+		},
+		"customizations": map[string]string{
+			"title": req.EventTitle,
+		},
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayCheckout{
+		CheckoutURL: data.Link,
+		PublicKey:   f.publicKey,
+	}, nil
+}
+
+func (f *flutterwaveGateway) VerifyTransaction(reference string) (*GatewayTransaction, error) {
+	var data struct {
+		ID                int64   `json:"id"`
+		TxRef             string  `json:"tx_ref"`
+		Status            string  `json:"status"`
+		Amount            float64 `json:"amount"` // Major units
+		Currency          string  `json:"currency"`
+		ProcessorResponse string  `json:"processor_response"`
+	}
+	if err := f.call(http.MethodGet, "/transactions/verify_by_reference?tx_ref="+url.QueryEscape(reference), nil, &data); err != nil {
+		return nil, err
+	}
+
+	status := data.Status
+	if status == "successful" {
+		status = "success"
+	}
+
+	return &GatewayTransaction{
+		ID:        strconv.FormatInt(data.ID, 10),
+		Reference: data.TxRef,
+		Status:    status,
+		Amount:    toMinorUnits(data.Amount),
+		Currency:  data.Currency,
+		Message:   data.ProcessorResponse,
+	}, nil
+}
+
+// Refund refunds by Flutterwave's transaction ID, which is looked up first
+// since we only keep our own reference.
+func (f *flutterwaveGateway) Refund(reference string, amount int64, note string) (*GatewayRefund, error) {
+	transaction, err := f.VerifyTransaction(reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up transaction to refund: %w", err)
+	}
+
+	var data struct {
+		ID             int64   `json:"id"`
+		Status         string  `json:"status"`
+		AmountRefunded float64 `json:"amount_refunded"` // Major units
+	}
+	err = f.call(http.MethodPost, "/transactions/"+url.PathEscape(transaction.ID)+"/refund", map[string]interface{}{
+		"amount":   fromMinorUnits(amount),
+		"comments": note,
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayRefund{
+		ID:       strconv.FormatInt(data.ID, 10),
+		Status:   data.Status,
+		Amount:   toMinorUnits(data.AmountRefunded),
+		Currency: transaction.Currency,
+	}, nil
+}
+
+// CreateRecipient resolves the account to check it exists. Flutterwave
+// transfers go straight to account details, so there is no recipient code.
+func (f *flutterwaveGateway) CreateRecipient(account *models.HostBankAccount) (string, error) {
+	var data struct {
+		AccountName string `json:"account_name"`
+	}
+	err := f.call(http.MethodPost, "/accounts/resolve", map[string]interface{}{
+		"account_number": account.AccountNumber,
+		"account_bank":   account.BankCode,
+	}, &data)
+	if err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+func (f *flutterwaveGateway) Transfer(req TransferRequest) (*GatewayTransfer, error) {
+	var data struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+	}
+	err := f.call(http.MethodPost, "/transfers", map[string]interface{}{
+		"account_bank":   req.Account.BankCode,
+		"account_number": req.Account.AccountNumber,
+		"amount":         fromMinorUnits(req.Amount),
+		"currency":       req.Currency,
+		"debit_currency": req.Currency,
+		"reference":      req.Reference,
+		"narration":      req.Narration,
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayTransfer{
+		ID:     strconv.FormatInt(data.ID, 10),
+		Status: strings.ToLower(data.Status),
+	}, nil
+}
+
+// VerifyTransfer looks a transfer up by Flutterwave's ID, since transfers
+// can't be fetched by our reference. One with no ID yet can't be checked.
+func (f *flutterwaveGateway) VerifyTransfer(reference, transferID string) (*GatewayTransfer, error) {
+	if transferID == "" {
+		return nil, fmt.Errorf("flutterwave transfer %s has no ID to look it up by", reference)
+	}
+
+	var data struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := f.call(http.MethodGet, "/transfers/"+url.PathEscape(transferID), nil, &data); err != nil {
+		return nil, err
+	}
+
+	status := strings.ToLower(data.Status)
+	if status == "successful" {
+		status = "success"
+	}
+	return &GatewayTransfer{
+		ID:     strconv.FormatInt(data.ID, 10),
+		Status: status,
+	}, nil
+}
+
+// VerifyWebhook compares the verif-hash header with the configured secret hash.
+func (f *flutterwaveGateway) VerifyWebhook(header func(string) string, body []byte) error {
+	if f.secretHash == "" {
+		return ErrGatewayNotConfigured
+	}
+
+	signature := header("verif-hash")
+	if signature == "" {
+		return ErrWebhookSignatureMissing
+	}
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(f.secretHash)) != 1 {
+		return ErrWebhookSignatureInvalid
+	}
+	return nil
+}
+
+func (f *flutterwaveGateway) ParseWebhook(body []byte) (*GatewayWebhookEvent, error) {
+	var payload flutterwaveWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	data := payload.Data
+
+	// Flutterwave sends our metadata as meta_data on some webhooks and data.meta on others
+	meta := payload.MetaData
+	if meta.AttendeeEmail == "" {
+		meta = data.Meta
+	}
+
+	event := &GatewayWebhookEvent{
+		Provider:  FlutterwaveGateway,
+		EventID:   fmt.Sprintf("%s:%d:%s", payload.Event, data.ID, data.Status),
+		RawType:   payload.Event,
+		Reference: data.TxRef,
+		Status:    data.Status,
+		Amount:    toMinorUnits(data.Amount),
+		Currency:  data.Currency,
+		Message:   data.ProcessorResponse,
+	}
+	event.Attendee.FullName = meta.AttendeeFullName
+	event.Attendee.Email = meta.AttendeeEmail
+	event.Attendee.Phone = meta.AttendeePhone
+
+	// Flutterwave reports both outcomes of a charge or refund under one event name
+	switch payload.Event {
+	case "charge.completed":
+		// A pending charge can still succeed; it is left unhandled until it settles
+		switch data.Status {
+		case "successful":
+			event.Type = GatewayChargeSuccess
+		case "failed", "cancelled":
+			event.Type = GatewayChargeFailed
+		}
+	case "refund.completed":
+		event.RefundID = strconv.FormatInt(data.ID, 10)
+		event.Amount = toMinorUnits(data.AmountRefunded)
+		event.Message = data.Comments
+		if data.Status == "completed" {
+			event.Type = GatewayRefundProcessed
+		} else {
+			event.Type = GatewayRefundFailed
+		}
+	case "transfer.completed":
+		event.Reference = data.Reference
+		event.TransferID = strconv.FormatInt(data.ID, 10)
+		event.Message = data.CompleteMessage
+		if data.Status == "SUCCESSFUL" {
+			event.Type = GatewayTransferSuccess
+		} else {
+			event.Type = GatewayTransferFailed
+		}
+	}
+
+	return event, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import "testing"
+
+func TestFlutterwaveParseWebhook(t *testing.T) {
+	gateway := NewFlutterwaveGateway("", "sk_test", "pk_test", "hash", "")
+
+	tests := []struct {
+		name string
+		body string
+		want GatewayWebhookEvent
+	}{
+		{
+			name: "charge succeeded",
+			body: `{"event":"charge.completed","data":{"id":9001,"tx_ref":"MOTIV-1","status":"successful","amount":5000.5,"currency":"NGN"}}`,
+			want: GatewayWebhookEvent{Type: GatewayChargeSuccess, EventID: "charge.completed:9001:successful", Reference: "MOTIV-1", Status: "successful", Amount: 500050, Currency: "NGN"},
+		},
+		{
+			name: "charge failed",
+			body: `{"event":"charge.completed","data":{"id":9002,"tx_ref":"MOTIV-2","status":"failed","amount":5000,"currency":"NGN","processor_response":"Declined"}}`,
+			want: GatewayWebhookEvent{Type: GatewayChargeFailed, EventID: "charge.completed:9002:failed", Reference: "MOTIV-2", Status: "failed", Amount: 500000, Currency: "NGN", Message: "Declined"},
+		},
+		{
+			name: "charge cancelled",
+			body: `{"event":"charge.completed","data":{"id":9003,"tx_ref":"MOTIV-3","status":"cancelled","amount":5000,"currency":"NGN"}}`,
+			want: GatewayWebhookEvent{Type: GatewayChargeFailed, EventID: "charge.completed:9003:cancelled", Reference: "MOTIV-3", Status: "cancelled", Amount: 500000, Currency: "NGN"},
+		},
+		{
+			name: "pending charge is left until it settles",
+			body: `{"event":"charge.completed","data":{"id":9004,"tx_ref":"MOTIV-4","status":"pending","amount":5000,"currency":"NGN"}}`,
+			want: GatewayWebhookEvent{EventID: "charge.completed:9004:pending", Reference: "MOTIV-4", Status: "pending", Amount: 500000, Currency: "NGN"},
+		},
+		{
+			name: "refund completed",
+			body: `{"event":"refund.completed","data":{"id":61,"tx_ref":"MOTIV-1","status":"completed","amount":5000,"amount_refunded":2000,"currency":"NGN","comments":"Requested by host"}}`,
+			want: GatewayWebhookEvent{Type: GatewayRefundProcessed, EventID: "refund.completed:61:completed", Reference: "MOTIV-1", RefundID: "61", Status: "completed", Amount: 200000, Currency: "NGN", Message: "Requested by host"},
+		},
+		{
+			name: "refund failed",
+			body: `{"event":"refund.completed","data":{"id":62,"tx_ref":"MOTIV-1","status":"failed","amount_refunded":2000,"currency":"NGN","comments":"Insufficient balance"}}`,
+			want: GatewayWebhookEvent{Type: GatewayRefundFailed, EventID: "refund.completed:62:failed", Reference: "MOTIV-1", RefundID: "62", Status: "failed", Amount: 200000, Currency: "NGN", Message: "Insufficient balance"},
+		},
+		{
+			name: "transfer succeeded",
+			body: `{"event":"transfer.completed","data":{"id":701,"reference":"PAYOUT-1","status":"SUCCESSFUL","amount":9000,"currency":"NGN","complete_message":"Approved"}}`,
+			want: GatewayWebhookEvent{Type: GatewayTransferSuccess, EventID: "transfer.completed:701:SUCCESSFUL", Reference: "PAYOUT-1", TransferID: "701", Status: "SUCCESSFUL", Amount: 900000, Currency: "NGN", Message: "Approved"},
+		},
+		{
+			name: "transfer failed",
+			body: `{"event":"transfer.completed","data":{"id":702,"reference":"PAYOUT-2","status":"FAILED","amount":9000,"currency":"NGN","complete_message":"Account not found"}}`,
+			want: GatewayWebhookEvent{Type: GatewayTransferFailed, EventID: "transfer.completed:702:FAILED", Reference: "PAYOUT-2", TransferID: "702", Status: "FAILED", Amount: 900000, Currency: "NGN", Message: "Account not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gateway.ParseWebhook([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+			if got.Provider != FlutterwaveGateway {
+				t.Errorf("Provider = %s, want %s", got.Provider, FlutterwaveGateway)
+			}
+			checkWebhookEvent(t, got, &tt.want)
+		})
+	}
+
+	attendees := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "attendee from meta_data",
+			body: `{"event":"charge.completed","data":{"id":1,"status":"successful"},
+				"meta_data":{"attendeeFullName":"Ada Obi","attendeeEmail":"ada@example.com","attendeePhone":"08030000000"}}`,
+		},
+		{
+			name: "attendee from data.meta",
+			body: `{"event":"charge.completed","data":{"id":1,"status":"successful",
+				"meta":{"attendeeFullName":"Ada Obi","attendeeEmail":"ada@example.com","attendeePhone":"08030000000"}}}`,
+		},
+	}
+	for _, tt := range attendees {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gateway.ParseWebhook([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+			if got.Attendee.FullName != "Ada Obi" || got.Attendee.Email != "ada@example.com" || got.Attendee.Phone != "08030000000" {
+				t.Fatalf("Attendee = %+v", got.Attendee)
+			}
+		})
+	}
+
+	t.Run("malformed body", func(t *testing.T) {
+		if _, err := gateway.ParseWebhook([]byte(`not json`)); err == nil {
+			t.Fatal("ParseWebhook() error = nil, want an error")
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+// FulfilmentService issues tickets for completed orders. Each order's tickets,
+// attendee records, sold quantities and payment status are written in one
+// transaction, so an order is either fully issued or not issued at all.
+type FulfilmentService interface {
+	// FulfilPayment issues one ticket per unit on the payment's line items,
+	// each to the attendee given for its place in the order, and marks the
+	// payment completed. Tickets with no attendee go to the buyer unnamed.
+	// If the payment's hold lapsed and its tickets have since sold, nothing
+	// is issued: the payment is refunded in full and ErrPaymentUnfulfillable
+	// returned.
+	FulfilPayment(payment *models.Payment) ([]*models.Ticket, error)
+	// IssueFreeTicket issues a single free ticket and counts it against its ticket type.
+	IssueFreeTicket(ticket *models.Ticket) error
+}
+
+// ErrPaymentAlreadyFulfilled is returned when a payment has already been
+// completed, so its tickets must not be issued again.
+var ErrPaymentAlreadyFulfilled = errors.New("payment has already been fulfilled")
+
+// ErrPaymentUnfulfillable is returned when a payment arrives after its hold
+// lapsed and its tickets have since sold. The payment is refunded instead.
+var ErrPaymentUnfulfillable = errors.New("tickets sold out before the payment completed")
+
+type fulfilmentService struct {
+	uow           repository.UnitOfWork
+	signer        TicketSigner
+	refundService RefundService
+}
+
+func NewFulfilmentService(uow repository.UnitOfWork, signer TicketSigner, refundService RefundService) FulfilmentService {
+	return &fulfilmentService{
+		uow:           uow,
+		signer:        signer,
+		refundService: refundService,
+	}
+}
+
+func (s *fulfilmentService) FulfilPayment(payment *models.Payment) ([]*models.Ticket, error) {
+	if len(payment.LineItems) == 0 {
+		return nil, fmt.Errorf("no line items recorded for payment %s", payment.Reference)
+	}
+	if payment.User.Email == "" {
+		return nil, fmt.Errorf("buyer not loaded for payment %s", payment.Reference)
+	}
+
+	var tickets []*models.Ticket
+	err := s.uow.Do(func(repos repository.TxRepositories) error {
+		// Claim the payment first; a concurrent or repeated fulfilment stops here
+		completed, err := repos.Payments.CompletePayment(payment.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to complete payment: %w", err)
+		}
+		if !completed {
+			return ErrPaymentAlreadyFulfilled
+		}
+
+		if err := confirmPromoRedemption(repos, payment.Reference); err != nil {
+			return err
+		}
+		if err := postCharge(repos.Ledger, payment, time.Now()); err != nil {
+			return err
+		}
+
+		// The checkout hold becomes a sale; the sold quantities below take its place
+		converted, released, err := repos.Reservations.ConvertByReference(payment.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to convert ticket hold: %w", err)
+		}
+		if converted == 0 || released > 0 {
+			// The hold lapsed before payment and its tickets may have been sold
+			// to someone else since, so they must still be there to sell
+			log.Printf("⚠️ HOLD WARNING: Hold for payment %s expired before payment, checking what's left", payment.Reference)
+			if err := checkLineItemCapacity(repos, payment.UserID, payment.LineItems); err != nil {
+				return err
+			}
+		}
+
+		// Attendees keep the place they were given at checkout; gaps are unnamed
+		attendees := make(map[int]models.PaymentAttendee, len(payment.Attendees))
+		for _, attendee := range payment.Attendees {
+			attendees[attendee.Position] = attendee
+		}
+
+		attendeeIndex := 0
+		for _, lineItem := range payment.LineItems {
+			for i := 0; i < lineItem.Quantity; i++ {
+				ticket := &models.Ticket{
+					EventID:          payment.EventID,
+					UserID:           payment.UserID,
+					TicketTypeID:     lineItem.TicketTypeID,
+					PaymentReference: payment.Reference,
+					AttendeeEmail:    payment.User.Email, // Unnamed; the buyer fills the attendee in later
+					Quantity:         1,                  // Each ticket is for one person
+				}
+				if attendee, ok := attendees[attendeeIndex]; ok {
+					ticket.AttendeeFullName = attendee.FullName
+					ticket.AttendeeEmail = attendee.Email
+					ticket.AttendeePhone = attendee.Phone
+				}
+
+				if err := issueTicket(repos.Tickets, repos.Attendees, s.signer, ticket); err != nil {
+					return fmt.Errorf("failed to issue ticket %d for %s: %w", i+1, lineItem.TicketTypeName, err)
+				}
+
+				tickets = append(tickets, ticket)
+				attendeeIndex++
+			}
+
+			if err := repos.Tickets.UpdateSoldQuantity(lineItem.TicketTypeID, lineItem.Quantity); err != nil {
+				return fmt.Errorf("failed to update sold quantity for %s: %w", lineItem.TicketTypeName, err)
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, ErrTicketsUnavailable) {
+		log.Printf("🚩 PAYMENT UNFULFILLABLE: %v - refunding payment %s in full", err, payment.Reference)
+		if _, refundErr := s.refundService.RefundUnfulfilled(payment, err.Error()); refundErr != nil {
+			return nil, fmt.Errorf("failed to refund unfulfillable payment: %w", refundErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentUnfulfillable, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+// checkLineItemCapacity locks each line item's ticket type, in a fixed order
+// so concurrent checkouts can't deadlock, and checks its quantity is left
+// for userID.
+func checkLineItemCapacity(repos repository.TxRepositories, userID uuid.UUID, lineItems []models.PaymentLineItem) error {
+	items := make([]models.PaymentLineItem, len(lineItems))
+	copy(items, lineItems)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].TicketTypeID.String() < items[j].TicketTypeID.String()
+	})
+
+	for _, item := range items {
+		if _, err := checkCapacity(repos, userID, item.TicketTypeID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fulfilmentService) IssueFreeTicket(ticket *models.Ticket) error {
+	return s.uow.Do(func(repos repository.TxRepositories) error {
+		if err := claimWaitlistOffers(repos, ticket.EventID, ticket.UserID, []uuid.UUID{ticket.TicketTypeID}, ticket.PaymentReference); err != nil {
+			return err
+		}
+		attendee := models.PaymentAttendee{FullName: ticket.AttendeeFullName, Email: ticket.AttendeeEmail, Phone: ticket.AttendeePhone}
+		if err := checkAvailability(repos, ticket.UserID, ticket.TicketTypeID, 1, []models.PaymentAttendee{attendee}); err != nil {
+			return err
+		}
+		if err := issueTicket(repos.Tickets, repos.Attendees, s.signer, ticket); err != nil {
+			return err
+		}
+		return repos.Tickets.UpdateSoldQuantity(ticket.TicketTypeID, 1)
+	})
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+// LedgerService reports on the ledger. Postings are made by the services that
+// move money, inside their own units of work, through the post* helpers below.
+type LedgerService interface {
+	GetAccountBalances() ([]models.LedgerAccountBalance, error)
+	// Backfill posts payments, refunds and payouts that have no ledger
+	// postings yet, dated when the money moved. It is safe to run repeatedly.
+	Backfill() (int, error)
+}
+
+type ledgerService struct {
+	ledgerRepo repository.LedgerRepository
+	uow        repository.UnitOfWork
+}
+
+func NewLedgerService(ledgerRepo repository.LedgerRepository, uow repository.UnitOfWork) LedgerService {
+	return &ledgerService{
+		ledgerRepo: ledgerRepo,
+		uow:        uow,
+	}
+}
+
+func (s *ledgerService) GetAccountBalances() ([]models.LedgerAccountBalance, error) {
+	return s.ledgerRepo.GetAccountBalances()
+}
+
+func (s *ledgerService) Backfill() (int, error) {
+	posted := 0
+
+	payments, err := s.ledgerRepo.GetUnpostedPayments()
+	if err != nil {
+		return posted, fmt.Errorf("failed to load unposted payments: %w", err)
+	}
+	for i := range payments {
+		payment := &payments[i]
+		effectiveAt := payment.CreatedAt
+		if payment.ProcessedAt != nil {
+			effectiveAt = *payment.ProcessedAt
+		}
+		err := s.uow.Do(func(repos repository.TxRepositories) error {
+			return postCharge(repos.Ledger, payment, effectiveAt)
+		})
+		if err != nil {
+			return posted, fmt.Errorf("failed to post payment %s: %w", payment.Reference, err)
+		}
+		posted++
+	}
+
+	refunds, err := s.ledgerRepo.GetUnpostedRefunds()
+	if err != nil {
+		return posted, fmt.Errorf("failed to load unposted refunds: %w", err)
+	}
+	for i := range refunds {
+		refund := &refunds[i]
+		err := s.uow.Do(func(repos repository.TxRepositories) error {
+			if err := postRefund(repos.Ledger, refund, &refund.Payment, refund.CreatedAt); err != nil {
+				return err
+			}
+			switch refund.Status {
+			case models.RefundProcessed:
+				settledAt := refund.UpdatedAt
+				if refund.ProcessedAt != nil {
+					settledAt = *refund.ProcessedAt
+				}
+				return postRefundSettled(repos.Ledger, refund, &refund.Payment, settledAt)
+			case models.RefundFailed:
+				return postRefundReversed(repos.Ledger, refund, &refund.Payment, refund.UpdatedAt)
+			}
+			return nil
+		})
+		if err != nil {
+			return posted, fmt.Errorf("failed to post refund %s: %w", refund.ID, err)
+		}
+		posted++
+	}
+
+	payouts, err := s.ledgerRepo.GetUnpostedPayouts()
+	if err != nil {
+		return posted, fmt.Errorf("failed to load unposted payouts: %w", err)
+	}
+	for i := range payouts {
+		payout := &payouts[i]
+		err := s.uow.Do(func(repos repository.TxRepositories) error {
+			if err := postPayout(repos.Ledger, payout, payout.CreatedAt); err != nil {
+				return err
+			}
+			switch payout.Status {
+			case models.PayoutCompleted:
+				settledAt := payout.UpdatedAt
+				if payout.ProcessedAt != nil {
+					settledAt = *payout.ProcessedAt
+				}
+				return postPayoutSettled(repos.Ledger, payout, settledAt)
+			case models.PayoutFailed:
+				return postPayoutReversed(repos.Ledger, payout, false, payout.UpdatedAt)
+			}
+			return nil
+		})
+		if err != nil {
+			return posted, fmt.Errorf("failed to post payout %s: %w", payout.Reference, err)
+		}
+		posted++
+	}
+
+	return posted, nil
+}
+
+func debit(account models.LedgerAccount, amount float64) models.LedgerEntry {
+	return models.LedgerEntry{Account: account, Debit: amount}
+}
+
+func credit(account models.LedgerAccount, amount float64) models.LedgerEntry {
+	return models.LedgerEntry{Account: account, Credit: amount}
+}
+
+// postLedger checks txn balances and posts it with entries. A reference
+// that has already been posted is skipped, so retried work posts once.
+func postLedger(ledger repository.LedgerRepository, txn *models.LedgerTransaction, entries ...models.LedgerEntry) error {
+	if txn.HostID == uuid.Nil || txn.EventID == uuid.Nil {
+		return fmt.Errorf("ledger transaction %s has no event or host", txn.Reference)
+	}
+
+	var debits, credits float64
+	for _, entry := range entries {
+		entry.Debit = math.Round(entry.Debit*100) / 100
+		entry.Credit = math.Round(entry.Credit*100) / 100
+		if entry.Debit == 0 && entry.Credit == 0 {
+			continue
+		}
+		debits += entry.Debit
+		credits += entry.Credit
+
+		entry.TransactionType = txn.Type
+		entry.EventID = txn.EventID
+		entry.HostID = txn.HostID
+		entry.PaymentID = txn.PaymentID
+		entry.Currency = txn.Currency
+		entry.EffectiveAt = txn.EffectiveAt
+		txn.Entries = append(txn.Entries, entry)
+	}
+	if math.Abs(debits-credits) > 0.001 {
+		return fmt.Errorf("ledger transaction %s does not balance: debits %.2f, credits %.2f", txn.Reference, debits, credits)
+	}
+	if len(txn.Entries) == 0 {
+		return nil
+	}
+
+	posted, err := ledger.Post(txn)
+	if err != nil {
+		return fmt.Errorf("failed to post ledger transaction %s: %w", txn.Reference, err)
+	}
+	if !posted {
+		log.Printf("🔁 LEDGER SKIPPED: %s is already posted", txn.Reference)
+	}
+	return nil
+}
+
+// postCharge records a buyer's payment as owed to the event's host, less any
+// fees passed to the buyer, which the platform keeps, and the gateway's fee
+// on it. payment must have its Event loaded.
+func postCharge(ledger repository.LedgerRepository, payment *models.Payment, effectiveAt time.Time) error {
+	err := postLedger(ledger, paymentTransaction(models.LedgerCharge, "charge:", "Payment ", payment, effectiveAt),
+		debit(models.LedgerBuyer, payment.Amount),
+		credit(models.LedgerHostBalance, payment.Amount-payment.BuyerFee),
+		credit(models.LedgerPlatformFees, payment.BuyerFee),
+	)
+	if err != nil {
+		return err
+	}
+	return postGatewayFee(ledger, payment, effectiveAt)
+}
+
+// postGatewayFee records the gateway's fee on a payment as taken from the
+// money it holds. Whoever bears it, the platform pays it: a host's share is
+// already in the fees they are charged. The fee is the one estimated at
+// checkout.
+func postGatewayFee(ledger repository.LedgerRepository, payment *models.Payment, effectiveAt time.Time) error {
+	return postLedger(ledger, paymentTransaction(models.LedgerGatewayFee, "gateway_fee:", "Gateway fee on payment ", payment, effectiveAt),
+		debit(models.LedgerGatewayFees, payment.GatewayFee),
+		credit(models.LedgerBuyer, payment.GatewayFee),
+	)
+}
+
+// postBoxOfficeSale records money a host took at the door as revenue they've
+// already collected. It is posted to its own pair of accounts, not the host's
+// balance, so it shows in their earnings but is never owed or paid out.
+// payment must have its Event loaded.
+func postBoxOfficeSale(ledger repository.LedgerRepository, payment *models.Payment, effectiveAt time.Time) error {
+	return postLedger(ledger, paymentTransaction(models.LedgerBoxOfficeSale, "box_office:", "Box office sale ", payment, effectiveAt),
+		debit(models.LedgerHostCollected, payment.Amount),
+		credit(models.LedgerBoxOfficeRevenue, payment.Amount),
+	)
+}
+
+// postUnfulfilled records a payment that arrived after its tickets were gone
+// as owed back to the buyer in full; none of it is the host's. It is
+// posted under the payment's charge reference, so it is never also charged.
+// The gateway still keeps its fee. payment must have its Event loaded.
+func postUnfulfilled(ledger repository.LedgerRepository, payment *models.Payment, effectiveAt time.Time) error {
+	err := postLedger(ledger, paymentTransaction(models.LedgerUnfulfilled, "charge:", "Unfulfilled payment ", payment, effectiveAt),
+		debit(models.LedgerBuyer, payment.Amount),
+		credit(models.LedgerUnallocated, payment.Amount),
+	)
+	if err != nil {
+		return err
+	}
+	return postGatewayFee(ledger, payment, effectiveAt)
+}
+
+func paymentTransaction(txnType models.LedgerTransactionType, prefix, description string, payment *models.Payment, effectiveAt time.Time) *models.LedgerTransaction {
+	return &models.LedgerTransaction{
+		Type:        txnType,
+		Reference:   prefix + payment.Reference,
+		Description: description + payment.Reference,
+		EventID:     payment.EventID,
+		HostID:      payment.Event.HostID,
+		PaymentID:   &payment.ID,
+		Currency:    payment.Currency,
+		EffectiveAt: effectiveAt,
+	}
+}
+
+// refundSource is the account a refund is paid from: the host's balance,
+// or unallocated for a payment that was never fulfilled.
+func refundSource(refund *models.Refund) models.LedgerAccount {
+	if refund.Unfulfilled {
+		return models.LedgerUnallocated
+	}
+	return models.LedgerHostBalance
+}
+
+// postRefund takes an initiated refund off the host's balance until the
+// gateway returns the money. payment must have its Event loaded.
+func postRefund(ledger repository.LedgerRepository, refund *models.Refund, payment *models.Payment, effectiveAt time.Time) error {
+	return postLedger(ledger, refundTransaction(models.LedgerRefund, "refund:", refund, payment, effectiveAt),
+		debit(refundSource(refund), refund.Amount),
+		credit(models.LedgerRefunds, refund.Amount),
+	)
+}
+
+func postRefundSettled(ledger repository.LedgerRepository, refund *models.Refund, payment *models.Payment, effectiveAt time.Time) error {
+	return postLedger(ledger, refundTransaction(models.LedgerRefundSettled, "refund_settled:", refund, payment, effectiveAt),
+		debit(models.LedgerRefunds, refund.Amount),
+		credit(models.LedgerBuyer, refund.Amount),
+	)
+}
+
+// postRefundReversed gives a refund the gateway refused back to the
+// account it was paid from.
+func postRefundReversed(ledger repository.LedgerRepository, refund *models.Refund, payment *models.Payment, effectiveAt time.Time) error {
+	return postLedger(ledger, refundTransaction(models.LedgerRefundReversed, "refund_reversed:", refund, payment, effectiveAt),
+		debit(models.LedgerRefunds, refund.Amount),
+		credit(refundSource(refund), refund.Amount),
+	)
+}
+
+func refundTransaction(txnType models.LedgerTransactionType, prefix string, refund *models.Refund, payment *models.Payment, effectiveAt time.Time) *models.LedgerTransaction {
+	return &models.LedgerTransaction{
+		Type:        txnType,
+		Reference:   prefix + refund.ID.String(),
+		Description: "Refund on payment " + payment.Reference,
+		EventID:     payment.EventID,
+		HostID:      payment.Event.HostID,
+		PaymentID:   &payment.ID,
+		Currency:    refund.Currency,
+		EffectiveAt: effectiveAt,
+	}
+}
+
+// postPayout takes a payout's gross amount off the host's balance: the fee
+// to the platform, the rest into clearing until the transfer settles.
+func postPayout(ledger repository.LedgerRepository, payout *models.Payout, effectiveAt time.Time) error {
+	return postLedger(ledger, payoutTransaction(models.LedgerPayout, "payout:", payout, effectiveAt),
+		debit(models.LedgerHostBalance, payout.GrossAmount),
+		credit(models.LedgerPlatformFees, payout.FeeAmount),
+		credit(models.LedgerPayoutClearing, payout.Amount),
+	)
+}
+
+func postPayoutSettled(ledger repository.LedgerRepository, payout *models.Payout, effectiveAt time.Time) error {
+	return postLedger(ledger, payoutTransaction(models.LedgerPayoutSettled, "payout_settled:", payout, effectiveAt),
+		debit(models.LedgerPayoutClearing, payout.Amount),
+		credit(models.LedgerBuyer, payout.Amount),
+	)
+}
+
+// postPayoutReversed returns a failed payout's gross amount to the host,
+// including its fee, which is charged again on the next payout. A settled
+// payout that was reversed brings the money back from the host's bank.
+func postPayoutReversed(ledger repository.LedgerRepository, payout *models.Payout, settled bool, effectiveAt time.Time) error {
+	from := models.LedgerPayoutClearing
+	if settled {
+		from = models.LedgerBuyer
+	}
+	return postLedger(ledger, payoutTransaction(models.LedgerPayoutReversed, "payout_reversed:", payout, effectiveAt),
+		debit(from, payout.Amount),
+		debit(models.LedgerPlatformFees, payout.FeeAmount),
+		credit(models.LedgerHostBalance, payout.GrossAmount),
+	)
+}
+
+func payoutTransaction(txnType models.LedgerTransactionType, prefix string, payout *models.Payout, effectiveAt time.Time) *models.LedgerTransaction {
+	return &models.LedgerTransaction{
+		Type:        txnType,
+		Reference:   prefix + payout.Reference,
+		Description: "Payout " + payout.Reference,
+		EventID:     payout.EventID,
+		HostID:      payout.HostID,
+		Currency:    payout.Currency,
+		EffectiveAt: effectiveAt,
+	}
+}
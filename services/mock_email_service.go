@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/hidenkeys/motiv-backend/models"
 )
@@ -58,3 +59,28 @@ func (m *MockEmailService) SendWelcomeEmail(user *models.User) error {
 
 	return nil
 }
+
+func (m *MockEmailService) SendRefundNotification(refund *models.Refund, payment *models.Payment, event *models.Event, user *models.User) error {
+	log.Printf("MOCK EMAIL: Refund notification sent to %s for %.2f %s on %s", user.Email, refund.Amount, refund.Currency, event.Title)
+	return nil
+}
+
+func (m *MockEmailService) SendPaymentReceipt(payment *models.Payment, event *models.Event, user *models.User) error {
+	log.Printf("MOCK EMAIL: Payment receipt sent to %s for %.2f %s (fees %.2f) on %s", user.Email, payment.Amount, payment.Currency, payment.BuyerFee, event.Title)
+	return nil
+}
+
+func (m *MockEmailService) SendWaitlistOffer(entry *models.WaitlistEntry, event *models.Event, user *models.User) error {
+	log.Printf("MOCK EMAIL: Waitlist offer of %d %s sent to %s for %s, expires %s", entry.OfferedQuantity, entry.TicketType.Name, user.Email, event.Title, entry.OfferExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+func (m *MockEmailService) SendTicketTransferInvite(transfer *models.TicketTransfer, ticket *models.Ticket, event *models.Event, from *models.User) error {
+	log.Printf("MOCK EMAIL: Ticket transfer invite for %s sent to %s from %s", event.Title, transfer.ToEmail, from.Email)
+	return nil
+}
+
+func (m *MockEmailService) SendStaffInvite(staff *models.EventStaff, event *models.Event, inviter *models.User) error {
+	log.Printf("MOCK EMAIL: Staff invite as %s for %s sent to %s from %s", staff.Role, event.Title, staff.Email, inviter.Email)
+	return nil
+}
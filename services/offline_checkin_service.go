@@ -0,0 +1,278 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type OfflineCheckInService interface {
+	// GetManifest builds a signed manifest of an event's tickets for a door
+	// scanner to check them without a connection.
+	GetManifest(event *models.Event) (*models.CheckInManifest, error)
+	// GetManifestKeys returns the public keys that check manifest signatures, by key ID.
+	GetManifestKeys() map[string]string
+	// SyncCheckIns logs a scanner's offline check-ins for an event. A
+	// ticket's scans, online and offline, count as entries in the order they
+	// were made, ties going to online scans and then the lowest device ID,
+	// until its type's entries run out; the rest are reported as duplicates.
+	// Re-sending a batch is safe.
+	SyncCheckIns(eventID, checkedInBy uuid.UUID, req *models.OfflineCheckInSyncRequest) (*OfflineCheckInSyncResult, error)
+}
+
+// OfflineCheckInResult is what became of one synced check-in.
+type OfflineCheckInResult struct {
+	Index     int                   `json:"index"` // Position in the synced batch
+	TicketID  *uuid.UUID            `json:"ticket_id,omitempty"`
+	CheckInID *uuid.UUID            `json:"check_in_id,omitempty"` // The logged scan; nil if it had no usable time
+	Outcome   models.CheckInOutcome `json:"outcome"`
+	Message   string                `json:"message"`
+	ScannedAt time.Time             `json:"scanned_at"`
+	// Conflict is the scan that beat this one, or that this one superseded
+	Conflict *ScanConflict `json:"conflict,omitempty"`
+}
+
+// ScanConflict is the other side of a ticket scanned more than once.
+type ScanConflict struct {
+	DeviceID  string    `json:"device_id,omitempty"` // Empty for an online check-in
+	Gate      string    `json:"gate,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+type OfflineCheckInSyncResult struct {
+	Results    []OfflineCheckInResult `json:"results"`
+	Admitted   int                    `json:"admitted"`
+	Duplicates int                    `json:"duplicates"`
+	Rejected   int                    `json:"rejected"`
+}
+
+var ErrCheckInSyncInvalid = errors.New("invalid check-in sync")
+
+const (
+	// maxSyncCheckIns caps a single sync; scanners send bigger backlogs in batches
+	maxSyncCheckIns = 1000
+	// maxScanClockSkew is how far ahead of the server a device clock may run
+	maxScanClockSkew = 5 * time.Minute
+)
+
+type offlineCheckInService struct {
+	attendeeRepo repository.AttendeeRepository
+	uow          repository.UnitOfWork
+	signer       TicketSigner
+	entryDays    *time.Location
+	feed         CheckInFeed
+}
+
+// NewOfflineCheckInService counts per-day entries by calendar days in
+// entryDays, and publishes every check-in it logs to feed.
+func NewOfflineCheckInService(attendeeRepo repository.AttendeeRepository, uow repository.UnitOfWork, signer TicketSigner, entryDays *time.Location, feed CheckInFeed) OfflineCheckInService {
+	return &offlineCheckInService{
+		attendeeRepo: attendeeRepo,
+		uow:          uow,
+		signer:       signer,
+		entryDays:    entryDays,
+		feed:         feed,
+	}
+}
+
+func (s *offlineCheckInService) GetManifest(event *models.Event) (*models.CheckInManifest, error) {
+	const pageSize = 1000
+	manifest := &models.CheckInManifest{
+		EventID:     event.ID,
+		EventTitle:  event.Title,
+		GeneratedAt: time.Now().UTC(),
+		Tickets:     []models.CheckInManifestTicket{},
+	}
+	// Paged by ID, so attendees added mid-export can't shift others out of a page
+	afterID := uuid.Nil
+	for {
+		attendees, err := s.attendeeRepo.GetByEventIDAfter(event.ID, afterID, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load attendees: %w", err)
+		}
+		for _, attendee := range attendees {
+			name := attendee.Ticket.AttendeeFullName
+			if name == "" {
+				name = attendee.User.Name
+			}
+			qrHash := sha256.Sum256([]byte(attendee.Ticket.QRCode))
+			manifest.Tickets = append(manifest.Tickets, models.CheckInManifestTicket{
+				TicketID:     attendee.TicketID,
+				QRCodeHash:   hex.EncodeToString(qrHash[:]),
+				TicketType:   attendee.Ticket.TicketType.Name,
+				AttendeeName: name,
+				Status:       attendee.Status,
+				CheckedInAt:  attendee.CheckedInAt,
+				EntryLimit:   attendee.Ticket.TicketType.EntryLimit,
+				EntryPerDay:  attendee.Ticket.TicketType.EntryPerDay,
+			})
+		}
+		if len(attendees) < pageSize {
+			break
+		}
+		afterID = attendees[len(attendees)-1].ID
+	}
+
+	manifest.KeyID = s.signer.CurrentKeyID()
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	manifest.KeyID, manifest.Signature = s.signer.SignManifest(body)
+	return manifest, nil
+}
+
+func (s *offlineCheckInService) GetManifestKeys() map[string]string {
+	return s.signer.ManifestPublicKeys()
+}
+
+func (s *offlineCheckInService) SyncCheckIns(eventID, checkedInBy uuid.UUID, req *models.OfflineCheckInSyncRequest) (*OfflineCheckInSyncResult, error) {
+	deviceID := strings.TrimSpace(req.DeviceID)
+	if deviceID == "" {
+		return nil, fmt.Errorf("%w: device ID is required", ErrCheckInSyncInvalid)
+	}
+	if len(req.CheckIns) > maxSyncCheckIns {
+		return nil, fmt.Errorf("%w: at most %d check-ins per sync", ErrCheckInSyncInvalid, maxSyncCheckIns)
+	}
+
+	// Apply scans in the order they happened, so the batch reads the way the night went
+	order := make([]int, len(req.CheckIns))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return req.CheckIns[order[a]].ScannedAt.Before(req.CheckIns[order[b]].ScannedAt)
+	})
+
+	result := &OfflineCheckInSyncResult{Results: make([]OfflineCheckInResult, len(req.CheckIns))}
+	for _, i := range order {
+		checkIn := req.CheckIns[i]
+		synced, err := s.syncCheckIn(eventID, checkedInBy, deviceID, &checkIn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sync check-in %d: %w", i, err)
+		}
+		synced.Index = i
+		result.Results[i] = *synced
+		switch synced.Outcome {
+		case models.CheckInAdmitted:
+			result.Admitted++
+		case models.CheckInDuplicate:
+			result.Duplicates++
+		default:
+			result.Rejected++
+		}
+	}
+	log.Printf("📴 OFFLINE CHECK-IN SYNC: Device %s for event %s: %d admitted, %d duplicates, %d rejected",
+		deviceID, eventID, result.Admitted, result.Duplicates, result.Rejected)
+	return result, nil
+}
+
+// syncCheckIn logs a single offline scan and counts it against the ticket's
+// other scans. Scans without a usable time aren't logged, since they can't be
+// placed among the others.
+func (s *offlineCheckInService) syncCheckIn(eventID, checkedInBy uuid.UUID, deviceID string, checkIn *models.OfflineCheckInRequest) (*OfflineCheckInResult, error) {
+	// Postgres keeps microseconds; match what it stores so re-sent scans are recognised
+	scannedAt := checkIn.ScannedAt.UTC().Truncate(time.Microsecond)
+	synced := &OfflineCheckInResult{Outcome: models.CheckInRejected, ScannedAt: scannedAt}
+	if scannedAt.IsZero() || scannedAt.After(time.Now().Add(maxScanClockSkew)) {
+		synced.Message = "Scan time is missing or in the future - Check the device clock"
+		return synced, nil
+	}
+
+	scan := &models.CheckInEvent{
+		EventID:   eventID,
+		StaffID:   checkedInBy,
+		Source:    models.CheckInOffline,
+		DeviceID:  deviceID,
+		Gate:      strings.TrimSpace(checkIn.Gate),
+		ScannedAt: scannedAt,
+		Outcome:   models.CheckInRejected,
+	}
+	qrCode := strings.TrimSpace(checkIn.QRCode)
+	qrClaims, err := s.signer.Verify(qrCode)
+	if err == nil {
+		scan.TicketID = &qrClaims.TicketID
+		synced.TicketID = &qrClaims.TicketID
+	}
+
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		var attendee *models.Attendee
+		if scan.TicketID != nil {
+			// Locking the attendee serialises every sync of this ticket
+			attendee, _ = repos.Attendees.GetByTicketIDForUpdate(*scan.TicketID)
+		}
+
+		if previous, err := repos.CheckIns.GetDeviceScan(eventID, scan.TicketID, deviceID, scannedAt); err == nil {
+			synced.CheckInID = &previous.ID
+			synced.Outcome = previous.Outcome
+			synced.Message = "Already synced"
+			if previous.Reason != "" {
+				synced.Message += " - " + previous.Reason
+			}
+			return nil
+		}
+
+		var ticket *models.Ticket
+		if qrClaims != nil && qrClaims.EventID == eventID {
+			ticket, _ = repos.Tickets.GetByQRCode(qrCode)
+		}
+		switch {
+		case qrClaims == nil:
+			scan.Reason = "Invalid QR Code - This is not a genuine ticket"
+		case qrClaims.EventID != eventID:
+			scan.Reason = "Wrong event - This ticket is for a different event"
+		case ticket == nil || ticket.ID != qrClaims.TicketID:
+			scan.Reason = "QR code has been replaced"
+		case attendee == nil:
+			scan.Reason = "Attendee not found for this ticket"
+		case attendee.Status == models.AttendeeCancelled:
+			scan.Reason = "Ticket has been cancelled"
+		}
+		if scan.Reason != "" {
+			if err := repos.CheckIns.Create(scan); err != nil {
+				return err
+			}
+			synced.CheckInID = &scan.ID
+			synced.Message = scan.Reason
+			return nil
+		}
+
+		checkIns, superseded, err := recordScan(repos, &ticket.TicketType, attendee, scan, s.entryDays)
+		if err != nil {
+			return err
+		}
+		synced.CheckInID = &scan.ID
+		synced.Outcome = scan.Outcome
+		synced.Message = scan.Reason
+		switch {
+		case scan.Outcome == models.CheckInDuplicate:
+			if entry := lastEntryBefore(checkIns, scan, &ticket.TicketType, s.entryDays); entry != nil {
+				synced.Conflict = &ScanConflict{DeviceID: entry.DeviceID, Gate: entry.Gate, ScannedAt: entry.ScannedAt}
+			}
+		case len(superseded) > 0:
+			synced.Conflict = &ScanConflict{DeviceID: superseded[0].DeviceID, Gate: superseded[0].Gate, ScannedAt: superseded[0].ScannedAt}
+		}
+		if scan.Outcome == models.CheckInAdmitted {
+			synced.Message = "Successfully checked in!"
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Re-sent scans were published when first synced
+	if synced.CheckInID != nil && *synced.CheckInID == scan.ID {
+		s.feed.Publish(*scan)
+	}
+	return synced, nil
+}
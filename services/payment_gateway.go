@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+// Provider-neutral webhook event types. Each gateway maps its own event names
+// onto these; anything else is normalised with an empty Type and ignored.
+type GatewayEventType string
+
+const (
+	GatewayChargeSuccess   GatewayEventType = "charge.success"
+	GatewayChargeFailed    GatewayEventType = "charge.failed"
+	GatewayRefundProcessed GatewayEventType = "refund.processed"
+	GatewayRefundFailed    GatewayEventType = "refund.failed"
+
+	// Transfer events carry a payout reference rather than a payment's
+	GatewayTransferSuccess  GatewayEventType = "transfer.success"
+	GatewayTransferFailed   GatewayEventType = "transfer.failed"
+	GatewayTransferReversed GatewayEventType = "transfer.reversed"
+)
+
+var (
+	ErrWebhookSignatureMissing = errors.New("missing webhook signature")
+	ErrWebhookSignatureInvalid = errors.New("invalid webhook signature")
+	ErrGatewayNotConfigured    = errors.New("payment gateway is not configured")
+	ErrUnknownGateway          = errors.New("unknown payment gateway")
+	// ErrGatewayRejected wraps a provider's definite refusal of a request. Any
+	// other error from a call, e.g. a timeout or a 5xx, leaves it unknown
+	// whether the provider acted on it.
+	ErrGatewayRejected = errors.New("payment gateway rejected the request")
+	// ErrGatewayRecordNotFound wraps a provider saying it has no record of
+	// what was asked for. It is also a rejection.
+	ErrGatewayRecordNotFound = errors.New("payment gateway has no such record")
+)
+
+// PaymentGateway is a payment provider. Amounts are always in the currency's
+// minor unit (kobo, pesewas, cents) regardless of what the provider uses.
+type PaymentGateway interface {
+	// Name is the provider key stored on payments and webhook deliveries.
+	Name() string
+	// SupportsCurrency reports whether checkouts in currency can be taken.
+	SupportsCurrency(currency string) bool
+	// EstimateFee is what the provider is expected to keep from a charge of
+	// amount, from its published pricing.
+	EstimateFee(amount int64, currency string) int64
+	// InitializeTransaction starts a checkout and returns where to send the buyer.
+	InitializeTransaction(req GatewayCheckoutRequest) (*GatewayCheckout, error)
+	// VerifyTransaction fetches the provider's record of a transaction.
+	VerifyTransaction(reference string) (*GatewayTransaction, error)
+	// Refund asks the provider to refund amount of a transaction. The outcome
+	// arrives later as a GatewayRefundProcessed or GatewayRefundFailed webhook.
+	// Only an error wrapping ErrGatewayRejected means no refund was made.
+	Refund(reference string, amount int64, note string) (*GatewayRefund, error)
+	// VerifyWebhook checks a delivery's signature. header looks up a request header.
+	VerifyWebhook(header func(string) string, body []byte) error
+	// ParseWebhook normalises a delivery into a provider-neutral event.
+	ParseWebhook(body []byte) (*GatewayWebhookEvent, error)
+}
+
+// GatewayCheckoutRequest describes a checkout to start with a provider.
+type GatewayCheckoutRequest struct {
+	Reference  string
+	Amount     int64 // Minor units
+	Currency   string
+	Email      string
+	EventID    string
+	EventTitle string
+	Attendee   models.AttendeeDataRequest
+}
+
+// GatewayCheckout is what the client needs to send the buyer to pay.
+type GatewayCheckout struct {
+	CheckoutURL string
+	AccessCode  string // Paystack inline checkout only
+	PublicKey   string
+}
+
+// GatewayTransaction is a provider's record of a transaction.
+type GatewayTransaction struct {
+	ID        string
+	Reference string
+	Status    string // "success" once paid, otherwise the provider's own status
+	Amount    int64  // Minor units
+	Currency  string
+	Message   string
+}
+
+// GatewayRefund is a provider's record of a refund it has accepted.
+type GatewayRefund struct {
+	ID       string
+	Status   string
+	Amount   int64 // Minor units
+	Currency string
+}
+
+// GatewayWebhookEvent is a webhook delivery normalised across providers.
+type GatewayWebhookEvent struct {
+	Provider   string
+	EventID    string // Stable across retries of the same delivery
+	RawType    string // The provider's own event name
+	Type       GatewayEventType
+	Reference  string // Our payment reference, or payout reference on transfer events
+	RefundID   string // Provider refund ID, on refund events
+	TransferID string // Provider transfer ID, on transfer events
+	Status     string
+	Amount     int64 // Minor units
+	Currency   string
+	Message    string // Failure reason or gateway response
+	Attendee   models.AttendeeDataRequest
+}
+
+// GatewayRegistry holds the configured gateways and picks one per checkout.
+type GatewayRegistry interface {
+	Get(name string) (PaymentGateway, error)
+	// ForEvent picks the event's own gateway if set, then the gateway mapped
+	// to the event's currency, then the default.
+	ForEvent(event *models.Event) (PaymentGateway, error)
+	Names() []string
+	// Transfers returns the named gateway's payout transfers.
+	Transfers(name string) (TransferGateway, error)
+}
+
+type gatewayRegistry struct {
+	gateways       map[string]PaymentGateway
+	byCurrency     map[string]string
+	defaultGateway string
+}
+
+// NewGatewayRegistry registers gateways by name. byCurrency maps upper-case
+// currency codes to gateway names.
+func NewGatewayRegistry(defaultGateway string, byCurrency map[string]string, gateways ...PaymentGateway) GatewayRegistry {
+	registry := &gatewayRegistry{
+		gateways:       make(map[string]PaymentGateway),
+		byCurrency:     make(map[string]string),
+		defaultGateway: defaultGateway,
+	}
+	for _, gateway := range gateways {
+		registry.gateways[gateway.Name()] = gateway
+	}
+	for currency, name := range byCurrency {
+		registry.byCurrency[strings.ToUpper(currency)] = name
+	}
+	return registry
+}
+
+func (r *gatewayRegistry) Get(name string) (PaymentGateway, error) {
+	gateway, ok := r.gateways[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGateway, name)
+	}
+	return gateway, nil
+}
+
+func (r *gatewayRegistry) ForEvent(event *models.Event) (PaymentGateway, error) {
+	name := event.PaymentGateway
+	if name == "" {
+		name = r.byCurrency[strings.ToUpper(event.Currency)]
+	}
+	if name == "" {
+		name = r.defaultGateway
+	}
+
+	gateway, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if !gateway.SupportsCurrency(event.Currency) {
+		return nil, fmt.Errorf("%s does not support %s payments", gateway.Name(), event.Currency)
+	}
+	return gateway, nil
+}
+
+func (r *gatewayRegistry) Names() []string {
+	names := make([]string, 0, len(r.gateways))
+	for name := range r.gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *gatewayRegistry) Transfers(name string) (TransferGateway, error) {
+	gateway, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	transfers, ok := gateway.(TransferGateway)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support payout transfers", gateway.Name())
+	}
+	return transfers, nil
+}
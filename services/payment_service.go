@@ -1,7 +1,10 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/hidenkeys/motiv-backend/models"
@@ -15,12 +18,12 @@ type PaymentService interface {
 	ProcessPayment(eventID, userID uuid.UUID, amount float64, method models.PaymentMethod) (*models.Payment, error)
 	UpdatePaymentStatus(reference string, status models.PaymentStatus, failureReason string) error
 	GetPaymentByReference(reference string) (*models.Payment, error)
+	GetPaymentByID(id uuid.UUID) (*models.Payment, error)
 	GetUserIDByEmail(email string) (uuid.UUID, error)
+	VerifyPayment(reference string) (*models.Payment, error)
 	
 	// Payouts
-	CreatePayout(hostID, eventID uuid.UUID, amount float64) (*models.Payout, error)
 	GetHostPayouts(hostID uuid.UUID, page, limit int) ([]models.Payout, error)
-	ProcessPayout(payoutID uuid.UUID) error
 	GetPendingPayouts(hostID uuid.UUID) ([]models.Payout, error)
 	
 	// Financial stats
@@ -28,15 +31,21 @@ type PaymentService interface {
 	GetEventRevenue(eventID uuid.UUID) (float64, error)
 }
 
+// ErrPaymentDisputed is returned when the gateway's record of a transaction
+// does not match the payment we stored at initiation.
+var ErrPaymentDisputed = errors.New("payment does not match gateway transaction")
+
 type paymentService struct {
 	paymentRepo repository.PaymentRepository
 	userRepo    repository.UserRepository
+	gateways    GatewayRegistry
 }
 
-func NewPaymentService(paymentRepo repository.PaymentRepository, userRepo repository.UserRepository) PaymentService {
+func NewPaymentService(paymentRepo repository.PaymentRepository, userRepo repository.UserRepository, gateways GatewayRegistry) PaymentService {
 	return &paymentService{
 		paymentRepo: paymentRepo,
 		userRepo:    userRepo,
+		gateways:    gateways,
 	}
 }
 
@@ -92,36 +101,71 @@ func (s *paymentService) UpdatePaymentStatus(reference string, status models.Pay
 case models.PaymentCompleted:
 		now := time.Now()
 		payment.ProcessedAt = &now
-	case models.PaymentFailed:
+	case models.PaymentFailed, models.PaymentDisputed:
 		payment.FailureReason = failureReason
 	}
 	
 	return s.paymentRepo.UpdatePayment(payment)
 }
 
+// VerifyPayment asks the gateway for its record of the transaction and checks
+// it against the payment stored at initiation. Mismatches move the payment to
+// PaymentDisputed and return ErrPaymentDisputed so no tickets are issued.
+func (s *paymentService) VerifyPayment(reference string) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetPaymentByReference(reference)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := gateway.VerifyTransaction(reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify transaction: %w", err)
+	}
+
+	if transaction.Status != "success" {
+		return nil, fmt.Errorf("transaction %s is not successful (status: %s)", reference, transaction.Status)
+	}
+
+	if mismatch := transactionMismatch(payment, transaction); mismatch != "" {
+		if err := s.UpdatePaymentStatus(reference, models.PaymentDisputed, mismatch); err != nil {
+			return nil, fmt.Errorf("failed to mark payment as disputed: %w", err)
+		}
+		return payment, fmt.Errorf("%w: %s", ErrPaymentDisputed, mismatch)
+	}
+
+	return payment, nil
+}
+
+// transactionMismatch describes the first difference between our payment and
+// the gateway transaction, or returns an empty string when they agree.
+func transactionMismatch(payment *models.Payment, transaction *GatewayTransaction) string {
+	if transaction.Reference != payment.Reference {
+		return fmt.Sprintf("reference mismatch: expected %s, gateway returned %s", payment.Reference, transaction.Reference)
+	}
+
+	expectedAmount := int64(math.Round(payment.Amount * 100))
+	if transaction.Amount != expectedAmount {
+		return fmt.Sprintf("amount mismatch: expected %d, gateway returned %d", expectedAmount, transaction.Amount)
+	}
+
+	if !strings.EqualFold(transaction.Currency, payment.Currency) {
+		return fmt.Sprintf("currency mismatch: expected %s, gateway returned %s", payment.Currency, transaction.Currency)
+	}
+
+	return ""
+}
+
 func (s *paymentService) GetPaymentByReference(reference string) (*models.Payment, error) {
 	return s.paymentRepo.GetPaymentByReference(reference)
 }
 
-func (s *paymentService) CreatePayout(hostID, eventID uuid.UUID, amount float64) (*models.Payout, error) {
-	// Generate unique reference
-	reference := fmt.Sprintf("PAYOUT-%s-%d", hostID.String()[:8], time.Now().Unix())
-	
-	// Calculate payout date (5 business days after event)
-	payoutDate := time.Now().AddDate(0, 0, 7) // 7 days for simplicity
-	
-	payout := &models.Payout{
-		HostID:     hostID,
-		EventID:    eventID,
-		Amount:     amount * 0.99, // 1% commission
-		Currency:   "NGN",
-		Status:     models.PaymentPending,
-		Method:     models.BankTransfer,
-		Reference:  reference,
-		PayoutDate: payoutDate,
-	}
-	
-	return payout, s.paymentRepo.CreatePayout(payout)
+func (s *paymentService) GetPaymentByID(id uuid.UUID) (*models.Payment, error) {
+	return s.paymentRepo.GetPaymentByID(id)
 }
 
 func (s *paymentService) GetHostPayouts(hostID uuid.UUID, page, limit int) ([]models.Payout, error) {
@@ -129,21 +173,6 @@ func (s *paymentService) GetHostPayouts(hostID uuid.UUID, page, limit int) ([]mo
 	return s.paymentRepo.GetPayoutsByHostID(hostID, limit, offset)
 }
 
-func (s *paymentService) ProcessPayout(payoutID uuid.UUID) error {
-	payout, err := s.paymentRepo.GetPayoutByID(payoutID)
-	if err != nil {
-		return err
-	}
-	
-	// Here you would integrate with actual payout processor
-	// For now, we'll simulate successful payout
-	payout.Status = models.PaymentCompleted
-	now := time.Now()
-	payout.ProcessedAt = &now
-	
-	return s.paymentRepo.UpdatePayout(payout)
-}
-
 func (s *paymentService) GetPendingPayouts(hostID uuid.UUID) ([]models.Payout, error) {
 	return s.paymentRepo.GetPendingPayouts(hostID)
 }
@@ -176,7 +205,6 @@ func (s *paymentService) GetHostEarnings(hostID uuid.UUID) (map[string]interface
 		"total_earnings":    totalEarnings,
 		"monthly_earnings":  monthlyEarnings,
 		"pending_payouts":   pendingAmount,
-		"next_payout_date":  time.Now().AddDate(0, 0, 7).Format("2006-01-02"),
 	}, nil
 }
 
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+func TestTransactionMismatch(t *testing.T) {
+	payment := &models.Payment{Reference: "MOTIV-123", Amount: 19.99, Currency: "NGN"}
+
+	tests := []struct {
+		name        string
+		transaction GatewayTransaction
+		want        string // Prefix of the mismatch, or empty when they agree
+	}{
+		{
+			name:        "matching transaction",
+			transaction: GatewayTransaction{Reference: "MOTIV-123", Amount: 1999, Currency: "NGN"},
+		},
+		{
+			name:        "currency compared case-insensitively",
+			transaction: GatewayTransaction{Reference: "MOTIV-123", Amount: 1999, Currency: "ngn"},
+		},
+		{
+			name:        "different reference",
+			transaction: GatewayTransaction{Reference: "MOTIV-456", Amount: 1999, Currency: "NGN"},
+			want:        "reference mismatch",
+		},
+		{
+			name:        "underpaid",
+			transaction: GatewayTransaction{Reference: "MOTIV-123", Amount: 1998, Currency: "NGN"},
+			want:        "amount mismatch",
+		},
+		{
+			name:        "overpaid",
+			transaction: GatewayTransaction{Reference: "MOTIV-123", Amount: 2000, Currency: "NGN"},
+			want:        "amount mismatch",
+		},
+		{
+			name:        "different currency",
+			transaction: GatewayTransaction{Reference: "MOTIV-123", Amount: 1999, Currency: "USD"},
+			want:        "currency mismatch",
+		},
+		{
+			name:        "reference checked first",
+			transaction: GatewayTransaction{Reference: "MOTIV-456", Amount: 1, Currency: "USD"},
+			want:        "reference mismatch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := transactionMismatch(payment, &tt.transaction)
+			if tt.want == "" && got != "" {
+				t.Fatalf("transactionMismatch() = %q, want no mismatch", got)
+			}
+			if !strings.HasPrefix(got, tt.want) {
+				t.Fatalf("transactionMismatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
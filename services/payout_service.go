@@ -0,0 +1,511 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+	"gorm.io/gorm"
+)
+
+type PayoutService interface {
+	GetBankAccount(hostID uuid.UUID) (*models.HostBankAccount, error)
+	// SaveBankAccount checks the account with the transfer gateway and makes
+	// it the host's payout account, replacing any previous one.
+	SaveBankAccount(hostID uuid.UUID, req *models.BankAccountRequest) (*models.HostBankAccount, error)
+
+	// RunSettlement checks on payouts whose outcome is unknown, then pays out
+	// every event whose payout delay has passed since it ended, and returns
+	// how many payouts were sent.
+	RunSettlement() (int, error)
+	// SettleEvent pays out an ended event's unsettled balance straight away,
+	// without waiting for the payout delay.
+	SettleEvent(eventID uuid.UUID) (*models.Payout, error)
+	// HandleTransferSuccess completes a payout once the gateway confirms it.
+	HandleTransferSuccess(reference, transferID string) error
+	// HandleTransferFailed fails a payout, including one the gateway reversed
+	// after completing. Its amount becomes payable again.
+	HandleTransferFailed(reference, transferID, reason string) error
+
+	// NextPayoutDate is when the host's next payout is due, or nil if nothing
+	// is owed to them.
+	NextPayoutDate(hostID uuid.UUID) (*time.Time, error)
+	// StartScheduler runs settlement every interval in the background.
+	StartScheduler(interval time.Duration)
+}
+
+var (
+	ErrNoBankAccount      = errors.New("host has no payout bank account")
+	ErrBankAccountInvalid = errors.New("bank account could not be verified")
+	ErrNothingToSettle    = errors.New("event has no unsettled balance")
+	ErrEventNotEnded      = errors.New("event has not ended yet")
+	ErrPayoutCurrency     = errors.New("bank account currency does not match the event's")
+)
+
+var accountNumberPattern = regexp.MustCompile(`^[0-9]{6,20}$`)
+
+// Balances below this are left until more revenue comes in
+const minimumPayout = 0.01
+
+const (
+	// payoutCheckAfter is how long a payout can sit pending or processing
+	// before its transfer is looked up with the gateway.
+	payoutCheckAfter = 30 * time.Minute
+	// payoutCheckBatch is the most payouts looked up per settlement run.
+	payoutCheckBatch = 50
+)
+
+type payoutService struct {
+	payoutRepo repository.PayoutRepository
+	uow        repository.UnitOfWork
+	transfers  TransferGateway
+	delay      time.Duration
+	mu         sync.Mutex // Serialises settlement runs within this process
+}
+
+// NewPayoutService settles host earnings through transfers. Each event is
+// paid out delay after it ends, less the host fees recorded on its payments
+// at checkout.
+func NewPayoutService(payoutRepo repository.PayoutRepository, uow repository.UnitOfWork, transfers TransferGateway, delay time.Duration) PayoutService {
+	return &payoutService{
+		payoutRepo: payoutRepo,
+		uow:        uow,
+		transfers:  transfers,
+		delay:      delay,
+	}
+}
+
+// eventEndsAt combines an event's date with its end time. An end time at or
+// before the start time runs past midnight; an unreadable one counts as the
+// end of the day.
+func eventEndsAt(startDate time.Time, startTime, endTime string) time.Time {
+	day := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
+	end, err := time.Parse("15:04", endTime)
+	if err != nil {
+		return day.AddDate(0, 0, 1)
+	}
+
+	endsAt := day.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute)
+	if start, err := time.Parse("15:04", startTime); err == nil && !end.After(start) {
+		endsAt = endsAt.AddDate(0, 0, 1)
+	}
+	return endsAt
+}
+
+func (s *payoutService) GetBankAccount(hostID uuid.UUID) (*models.HostBankAccount, error) {
+	return s.payoutRepo.GetBankAccountByHostID(hostID)
+}
+
+func (s *payoutService) SaveBankAccount(hostID uuid.UUID, req *models.BankAccountRequest) (*models.HostBankAccount, error) {
+	accountNumber := strings.TrimSpace(req.AccountNumber)
+	if !accountNumberPattern.MatchString(accountNumber) {
+		return nil, fmt.Errorf("%w: account number must be digits only", ErrBankAccountInvalid)
+	}
+	if strings.TrimSpace(req.BankCode) == "" || strings.TrimSpace(req.AccountName) == "" {
+		return nil, fmt.Errorf("%w: bank code and account name are required", ErrBankAccountInvalid)
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = "NGN"
+	}
+
+	account, err := s.payoutRepo.GetBankAccountByHostID(hostID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		account = &models.HostBankAccount{HostID: hostID}
+	}
+	account.BankCode = strings.TrimSpace(req.BankCode)
+	account.BankName = strings.TrimSpace(req.BankName)
+	account.AccountNumber = accountNumber
+	account.AccountName = strings.TrimSpace(req.AccountName)
+	account.Currency = currency
+
+	recipientCode, err := s.transfers.CreateRecipient(account)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBankAccountInvalid, err)
+	}
+	account.RecipientCode = recipientCode
+	account.Gateway = s.transfers.Name()
+
+	if err := s.payoutRepo.SaveBankAccount(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *payoutService) RunSettlement() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resolveUnresolved()
+
+	now := time.Now()
+	balances, err := s.payoutRepo.GetEventBalancesStartedBefore(now.Add(-s.delay))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load event balances: %w", err)
+	}
+
+	sent := 0
+	for _, balance := range balances {
+		if balance.Gross-balance.Settled < minimumPayout {
+			continue
+		}
+		if eventEndsAt(balance.StartDate, balance.StartTime, balance.EndTime).Add(s.delay).After(now) {
+			continue
+		}
+
+		account, err := s.payoutRepo.GetBankAccountByHostID(balance.HostID)
+		if err != nil {
+			log.Printf("⚠️ PAYOUT WARNING: Host %s has no bank account, holding payout for event %s", balance.HostID, balance.EventID)
+			continue
+		}
+		// A failed payout is retried once the host has updated their account
+		if balance.LastFailedAt != nil && account.UpdatedAt.Before(*balance.LastFailedAt) {
+			continue
+		}
+
+		if _, err := s.settle(balance.EventID, account); err != nil {
+			log.Printf("❌ PAYOUT ERROR: Failed to settle event %s: %v", balance.EventID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *payoutService) SettleEvent(eventID uuid.UUID) (*models.Payout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balance, err := s.payoutRepo.GetEventBalance(eventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNothingToSettle
+	}
+	if err != nil {
+		return nil, err
+	}
+	if eventEndsAt(balance.StartDate, balance.StartTime, balance.EndTime).After(time.Now()) {
+		return nil, ErrEventNotEnded
+	}
+
+	account, err := s.payoutRepo.GetBankAccountByHostID(balance.HostID)
+	if err != nil {
+		return nil, ErrNoBankAccount
+	}
+	return s.settle(eventID, account)
+}
+
+// settle records a payout of the event's unsettled balance, then sends it.
+// The balance is recomputed under the event's lock, so payouts that raced
+// ahead of this one are accounted for.
+func (s *payoutService) settle(eventID uuid.UUID, account *models.HostBankAccount) (*models.Payout, error) {
+	var payout *models.Payout
+	err := s.uow.Do(func(repos repository.TxRepositories) error {
+		if err := repos.Payouts.LockEvent(eventID); err != nil {
+			return fmt.Errorf("failed to lock event: %w", err)
+		}
+
+		balance, err := repos.Payouts.GetEventBalance(eventID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNothingToSettle
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load event balance: %w", err)
+		}
+		if !strings.EqualFold(balance.Currency, account.Currency) {
+			return fmt.Errorf("%w: %s payout to %s account", ErrPayoutCurrency, balance.Currency, account.Currency)
+		}
+
+		gross := math.Round((balance.Gross-balance.Settled)*100) / 100
+		if gross < minimumPayout {
+			return ErrNothingToSettle
+		}
+		// Fees were worked out per payment at checkout; take what's still owed
+		fee := math.Round((balance.Fees-balance.FeesSettled)*100) / 100
+		fee = math.Max(0, math.Min(fee, gross))
+
+		payout = &models.Payout{
+			HostID:          balance.HostID,
+			EventID:         eventID,
+			Amount:          gross - fee,
+			Currency:        strings.ToUpper(balance.Currency),
+			Status:          models.PayoutPending,
+			Method:          models.BankTransfer,
+			Reference:       "payout-" + uuid.New().String(),
+			PayoutDate:      time.Now(),
+			GrossAmount:     gross,
+			FeeAmount:       fee,
+			BankAccountID:   &account.ID,
+			TransferGateway: s.transfers.Name(),
+		}
+		if err := repos.Payouts.CreatePayout(payout); err != nil {
+			return err
+		}
+		return postPayout(repos.Ledger, payout, payout.PayoutDate)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("💸 PAYOUT CREATED: %.2f %s (fee %.2f) for event %s, reference %s",
+		payout.Amount, payout.Currency, payout.FeeAmount, eventID, payout.Reference)
+	return payout, s.send(payout, account)
+}
+
+// send hands a pending payout to the transfer gateway. A payout the gateway
+// refuses is failed, so its amount is payable again. One it gives no clear
+// answer on may still have been sent, so it is left processing until its
+// transfer is looked up.
+func (s *payoutService) send(payout *models.Payout, account *models.HostBankAccount) error {
+	// Recipient codes belong to the gateway that issued them
+	if account.Gateway != s.transfers.Name() {
+		recipientCode, err := s.transfers.CreateRecipient(account)
+		if err != nil {
+			return s.fail(payout, fmt.Sprintf("bank account could not be verified: %v", err))
+		}
+		account.RecipientCode = recipientCode
+		account.Gateway = s.transfers.Name()
+		if err := s.payoutRepo.SaveBankAccount(account); err != nil {
+			log.Printf("⚠️ PAYOUT WARNING: Failed to save recipient for host %s: %v", account.HostID, err)
+		}
+	}
+
+	transfer, err := s.transfers.Transfer(TransferRequest{
+		Reference: payout.Reference,
+		Amount:    toMinorUnits(payout.Amount),
+		Currency:  payout.Currency,
+		Account:   account,
+		Narration: "Motiv payout " + payout.Reference,
+	})
+	if errors.Is(err, ErrGatewayRejected) {
+		return s.fail(payout, err.Error())
+	}
+	if err != nil {
+		if _, markErr := s.payoutRepo.MarkSent(payout.ID, ""); markErr != nil {
+			return fmt.Errorf("failed to mark payout sent: %w", markErr)
+		}
+		payout.Status = models.PayoutProcessing
+		return fmt.Errorf("transfer outcome unknown, will check with %s: %w", s.transfers.Name(), err)
+	}
+
+	return s.accept(payout, transfer)
+}
+
+// accept records that the gateway has a payout's transfer, completing the
+// payout if the transfer has already settled.
+func (s *payoutService) accept(payout *models.Payout, transfer *GatewayTransfer) error {
+	if _, err := s.payoutRepo.MarkSent(payout.ID, transfer.ID); err != nil {
+		return fmt.Errorf("failed to mark payout sent: %w", err)
+	}
+	payout.Status = models.PayoutProcessing
+	payout.TransferCode = transfer.ID
+	log.Printf("📤 PAYOUT SENT: %s accepted by %s as %s (%s)", payout.Reference, s.transfers.Name(), transfer.ID, transfer.Status)
+
+	if transfer.Status == "success" {
+		if err := s.HandleTransferSuccess(payout.Reference, transfer.ID); err != nil {
+			return err
+		}
+		payout.Status = models.PayoutCompleted
+	}
+	return nil
+}
+
+// resolveUnresolved looks up the transfers of payouts left pending, e.g. by a
+// crash before they were sent, or processing with no webhook, and settles
+// each the way the gateway reports it. A pending payout the gateway has
+// never seen is sent; a processing one is failed so it can be paid again.
+func (s *payoutService) resolveUnresolved() {
+	payouts, err := s.payoutRepo.GetUnresolvedPayouts(time.Now().Add(-payoutCheckAfter), payoutCheckBatch)
+	if err != nil {
+		log.Printf("❌ PAYOUT ERROR: Failed to load unresolved payouts: %v", err)
+		return
+	}
+
+	for i := range payouts {
+		payout := &payouts[i]
+		if err := s.resolve(payout); err != nil {
+			log.Printf("❌ PAYOUT ERROR: Failed to resolve payout %s: %v", payout.Reference, err)
+		}
+		// Whatever the outcome, it isn't looked at again until the next window
+		if err := s.payoutRepo.TouchPayout(payout.ID); err != nil {
+			log.Printf("⚠️ PAYOUT WARNING: Failed to mark payout %s checked: %v", payout.Reference, err)
+		}
+	}
+}
+
+func (s *payoutService) resolve(payout *models.Payout) error {
+	if payout.TransferGateway != s.transfers.Name() {
+		return fmt.Errorf("sent through %s, but payouts now go through %s", payout.TransferGateway, s.transfers.Name())
+	}
+
+	transfer, err := s.transfers.VerifyTransfer(payout.Reference, payout.TransferCode)
+	if errors.Is(err, ErrGatewayRecordNotFound) {
+		if payout.Status == models.PayoutProcessing {
+			_, err := s.failPayout(payout, "transfer never reached the gateway")
+			return err
+		}
+		account, err := s.payoutRepo.GetBankAccountByHostID(payout.HostID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrNoBankAccount, err)
+		}
+		log.Printf("🔁 PAYOUT RESEND: %s was never sent, sending it now", payout.Reference)
+		return s.send(payout, account)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up transfer: %w", err)
+	}
+
+	switch transfer.Status {
+	case "success":
+		if payout.Status == models.PayoutPending {
+			return s.accept(payout, transfer)
+		}
+		return s.HandleTransferSuccess(payout.Reference, transfer.ID)
+	case "failed":
+		return s.HandleTransferFailed(payout.Reference, transfer.ID, "transfer failed at gateway")
+	}
+	if payout.Status == models.PayoutPending {
+		return s.accept(payout, transfer)
+	}
+	return nil
+}
+
+func (s *payoutService) fail(payout *models.Payout, reason string) error {
+	if _, err := s.failPayout(payout, reason); err != nil {
+		return err
+	}
+	payout.Status = models.PayoutFailed
+	payout.FailureReason = reason
+	return fmt.Errorf("transfer failed: %s", reason)
+}
+
+// failPayout fails a payout and returns its gross amount to the host's
+// balance, and reports whether it did. A payout that had completed was
+// reversed, so the money is coming back from the host's bank.
+func (s *payoutService) failPayout(payout *models.Payout, reason string) (bool, error) {
+	var moved bool
+	err := s.uow.Do(func(repos repository.TxRepositories) error {
+		reversed, err := repos.Payouts.TransitionPayout(payout.ID,
+			[]models.PayoutStatus{models.PayoutCompleted}, models.PayoutFailed, reason)
+		if err != nil {
+			return fmt.Errorf("failed to mark payout failed: %w", err)
+		}
+		if !reversed {
+			moved, err = repos.Payouts.TransitionPayout(payout.ID,
+				[]models.PayoutStatus{models.PayoutPending, models.PayoutProcessing}, models.PayoutFailed, reason)
+			if err != nil {
+				return fmt.Errorf("failed to mark payout failed: %w", err)
+			}
+			if !moved {
+				return nil
+			}
+		}
+		moved = true
+		return postPayoutReversed(repos.Ledger, payout, reversed, time.Now())
+	})
+	return moved, err
+}
+
+func (s *payoutService) HandleTransferSuccess(reference, transferID string) error {
+	payout, err := s.payoutRepo.GetPayoutByReference(reference)
+	if err != nil {
+		return fmt.Errorf("payout not found for %s: %w", reference, err)
+	}
+
+	var moved bool
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		moved, err = repos.Payouts.TransitionPayout(payout.ID,
+			[]models.PayoutStatus{models.PayoutPending, models.PayoutProcessing}, models.PayoutCompleted, "")
+		if err != nil {
+			return fmt.Errorf("failed to mark payout completed: %w", err)
+		}
+		if !moved {
+			return nil
+		}
+		return postPayoutSettled(repos.Ledger, payout, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+	if !moved {
+		log.Printf("🔁 PAYOUT SKIPPED: Payout %s is already %s", reference, payout.Status)
+		return nil
+	}
+
+	log.Printf("✅ PAYOUT COMPLETED: %.2f %s paid to host %s (%s)", payout.Amount, payout.Currency, payout.HostID, transferID)
+	return nil
+}
+
+func (s *payoutService) HandleTransferFailed(reference, transferID, reason string) error {
+	payout, err := s.payoutRepo.GetPayoutByReference(reference)
+	if err != nil {
+		return fmt.Errorf("payout not found for %s: %w", reference, err)
+	}
+	if reason == "" {
+		reason = "transfer failed at gateway"
+	}
+
+	moved, err := s.failPayout(payout, reason)
+	if err != nil {
+		return err
+	}
+	if !moved {
+		log.Printf("🔁 PAYOUT SKIPPED: Payout %s is already %s", reference, payout.Status)
+		return nil
+	}
+
+	log.Printf("💔 PAYOUT FAILED: %s (%s): %s; %.2f %s is payable to host %s again",
+		reference, transferID, reason, payout.GrossAmount, payout.Currency, payout.HostID)
+	return nil
+}
+
+func (s *payoutService) NextPayoutDate(hostID uuid.UUID) (*time.Time, error) {
+	balances, err := s.payoutRepo.GetHostEventBalances(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var next *time.Time
+	for _, balance := range balances {
+		if balance.Gross-balance.Settled < minimumPayout {
+			continue
+		}
+		due := eventEndsAt(balance.StartDate, balance.StartTime, balance.EndTime).Add(s.delay)
+		if due.Before(now) {
+			due = now
+		}
+		if next == nil || due.Before(*next) {
+			next = &due
+		}
+	}
+	return next, nil
+}
+
+func (s *payoutService) StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sent, err := s.RunSettlement()
+			if err != nil {
+				log.Printf("❌ PAYOUT SCHEDULER ERROR: %v", err)
+				continue
+			}
+			if sent > 0 {
+				log.Printf("🗓️ PAYOUT SCHEDULER: Sent %d payouts", sent)
+			}
+		}
+	}()
+}
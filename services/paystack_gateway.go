@@ -0,0 +1,375 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+const (
+	PaystackGateway        = "paystack"
+	defaultPaystackBaseURL = "https://api.paystack.co"
+)
+
+var paystackCurrencies = map[string]bool{"NGN": true, "GHS": true, "ZAR": true, "KES": true, "USD": true}
+
+// paystackRecipientTypes maps payout currencies to Paystack recipient types.
+var paystackRecipientTypes = map[string]string{"NGN": "nuban", "GHS": "ghipss", "ZAR": "basa", "KES": "mobile_money"}
+
+// paystackWebhookPayload is the subset of a Paystack webhook we read.
+type paystackWebhookPayload struct {
+	Event string `json:"event"`
+	Data  struct {
+		ID                   int64  `json:"id"`
+		Status               string `json:"status"`
+		Reference            string `json:"reference"`
+		TransactionReference string `json:"transaction_reference"` // Refund events reference the refunded transaction here
+		RefundReference      string `json:"refund_reference"`
+		TransferCode         string `json:"transfer_code"`
+		Amount               int64  `json:"amount"` // Amount in kobo
+		Message              string `json:"message"`
+		GatewayResponse      string `json:"gateway_response"`
+		Currency             string `json:"currency"`
+		Metadata             struct {
+			AttendeeData struct {
+				FullName string `json:"fullName"`
+				Email    string `json:"email"`
+				Phone    string `json:"phone"`
+			} `json:"attendeeData"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+type paystackGateway struct {
+	baseURL     string
+	secretKey   string
+	publicKey   string
+	callbackURL string
+	httpClient  *http.Client
+}
+
+// NewPaystackGateway creates a gateway backed by the Paystack API. An empty
+// baseURL falls back to the live Paystack API, so a local fake server can
+// stand in during development.
+func NewPaystackGateway(baseURL, secretKey, publicKey, callbackURL string) PaymentGateway {
+	if baseURL == "" {
+		baseURL = defaultPaystackBaseURL
+	}
+
+	return &paystackGateway{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		secretKey:   secretKey,
+		publicKey:   publicKey,
+		callbackURL: callbackURL,
+		httpClient:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *paystackGateway) Name() string {
+	return PaystackGateway
+}
+
+func (p *paystackGateway) SupportsCurrency(currency string) bool {
+	return paystackCurrencies[strings.ToUpper(currency)]
+}
+
+// Local NGN cards are 1.5% plus ₦100, the ₦100 waived under ₦2,500, capped
+// at ₦2,000. Other currencies are estimated at the international rate.
+func (p *paystackGateway) EstimateFee(amount int64, currency string) int64 {
+	if !strings.EqualFold(currency, "NGN") {
+		return int64(math.Round(float64(amount) * 0.039))
+	}
+	fee := int64(math.Round(float64(amount) * 0.015))
+	if amount >= 250000 {
+		fee += 10000
+	}
+	if fee > 200000 {
+		fee = 200000
+	}
+	return fee
+}
+
+// call sends a request to Paystack and decodes the envelope's data into out.
+func (p *paystackGateway) call(method, path string, payload interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode paystack request: %w", err)
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, p.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to build paystack request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call paystack %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Status  bool            `json:"status"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode paystack %s response: %w", path, err)
+	}
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("paystack %s failed (HTTP %d): %s", path, resp.StatusCode, body.Message)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w: paystack %s failed (HTTP %d): %s", ErrGatewayRejected, ErrGatewayRecordNotFound, path, resp.StatusCode, body.Message)
+	}
+	if resp.StatusCode >= 300 || !body.Status {
+		return fmt.Errorf("%w: paystack %s failed (HTTP %d): %s", ErrGatewayRejected, path, resp.StatusCode, body.Message)
+	}
+
+	if err := json.Unmarshal(body.Data, out); err != nil {
+		return fmt.Errorf("failed to decode paystack %s data: %w", path, err)
+	}
+	return nil
+}
+
+func (p *paystackGateway) InitializeTransaction(req GatewayCheckoutRequest) (*GatewayCheckout, error) {
+	var data struct {
+		AuthorizationURL string `json:"authorization_url"`
+		AccessCode       string `json:"access_code"`
+	}
+	err := p.call(http.MethodPost, "/transaction/initialize", map[string]interface{}{
+		"reference":    req.Reference,
+		"amount":       req.Amount,
+		"currency":     req.Currency,
+		"email":        req.Email,
+		"callback_url": p.callbackURL,
+		"metadata": map[string]interface{}{
+			"eventId":      req.EventID,
+			"eventTitle":   req.EventTitle,
+			"attendeeData": req.Attendee,
+		},
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayCheckout{
+		CheckoutURL: data.AuthorizationURL,
+		AccessCode:  data.AccessCode,
+		PublicKey:   p.publicKey,
+	}, nil
+}
+
+func (p *paystackGateway) VerifyTransaction(reference string) (*GatewayTransaction, error) {
+	var data struct {
+		ID              int64  `json:"id"`
+		Status          string `json:"status"`
+		Reference       string `json:"reference"`
+		Amount          int64  `json:"amount"` // Amount in kobo
+		Currency        string `json:"currency"`
+		GatewayResponse string `json:"gateway_response"`
+	}
+	if err := p.call(http.MethodGet, "/transaction/verify/"+url.PathEscape(reference), nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &GatewayTransaction{
+		ID:        strconv.FormatInt(data.ID, 10),
+		Reference: data.Reference,
+		Status:    data.Status,
+		Amount:    data.Amount,
+		Currency:  data.Currency,
+		Message:   data.GatewayResponse,
+	}, nil
+}
+
+func (p *paystackGateway) Refund(reference string, amount int64, note string) (*GatewayRefund, error) {
+	var data struct {
+		ID       int64  `json:"id"`
+		Status   string `json:"status"`
+		Amount   int64  `json:"amount"` // Amount in kobo
+		Currency string `json:"currency"`
+	}
+	err := p.call(http.MethodPost, "/refund", map[string]interface{}{
+		"transaction":   reference,
+		"amount":        amount,
+		"merchant_note": note,
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayRefund{
+		ID:       strconv.FormatInt(data.ID, 10),
+		Status:   data.Status,
+		Amount:   data.Amount,
+		Currency: data.Currency,
+	}, nil
+}
+
+func (p *paystackGateway) CreateRecipient(account *models.HostBankAccount) (string, error) {
+	recipientType, ok := paystackRecipientTypes[strings.ToUpper(account.Currency)]
+	if !ok {
+		return "", fmt.Errorf("paystack cannot pay out in %s", account.Currency)
+	}
+
+	var data struct {
+		RecipientCode string `json:"recipient_code"`
+	}
+	err := p.call(http.MethodPost, "/transferrecipient", map[string]interface{}{
+		"type":           recipientType,
+		"name":           account.AccountName,
+		"account_number": account.AccountNumber,
+		"bank_code":      account.BankCode,
+		"currency":       strings.ToUpper(account.Currency),
+	}, &data)
+	if err != nil {
+		return "", err
+	}
+	return data.RecipientCode, nil
+}
+
+func (p *paystackGateway) Transfer(req TransferRequest) (*GatewayTransfer, error) {
+	var data struct {
+		TransferCode string `json:"transfer_code"`
+		Status       string `json:"status"`
+	}
+	err := p.call(http.MethodPost, "/transfer", map[string]interface{}{
+		"source":    "balance",
+		"amount":    req.Amount,
+		"currency":  req.Currency,
+		"recipient": req.Account.RecipientCode,
+		"reference": req.Reference,
+		"reason":    req.Narration,
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayTransfer{
+		ID:     data.TransferCode,
+		Status: data.Status,
+	}, nil
+}
+
+func (p *paystackGateway) VerifyTransfer(reference, transferID string) (*GatewayTransfer, error) {
+	var data struct {
+		TransferCode string `json:"transfer_code"`
+		Status       string `json:"status"`
+	}
+	if err := p.call(http.MethodGet, "/transfer/verify/"+url.PathEscape(reference), nil, &data); err != nil {
+		return nil, err
+	}
+
+	status := data.Status
+	switch status {
+	case "failed", "reversed", "abandoned", "blocked", "rejected":
+		status = "failed"
+	}
+	return &GatewayTransfer{
+		ID:     data.TransferCode,
+		Status: status,
+	}, nil
+}
+
+// VerifyWebhook checks the HMAC-SHA512 of the body, keyed with the secret key.
+func (p *paystackGateway) VerifyWebhook(header func(string) string, body []byte) error {
+	if p.secretKey == "" {
+		return ErrGatewayNotConfigured
+	}
+
+	signature := header("x-paystack-signature")
+	if signature == "" {
+		return ErrWebhookSignatureMissing
+	}
+
+	mac := hmac.New(sha512.New, []byte(p.secretKey))
+	mac.Write(body)
+	expectedSignature := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
+		return ErrWebhookSignatureInvalid
+	}
+	return nil
+}
+
+func (p *paystackGateway) ParseWebhook(body []byte) (*GatewayWebhookEvent, error) {
+	var payload paystackWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	data := payload.Data
+
+	event := &GatewayWebhookEvent{
+		Provider:  PaystackGateway,
+		RawType:   payload.Event,
+		Reference: data.Reference,
+		Status:    data.Status,
+		Amount:    data.Amount,
+		Currency:  data.Currency,
+		Message:   data.Message,
+	}
+	event.Attendee.FullName = data.Metadata.AttendeeData.FullName
+	event.Attendee.Email = data.Metadata.AttendeeData.Email
+	event.Attendee.Phone = data.Metadata.AttendeeData.Phone
+
+	switch payload.Event {
+	case "charge.success":
+		event.Type = GatewayChargeSuccess
+	case "charge.failed":
+		event.Type = GatewayChargeFailed
+	case "refund.processed":
+		event.Type = GatewayRefundProcessed
+	case "refund.failed":
+		event.Type = GatewayRefundFailed
+		event.Message = data.GatewayResponse
+	case "transfer.success":
+		event.Type = GatewayTransferSuccess
+	case "transfer.failed":
+		event.Type = GatewayTransferFailed
+	case "transfer.reversed":
+		event.Type = GatewayTransferReversed
+	}
+	event.TransferID = data.TransferCode
+
+	// Refund events reference the refunded transaction separately
+	if event.Reference == "" {
+		event.Reference = data.TransactionReference
+	}
+	if (event.Type == GatewayRefundProcessed || event.Type == GatewayRefundFailed) && data.ID != 0 {
+		event.RefundID = strconv.FormatInt(data.ID, 10)
+	}
+
+	// Paystack has no event ID of its own, so the event type is combined with
+	// the transaction or refund ID; retries of the same event carry the same pair
+	switch {
+	case data.ID != 0:
+		event.EventID = fmt.Sprintf("%s:%d", payload.Event, data.ID)
+	case data.RefundReference != "":
+		event.EventID = fmt.Sprintf("%s:%s", payload.Event, data.RefundReference)
+	default:
+		event.EventID = fmt.Sprintf("%s:%s", payload.Event, event.Reference)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import "testing"
+
+func TestPaystackParseWebhook(t *testing.T) {
+	gateway := NewPaystackGateway("", "sk_test", "pk_test", "")
+
+	tests := []struct {
+		name string
+		body string
+		want GatewayWebhookEvent
+	}{
+		{
+			name: "charge succeeded",
+			body: `{"event":"charge.success","data":{"id":3021,"status":"success","reference":"MOTIV-1","amount":500000,"currency":"NGN",
+				"metadata":{"attendeeData":{"fullName":"Ada Obi","email":"ada@example.com","phone":"08030000000"}}}}`,
+			want: GatewayWebhookEvent{Type: GatewayChargeSuccess, EventID: "charge.success:3021", Reference: "MOTIV-1", Status: "success", Amount: 500000, Currency: "NGN"},
+		},
+		{
+			name: "charge failed",
+			body: `{"event":"charge.failed","data":{"id":3022,"status":"failed","reference":"MOTIV-2","amount":500000,"currency":"NGN","message":"Declined"}}`,
+			want: GatewayWebhookEvent{Type: GatewayChargeFailed, EventID: "charge.failed:3022", Reference: "MOTIV-2", Status: "failed", Amount: 500000, Currency: "NGN", Message: "Declined"},
+		},
+		{
+			name: "refund processed references the refunded transaction",
+			body: `{"event":"refund.processed","data":{"id":77,"status":"processed","transaction_reference":"MOTIV-1","amount":200000,"currency":"NGN"}}`,
+			want: GatewayWebhookEvent{Type: GatewayRefundProcessed, EventID: "refund.processed:77", Reference: "MOTIV-1", RefundID: "77", Status: "processed", Amount: 200000, Currency: "NGN"},
+		},
+		{
+			name: "refund failed keeps the gateway response",
+			body: `{"event":"refund.failed","data":{"id":78,"status":"failed","transaction_reference":"MOTIV-1","amount":200000,"currency":"NGN","message":"x","gateway_response":"Insufficient balance"}}`,
+			want: GatewayWebhookEvent{Type: GatewayRefundFailed, EventID: "refund.failed:78", Reference: "MOTIV-1", RefundID: "78", Status: "failed", Amount: 200000, Currency: "NGN", Message: "Insufficient balance"},
+		},
+		{
+			name: "refund without an ID is keyed by its refund reference",
+			body: `{"event":"refund.processed","data":{"status":"processed","transaction_reference":"MOTIV-1","refund_reference":"RF-9"}}`,
+			want: GatewayWebhookEvent{Type: GatewayRefundProcessed, EventID: "refund.processed:RF-9", Reference: "MOTIV-1", Status: "processed"},
+		},
+		{
+			name: "transfer succeeded",
+			body: `{"event":"transfer.success","data":{"id":501,"status":"success","reference":"PAYOUT-1","transfer_code":"TRF_abc","amount":900000,"currency":"NGN"}}`,
+			want: GatewayWebhookEvent{Type: GatewayTransferSuccess, EventID: "transfer.success:501", Reference: "PAYOUT-1", TransferID: "TRF_abc", Status: "success", Amount: 900000, Currency: "NGN"},
+		},
+		{
+			name: "transfer failed",
+			body: `{"event":"transfer.failed","data":{"id":502,"status":"failed","reference":"PAYOUT-2","transfer_code":"TRF_def"}}`,
+			want: GatewayWebhookEvent{Type: GatewayTransferFailed, EventID: "transfer.failed:502", Reference: "PAYOUT-2", TransferID: "TRF_def", Status: "failed"},
+		},
+		{
+			name: "transfer reversed",
+			body: `{"event":"transfer.reversed","data":{"id":503,"status":"reversed","reference":"PAYOUT-3","transfer_code":"TRF_ghi"}}`,
+			want: GatewayWebhookEvent{Type: GatewayTransferReversed, EventID: "transfer.reversed:503", Reference: "PAYOUT-3", TransferID: "TRF_ghi", Status: "reversed"},
+		},
+		{
+			name: "unhandled events have no type",
+			body: `{"event":"subscription.create","data":{"reference":"SUB-1"}}`,
+			want: GatewayWebhookEvent{EventID: "subscription.create:SUB-1", Reference: "SUB-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gateway.ParseWebhook([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseWebhook() error = %v", err)
+			}
+			if got.Provider != PaystackGateway {
+				t.Errorf("Provider = %s, want %s", got.Provider, PaystackGateway)
+			}
+			checkWebhookEvent(t, got, &tt.want)
+		})
+	}
+
+	t.Run("attendee from metadata", func(t *testing.T) {
+		got, err := gateway.ParseWebhook([]byte(tests[0].body))
+		if err != nil {
+			t.Fatalf("ParseWebhook() error = %v", err)
+		}
+		if got.Attendee.FullName != "Ada Obi" || got.Attendee.Email != "ada@example.com" || got.Attendee.Phone != "08030000000" {
+			t.Fatalf("Attendee = %+v", got.Attendee)
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		if _, err := gateway.ParseWebhook([]byte(`{"event":`)); err == nil {
+			t.Fatal("ParseWebhook() error = nil, want an error")
+		}
+	})
+}
+
+// checkWebhookEvent compares the fields webhook handling acts on.
+func checkWebhookEvent(t *testing.T, got, want *GatewayWebhookEvent) {
+	t.Helper()
+	fields := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"Type", got.Type, want.Type},
+		{"EventID", got.EventID, want.EventID},
+		{"Reference", got.Reference, want.Reference},
+		{"RefundID", got.RefundID, want.RefundID},
+		{"TransferID", got.TransferID, want.TransferID},
+		{"Status", got.Status, want.Status},
+		{"Amount", got.Amount, want.Amount},
+		{"Currency", got.Currency, want.Currency},
+		{"Message", got.Message, want.Message},
+	}
+	for _, field := range fields {
+		if field.got != field.want {
+			t.Errorf("%s = %v, want %v", field.name, field.got, field.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+	"gorm.io/gorm"
+)
+
+type PromoService interface {
+	// CreatePromoCode validates and stores a new code; the code is upper-cased.
+	CreatePromoCode(promo *models.PromoCode) error
+	GetPromoCode(id uuid.UUID) (*models.PromoCode, error)
+	GetEventPromoCodes(eventID uuid.UUID) ([]models.PromoCode, error)
+	UpdatePromoCode(promo *models.PromoCode) error
+	DeletePromoCode(id uuid.UUID) error
+
+	// ApplyPromoCode checks a code can be used by userID on this order and
+	// sets Discount on each eligible line item. It returns the code and the
+	// total discount. Nothing is recorded until RedeemPromoCode.
+	ApplyPromoCode(eventID, userID uuid.UUID, code string, lineItems []models.PaymentLineItem) (*models.PromoCode, float64, error)
+	// RedeemPromoCode counts a use of the code against a created payment.
+	RedeemPromoCode(promo *models.PromoCode, payment *models.Payment) error
+	// ReleaseRedemption gives the use back when a payment fails. Payments
+	// without a promo code are ignored.
+	ReleaseRedemption(reference string) error
+}
+
+var (
+	ErrPromoCodeInvalid       = errors.New("promo code is not valid for this event")
+	ErrPromoCodeNotActive     = errors.New("promo code is not active yet or has expired")
+	ErrPromoCodeExhausted     = errors.New("promo code has been fully redeemed")
+	ErrPromoCodeUserLimit     = errors.New("you have already used this promo code the maximum number of times")
+	ErrPromoCodeNotApplicable = errors.New("promo code does not apply to the selected tickets")
+	ErrPromoCodeSetup         = errors.New("invalid promo code")
+)
+
+type promoService struct {
+	promoRepo repository.PromoRepository
+	uow       repository.UnitOfWork
+}
+
+func NewPromoService(promoRepo repository.PromoRepository, uow repository.UnitOfWork) PromoService {
+	return &promoService{
+		promoRepo: promoRepo,
+		uow:       uow,
+	}
+}
+
+// validatePromoCode checks the settings a host controls.
+func validatePromoCode(promo *models.PromoCode) error {
+	promo.Code = strings.ToUpper(strings.TrimSpace(promo.Code))
+	if promo.Code == "" || strings.ContainsAny(promo.Code, " \t\n") {
+		return fmt.Errorf("%w: code must be a single word", ErrPromoCodeSetup)
+	}
+
+	switch promo.DiscountType {
+	case models.DiscountPercentage:
+		if promo.DiscountValue <= 0 || promo.DiscountValue > 100 {
+			return fmt.Errorf("%w: percentage must be between 0 and 100", ErrPromoCodeSetup)
+		}
+	case models.DiscountFixed:
+		if promo.DiscountValue <= 0 {
+			return fmt.Errorf("%w: fixed discount must be greater than zero", ErrPromoCodeSetup)
+		}
+	default:
+		return fmt.Errorf("%w: discount type must be 'percentage' or 'fixed'", ErrPromoCodeSetup)
+	}
+
+	if promo.MaxUses != nil && *promo.MaxUses < 1 {
+		return fmt.Errorf("%w: max uses must be at least 1", ErrPromoCodeSetup)
+	}
+	if promo.MaxUsesPerUser != nil && *promo.MaxUsesPerUser < 1 {
+		return fmt.Errorf("%w: max uses per user must be at least 1", ErrPromoCodeSetup)
+	}
+	if promo.StartsAt != nil && promo.EndsAt != nil && !promo.EndsAt.After(*promo.StartsAt) {
+		return fmt.Errorf("%w: end must be after start", ErrPromoCodeSetup)
+	}
+	return nil
+}
+
+func (s *promoService) CreatePromoCode(promo *models.PromoCode) error {
+	if err := validatePromoCode(promo); err != nil {
+		return err
+	}
+	if _, err := s.promoRepo.GetPromoCodeByCode(promo.EventID, promo.Code); err == nil {
+		return fmt.Errorf("%w: %s already exists for this event", ErrPromoCodeSetup, promo.Code)
+	}
+	return s.promoRepo.CreatePromoCode(promo)
+}
+
+func (s *promoService) GetPromoCode(id uuid.UUID) (*models.PromoCode, error) {
+	return s.promoRepo.GetPromoCodeByID(id)
+}
+
+func (s *promoService) GetEventPromoCodes(eventID uuid.UUID) ([]models.PromoCode, error) {
+	return s.promoRepo.GetPromoCodesByEventID(eventID)
+}
+
+func (s *promoService) UpdatePromoCode(promo *models.PromoCode) error {
+	if err := validatePromoCode(promo); err != nil {
+		return err
+	}
+	if existing, err := s.promoRepo.GetPromoCodeByCode(promo.EventID, promo.Code); err == nil && existing.ID != promo.ID {
+		return fmt.Errorf("%w: %s already exists for this event", ErrPromoCodeSetup, promo.Code)
+	}
+	return s.promoRepo.UpdatePromoCode(promo)
+}
+
+func (s *promoService) DeletePromoCode(id uuid.UUID) error {
+	return s.promoRepo.DeletePromoCode(id)
+}
+
+func (s *promoService) ApplyPromoCode(eventID, userID uuid.UUID, code string, lineItems []models.PaymentLineItem) (*models.PromoCode, float64, error) {
+	promo, err := s.promoRepo.GetPromoCodeByCode(eventID, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil || !promo.Active {
+		return nil, 0, ErrPromoCodeInvalid
+	}
+
+	now := time.Now()
+	if (promo.StartsAt != nil && now.Before(*promo.StartsAt)) || (promo.EndsAt != nil && now.After(*promo.EndsAt)) {
+		return nil, 0, ErrPromoCodeNotActive
+	}
+	// Checked again atomically when the code is redeemed
+	if promo.MaxUses != nil && promo.UsedCount >= *promo.MaxUses {
+		return nil, 0, ErrPromoCodeExhausted
+	}
+	if promo.MaxUsesPerUser != nil {
+		used, err := s.promoRepo.CountUserRedemptions(promo.ID, userID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count redemptions: %w", err)
+		}
+		if used >= int64(*promo.MaxUsesPerUser) {
+			return nil, 0, ErrPromoCodeUserLimit
+		}
+	}
+
+	eligible := make(map[string]bool, len(promo.TicketTypeIDs))
+	for _, id := range promo.TicketTypeIDs {
+		eligible[id] = true
+	}
+
+	var total float64
+	for i := range lineItems {
+		lineItem := &lineItems[i]
+		if len(eligible) > 0 && !eligible[lineItem.TicketTypeID.String()] {
+			continue
+		}
+
+		var discount float64
+		switch promo.DiscountType {
+		case models.DiscountPercentage:
+			discount = lineItem.Subtotal * promo.DiscountValue / 100
+		case models.DiscountFixed:
+			discount = math.Min(promo.DiscountValue, lineItem.UnitPrice) * float64(lineItem.Quantity)
+		}
+		lineItem.Discount = math.Round(discount*100) / 100
+		total += lineItem.Discount
+	}
+
+	if total <= 0 {
+		return nil, 0, ErrPromoCodeNotApplicable
+	}
+	return promo, math.Round(total*100) / 100, nil
+}
+
+func (s *promoService) RedeemPromoCode(promo *models.PromoCode, payment *models.Payment) error {
+	return s.uow.Do(func(repos repository.TxRepositories) error {
+		if promo.MaxUsesPerUser != nil {
+			used, err := repos.Promos.CountUserRedemptions(promo.ID, payment.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to count redemptions: %w", err)
+			}
+			if used >= int64(*promo.MaxUsesPerUser) {
+				return ErrPromoCodeUserLimit
+			}
+		}
+
+		claimed, err := repos.Promos.ClaimUse(promo.ID)
+		if err != nil {
+			return fmt.Errorf("failed to claim promo code use: %w", err)
+		}
+		if !claimed {
+			return ErrPromoCodeExhausted
+		}
+
+		return repos.Promos.CreateRedemption(&models.PromoRedemption{
+			PromoCodeID:      promo.ID,
+			PaymentID:        payment.ID,
+			PaymentReference: payment.Reference,
+			UserID:           payment.UserID,
+			DiscountAmount:   payment.DiscountAmount,
+			Status:           models.RedemptionPending,
+		})
+	})
+}
+
+func (s *promoService) ReleaseRedemption(reference string) error {
+	return s.uow.Do(func(repos repository.TxRepositories) error {
+		redemption, err := repos.Promos.GetRedemptionByReference(reference)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load promo redemption: %w", err)
+		}
+
+		released, err := repos.Promos.TransitionRedemption(redemption.ID,
+			[]models.PromoRedemptionStatus{models.RedemptionPending}, models.RedemptionReleased)
+		if err != nil {
+			return fmt.Errorf("failed to release promo redemption: %w", err)
+		}
+		if !released {
+			return nil
+		}
+		return repos.Promos.ReturnUse(redemption.PromoCodeID)
+	})
+}
+
+// confirmPromoRedemption confirms the promo redemption on a payment being
+// fulfilled, if it has one. A redemption released by an earlier failure is
+// confirmed anyway, since the buyer has now paid the discounted price.
+func confirmPromoRedemption(repos repository.TxRepositories, reference string) error {
+	redemption, err := repos.Promos.GetRedemptionByReference(reference)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load promo redemption: %w", err)
+	}
+
+	confirmed, err := repos.Promos.TransitionRedemption(redemption.ID,
+		[]models.PromoRedemptionStatus{models.RedemptionPending}, models.RedemptionConfirmed)
+	if err != nil || confirmed {
+		return err
+	}
+
+	reclaimed, err := repos.Promos.TransitionRedemption(redemption.ID,
+		[]models.PromoRedemptionStatus{models.RedemptionReleased}, models.RedemptionConfirmed)
+	if err != nil || !reclaimed {
+		return err
+	}
+	log.Printf("⚠️ PROMO WARNING: Redemption for %s was released before payment completed, counting it again", reference)
+	return repos.Promos.ReclaimUse(redemption.PromoCodeID)
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type ReconciliationService interface {
+	// Reconcile checks payments that have been pending longer than the stale
+	// window against their gateway. Paid ones are fulfilled, failed ones
+	// failed, and ones still unpaid after the expiry window expired. Each
+	// action is recorded; the run's counts are returned.
+	Reconcile() ([]models.ReconciliationSummary, error)
+	// GetReport counts actions since t and lists the discrepancies found.
+	GetReport(since time.Time, page, limit int) ([]models.ReconciliationSummary, []models.PaymentReconciliation, int64, error)
+	// StartScheduler reconciles every interval in the background.
+	StartScheduler(interval time.Duration)
+}
+
+// Payments reconciled per run; the rest wait for the next one
+const reconcileBatchSize = 100
+
+// Gateway statuses for a charge that will never complete
+var failedTransactionStatuses = map[string]bool{"failed": true, "reversed": true, "cancelled": true}
+
+type reconciliationService struct {
+	reconciliationRepo repository.ReconciliationRepository
+	paymentRepo        repository.PaymentRepository
+	userRepo           repository.UserRepository
+	gateways           GatewayRegistry
+	fulfilmentService  FulfilmentService
+	reservationService ReservationService
+	promoService       PromoService
+	emailService       EmailService
+	staleAfter         time.Duration
+	expireAfter        time.Duration
+	mu                 sync.Mutex // Serialises runs within this process
+}
+
+// NewReconciliationService checks payments pending for staleAfter against
+// their gateway, through gateways so tests and local runs can swap in their
+// own, and expires those still unpaid after expireAfter.
+func NewReconciliationService(reconciliationRepo repository.ReconciliationRepository, paymentRepo repository.PaymentRepository, userRepo repository.UserRepository, gateways GatewayRegistry, fulfilmentService FulfilmentService, reservationService ReservationService, promoService PromoService, emailService EmailService, staleAfter, expireAfter time.Duration) ReconciliationService {
+	return &reconciliationService{
+		reconciliationRepo: reconciliationRepo,
+		paymentRepo:        paymentRepo,
+		userRepo:           userRepo,
+		gateways:           gateways,
+		fulfilmentService:  fulfilmentService,
+		reservationService: reservationService,
+		promoService:       promoService,
+		emailService:       emailService,
+		staleAfter:         staleAfter,
+		expireAfter:        expireAfter,
+	}
+}
+
+func (s *reconciliationService) Reconcile() ([]models.ReconciliationSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payments, err := s.reconciliationRepo.GetStalePendingPayments(time.Now().Add(-s.staleAfter), reconcileBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stale payments: %w", err)
+	}
+
+	// Checked payments go to the back of the queue, so the ones the gateway
+	// can't settle yet don't crowd out the rest
+	checked := make([]uuid.UUID, 0, len(payments))
+	for _, payment := range payments {
+		checked = append(checked, payment.ID)
+	}
+	if err := s.reconciliationRepo.MarkReconciled(checked); err != nil {
+		return nil, fmt.Errorf("failed to mark payments reconciled: %w", err)
+	}
+
+	runID := uuid.New()
+	counts := make(map[models.ReconciliationAction]*models.ReconciliationSummary)
+	var summary []models.ReconciliationSummary
+	for i := range payments {
+		record := s.reconcilePayment(&payments[i])
+		if record == nil {
+			continue
+		}
+
+		record.RunID = runID
+		if err := s.reconciliationRepo.Record(record); err != nil {
+			log.Printf("⚠️ RECONCILE WARNING: Failed to record %s for %s: %v", record.Action, record.Reference, err)
+		}
+		if counts[record.Action] == nil {
+			counts[record.Action] = &models.ReconciliationSummary{Action: record.Action}
+		}
+		counts[record.Action].Count++
+		if record.Discrepancy != "" {
+			counts[record.Action].Discrepancies++
+		}
+	}
+	for _, count := range counts {
+		summary = append(summary, *count)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Action < summary[j].Action })
+	return summary, nil
+}
+
+// reconcilePayment settles one stale payment with its gateway's record, and
+// returns what was done, or nil if it was left pending.
+func (s *reconciliationService) reconcilePayment(payment *models.Payment) *models.PaymentReconciliation {
+	record := &models.PaymentReconciliation{
+		PaymentID:      payment.ID,
+		Reference:      payment.Reference,
+		Gateway:        payment.Gateway,
+		PaymentAmount:  payment.Amount,
+		Currency:       payment.Currency,
+		PaymentCreated: payment.CreatedAt,
+	}
+	expired := time.Since(payment.CreatedAt) > s.expireAfter
+
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err != nil {
+		record.Action = models.ReconcileError
+		record.Detail = err.Error()
+		return record
+	}
+
+	transaction, err := gateway.VerifyTransaction(payment.Reference)
+	if err != nil {
+		// Checkouts that never reached the gateway can't be found there
+		if expired {
+			return s.abandon(record, models.PaymentExpired, models.ReconcileExpired, "", fmt.Sprintf("unpaid after %s; gateway lookup failed: %v", s.expireAfter, err))
+		}
+		record.Action = models.ReconcileError
+		record.Detail = fmt.Sprintf("failed to verify transaction: %v", err)
+		return record
+	}
+	record.GatewayStatus = transaction.Status
+
+	switch {
+	case transaction.Status == "success":
+		return s.complete(record, payment, transaction)
+	case failedTransactionStatuses[transaction.Status]:
+		return s.abandon(record, models.PaymentFailed, models.ReconcileFailed,
+			"gateway reported the charge failed but no webhook was processed", transaction.Message)
+	case expired:
+		return s.abandon(record, models.PaymentExpired, models.ReconcileExpired, "",
+			fmt.Sprintf("unpaid after %s (gateway status %s)", s.expireAfter, transaction.Status))
+	}
+	return nil
+}
+
+// complete fulfils a payment the gateway took but we never heard about, or
+// disputes it if the gateway's record doesn't match ours.
+func (s *reconciliationService) complete(record *models.PaymentReconciliation, payment *models.Payment, transaction *GatewayTransaction) *models.PaymentReconciliation {
+	if mismatch := transactionMismatch(payment, transaction); mismatch != "" {
+		moved, err := s.paymentRepo.TransitionPayment(payment.Reference, []models.PaymentStatus{models.PaymentPending}, models.PaymentDisputed, mismatch)
+		if err != nil {
+			record.Action = models.ReconcileError
+			record.Detail = fmt.Sprintf("failed to mark payment disputed: %v", err)
+			return record
+		}
+		if !moved {
+			return nil
+		}
+		log.Printf("⚠️ RECONCILE DISPUTED: %s: %s", payment.Reference, mismatch)
+		record.Action = models.ReconcileDisputed
+		record.Discrepancy = mismatch
+		return record
+	}
+
+	tickets, err := s.fulfilmentService.FulfilPayment(payment)
+	if errors.Is(err, ErrPaymentAlreadyFulfilled) {
+		log.Printf("🔁 RECONCILE SKIPPED: %s was fulfilled concurrently", payment.Reference)
+		return nil
+	}
+	if errors.Is(err, ErrPaymentUnfulfillable) {
+		record.Action = models.ReconcileDisputed
+		record.Discrepancy = "gateway reported success after the tickets sold out"
+		record.Detail = fmt.Sprintf("refunded in full instead of issuing tickets: %v", err)
+		return record
+	}
+	if err != nil {
+		record.Action = models.ReconcileError
+		record.Detail = fmt.Sprintf("gateway reported success but fulfilment failed: %v", err)
+		record.Discrepancy = "gateway reported success but no webhook was processed"
+		return record
+	}
+
+	log.Printf("✅ RECONCILE COMPLETED: %s was paid at %s; issued %d tickets", payment.Reference, payment.Gateway, len(tickets))
+	s.sendPurchaseEmails(payment, tickets)
+	record.Action = models.ReconcileCompleted
+	record.Discrepancy = "gateway reported success but no webhook was processed"
+	record.Detail = fmt.Sprintf("issued %d tickets", len(tickets))
+	return record
+}
+
+// abandon moves a pending payment to status and gives back its ticket hold
+// and promo code use.
+func (s *reconciliationService) abandon(record *models.PaymentReconciliation, status models.PaymentStatus, action models.ReconciliationAction, discrepancy, reason string) *models.PaymentReconciliation {
+	moved, err := s.paymentRepo.TransitionPayment(record.Reference, []models.PaymentStatus{models.PaymentPending}, status, reason)
+	if err != nil {
+		record.Action = models.ReconcileError
+		record.Detail = fmt.Sprintf("failed to mark payment %s: %v", status, err)
+		return record
+	}
+	if !moved {
+		return nil
+	}
+
+	if err := s.reservationService.ReleaseHold(record.Reference); err != nil {
+		log.Printf("⚠️ HOLD WARNING: Failed to release hold for %s: %v", record.Reference, err)
+	}
+	if err := s.promoService.ReleaseRedemption(record.Reference); err != nil {
+		log.Printf("⚠️ PROMO WARNING: Failed to release promo code use for %s: %v", record.Reference, err)
+	}
+
+	log.Printf("🧹 RECONCILE %s: %s: %s", status, record.Reference, reason)
+	record.Action = action
+	record.Discrepancy = discrepancy
+	record.Detail = reason
+	return record
+}
+
+// sendPurchaseEmails sends what the webhook would have: each ticket to its
+// attendee and the host, and the receipt to the buyer.
+func (s *reconciliationService) sendPurchaseEmails(payment *models.Payment, tickets []*models.Ticket) {
+	host, err := s.userRepo.GetUserByID(payment.Event.HostID)
+	if err != nil {
+		log.Printf("⚠️ EMAIL WARNING: Failed to load host for %s: %v", payment.Reference, err)
+	}
+
+	for _, ticket := range tickets {
+		if err := s.emailService.SendTicketConfirmation(ticket, &payment.Event, &payment.User); err != nil {
+			log.Printf("❌ EMAIL ERROR: Failed to send ticket confirmation for ticket %s: %v", ticket.ID, err)
+		}
+		if host == nil {
+			continue
+		}
+		if err := s.emailService.SendHostNotification(ticket, &payment.Event, &payment.User, host); err != nil {
+			log.Printf("❌ EMAIL ERROR: Failed to send host notification for ticket %s: %v", ticket.ID, err)
+		}
+	}
+	if err := s.emailService.SendPaymentReceipt(payment, &payment.Event, &payment.User); err != nil {
+		log.Printf("❌ EMAIL ERROR: Failed to send payment receipt for %s: %v", payment.Reference, err)
+	}
+}
+
+func (s *reconciliationService) GetReport(since time.Time, page, limit int) ([]models.ReconciliationSummary, []models.PaymentReconciliation, int64, error) {
+	summary, err := s.reconciliationRepo.GetSummary(since)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	discrepancies, total, err := s.reconciliationRepo.GetDiscrepancies(since, limit, (page-1)*limit)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return summary, discrepancies, total, nil
+}
+
+func (s *reconciliationService) StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			summary, err := s.Reconcile()
+			if err != nil {
+				log.Printf("❌ RECONCILE SCHEDULER ERROR: %v", err)
+				continue
+			}
+			for _, count := range summary {
+				log.Printf("🗓️ RECONCILE SCHEDULER: %d %s (%d discrepancies)", count.Count, count.Action, count.Discrepancies)
+			}
+		}
+	}()
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+	"gorm.io/gorm"
+)
+
+type RefundService interface {
+	// InitiateRefund refunds part or all of a completed payment. With no amount
+	// and no tickets, the remaining balance is refunded and every active ticket
+	// cancelled. With tickets, those are cancelled and the amount defaults to
+	// what was paid for them. With only an amount, no tickets are cancelled.
+	InitiateRefund(paymentID, initiatedBy uuid.UUID, amount float64, ticketIDs []uuid.UUID, reason string) (*models.Refund, error)
+	// RefundUnfulfilled refunds all of a pending or expired payment that was
+	// paid but can't be fulfilled, e.g. its tickets sold out after its hold
+	// lapsed, and marks it disputed. It returns nil if the payment has
+	// already moved on.
+	RefundUnfulfilled(payment *models.Payment, reason string) (*models.Refund, error)
+	// HandleRefundProcessed settles a pending refund once the gateway confirms
+	// it and emails the buyer. gatewayRefundID may be empty, in which case the
+	// oldest pending refund on the payment is used.
+	HandleRefundProcessed(gatewayRefundID, reference string) error
+	// HandleRefundFailed marks a pending refund failed and takes its amount off
+	// the payment's refunded total. Cancelled tickets stay cancelled; the host
+	// can issue a money-only refund to retry.
+	HandleRefundFailed(gatewayRefundID, reference, reason string) error
+	GetPaymentRefunds(paymentID uuid.UUID) ([]models.Refund, error)
+	GetHostRefunds(hostID uuid.UUID, page, limit int) ([]models.Refund, error)
+}
+
+var (
+	ErrPaymentNotRefundable = errors.New("only completed payments can be refunded")
+	ErrRefundExceedsBalance = errors.New("refund exceeds the amount left on the payment")
+	ErrRefundInvalidTickets = errors.New("tickets are not active tickets on this payment")
+	ErrRefundInvalidAmount  = errors.New("refund amount must be greater than zero")
+	ErrRefundBoxOffice      = errors.New("box office sales are refunded at the desk, not online")
+)
+
+type refundService struct {
+	refundRepo   repository.RefundRepository
+	paymentRepo  repository.PaymentRepository
+	uow          repository.UnitOfWork
+	gateways     GatewayRegistry
+	emailService EmailService
+}
+
+func NewRefundService(refundRepo repository.RefundRepository, paymentRepo repository.PaymentRepository, uow repository.UnitOfWork, gateways GatewayRegistry, emailService EmailService) RefundService {
+	return &refundService{
+		refundRepo:   refundRepo,
+		paymentRepo:  paymentRepo,
+		uow:          uow,
+		gateways:     gateways,
+		emailService: emailService,
+	}
+}
+
+func (s *refundService) InitiateRefund(paymentID, initiatedBy uuid.UUID, amount float64, ticketIDs []uuid.UUID, reason string) (*models.Refund, error) {
+	payment, err := s.paymentRepo.GetPaymentByID(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+	if payment.Status != models.PaymentCompleted {
+		return nil, ErrPaymentNotRefundable
+	}
+	if payment.Gateway == BoxOfficeGateway {
+		return nil, ErrRefundBoxOffice
+	}
+
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	// What each ticket actually cost, after any promo discount
+	unitPrices := make(map[uuid.UUID]float64)
+	for _, lineItem := range payment.LineItems {
+		unitPrices[lineItem.TicketTypeID] = (lineItem.Subtotal - lineItem.Discount) / float64(lineItem.Quantity)
+	}
+
+	fullRefund := amount == 0 && len(ticketIDs) == 0
+
+	// The refund is reserved and recorded before the gateway is asked, so a
+	// refund the gateway makes is never lost to a rollback, and its webhook
+	// always finds the refund row
+	var refund *models.Refund
+	perType := make(map[uuid.UUID][]uuid.UUID)
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		tickets, err := repos.Tickets.GetTicketsByPaymentReference(payment.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to load tickets: %w", err)
+		}
+
+		toCancel, err := selectTicketsToCancel(repos, tickets, ticketIDs, fullRefund)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case fullRefund:
+			amount = payment.Amount - payment.BuyerFee - payment.RefundedAmount
+		case amount == 0:
+			for _, ticket := range toCancel {
+				amount += unitPrices[ticket.TicketTypeID]
+			}
+		}
+		amount = math.Round(amount*100) / 100
+		if amount <= 0 {
+			return ErrRefundInvalidAmount
+		}
+
+		reserved, err := repos.Payments.ReserveRefund(payment.ID, amount)
+		if err != nil {
+			return fmt.Errorf("failed to reserve refund: %w", err)
+		}
+		if !reserved {
+			return ErrRefundExceedsBalance
+		}
+
+		// Cancel the tickets and put them back on sale
+		cancelIDs := make([]uuid.UUID, 0, len(toCancel))
+		refundTicketIDs := make([]string, 0, len(toCancel))
+		for _, ticket := range toCancel {
+			cancelIDs = append(cancelIDs, ticket.ID)
+			perType[ticket.TicketTypeID] = append(perType[ticket.TicketTypeID], ticket.ID)
+			refundTicketIDs = append(refundTicketIDs, ticket.ID.String())
+		}
+		if _, err := repos.Attendees.CancelByTicketIDs(cancelIDs); err != nil {
+			return fmt.Errorf("failed to cancel tickets: %w", err)
+		}
+		for ticketTypeID, cancelled := range perType {
+			if err := repos.Tickets.UpdateSoldQuantity(ticketTypeID, -len(cancelled)); err != nil {
+				return fmt.Errorf("failed to return inventory: %w", err)
+			}
+		}
+
+		refund = &models.Refund{
+			PaymentID:        payment.ID,
+			PaymentReference: payment.Reference,
+			Amount:           amount,
+			Currency:         payment.Currency,
+			Reason:           reason,
+			Status:           models.RefundPending,
+			TicketIDs:        refundTicketIDs,
+			InitiatedBy:      initiatedBy,
+		}
+		if err := repos.Refunds.Create(refund); err != nil {
+			return err
+		}
+		return postRefund(repos.Ledger, refund, payment, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sendRefund(gateway, refund, payment, perType); err != nil {
+		return nil, err
+	}
+
+	log.Printf("💸 REFUND INITIATED: %.2f %s on payment %s (%d tickets cancelled)", refund.Amount, refund.Currency, payment.Reference, len(refund.TicketIDs))
+	return refund, nil
+}
+
+func (s *refundService) RefundUnfulfilled(payment *models.Payment, reason string) (*models.Refund, error) {
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	var refund *models.Refund
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		moved, err := repos.Payments.MarkUnfulfillable(payment.Reference,
+			[]models.PaymentStatus{models.PaymentPending, models.PaymentFailed, models.PaymentExpired}, reason)
+		if err != nil {
+			return fmt.Errorf("failed to mark payment unfulfillable: %w", err)
+		}
+		if !moved {
+			return nil
+		}
+
+		refund = &models.Refund{
+			PaymentID:        payment.ID,
+			PaymentReference: payment.Reference,
+			Amount:           payment.Amount,
+			Currency:         payment.Currency,
+			Reason:           reason,
+			Status:           models.RefundPending,
+			InitiatedBy:      uuid.Nil, // Refunded automatically
+			Unfulfilled:      true,
+		}
+		if err := repos.Refunds.Create(refund); err != nil {
+			return err
+		}
+		if err := postUnfulfilled(repos.Ledger, payment, time.Now()); err != nil {
+			return err
+		}
+		return postRefund(repos.Ledger, refund, payment, time.Now())
+	})
+	if err != nil || refund == nil {
+		return nil, err
+	}
+
+	if err := s.sendRefund(gateway, refund, payment, nil); err != nil {
+		return nil, err
+	}
+
+	log.Printf("💸 REFUND INITIATED: %.2f %s on unfulfilled payment %s: %s", refund.Amount, refund.Currency, payment.Reference, reason)
+	return refund, nil
+}
+
+// sendRefund asks the gateway to make a refund already recorded as pending.
+// A refusal fails the refund and reverses it, reinstating the tickets in
+// perType; with no answer the refund is left pending for its webhook.
+func (s *refundService) sendRefund(gateway PaymentGateway, refund *models.Refund, payment *models.Payment, perType map[uuid.UUID][]uuid.UUID) error {
+	gatewayRefund, err := gateway.Refund(payment.Reference, int64(math.Round(refund.Amount*100)), refund.Reason)
+	switch {
+	case errors.Is(err, ErrGatewayRejected):
+		if reverseErr := s.reverseRejectedRefund(refund, payment, perType, err.Error()); reverseErr != nil {
+			log.Printf("❌ REFUND ERROR: Failed to reverse refused refund %s on payment %s: %v", refund.ID, payment.Reference, reverseErr)
+		}
+		return fmt.Errorf("gateway refused refund: %w", err)
+	case err != nil:
+		// The gateway may still have made the refund; its webhook settles or fails it
+		log.Printf("⚠️ REFUND WARNING: No answer from %s for refund %s on payment %s, leaving it pending: %v", payment.Gateway, refund.ID, payment.Reference, err)
+	default:
+		refund.GatewayRefundID = gatewayRefund.ID
+		if err := s.refundRepo.SetGatewayRefundID(refund.ID, gatewayRefund.ID); err != nil {
+			// Its webhook still finds the refund by payment reference
+			log.Printf("⚠️ REFUND WARNING: Failed to record gateway refund %s for refund %s: %v", gatewayRefund.ID, refund.ID, err)
+		}
+	}
+	return nil
+}
+
+// reverseRejectedRefund fails a refund the gateway refused outright, gives
+// its amount back to the payment and host, and reinstates the tickets it
+// cancelled where they haven't been sold on since.
+func (s *refundService) reverseRejectedRefund(refund *models.Refund, payment *models.Payment, perType map[uuid.UUID][]uuid.UUID, reason string) error {
+	// Lock ticket types in a fixed order so concurrent checkouts can't deadlock
+	ticketTypeIDs := make([]uuid.UUID, 0, len(perType))
+	for ticketTypeID := range perType {
+		ticketTypeIDs = append(ticketTypeIDs, ticketTypeID)
+	}
+	sort.Slice(ticketTypeIDs, func(i, j int) bool {
+		return ticketTypeIDs[i].String() < ticketTypeIDs[j].String()
+	})
+
+	return s.uow.Do(func(repos repository.TxRepositories) error {
+		moved, err := repos.Refunds.MarkFailed(refund.ID, reason)
+		if err != nil {
+			return fmt.Errorf("failed to mark refund failed: %w", err)
+		}
+		if !moved {
+			return nil
+		}
+		if err := repos.Payments.ReleaseRefund(refund.PaymentID, refund.Amount); err != nil {
+			return err
+		}
+		if err := postRefundReversed(repos.Ledger, refund, payment, time.Now()); err != nil {
+			return err
+		}
+
+		for _, ticketTypeID := range ticketTypeIDs {
+			cancelled := perType[ticketTypeID]
+			if _, err := checkCapacity(repos, payment.UserID, ticketTypeID, len(cancelled)); err != nil {
+				if errors.Is(err, ErrTicketsUnavailable) {
+					log.Printf("⚠️ REFUND WARNING: %d tickets on refused refund %s stay cancelled: %v", len(cancelled), refund.ID, err)
+					continue
+				}
+				return err
+			}
+			reinstated, err := repos.Attendees.ReinstateByTicketIDs(cancelled)
+			if err != nil {
+				return fmt.Errorf("failed to reinstate tickets: %w", err)
+			}
+			if err := repos.Tickets.UpdateSoldQuantity(ticketTypeID, int(reinstated)); err != nil {
+				return fmt.Errorf("failed to take back inventory: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// selectTicketsToCancel picks the tickets a refund cancels. A full refund takes
+// every active ticket; otherwise each requested ticket must be on the payment
+// and still active.
+func selectTicketsToCancel(repos repository.TxRepositories, tickets []*models.Ticket, ticketIDs []uuid.UUID, fullRefund bool) ([]*models.Ticket, error) {
+	if !fullRefund && len(ticketIDs) == 0 {
+		return nil, nil
+	}
+
+	allIDs := make([]uuid.UUID, 0, len(tickets))
+	for _, ticket := range tickets {
+		allIDs = append(allIDs, ticket.ID)
+	}
+	attendees, err := repos.Attendees.GetByTicketIDs(allIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attendees: %w", err)
+	}
+	active := make(map[uuid.UUID]bool)
+	for _, attendee := range attendees {
+		if attendee.Status == models.AttendeeActive {
+			active[attendee.TicketID] = true
+		}
+	}
+
+	var selected []*models.Ticket
+	if fullRefund {
+		for _, ticket := range tickets {
+			if active[ticket.ID] {
+				selected = append(selected, ticket)
+			}
+		}
+		return selected, nil
+	}
+
+	byID := make(map[uuid.UUID]*models.Ticket)
+	for _, ticket := range tickets {
+		byID[ticket.ID] = ticket
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range ticketIDs {
+		ticket, ok := byID[id]
+		if !ok || !active[id] || seen[id] {
+			return nil, ErrRefundInvalidTickets
+		}
+		seen[id] = true
+		selected = append(selected, ticket)
+	}
+	return selected, nil
+}
+
+func (s *refundService) findRefund(gatewayRefundID, reference string) (*models.Refund, error) {
+	if gatewayRefundID != "" {
+		if refund, err := s.refundRepo.GetByGatewayRefundID(gatewayRefundID); err == nil {
+			return refund, nil
+		}
+	}
+	return s.refundRepo.GetOldestPendingByReference(reference)
+}
+
+func (s *refundService) HandleRefundProcessed(gatewayRefundID, reference string) error {
+	refund, err := s.findRefund(gatewayRefundID, reference)
+	if err != nil {
+		return fmt.Errorf("refund not found for %s: %w", reference, err)
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByID(refund.PaymentID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+
+	var moved, refunded bool
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		// Concurrent refunds on the payment settle one at a time, so the
+		// last to settle sees the others and its refunded total as they are
+		locked, err := repos.Payments.GetPaymentForUpdate(payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock payment: %w", err)
+		}
+
+		moved, err = repos.Refunds.MarkProcessed(refund.ID)
+		if err != nil {
+			return fmt.Errorf("failed to mark refund processed: %w", err)
+		}
+		if !moved {
+			return nil
+		}
+		if err := postRefundSettled(repos.Ledger, refund, payment, time.Now()); err != nil {
+			return err
+		}
+
+		// The payment is refunded once everything but its fees has been returned
+		if locked.Status != models.PaymentCompleted || locked.RefundedAmount < locked.Amount-locked.BuyerFee-0.005 {
+			return nil
+		}
+		_, err = repos.Refunds.GetOldestPendingByReference(payment.Reference)
+		if err == nil {
+			// Another refund is still out
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check pending refunds: %w", err)
+		}
+		refunded, err = repos.Payments.TransitionPayment(payment.Reference, []models.PaymentStatus{models.PaymentCompleted}, models.PaymentRefunded, "")
+		if err != nil {
+			return fmt.Errorf("failed to mark payment refunded: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !moved {
+		log.Printf("🔁 REFUND SKIPPED: Refund %s is already %s", refund.ID, refund.Status)
+		return nil
+	}
+	if refunded {
+		payment.Status = models.PaymentRefunded
+	}
+
+	if err := s.emailService.SendRefundNotification(refund, payment, &payment.Event, &payment.User); err != nil {
+		log.Printf("❌ EMAIL ERROR: Failed to send refund notification for refund %s: %v", refund.ID, err)
+	}
+
+	log.Printf("✅ REFUND PROCESSED: %.2f %s refunded on payment %s", refund.Amount, refund.Currency, payment.Reference)
+	return nil
+}
+
+func (s *refundService) HandleRefundFailed(gatewayRefundID, reference, reason string) error {
+	refund, err := s.findRefund(gatewayRefundID, reference)
+	if err != nil {
+		return fmt.Errorf("refund not found for %s: %w", reference, err)
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByID(refund.PaymentID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+
+	return s.uow.Do(func(repos repository.TxRepositories) error {
+		moved, err := repos.Refunds.MarkFailed(refund.ID, reason)
+		if err != nil {
+			return fmt.Errorf("failed to mark refund failed: %w", err)
+		}
+		if !moved {
+			return nil
+		}
+
+		log.Printf("💔 REFUND FAILED: Refund %s on payment %s failed: %s", refund.ID, refund.PaymentReference, reason)
+		if err := repos.Payments.ReleaseRefund(refund.PaymentID, refund.Amount); err != nil {
+			return err
+		}
+		return postRefundReversed(repos.Ledger, refund, payment, time.Now())
+	})
+}
+
+func (s *refundService) GetPaymentRefunds(paymentID uuid.UUID) ([]models.Refund, error) {
+	return s.refundRepo.GetByPaymentID(paymentID)
+}
+
+func (s *refundService) GetHostRefunds(hostID uuid.UUID, page, limit int) ([]models.Refund, error) {
+	offset := (page - 1) * limit
+	return s.refundRepo.GetByHostID(hostID, limit, offset)
+}
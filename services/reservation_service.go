@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type ReservationService interface {
+	// HoldTickets reserves the line items' quantities for the given payment
+	// reference until the hold TTL elapses. Either every line item is held or
+	// none is. Holds past a ticket type's per-person or one-per-contact limits
+	// fail with ErrPurchaseLimit. The buyer's waitlist offers on these ticket
+	// types are used up, their tickets going towards this hold.
+	HoldTickets(eventID, userID uuid.UUID, reference string, lineItems []models.PaymentLineItem, attendees []models.PaymentAttendee) (time.Time, error)
+	ReleaseHold(reference string) error
+	ReleaseExpired() (int64, error)
+	// GetHeldQuantities returns the active held quantity per ticket type.
+	GetHeldQuantities(ticketTypeIDs []uuid.UUID) (map[uuid.UUID]int, error)
+	// StartSweeper releases expired holds every interval in the background.
+	StartSweeper(interval time.Duration)
+}
+
+// ErrTicketsUnavailable is returned when a ticket type doesn't have enough
+// unsold, unheld inventory for a request.
+var ErrTicketsUnavailable = errors.New("not enough tickets available")
+
+type reservationService struct {
+	reservationRepo repository.ReservationRepository
+	uow             repository.UnitOfWork
+	holdTTL         time.Duration
+}
+
+func NewReservationService(reservationRepo repository.ReservationRepository, uow repository.UnitOfWork, holdTTL time.Duration) ReservationService {
+	return &reservationService{
+		reservationRepo: reservationRepo,
+		uow:             uow,
+		holdTTL:         holdTTL,
+	}
+}
+
+func (s *reservationService) HoldTickets(eventID, userID uuid.UUID, reference string, lineItems []models.PaymentLineItem, attendees []models.PaymentAttendee) (time.Time, error) {
+	expiresAt := time.Now().Add(s.holdTTL)
+	named := lineItemAttendees(lineItems, attendees)
+
+	// Lock ticket types in a fixed order so concurrent checkouts can't deadlock
+	order := make([]int, len(lineItems))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return lineItems[order[i]].TicketTypeID.String() < lineItems[order[j]].TicketTypeID.String()
+	})
+
+	ticketTypeIDs := make([]uuid.UUID, 0, len(lineItems))
+	for _, i := range order {
+		ticketTypeIDs = append(ticketTypeIDs, lineItems[i].TicketTypeID)
+	}
+
+	err := s.uow.Do(func(repos repository.TxRepositories) error {
+		if err := claimWaitlistOffers(repos, eventID, userID, ticketTypeIDs, reference); err != nil {
+			return err
+		}
+		for _, i := range order {
+			item := lineItems[i]
+			if err := checkAvailability(repos, userID, item.TicketTypeID, item.Quantity, named[i]); err != nil {
+				return err
+			}
+
+			emails, phones := attendeeContacts(named[i])
+			reservation := &models.TicketReservation{
+				TicketTypeID:     item.TicketTypeID,
+				EventID:          eventID,
+				UserID:           userID,
+				PaymentReference: reference,
+				Quantity:         item.Quantity,
+				Status:           models.ReservationActive,
+				ExpiresAt:        expiresAt,
+				AttendeeEmails:   emails,
+				AttendeePhones:   phones,
+			}
+			if err := repos.Reservations.Create(reservation); err != nil {
+				return fmt.Errorf("failed to hold %s: %w", item.TicketTypeName, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return expiresAt, nil
+}
+
+// lineItemAttendees splits an order's named attendees between its line items.
+// Tickets are issued in line item order, so the nth ticket is the nth
+// attendee's.
+func lineItemAttendees(lineItems []models.PaymentLineItem, attendees []models.PaymentAttendee) [][]models.PaymentAttendee {
+	byPosition := make(map[int]models.PaymentAttendee, len(attendees))
+	for _, attendee := range attendees {
+		byPosition[attendee.Position] = attendee
+	}
+
+	named := make([][]models.PaymentAttendee, len(lineItems))
+	position := 0
+	for i, item := range lineItems {
+		for j := 0; j < item.Quantity; j++ {
+			if attendee, ok := byPosition[position]; ok {
+				named[i] = append(named[i], attendee)
+			}
+			position++
+		}
+	}
+	return named
+}
+
+// checkAvailability locks the ticket type and checks that quantity more can be
+// sold once existing sales and active holds are accounted for, and that the
+// buyer and the tickets' attendees stay within the type's per-person and
+// one-per-contact limits.
+func checkAvailability(repos repository.TxRepositories, userID, ticketTypeID uuid.UUID, quantity int, attendees []models.PaymentAttendee) error {
+	ticketType, err := checkCapacity(repos, userID, ticketTypeID, quantity)
+	if err != nil {
+		return err
+	}
+
+	// Holds are counted under the ticket type's lock, so concurrent checkouts can't both slip under the limit
+	if ticketType.MaxPerUser > 0 {
+		owned, err := repos.Tickets.CountUserTickets(userID, ticketTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to count tickets: %w", err)
+		}
+		userHeld, err := repos.Reservations.GetUserHeldQuantity(userID, ticketTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to load holds: %w", err)
+		}
+		if int(owned)+userHeld+quantity > ticketType.MaxPerUser {
+			return fmt.Errorf("%w: at most %d %s per person, and you already have or are buying %d",
+				ErrPurchaseLimit, ticketType.MaxPerUser, ticketType.Name, int(owned)+userHeld)
+		}
+	}
+	return checkOnePerContact(repos, ticketType, userID, quantity, attendees)
+}
+
+// checkCapacity locks a ticket type and checks quantity more of it are left
+// for userID, counting active holds as taken. Tickets freed while others are
+// on the waitlist are kept for them until they're offered, so waiting
+// entries count as taken too, except userID's own; pass uuid.Nil when no
+// buyer is on the waitlist. The lock is held until the unit of work ends.
+func checkCapacity(repos repository.TxRepositories, userID, ticketTypeID uuid.UUID, quantity int) (*models.TicketType, error) {
+	ticketType, err := repos.Tickets.GetTicketTypeForUpdate(ticketTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock ticket type: %w", err)
+	}
+
+	held, err := repos.Reservations.GetHeldQuantity(ticketTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holds: %w", err)
+	}
+	waiting, err := repos.Waitlist.GetWaitingQuantity(ticketTypeID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load waitlist: %w", err)
+	}
+
+	available := ticketType.TotalQuantity - ticketType.SoldQuantity - held - waiting
+	if quantity > available {
+		return nil, fmt.Errorf("%w: only %d %s left", ErrTicketsUnavailable, max(available, 0), ticketType.Name)
+	}
+	return ticketType, nil
+}
+
+func (s *reservationService) ReleaseHold(reference string) error {
+	_, err := s.reservationRepo.ReleaseByReference(reference)
+	return err
+}
+
+func (s *reservationService) ReleaseExpired() (int64, error) {
+	return s.reservationRepo.ReleaseExpired(time.Now())
+}
+
+func (s *reservationService) GetHeldQuantities(ticketTypeIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	return s.reservationRepo.GetHeldQuantities(ticketTypeIDs)
+}
+
+func (s *reservationService) StartSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			released, err := s.ReleaseExpired()
+			if err != nil {
+				log.Printf("❌ HOLD SWEEPER ERROR: Failed to release expired holds: %v", err)
+				continue
+			}
+			if released > 0 {
+				log.Printf("🧹 HOLD SWEEPER: Released %d expired ticket holds", released)
+			}
+		}
+	}()
+}
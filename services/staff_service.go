@@ -0,0 +1,255 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+	"gorm.io/gorm"
+)
+
+// StaffPermission is something event staff may be allowed to do. An
+// event's host can do all of them.
+type StaffPermission string
+
+const (
+	PermCheckIn       StaffPermission = "check_in"       // Check tickets in, online or from a scanner
+	PermViewAttendees StaffPermission = "view_attendees" // Look attendees up
+	PermViewScans     StaffPermission = "view_scans"     // Review the check-in log and its conflicts
+	PermUndoCheckIn   StaffPermission = "undo_check_in"  // Take back a check-in made by mistake
+	PermSellTickets   StaffPermission = "sell_tickets"   // Sell tickets at the box office for cash or POS
+	PermCompTickets   StaffPermission = "comp_tickets"   // Give tickets away at the box office
+	PermManageStaff   StaffPermission = "manage_staff"   // Invite and remove scanners and box office staff
+)
+
+var staffPermissions = map[models.StaffRole][]StaffPermission{
+	models.StaffScanner:   {PermCheckIn, PermViewAttendees},
+	models.StaffBoxOffice: {PermCheckIn, PermViewAttendees, PermViewScans, PermSellTickets},
+	models.StaffCoManager: {PermCheckIn, PermViewAttendees, PermViewScans, PermUndoCheckIn, PermSellTickets, PermCompTickets, PermManageStaff},
+}
+
+// RoleAllows reports whether a staff role carries perm.
+func RoleAllows(role models.StaffRole, perm StaffPermission) bool {
+	for _, allowed := range staffPermissions[role] {
+		if allowed == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type StaffService interface {
+	// Authorize reports whether userID may do perm at event, as its host or
+	// as active staff whose role allows it.
+	Authorize(event *models.Event, userID uuid.UUID, perm StaffPermission) (bool, error)
+
+	// InviteStaff emails email an invite to join event's staff in role.
+	// Only the host can invite co-managers.
+	InviteStaff(event *models.Event, inviter *models.User, email string, role models.StaffRole) (*models.EventStaff, error)
+	GetEventStaff(eventID uuid.UUID) ([]models.EventStaff, error)
+	GetStaffMember(id uuid.UUID) (*models.EventStaff, error)
+	// UpdateRole changes a staff member's role. Only the host can make or
+	// change co-managers.
+	UpdateRole(event *models.Event, callerID uuid.UUID, staff *models.EventStaff, role models.StaffRole) error
+	// RemoveStaff withdraws an invite or takes an active staff member off the
+	// event. Only the host can remove co-managers.
+	RemoveStaff(event *models.Event, callerID uuid.UUID, staff *models.EventStaff) error
+
+	// GetInvites returns the unexpired staff invites sent to user's email.
+	GetInvites(user *models.User) ([]models.EventStaff, error)
+	AcceptInvite(token string, user *models.User) (*models.EventStaff, error)
+	DeclineInvite(token string, user *models.User) error
+	// GetStaffedEvents returns user's active staff roles, with their events.
+	GetStaffedEvents(userID uuid.UUID) ([]models.EventStaff, error)
+}
+
+var (
+	ErrStaffInvalid     = errors.New("invalid staff invite")
+	ErrStaffNotAllowed  = errors.New("only the host can manage co-managers")
+	ErrStaffExists      = errors.New("already invited to or on this event's staff")
+	ErrStaffNotFound    = errors.New("staff invite not found")
+	ErrStaffNotPending  = errors.New("staff invite is no longer pending")
+	ErrStaffNotEditable = errors.New("staff member has already left the event")
+)
+
+type staffService struct {
+	staffRepo    repository.EventStaffRepository
+	emailService EmailService
+	inviteTTL    time.Duration
+}
+
+// NewStaffService keeps staff invites open for inviteTTL.
+func NewStaffService(staffRepo repository.EventStaffRepository, emailService EmailService, inviteTTL time.Duration) StaffService {
+	return &staffService{
+		staffRepo:    staffRepo,
+		emailService: emailService,
+		inviteTTL:    inviteTTL,
+	}
+}
+
+func (s *staffService) Authorize(event *models.Event, userID uuid.UUID, perm StaffPermission) (bool, error) {
+	if event.HostID == userID {
+		return true, nil
+	}
+	staff, err := s.staffRepo.GetActive(event.ID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Not on the staff
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("❌ STAFF ERROR: Failed to load staff membership of %s for event %s: %v", userID, event.ID, err)
+		return false, fmt.Errorf("failed to load staff membership: %w", err)
+	}
+	return RoleAllows(staff.Role, perm), nil
+}
+
+// checkRole checks role is a staff role callerID may hand out at event.
+func checkRole(event *models.Event, callerID uuid.UUID, role models.StaffRole) error {
+	if _, ok := staffPermissions[role]; !ok {
+		return fmt.Errorf("%w: role must be 'scanner', 'box_office' or 'co_manager'", ErrStaffInvalid)
+	}
+	if role == models.StaffCoManager && callerID != event.HostID {
+		return ErrStaffNotAllowed
+	}
+	return nil
+}
+
+func (s *staffService) InviteStaff(event *models.Event, inviter *models.User, email string, role models.StaffRole) (*models.EventStaff, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, fmt.Errorf("%w: email is required", ErrStaffInvalid)
+	}
+	if err := checkRole(event, inviter.ID, role); err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(email, event.Host.Email) {
+		return nil, fmt.Errorf("%w: the host already runs this event", ErrStaffInvalid)
+	}
+	if _, err := s.staffRepo.GetOpenByEmail(event.ID, email); err == nil {
+		return nil, ErrStaffExists
+	}
+
+	token, err := newLinkToken()
+	if err != nil {
+		return nil, err
+	}
+	staff := &models.EventStaff{
+		EventID:   event.ID,
+		Email:     email,
+		Role:      role,
+		Status:    models.StaffInvited,
+		Token:     token,
+		InvitedBy: inviter.ID,
+		ExpiresAt: time.Now().Add(s.inviteTTL),
+	}
+	if err := s.staffRepo.Create(staff); err != nil {
+		return nil, err
+	}
+	log.Printf("🪪 STAFF INVITED: %s as %s for event %s by %s", email, role, event.ID, inviter.Email)
+
+	if err := s.emailService.SendStaffInvite(staff, event, inviter); err != nil {
+		log.Printf("❌ EMAIL ERROR: Failed to send staff invite %s to %s: %v", staff.ID, email, err)
+	}
+	return staff, nil
+}
+
+func (s *staffService) GetEventStaff(eventID uuid.UUID) ([]models.EventStaff, error) {
+	return s.staffRepo.GetByEventID(eventID)
+}
+
+func (s *staffService) GetStaffMember(id uuid.UUID) (*models.EventStaff, error) {
+	return s.staffRepo.GetByID(id)
+}
+
+func (s *staffService) UpdateRole(event *models.Event, callerID uuid.UUID, staff *models.EventStaff, role models.StaffRole) error {
+	if staff.Status != models.StaffInvited && staff.Status != models.StaffActive {
+		return ErrStaffNotEditable
+	}
+	if staff.Role == models.StaffCoManager && callerID != event.HostID {
+		return ErrStaffNotAllowed
+	}
+	if err := checkRole(event, callerID, role); err != nil {
+		return err
+	}
+	if err := s.staffRepo.UpdateRole(staff.ID, role); err != nil {
+		return err
+	}
+	staff.Role = role
+	return nil
+}
+
+func (s *staffService) RemoveStaff(event *models.Event, callerID uuid.UUID, staff *models.EventStaff) error {
+	if staff.Role == models.StaffCoManager && callerID != event.HostID {
+		return ErrStaffNotAllowed
+	}
+	removed, err := s.staffRepo.Transition(staff.ID, []models.StaffStatus{models.StaffInvited, models.StaffActive}, models.StaffRemoved)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrStaffNotEditable
+	}
+	log.Printf("🪪 STAFF REMOVED: %s from event %s", staff.Email, event.ID)
+	return nil
+}
+
+func (s *staffService) GetInvites(user *models.User) ([]models.EventStaff, error) {
+	return s.staffRepo.GetPendingByEmail(user.Email)
+}
+
+// loadInvite loads the invite carrying token, provided it was sent to user.
+func (s *staffService) loadInvite(token string, user *models.User) (*models.EventStaff, error) {
+	staff, err := s.staffRepo.GetByToken(strings.TrimSpace(token))
+	if err != nil || !strings.EqualFold(staff.Email, user.Email) {
+		return nil, ErrStaffNotFound
+	}
+	if staff.Status != models.StaffInvited || !time.Now().Before(staff.ExpiresAt) {
+		return nil, ErrStaffNotPending
+	}
+	return staff, nil
+}
+
+func (s *staffService) AcceptInvite(token string, user *models.User) (*models.EventStaff, error) {
+	staff, err := s.loadInvite(token, user)
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := s.staffRepo.MarkAccepted(staff.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrStaffNotPending
+	}
+	log.Printf("🪪 STAFF JOINED: %s as %s for event %s", user.Email, staff.Role, staff.EventID)
+
+	now := time.Now()
+	staff.Status = models.StaffActive
+	staff.UserID = &user.ID
+	staff.AcceptedAt = &now
+	return staff, nil
+}
+
+func (s *staffService) DeclineInvite(token string, user *models.User) error {
+	staff, err := s.loadInvite(token, user)
+	if err != nil {
+		return err
+	}
+	declined, err := s.staffRepo.Transition(staff.ID, []models.StaffStatus{models.StaffInvited}, models.StaffDeclined)
+	if err != nil {
+		return err
+	}
+	if !declined {
+		return ErrStaffNotPending
+	}
+	return nil
+}
+
+func (s *staffService) GetStaffedEvents(userID uuid.UUID) ([]models.EventStaff, error) {
+	return s.staffRepo.GetActiveByUserID(userID)
+}
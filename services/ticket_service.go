@@ -1,11 +1,16 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hidenkeys/motiv-backend/models"
 	"github.com/hidenkeys/motiv-backend/repository"
+	"gorm.io/gorm"
 )
 
 type TicketService interface {
@@ -13,23 +18,82 @@ type TicketService interface {
 	CreateTicketWithQR(ticket *models.Ticket) error
 	GetTicketsByUserID(userID uuid.UUID) ([]*models.Ticket, error)
 	GetTicketByID(id uuid.UUID) (*models.Ticket, error)
+	// AssignAttendee names who a ticket issued without an attendee is for.
+	// Tickets already named, or cancelled by a refund, can't be reassigned.
+	AssignAttendee(ticketID uuid.UUID, attendee *models.AttendeeDataRequest) (*models.Ticket, error)
+	// CountUserEventTickets counts a buyer's uncancelled tickets for an event.
+	CountUserEventTickets(userID, eventID uuid.UUID) (int64, error)
+	// CheckPurchaseLimits checks an order of quantity tickets of one type
+	// against its per-order limits and one-per-email or phone rules. attendees
+	// are the order's named attendees for this type. The per-person limit
+	// across orders is checked when the tickets are held or issued.
+	CheckPurchaseLimits(ticketType *models.TicketType, quantity int, attendees []models.PaymentAttendee) error
 
 	// Ticket Type methods
 	CreateTicketType(ticketType *models.TicketType) error
 	GetTicketTypesByEventID(eventID uuid.UUID) ([]*models.TicketType, error)
 	GetTicketTypeByID(ticketTypeID uuid.UUID) (*models.TicketType, error)
 	UpdateSoldQuantity(ticketTypeID uuid.UUID, quantity int) error
+	UpdateTicketTypeLimits(ticketType *models.TicketType, req *models.TicketTypeLimitsRequest) error
+	// UpdateTicketTypeSales sets a ticket type's sale window and the tier it follows.
+	UpdateTicketTypeSales(ticketType *models.TicketType, req *models.TicketTypeSalesRequest) error
+	// UpdateTicketTypeVisibility sets whether a ticket type is listed
+	// publicly or only with an access code that unlocks it.
+	UpdateTicketTypeVisibility(ticketType *models.TicketType, visibility string) error
+	// UpdateTicketTypeEntry sets how many times a ticket type gets in at the
+	// door. A ticket's earlier scans are counted again under the new rules
+	// the next time it's scanned.
+	UpdateTicketTypeEntry(ticketType *models.TicketType, req *models.TicketTypeEntryRequest) error
+	// UpdateTicketTypeCapacity sets how many tickets of a type there are in
+	// total. It can't drop below those already sold or held.
+	UpdateTicketTypeCapacity(ticketType *models.TicketType, total int) error
+
+	// SchedulePriceChange sets a ticket type's price to change at a future time.
+	SchedulePriceChange(ticketType *models.TicketType, req *models.TicketPriceChangeRequest) (*models.TicketPriceChange, error)
+	GetPriceChanges(ticketTypeID uuid.UUID) ([]models.TicketPriceChange, error)
+	// CancelPriceChange deletes a price change that hasn't been applied yet.
+	CancelPriceChange(ticketTypeID, changeID uuid.UUID) error
+	// ApplyDuePriceChanges applies every price change that has come into
+	// effect, and returns how many it applied.
+	ApplyDuePriceChanges() (int, error)
+	// StartPriceScheduler applies due price changes every interval in the background.
+	StartPriceScheduler(interval time.Duration)
+
+	// ReissueQRCodes re-signs every ticket QR code not signed with the current
+	// key, i.e. unsigned legacy codes and codes from retired keys, and returns
+	// how many it replaced. Each re-issued ticket is passed to onReissue, if
+	// set, so its holder can be sent the new code. It is safe to run repeatedly.
+	ReissueQRCodes(onReissue func(ticket *models.Ticket)) (int, error)
 }
 
+var (
+	ErrTicketAttendeeNamed = errors.New("ticket already has a named attendee")
+	ErrTicketCancelled     = errors.New("ticket has been cancelled")
+	// ErrPurchaseLimit is returned when an order breaks a ticket type's purchase limits.
+	ErrPurchaseLimit = errors.New("purchase limit exceeded")
+	// ErrTicketTypeLimitsInvalid is returned for limits that can't be satisfied.
+	ErrTicketTypeLimitsInvalid = errors.New("invalid purchase limits")
+	ErrTicketSalesInvalid      = errors.New("invalid sale window")
+	ErrPriceChangeInvalid      = errors.New("invalid price change")
+	ErrPriceChangeNotFound     = errors.New("price change not found or already applied")
+	ErrTicketVisibilityInvalid = errors.New("visibility must be 'public' or 'hidden'")
+	ErrTicketCapacityInvalid   = errors.New("invalid capacity")
+	ErrTicketEntryInvalid      = errors.New("entry limit can't be negative")
+)
+
 type ticketService struct {
 	ticketRepo   repository.TicketRepository
 	attendeeRepo repository.AttendeeRepository
+	uow          repository.UnitOfWork
+	signer       TicketSigner
 }
 
-func NewTicketService(ticketRepo repository.TicketRepository, attendeeRepo repository.AttendeeRepository) TicketService {
+func NewTicketService(ticketRepo repository.TicketRepository, attendeeRepo repository.AttendeeRepository, uow repository.UnitOfWork, signer TicketSigner) TicketService {
 	return &ticketService{
 		ticketRepo:   ticketRepo,
 		attendeeRepo: attendeeRepo,
+		uow:          uow,
+		signer:       signer,
 	}
 }
 
@@ -39,6 +103,22 @@ func (s *ticketService) PurchaseTicket(ticket *models.Ticket) error {
 }
 
 func (s *ticketService) CreateTicketWithQR(ticket *models.Ticket) error {
+	return issueTicket(s.ticketRepo, s.attendeeRepo, s.signer, ticket)
+}
+
+// attendeeAccount returns the user signed up with email, or nil if there is
+// none.
+func attendeeAccount(userRepo repository.UserRepository, email string) (*models.User, error) {
+	user, err := userRepo.GetUserByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// issueTicket creates a ticket, stamps its signed QR code and creates the
+// matching attendee record. Pass transaction-bound repositories to make it atomic.
+func issueTicket(ticketRepo repository.TicketRepository, attendeeRepo repository.AttendeeRepository, signer TicketSigner, ticket *models.Ticket) error {
 	// Validate that EventID and UserID are not nil
 	if ticket.EventID == uuid.Nil {
 		return fmt.Errorf("event ID cannot be nil")
@@ -51,17 +131,16 @@ func (s *ticketService) CreateTicketWithQR(ticket *models.Ticket) error {
 	}
 
 	// First create the ticket to get the ID
-	err := s.ticketRepo.CreateTicket(ticket)
+	err := ticketRepo.CreateTicket(ticket)
 	if err != nil {
 		return fmt.Errorf("failed to create ticket: %w", err)
 	}
 
-	// Now generate QR code data with the actual ticket ID
-	qrData := fmt.Sprintf("MOTIV-TICKET:%s:%s:%s", ticket.ID.String(), ticket.EventID.String(), ticket.UserID.String())
-	ticket.QRCode = qrData
+	// Now sign QR code data with the actual ticket ID
+	ticket.QRCode = signer.Sign(ticket)
 
 	// Update the ticket with the QR code
-	err = s.ticketRepo.UpdateTicket(ticket)
+	err = ticketRepo.UpdateTicket(ticket)
 	if err != nil {
 		return fmt.Errorf("failed to update ticket with QR code: %w", err)
 	}
@@ -74,7 +153,7 @@ func (s *ticketService) CreateTicketWithQR(ticket *models.Ticket) error {
 		Status:   models.AttendeeActive,
 	}
 
-	err = s.attendeeRepo.Create(attendee)
+	err = attendeeRepo.Create(attendee)
 	if err != nil {
 		return fmt.Errorf("failed to create attendee record: %w", err)
 	}
@@ -82,6 +161,41 @@ func (s *ticketService) CreateTicketWithQR(ticket *models.Ticket) error {
 	return nil
 }
 
+func (s *ticketService) ReissueQRCodes(onReissue func(ticket *models.Ticket)) (int, error) {
+	const batchSize = 500
+	reissued := 0
+	afterID := uuid.Nil
+	for {
+		tickets, err := s.ticketRepo.GetTicketsAfter(afterID, batchSize)
+		if err != nil {
+			return reissued, fmt.Errorf("failed to load tickets: %w", err)
+		}
+		for _, ticket := range tickets {
+			if claims, err := s.signer.Verify(ticket.QRCode); err == nil &&
+				claims.KeyID == s.signer.CurrentKeyID() && claims.TicketID == ticket.ID {
+				continue
+			}
+			qrCode := s.signer.Sign(ticket)
+			replaced, err := s.ticketRepo.ReplaceQRCode(ticket.ID, ticket.QRCode, qrCode)
+			if err != nil {
+				return reissued, fmt.Errorf("failed to re-issue QR code for ticket %s: %w", ticket.ID, err)
+			}
+			// A ticket whose code changed since it was loaded was just re-issued anyway
+			if replaced {
+				reissued++
+				ticket.QRCode = qrCode
+				if onReissue != nil {
+					onReissue(ticket)
+				}
+			}
+		}
+		if len(tickets) < batchSize {
+			return reissued, nil
+		}
+		afterID = tickets[len(tickets)-1].ID
+	}
+}
+
 func (s *ticketService) GetTicketTypeByID(ticketTypeID uuid.UUID) (*models.TicketType, error) {
 	return s.ticketRepo.GetTicketTypeByID(ticketTypeID)
 }
@@ -98,6 +212,421 @@ func (s *ticketService) GetTicketByID(id uuid.UUID) (*models.Ticket, error) {
 	return s.ticketRepo.GetTicketByID(id)
 }
 
+func (s *ticketService) AssignAttendee(ticketID uuid.UUID, attendee *models.AttendeeDataRequest) (*models.Ticket, error) {
+	records, err := s.attendeeRepo.GetByTicketIDs([]uuid.UUID{ticketID})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Status == models.AttendeeCancelled {
+			return nil, ErrTicketCancelled
+		}
+	}
+
+	ticket, err := s.ticketRepo.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	named := []models.PaymentAttendee{{FullName: attendee.FullName, Email: attendee.Email, Phone: attendee.Phone}}
+
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		ticketType, err := repos.Tickets.GetTicketTypeForUpdate(ticket.TicketTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to lock ticket type: %w", err)
+		}
+		// The ticket is already the buyer's, so only the attendee's contacts are new
+		if err := checkOnePerContact(repos, ticketType, ticket.UserID, 0, named); err != nil {
+			return err
+		}
+
+		assigned, err := repos.Tickets.AssignAttendee(ticketID, attendee.FullName, attendee.Email, attendee.Phone)
+		if err != nil {
+			return err
+		}
+		if !assigned {
+			return ErrTicketAttendeeNamed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ticketRepo.GetTicketByID(ticketID)
+}
+
+func (s *ticketService) CountUserEventTickets(userID, eventID uuid.UUID) (int64, error) {
+	return s.ticketRepo.CountUserEventTickets(userID, eventID)
+}
+
+func (s *ticketService) CheckPurchaseLimits(ticketType *models.TicketType, quantity int, attendees []models.PaymentAttendee) error {
+	if ticketType.MinPerOrder > 0 && quantity < ticketType.MinPerOrder {
+		return fmt.Errorf("%w: %s must be bought at least %d at a time", ErrPurchaseLimit, ticketType.Name, ticketType.MinPerOrder)
+	}
+	if ticketType.MaxPerOrder > 0 && quantity > ticketType.MaxPerOrder {
+		return fmt.Errorf("%w: at most %d %s per order", ErrPurchaseLimit, ticketType.MaxPerOrder, ticketType.Name)
+	}
+
+	if !ticketType.OnePerEmail && !ticketType.OnePerPhone {
+		return nil
+	}
+	// Unnamed tickets could go to anyone, so every ticket needs its attendee up front.
+	// Contacts are checked against other orders when the tickets are held.
+	if len(attendees) < quantity {
+		return fmt.Errorf("%w: every %s ticket needs a named attendee", ErrPurchaseLimit, ticketType.Name)
+	}
+	return nil
+}
+
+// checkOnePerContact checks that no two attendees, in the order, already
+// holding tickets of the type or named on another checkout's hold, share an
+// email or phone number where the type allows only one each. Under one per
+// email the buyer's own account, whose email is verified, also counts as a
+// contact, so it can hold only one ticket of the type however its tickets
+// are named. quantity is how many more tickets the buyer is taking on. Call
+// it with the ticket type locked, so concurrent checkouts can't both pass.
+func checkOnePerContact(repos repository.TxRepositories, ticketType *models.TicketType, userID uuid.UUID, quantity int, attendees []models.PaymentAttendee) error {
+	if !ticketType.OnePerEmail && !ticketType.OnePerPhone {
+		return nil
+	}
+
+	if ticketType.OnePerEmail && quantity > 0 {
+		owned, err := repos.Tickets.CountUserTickets(userID, ticketType.ID)
+		if err != nil {
+			return fmt.Errorf("failed to count tickets: %w", err)
+		}
+		held, err := repos.Reservations.GetUserHeldQuantity(userID, ticketType.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load holds: %w", err)
+		}
+		if int(owned)+held+quantity > 1 {
+			return fmt.Errorf("%w: one %s per account", ErrPurchaseLimit, ticketType.Name)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, attendee := range attendees {
+		if ticketType.OnePerEmail {
+			email := strings.ToLower(strings.TrimSpace(attendee.Email))
+			if email != "" && seen["email:"+email] {
+				return fmt.Errorf("%w: one %s per email; %s is used more than once", ErrPurchaseLimit, ticketType.Name, email)
+			}
+			seen["email:"+email] = true
+		}
+		if ticketType.OnePerPhone {
+			phone := phoneDigits(attendee.Phone)
+			if phone == "" {
+				return fmt.Errorf("%w: every %s ticket needs the attendee's phone number", ErrPurchaseLimit, ticketType.Name)
+			}
+			if seen["phone:"+phone] {
+				return fmt.Errorf("%w: one %s per phone number; %s is used more than once", ErrPurchaseLimit, ticketType.Name, attendee.Phone)
+			}
+			seen["phone:"+phone] = true
+		}
+	}
+
+	emails, phones := attendeeContacts(attendees)
+	if !ticketType.OnePerEmail {
+		emails = nil
+	}
+	if !ticketType.OnePerPhone {
+		phones = nil
+	}
+	if len(emails) == 0 && len(phones) == 0 {
+		return nil
+	}
+
+	existing, err := repos.Tickets.GetTicketsByAttendeeContact(ticketType.ID, emails, phones)
+	if err != nil {
+		return fmt.Errorf("failed to check existing tickets: %w", err)
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("%w: %s already has a %s ticket", ErrPurchaseLimit, existing[0].AttendeeFullName, ticketType.Name)
+	}
+	held, err := repos.Reservations.CountHeldContacts(ticketType.ID, emails, phones)
+	if err != nil {
+		return fmt.Errorf("failed to check held tickets: %w", err)
+	}
+	if held > 0 {
+		return fmt.Errorf("%w: an attendee is already named on a pending %s order", ErrPurchaseLimit, ticketType.Name)
+	}
+	return nil
+}
+
+// attendeeContacts returns the attendees' distinct emails, lowercased, and
+// phone numbers, as digits.
+func attendeeContacts(attendees []models.PaymentAttendee) (emails, phones []string) {
+	seen := make(map[string]bool)
+	for _, attendee := range attendees {
+		if email := strings.ToLower(strings.TrimSpace(attendee.Email)); email != "" && !seen["email:"+email] {
+			seen["email:"+email] = true
+			emails = append(emails, email)
+		}
+		if phone := phoneDigits(attendee.Phone); phone != "" && !seen["phone:"+phone] {
+			seen["phone:"+phone] = true
+			phones = append(phones, phone)
+		}
+	}
+	return emails, phones
+}
+
+// phoneDigits reduces a phone number to its digits so formatting doesn't matter.
+func phoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+// ValidatePurchaseLimits checks that purchase limits can be met by some order.
+func ValidatePurchaseLimits(req *models.TicketTypeLimitsRequest) error {
+	if req.MinPerOrder < 0 || req.MaxPerOrder < 0 || req.MaxPerUser < 0 {
+		return fmt.Errorf("%w: limits cannot be negative", ErrTicketTypeLimitsInvalid)
+	}
+	if req.MaxPerOrder > 0 && req.MinPerOrder > req.MaxPerOrder {
+		return fmt.Errorf("%w: minimum per order is more than the maximum", ErrTicketTypeLimitsInvalid)
+	}
+	if req.MaxPerUser > 0 && req.MinPerOrder > req.MaxPerUser {
+		return fmt.Errorf("%w: minimum per order is more than the maximum per person", ErrTicketTypeLimitsInvalid)
+	}
+	return nil
+}
+
+func (s *ticketService) UpdateTicketTypeLimits(ticketType *models.TicketType, req *models.TicketTypeLimitsRequest) error {
+	if err := ValidatePurchaseLimits(req); err != nil {
+		return err
+	}
+
+	ticketType.MinPerOrder = req.MinPerOrder
+	ticketType.MaxPerOrder = req.MaxPerOrder
+	ticketType.MaxPerUser = req.MaxPerUser
+	ticketType.OnePerEmail = req.OnePerEmail
+	ticketType.OnePerPhone = req.OnePerPhone
+	return s.ticketRepo.UpdateTicketTypeLimits(ticketType)
+}
+
+// ParseTicketVisibility reads a requested visibility; empty is public.
+func ParseTicketVisibility(visibility string) (models.TicketVisibility, error) {
+	switch models.TicketVisibility(visibility) {
+	case "", models.TicketPublic:
+		return models.TicketPublic, nil
+	case models.TicketHidden:
+		return models.TicketHidden, nil
+	}
+	return "", ErrTicketVisibilityInvalid
+}
+
+func (s *ticketService) UpdateTicketTypeVisibility(ticketType *models.TicketType, visibility string) error {
+	parsed, err := ParseTicketVisibility(visibility)
+	if err != nil {
+		return err
+	}
+
+	ticketType.Visibility = parsed
+	return s.ticketRepo.UpdateTicketTypeVisibility(ticketType)
+}
+
+func (s *ticketService) UpdateTicketTypeEntry(ticketType *models.TicketType, req *models.TicketTypeEntryRequest) error {
+	if req.EntryLimit < 0 {
+		return ErrTicketEntryInvalid
+	}
+
+	ticketType.EntryLimit = req.EntryLimit
+	ticketType.EntryPerDay = req.EntryPerDay
+	return s.ticketRepo.UpdateTicketTypeEntry(ticketType)
+}
+
+func (s *ticketService) UpdateTicketTypeCapacity(ticketType *models.TicketType, total int) error {
+	if total < 1 {
+		return fmt.Errorf("%w: total quantity must be at least 1", ErrTicketCapacityInvalid)
+	}
+
+	return s.uow.Do(func(repos repository.TxRepositories) error {
+		locked, err := repos.Tickets.GetTicketTypeForUpdate(ticketType.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock ticket type: %w", err)
+		}
+		held, err := repos.Reservations.GetHeldQuantity(ticketType.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load holds: %w", err)
+		}
+		if taken := locked.SoldQuantity + held; total < taken {
+			return fmt.Errorf("%w: %d tickets are already sold or held", ErrTicketCapacityInvalid, taken)
+		}
+
+		if err := repos.Tickets.UpdateTicketTypeCapacity(ticketType.ID, total); err != nil {
+			return err
+		}
+		ticketType.TotalQuantity = total
+		ticketType.SoldQuantity = locked.SoldQuantity
+		return nil
+	})
+}
+
+func (s *ticketService) UpdateTicketTypeSales(ticketType *models.TicketType, req *models.TicketTypeSalesRequest) error {
+	if req.SalesStart != nil && req.SalesEnd != nil && !req.SalesEnd.After(*req.SalesStart) {
+		return fmt.Errorf("%w: sales must end after they start", ErrTicketSalesInvalid)
+	}
+
+	var previousTierID *uuid.UUID
+	if req.PreviousTierID != nil && *req.PreviousTierID != "" {
+		id, err := uuid.Parse(*req.PreviousTierID)
+		if err != nil {
+			return fmt.Errorf("%w: invalid previous tier ID", ErrTicketSalesInvalid)
+		}
+
+		eventTypes, err := s.ticketRepo.GetTicketTypesByEventID(ticketType.EventID)
+		if err != nil {
+			return err
+		}
+		tiers := make(map[uuid.UUID]*models.TicketType, len(eventTypes))
+		for _, tt := range eventTypes {
+			tiers[tt.ID] = tt
+		}
+		if tiers[id] == nil {
+			return fmt.Errorf("%w: previous tier must be another ticket type for this event", ErrTicketSalesInvalid)
+		}
+		// Following the tiers back from the new previous one must not come round to this one
+		for tier := tiers[id]; tier != nil; {
+			if tier.ID == ticketType.ID {
+				return fmt.Errorf("%w: tiers cannot follow each other in a loop", ErrTicketSalesInvalid)
+			}
+			if tier.PreviousTierID == nil {
+				break
+			}
+			tier = tiers[*tier.PreviousTierID]
+		}
+		previousTierID = &id
+	}
+
+	ticketType.SalesStart = req.SalesStart
+	ticketType.SalesEnd = req.SalesEnd
+	ticketType.PreviousTierID = previousTierID
+	return s.ticketRepo.UpdateTicketTypeSales(ticketType)
+}
+
+func (s *ticketService) SchedulePriceChange(ticketType *models.TicketType, req *models.TicketPriceChangeRequest) (*models.TicketPriceChange, error) {
+	if req.Price < 0 {
+		return nil, fmt.Errorf("%w: price cannot be negative", ErrPriceChangeInvalid)
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: price changes must be scheduled for the future", ErrPriceChangeInvalid)
+	}
+	// A free type is handed out by RSVP, a paid one through checkout; prices can't cross between them
+	if (ticketType.Price == 0) != (req.Price == 0) {
+		return nil, fmt.Errorf("%w: free tickets can't be made paid, or paid tickets free", ErrPriceChangeInvalid)
+	}
+
+	change := &models.TicketPriceChange{
+		TicketTypeID: ticketType.ID,
+		Price:        req.Price,
+		EffectiveAt:  req.EffectiveAt,
+	}
+	if err := s.ticketRepo.CreatePriceChange(change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (s *ticketService) GetPriceChanges(ticketTypeID uuid.UUID) ([]models.TicketPriceChange, error) {
+	return s.ticketRepo.GetPriceChanges(ticketTypeID)
+}
+
+func (s *ticketService) CancelPriceChange(ticketTypeID, changeID uuid.UUID) error {
+	deleted, err := s.ticketRepo.DeletePendingPriceChange(changeID, ticketTypeID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPriceChangeNotFound
+	}
+	return nil
+}
+
+func (s *ticketService) ApplyDuePriceChanges() (int, error) {
+	changes, err := s.ticketRepo.GetDuePriceChanges(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to load due price changes: %w", err)
+	}
+
+	applied := 0
+	for i := range changes {
+		change := &changes[i]
+		err := s.uow.Do(func(repos repository.TxRepositories) error {
+			ok, err := repos.Tickets.ApplyPriceChange(change)
+			if ok {
+				applied++
+			}
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply price change %s: %w", change.ID, err)
+		}
+	}
+	return applied, nil
+}
+
+func (s *ticketService) StartPriceScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			applied, err := s.ApplyDuePriceChanges()
+			if err != nil {
+				log.Printf("❌ PRICE SCHEDULER ERROR: %v", err)
+			}
+			if applied > 0 {
+				log.Printf("🏷️ PRICE SCHEDULER: Applied %d scheduled price changes", applied)
+			}
+		}
+	}()
+}
+
+// SetSaleStates works out whether each of an event's ticket types is on sale
+// at now. held is the quantity of each held by pending checkouts. A tier that
+// follows another stays closed until that one sells out or its sales end.
+// Holds can make a tier sold out for now, but only sales move buyers on to
+// the next one, so lapsed holds can't open it early.
+func SetSaleStates(ticketTypes []models.TicketType, held map[uuid.UUID]int, now time.Time) {
+	tiers := make(map[uuid.UUID]*models.TicketType, len(ticketTypes))
+	for i := range ticketTypes {
+		ticketTypes[i].SaleState = ""
+		tiers[ticketTypes[i].ID] = &ticketTypes[i]
+	}
+
+	var saleState func(tt *models.TicketType, depth int) models.TicketSaleState
+	saleState = func(tt *models.TicketType, depth int) models.TicketSaleState {
+		if tt.SaleState != "" {
+			return tt.SaleState
+		}
+
+		state := models.TicketOnSale
+		switch {
+		case tt.SalesEnd != nil && !now.Before(*tt.SalesEnd):
+			state = models.TicketSaleEnded
+		case tt.TotalQuantity-tt.SoldQuantity-held[tt.ID] <= 0:
+			state = models.TicketSoldOut
+		case tt.SalesStart != nil && now.Before(*tt.SalesStart):
+			state = models.TicketSaleNotStarted
+		case tt.PreviousTierID != nil && tiers[*tt.PreviousTierID] != nil && depth < len(ticketTypes):
+			previousTier := tiers[*tt.PreviousTierID]
+			previous := saleState(previousTier, depth+1)
+			soldOut := previousTier.SoldQuantity >= previousTier.TotalQuantity
+			if previous != models.TicketSaleEnded && !soldOut {
+				state = models.TicketAwaitingTier
+			}
+		}
+		tt.SaleState = state
+		return state
+	}
+
+	for i := range ticketTypes {
+		saleState(&ticketTypes[i], 0)
+	}
+}
+
 // Ticket Type methods
 func (s *ticketService) CreateTicketType(ticketType *models.TicketType) error {
 	return s.ticketRepo.CreateTicketType(ticketType)
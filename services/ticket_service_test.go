@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+func TestSetSaleStates(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	earlyID, lateID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name  string
+		early models.TicketType // The first tier
+		late  models.TicketType // Follows early
+		held  map[uuid.UUID]int
+		want  [2]models.TicketSaleState
+	}{
+		{
+			name:  "later tier waits while the first is selling",
+			early: models.TicketType{TotalQuantity: 10, SoldQuantity: 4},
+			late:  models.TicketType{TotalQuantity: 10},
+			want:  [2]models.TicketSaleState{models.TicketOnSale, models.TicketAwaitingTier},
+		},
+		{
+			name:  "later tier opens once the first sells out",
+			early: models.TicketType{TotalQuantity: 10, SoldQuantity: 10},
+			late:  models.TicketType{TotalQuantity: 10},
+			want:  [2]models.TicketSaleState{models.TicketSoldOut, models.TicketOnSale},
+		},
+		{
+			name:  "holds close the first tier but don't open the next",
+			early: models.TicketType{TotalQuantity: 10, SoldQuantity: 6},
+			late:  models.TicketType{TotalQuantity: 10},
+			held:  map[uuid.UUID]int{earlyID: 4},
+			want:  [2]models.TicketSaleState{models.TicketSoldOut, models.TicketAwaitingTier},
+		},
+		{
+			name:  "later tier opens once the first's sales end",
+			early: models.TicketType{TotalQuantity: 10, SoldQuantity: 4, SalesEnd: &past},
+			late:  models.TicketType{TotalQuantity: 10},
+			want:  [2]models.TicketSaleState{models.TicketSaleEnded, models.TicketOnSale},
+		},
+		{
+			name:  "later tier's own start date still applies",
+			early: models.TicketType{TotalQuantity: 10, SoldQuantity: 10},
+			late:  models.TicketType{TotalQuantity: 10, SalesStart: &future},
+			want:  [2]models.TicketSaleState{models.TicketSoldOut, models.TicketSaleNotStarted},
+		},
+		{
+			name:  "later tier's own end date wins",
+			early: models.TicketType{TotalQuantity: 10},
+			late:  models.TicketType{TotalQuantity: 10, SalesEnd: &now},
+			want:  [2]models.TicketSaleState{models.TicketOnSale, models.TicketSaleEnded},
+		},
+		{
+			name:  "held stock sells out a tier",
+			early: models.TicketType{TotalQuantity: 10, SoldQuantity: 10},
+			late:  models.TicketType{TotalQuantity: 10, SoldQuantity: 7},
+			held:  map[uuid.UUID]int{lateID: 3},
+			want:  [2]models.TicketSaleState{models.TicketSoldOut, models.TicketSoldOut},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			early, late := tt.early, tt.late
+			early.ID, late.ID = earlyID, lateID
+			late.PreviousTierID = &early.ID
+			// Later tiers may be listed first
+			ticketTypes := []models.TicketType{late, early}
+
+			SetSaleStates(ticketTypes, tt.held, now)
+			got := [2]models.TicketSaleState{ticketTypes[1].SaleState, ticketTypes[0].SaleState}
+			if got != tt.want {
+				t.Fatalf("sale states = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSaleStatesUnknownPreviousTier(t *testing.T) {
+	missing := uuid.New()
+	ticketTypes := []models.TicketType{{ID: uuid.New(), TotalQuantity: 10, PreviousTierID: &missing}}
+
+	SetSaleStates(ticketTypes, nil, time.Now())
+	if ticketTypes[0].SaleState != models.TicketOnSale {
+		t.Fatalf("sale state = %s, want %s", ticketTypes[0].SaleState, models.TicketOnSale)
+	}
+}
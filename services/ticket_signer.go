@@ -0,0 +1,189 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+// ticketQRPrefix marks a signed ticket QR payload. Payloads read
+// MOTIV-TICKET-V2:<keyID>:<ticketID>:<eventID>:<userID>:<issuedAt>:<signature>.
+const ticketQRPrefix = "MOTIV-TICKET-V2"
+
+// TicketSigner signs the payloads encoded in ticket QR codes so they can't be
+// forged, and checks them at the door.
+type TicketSigner interface {
+	// Sign returns a QR payload for the ticket signed with the current key.
+	Sign(ticket *models.Ticket) string
+	// Verify checks a QR payload's signature against the key it names, and
+	// returns what it says. Payloads that aren't signed by a known key
+	// return ErrQRCodeInvalid.
+	Verify(payload string) (*TicketQRClaims, error)
+	// CurrentKeyID is the ID of the key new payloads are signed with.
+	CurrentKeyID() string
+	// SignManifest signs an offline check-in manifest with the Ed25519 key
+	// derived from the current one. Scanners check it with the public key
+	// alone, so holding what verifies a manifest doesn't let them forge
+	// manifests or tickets. It returns the key ID and signature.
+	SignManifest(body []byte) (string, string)
+	// ManifestPublicKeys returns the Ed25519 public key, base64, that
+	// checks manifests signed under each key ID, retired ones included.
+	ManifestPublicKeys() map[string]string
+}
+
+// TicketQRClaims is what a verified QR payload says about its ticket.
+type TicketQRClaims struct {
+	KeyID    string
+	TicketID uuid.UUID
+	EventID  uuid.UUID
+	UserID   uuid.UUID
+	IssuedAt time.Time
+}
+
+var ErrQRCodeInvalid = errors.New("QR code is not a valid Motiv ticket")
+
+// hmacTicketSigner signs QR codes with HMAC-SHA256, and manifests with an
+// Ed25519 key seeded from each HMAC key. Retired keys are kept for verifying
+// codes issued before a rotation.
+type hmacTicketSigner struct {
+	keys         map[string][]byte
+	manifestKeys map[string]ed25519.PrivateKey
+	currentKeyID string
+}
+
+// NewHMACTicketSigner signs with keys[currentKeyID] and verifies with any of keys.
+func NewHMACTicketSigner(keys map[string][]byte, currentKeyID string) (TicketSigner, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, fmt.Errorf("no QR signing key with ID %q", currentKeyID)
+	}
+	for keyID, key := range keys {
+		if keyID == "" || strings.Contains(keyID, ":") {
+			return nil, fmt.Errorf("QR signing key ID %q must be non-empty and contain no colons", keyID)
+		}
+		if len(key) < 32 {
+			return nil, fmt.Errorf("QR signing key %q must be at least 32 bytes", keyID)
+		}
+	}
+	manifestKeys := make(map[string]ed25519.PrivateKey, len(keys))
+	for keyID, key := range keys {
+		seed := hmac.New(sha256.New, key)
+		seed.Write([]byte("motiv-checkin-manifest"))
+		manifestKeys[keyID] = ed25519.NewKeyFromSeed(seed.Sum(nil))
+	}
+	return &hmacTicketSigner{keys: keys, manifestKeys: manifestKeys, currentKeyID: currentKeyID}, nil
+}
+
+// NewTicketSignerFromEnv builds a signer from QR_SIGNING_KEYS, a list of
+// base64 keys by ID, e.g. "2025b=...,2025a=...". QR_SIGNING_KEY_ID picks the
+// key to sign with, defaulting to the first listed; the rest only verify.
+// Without QR_SIGNING_KEYS a key is derived from JWT_SECRET.
+func NewTicketSignerFromEnv() (TicketSigner, error) {
+	spec := strings.TrimSpace(os.Getenv("QR_SIGNING_KEYS"))
+	if spec == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("QR_SIGNING_KEYS or JWT_SECRET must be set to sign ticket QR codes")
+		}
+		log.Printf("⚠️ QR SIGNING: QR_SIGNING_KEYS is not set; deriving a key from JWT_SECRET")
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("motiv-ticket-qr"))
+		return NewHMACTicketSigner(map[string][]byte{"default": mac.Sum(nil)}, "default")
+	}
+
+	keys := make(map[string][]byte)
+	currentKeyID := strings.TrimSpace(os.Getenv("QR_SIGNING_KEY_ID"))
+	for _, entry := range strings.Split(spec, ",") {
+		keyID, encoded, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("QR_SIGNING_KEYS entry %q is not id=base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("QR signing key %q is not valid base64: %w", keyID, err)
+		}
+		keys[keyID] = key
+		if currentKeyID == "" {
+			currentKeyID = keyID
+		}
+	}
+	return NewHMACTicketSigner(keys, currentKeyID)
+}
+
+func (s *hmacTicketSigner) CurrentKeyID() string {
+	return s.currentKeyID
+}
+
+func (s *hmacTicketSigner) signature(key []byte, body string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *hmacTicketSigner) Sign(ticket *models.Ticket) string {
+	body := strings.Join([]string{
+		ticketQRPrefix,
+		s.currentKeyID,
+		ticket.ID.String(),
+		ticket.EventID.String(),
+		ticket.UserID.String(),
+		strconv.FormatInt(time.Now().Unix(), 10),
+	}, ":")
+	return body + ":" + s.signature(s.keys[s.currentKeyID], body)
+}
+
+func (s *hmacTicketSigner) SignManifest(body []byte) (string, string) {
+	signature := ed25519.Sign(s.manifestKeys[s.currentKeyID], body)
+	return s.currentKeyID, base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (s *hmacTicketSigner) ManifestPublicKeys() map[string]string {
+	publicKeys := make(map[string]string, len(s.manifestKeys))
+	for keyID, key := range s.manifestKeys {
+		publicKeys[keyID] = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	}
+	return publicKeys
+}
+
+func (s *hmacTicketSigner) Verify(payload string) (*TicketQRClaims, error) {
+	parts := strings.Split(strings.TrimSpace(payload), ":")
+	if len(parts) != 7 || parts[0] != ticketQRPrefix {
+		return nil, ErrQRCodeInvalid
+	}
+	key, ok := s.keys[parts[1]]
+	if !ok {
+		return nil, ErrQRCodeInvalid
+	}
+	body := strings.Join(parts[:6], ":")
+	if !hmac.Equal([]byte(parts[6]), []byte(s.signature(key, body))) {
+		return nil, ErrQRCodeInvalid
+	}
+
+	claims := &TicketQRClaims{KeyID: parts[1]}
+	var err error
+	if claims.TicketID, err = uuid.Parse(parts[2]); err != nil {
+		return nil, ErrQRCodeInvalid
+	}
+	if claims.EventID, err = uuid.Parse(parts[3]); err != nil {
+		return nil, ErrQRCodeInvalid
+	}
+	if claims.UserID, err = uuid.Parse(parts[4]); err != nil {
+		return nil, ErrQRCodeInvalid
+	}
+	issuedAt, err := strconv.ParseInt(parts[5], 10, 64)
+	if err != nil {
+		return nil, ErrQRCodeInvalid
+	}
+	claims.IssuedAt = time.Unix(issuedAt, 0)
+	return claims, nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+func testSigner(t *testing.T, keys map[string][]byte, currentKeyID string) TicketSigner {
+	t.Helper()
+	signer, err := NewHMACTicketSigner(keys, currentKeyID)
+	if err != nil {
+		t.Fatalf("NewHMACTicketSigner() error = %v", err)
+	}
+	return signer
+}
+
+func TestHMACTicketSignerVerify(t *testing.T) {
+	keys := map[string][]byte{
+		"2026b": bytes.Repeat([]byte("b"), 32),
+		"2026a": bytes.Repeat([]byte("a"), 32),
+	}
+	signer := testSigner(t, keys, "2026b")
+	retired := testSigner(t, keys, "2026a")
+	stranger := testSigner(t, map[string][]byte{"2026b": bytes.Repeat([]byte("x"), 32)}, "2026b")
+
+	ticket := &models.Ticket{ID: uuid.New(), EventID: uuid.New(), UserID: uuid.New()}
+	payload := signer.Sign(ticket)
+	parts := strings.Split(payload, ":")
+	withPart := func(i int, value string) string {
+		changed := append([]string(nil), parts...)
+		changed[i] = value
+		return strings.Join(changed, ":")
+	}
+
+	tests := []struct {
+		name      string
+		payload   string
+		wantKeyID string // Empty when the payload must be rejected
+	}{
+		{name: "signed with the current key", payload: payload, wantKeyID: "2026b"},
+		{name: "surrounding whitespace", payload: "  " + payload + "\n", wantKeyID: "2026b"},
+		{name: "signed with a retired key", payload: retired.Sign(ticket), wantKeyID: "2026a"},
+		{name: "signed with an unknown key", payload: stranger.Sign(ticket)},
+		{name: "key ID swapped", payload: withPart(1, "2026a")},
+		{name: "ticket ID changed", payload: withPart(2, uuid.NewString())},
+		{name: "user ID changed", payload: withPart(4, uuid.NewString())},
+		{name: "issue time changed", payload: withPart(5, "0")},
+		{name: "signature changed", payload: withPart(6, strings.Repeat("A", len(parts[6])))},
+		{name: "wrong prefix", payload: withPart(0, "MOTIV-TICKET-V1")},
+		{name: "missing signature", payload: strings.Join(parts[:6], ":")},
+		{name: "bare ticket ID", payload: ticket.ID.String()},
+		{name: "empty", payload: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := signer.Verify(tt.payload)
+			if tt.wantKeyID == "" {
+				if !errors.Is(err, ErrQRCodeInvalid) {
+					t.Fatalf("Verify() error = %v, want ErrQRCodeInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.KeyID != tt.wantKeyID || claims.TicketID != ticket.ID || claims.EventID != ticket.EventID || claims.UserID != ticket.UserID {
+				t.Fatalf("Verify() = %+v, want ticket %s signed with %s", claims, ticket.ID, tt.wantKeyID)
+			}
+		})
+	}
+}
+
+func TestNewHMACTicketSignerRejectsBadKeys(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+
+	tests := []struct {
+		name         string
+		keys         map[string][]byte
+		currentKeyID string
+	}{
+		{name: "current key missing", keys: map[string][]byte{"a": key}, currentKeyID: "b"},
+		{name: "key too short", keys: map[string][]byte{"a": key[:31]}, currentKeyID: "a"},
+		{name: "colon in key ID", keys: map[string][]byte{"a": key, "b:c": key}, currentKeyID: "a"},
+		{name: "empty key ID", keys: map[string][]byte{"": key}, currentKeyID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHMACTicketSigner(tt.keys, tt.currentKeyID); err == nil {
+				t.Fatal("NewHMACTicketSigner() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestHMACTicketSignerManifest(t *testing.T) {
+	keys := map[string][]byte{
+		"2026b": bytes.Repeat([]byte("b"), 32),
+		"2026a": bytes.Repeat([]byte("a"), 32),
+	}
+	signer := testSigner(t, keys, "2026b")
+	body := []byte(`{"event_id":"e","tickets":[]}`)
+
+	keyID, signature := signer.SignManifest(body)
+	if keyID != "2026b" {
+		t.Fatalf("SignManifest() key ID = %s, want 2026b", keyID)
+	}
+	publicKeys := signer.ManifestPublicKeys()
+	if len(publicKeys) != len(keys) {
+		t.Fatalf("ManifestPublicKeys() has %d keys, want %d", len(publicKeys), len(keys))
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		t.Fatalf("signature is not base64url: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		keyID string
+		body  []byte
+		want  bool
+	}{
+		{name: "signed body and key", keyID: "2026b", body: body, want: true},
+		{name: "tampered body", keyID: "2026b", body: []byte(`{"event_id":"e","tickets":[{}]}`)},
+		{name: "retired key", keyID: "2026a", body: body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKey, err := base64.StdEncoding.DecodeString(publicKeys[tt.keyID])
+			if err != nil || len(publicKey) != ed25519.PublicKeySize {
+				t.Fatalf("public key %s is not a base64 Ed25519 key: %v", tt.keyID, err)
+			}
+			if got := ed25519.Verify(publicKey, tt.body, decoded); got != tt.want {
+				t.Fatalf("ed25519.Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
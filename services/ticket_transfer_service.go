@@ -0,0 +1,257 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type TicketTransferService interface {
+	// CreateTransfer invites toEmail to take over a ticket from from, its
+	// holder, and emails them a link to accept. A ticket has at most one
+	// pending transfer at a time.
+	CreateTransfer(ticket *models.Ticket, from *models.User, toEmail string) (*models.TicketTransfer, error)
+	// CancelTransfer withdraws a ticket's pending transfer.
+	CancelTransfer(ticketID uuid.UUID) error
+	// GetTicketTransfers returns a ticket's transfer history, newest first.
+	GetTicketTransfers(ticketID uuid.UUID) ([]models.TicketTransfer, error)
+	// GetIncomingTransfers returns the pending transfers sent to recipient's email.
+	GetIncomingTransfers(recipient *models.User) ([]models.TicketTransfer, error)
+	// AcceptTransfer re-issues the transfer's ticket to recipient with a new
+	// QR code, for attendee or, if attendee is nil, for recipient themselves.
+	AcceptTransfer(token string, recipient *models.User, attendee *models.AttendeeDataRequest) (*models.Ticket, error)
+	DeclineTransfer(token string, recipient *models.User) error
+}
+
+var (
+	ErrTransferInvalid    = errors.New("invalid ticket transfer")
+	ErrTransferNotAllowed = errors.New("ticket can't be transferred")
+	ErrTransferPending    = errors.New("ticket already has a pending transfer")
+	ErrTransferNotFound   = errors.New("ticket transfer not found")
+	ErrTransferNotPending = errors.New("ticket transfer is no longer pending")
+)
+
+type ticketTransferService struct {
+	transferRepo repository.TicketTransferRepository
+	attendeeRepo repository.AttendeeRepository
+	uow          repository.UnitOfWork
+	emailService EmailService
+	inviteTTL    time.Duration
+	signer       TicketSigner
+}
+
+// NewTicketTransferService keeps transfer invites open for inviteTTL.
+func NewTicketTransferService(transferRepo repository.TicketTransferRepository, attendeeRepo repository.AttendeeRepository, uow repository.UnitOfWork, emailService EmailService, inviteTTL time.Duration, signer TicketSigner) TicketTransferService {
+	return &ticketTransferService{
+		transferRepo: transferRepo,
+		attendeeRepo: attendeeRepo,
+		uow:          uow,
+		emailService: emailService,
+		inviteTTL:    inviteTTL,
+		signer:       signer,
+	}
+}
+
+// checkTransferable checks a ticket's event allows transfers and that the
+// ticket hasn't been used or cancelled.
+func (s *ticketTransferService) checkTransferable(ticket *models.Ticket) error {
+	if ticket.Event.TransfersDisabled {
+		return fmt.Errorf("%w: the host has turned off transfers for this event", ErrTransferNotAllowed)
+	}
+	if ticket.Event.Status == models.CancelledEvent {
+		return fmt.Errorf("%w: the event has been cancelled", ErrTransferNotAllowed)
+	}
+
+	records, err := s.attendeeRepo.GetByTicketIDs([]uuid.UUID{ticket.ID})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		switch record.Status {
+		case models.AttendeeCheckedIn:
+			return fmt.Errorf("%w: it has already been checked in", ErrTransferNotAllowed)
+		case models.AttendeeCancelled:
+			return ErrTicketCancelled
+		}
+	}
+	return nil
+}
+
+func (s *ticketTransferService) CreateTransfer(ticket *models.Ticket, from *models.User, toEmail string) (*models.TicketTransfer, error) {
+	toEmail = strings.TrimSpace(toEmail)
+	if toEmail == "" {
+		return nil, fmt.Errorf("%w: recipient email is required", ErrTransferInvalid)
+	}
+	if strings.EqualFold(toEmail, from.Email) {
+		return nil, fmt.Errorf("%w: you can't transfer a ticket to yourself", ErrTransferInvalid)
+	}
+	if err := s.checkTransferable(ticket); err != nil {
+		return nil, err
+	}
+
+	if err := s.transferRepo.ExpireLapsed(ticket.ID); err != nil {
+		return nil, err
+	}
+	if _, err := s.transferRepo.GetPendingByTicketID(ticket.ID); err == nil {
+		return nil, ErrTransferPending
+	}
+
+	token, err := newLinkToken()
+	if err != nil {
+		return nil, err
+	}
+	transfer := &models.TicketTransfer{
+		TicketID:          ticket.ID,
+		EventID:           ticket.EventID,
+		FromUserID:        from.ID,
+		ToEmail:           toEmail,
+		Token:             token,
+		Status:            models.TransferPending,
+		ExpiresAt:         time.Now().Add(s.inviteTTL),
+		FromAttendeeName:  ticket.AttendeeFullName,
+		FromAttendeeEmail: ticket.AttendeeEmail,
+	}
+	if err := s.transferRepo.Create(transfer); err != nil {
+		return nil, err
+	}
+	log.Printf("🔁 TICKET TRANSFER: Ticket %s offered by %s to %s", ticket.ID, from.Email, toEmail)
+
+	if err := s.emailService.SendTicketTransferInvite(transfer, ticket, &ticket.Event, from); err != nil {
+		log.Printf("❌ EMAIL ERROR: Failed to send transfer invite %s to %s: %v", transfer.ID, toEmail, err)
+	}
+	return transfer, nil
+}
+
+func (s *ticketTransferService) CancelTransfer(ticketID uuid.UUID) error {
+	transfer, err := s.transferRepo.GetPendingByTicketID(ticketID)
+	if err != nil {
+		return ErrTransferNotFound
+	}
+	cancelled, err := s.transferRepo.Transition(transfer.ID, models.TransferCancelled)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrTransferNotPending
+	}
+	return nil
+}
+
+func (s *ticketTransferService) GetTicketTransfers(ticketID uuid.UUID) ([]models.TicketTransfer, error) {
+	if err := s.transferRepo.ExpireLapsed(ticketID); err != nil {
+		return nil, err
+	}
+	return s.transferRepo.GetByTicketID(ticketID)
+}
+
+func (s *ticketTransferService) GetIncomingTransfers(recipient *models.User) ([]models.TicketTransfer, error) {
+	return s.transferRepo.GetPendingByEmail(recipient.Email)
+}
+
+// loadIncoming loads the transfer carrying token, provided it was sent to recipient.
+func (s *ticketTransferService) loadIncoming(token string, recipient *models.User) (*models.TicketTransfer, error) {
+	transfer, err := s.transferRepo.GetByToken(strings.TrimSpace(token))
+	if err != nil || !strings.EqualFold(transfer.ToEmail, recipient.Email) {
+		return nil, ErrTransferNotFound
+	}
+	if transfer.Status != models.TransferPending || !time.Now().Before(transfer.ExpiresAt) {
+		return nil, ErrTransferNotPending
+	}
+	return transfer, nil
+}
+
+func (s *ticketTransferService) AcceptTransfer(token string, recipient *models.User, attendee *models.AttendeeDataRequest) (*models.Ticket, error) {
+	transfer, err := s.loadIncoming(token, recipient)
+	if err != nil {
+		return nil, err
+	}
+	ticket := &transfer.Ticket
+	if err := s.checkTransferable(ticket); err != nil {
+		return nil, err
+	}
+
+	named := models.PaymentAttendee{FullName: recipient.Name, Email: recipient.Email}
+	if attendee != nil {
+		named = models.PaymentAttendee{FullName: attendee.FullName, Email: attendee.Email, Phone: attendee.Phone}
+	}
+
+	// A new holder gets a new QR code, so the old one stops working
+	fromUserID := transfer.FromUserID
+	reissuedTicket := *ticket
+	reissuedTicket.UserID = recipient.ID
+	reissuedTicket.AttendeeFullName = named.FullName
+	reissuedTicket.AttendeeEmail = named.Email
+	reissuedTicket.AttendeePhone = named.Phone
+	reissuedTicket.QRCode = s.signer.Sign(&reissuedTicket)
+
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		// The recipient takes the ticket on within the ticket type's limits
+		ticketType, err := repos.Tickets.GetTicketTypeForUpdate(ticket.TicketTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to lock ticket type: %w", err)
+		}
+		if limit := ticketType.MaxPerUser; limit > 0 {
+			held, err := repos.Tickets.CountUserTickets(recipient.ID, ticket.TicketTypeID)
+			if err != nil {
+				return fmt.Errorf("failed to count tickets: %w", err)
+			}
+			if int(held) >= limit {
+				return fmt.Errorf("%w: at most %d %s per person", ErrPurchaseLimit, limit, ticketType.Name)
+			}
+		}
+		if err := checkOnePerContact(repos, ticketType, recipient.ID, 1, []models.PaymentAttendee{named}); err != nil {
+			return err
+		}
+
+		accepted, err := repos.Transfers.MarkAccepted(transfer.ID, recipient.ID, named.FullName, named.Email)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrTransferNotPending
+		}
+
+		reissued, err := repos.Tickets.Reissue(ticket.ID, fromUserID, recipient.ID, named.FullName, named.Email, named.Phone, reissuedTicket.QRCode)
+		if err != nil {
+			return fmt.Errorf("failed to re-issue ticket: %w", err)
+		}
+		if !reissued {
+			return fmt.Errorf("%w: it has changed hands since the invite was sent", ErrTransferNotAllowed)
+		}
+		reassigned, err := repos.Attendees.ReassignTicket(ticket.ID, recipient.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update attendee record: %w", err)
+		}
+		if !reassigned {
+			return fmt.Errorf("%w: it has been checked in or cancelled since the invite was sent", ErrTransferNotAllowed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔁 TICKET TRANSFERRED: Ticket %s from %s to %s", ticket.ID, transfer.FromUserID, recipient.ID)
+
+	return &reissuedTicket, nil
+}
+
+func (s *ticketTransferService) DeclineTransfer(token string, recipient *models.User) error {
+	transfer, err := s.loadIncoming(token, recipient)
+	if err != nil {
+		return err
+	}
+	declined, err := s.transferRepo.Transition(transfer.ID, models.TransferDeclined)
+	if err != nil {
+		return err
+	}
+	if !declined {
+		return ErrTransferNotPending
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hidenkeys/motiv-backend/models"
+)
+
+// TransferGateway sends payouts to host bank accounts. Amounts are in minor
+// units, as for PaymentGateway.
+type TransferGateway interface {
+	Name() string
+	// CreateRecipient registers a bank account with the provider, checking
+	// it resolves, and returns the provider's recipient code. Providers that
+	// transfer straight to account details return an empty code.
+	CreateRecipient(account *models.HostBankAccount) (string, error)
+	// Transfer sends money to a bank account. Unless the returned status is
+	// "success", the outcome arrives later as a transfer webhook. Only an
+	// error wrapping ErrGatewayRejected means no transfer was made.
+	Transfer(req TransferRequest) (*GatewayTransfer, error)
+	// VerifyTransfer fetches the provider's record of a transfer by our
+	// reference, or by transferID where the provider needs its own ID. It
+	// fails with ErrGatewayRecordNotFound if the transfer was never made.
+	VerifyTransfer(reference, transferID string) (*GatewayTransfer, error)
+}
+
+// TransferRequest describes a payout to send.
+type TransferRequest struct {
+	Reference string // Our payout reference; providers reject repeats
+	Amount    int64  // Minor units
+	Currency  string
+	Account   *models.HostBankAccount
+	Narration string
+}
+
+// GatewayTransfer is a provider's record of a transfer it has accepted.
+type GatewayTransfer struct {
+	ID     string
+	Status string // "success" if settled, "failed" if failed or reversed, otherwise the provider's own status
+}
+
+const FakeTransferGateway = "fake"
+
+// fakeTransferGateway settles every transfer immediately without moving money.
+type fakeTransferGateway struct {
+	transfers int64
+	sent      sync.Map // Reference to transfer ID
+}
+
+// NewFakeTransferGateway creates a transfer gateway for local development and
+// tests. Every account resolves and every transfer succeeds at once.
+func NewFakeTransferGateway() TransferGateway {
+	return &fakeTransferGateway{}
+}
+
+func (f *fakeTransferGateway) Name() string {
+	return FakeTransferGateway
+}
+
+func (f *fakeTransferGateway) CreateRecipient(account *models.HostBankAccount) (string, error) {
+	return fmt.Sprintf("RCP_FAKE_%s_%s", account.BankCode, account.AccountNumber), nil
+}
+
+func (f *fakeTransferGateway) Transfer(req TransferRequest) (*GatewayTransfer, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("transfer amount must be positive")
+	}
+	id := fmt.Sprintf("TRF_FAKE_%d", atomic.AddInt64(&f.transfers, 1))
+	f.sent.Store(req.Reference, id)
+	log.Printf("🧪 FAKE TRANSFER: %s %.2f to %s %s (%s)", strings.ToUpper(req.Currency), fromMinorUnits(req.Amount),
+		req.Account.BankCode, req.Account.AccountNumber, req.Reference)
+	return &GatewayTransfer{
+		ID:     id,
+		Status: "success",
+	}, nil
+}
+
+func (f *fakeTransferGateway) VerifyTransfer(reference, transferID string) (*GatewayTransfer, error) {
+	id, ok := f.sent.Load(reference)
+	if !ok {
+		return nil, fmt.Errorf("%w: %w: transfer %s", ErrGatewayRejected, ErrGatewayRecordNotFound, reference)
+	}
+	return &GatewayTransfer{
+		ID:     id.(string),
+		Status: "success",
+	}, nil
+}
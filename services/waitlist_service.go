@@ -0,0 +1,409 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type WaitlistService interface {
+	// Join queues a buyer for quantity tickets of a sold-out ticket type.
+	Join(userID uuid.UUID, ticketType *models.TicketType, quantity int) (*models.WaitlistEntry, error)
+	// Leave removes a buyer's own entry, giving back any offer's hold.
+	Leave(userID, entryID uuid.UUID) error
+	GetUserEntries(userID uuid.UUID) ([]models.WaitlistEntry, error)
+	// GetUserOffers returns a buyer's unexpired offers for an event, by ticket type.
+	GetUserOffers(userID, eventID uuid.UUID) (map[uuid.UUID]*models.WaitlistEntry, error)
+
+	GetEntry(id uuid.UUID) (*models.WaitlistEntry, error)
+	// GetTicketTypeWaitlist lists every entry for a ticket type in position order.
+	GetTicketTypeWaitlist(ticketTypeID uuid.UUID) ([]models.WaitlistEntry, error)
+	// MoveEntry moves a waiting entry to place in line, 1 being next.
+	MoveEntry(entry *models.WaitlistEntry, place int) error
+	// RemoveEntry takes an entry off the waitlist, giving back any offer's hold.
+	RemoveEntry(entry *models.WaitlistEntry) error
+
+	// OfferFreedTickets offers a ticket type's unsold, unheld tickets to the
+	// waitlist in order, holding them for each entry and emailing the offer.
+	// It returns how many entries were offered tickets.
+	OfferFreedTickets(ticketTypeID uuid.UUID) (int, error)
+	// ProcessOffers expires lapsed offers, then offers freed tickets on every
+	// ticket type with a waitlist.
+	ProcessOffers() (int, error)
+	// StartScheduler processes offers every interval in the background.
+	StartScheduler(interval time.Duration)
+}
+
+var (
+	ErrWaitlistInvalid       = errors.New("invalid waitlist request")
+	ErrWaitlistJoined        = errors.New("you're already on the waitlist for this ticket type")
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrWaitlistNotWaiting    = errors.New("waitlist entry is no longer waiting")
+)
+
+type waitlistService struct {
+	waitlistRepo    repository.WaitlistRepository
+	reservationRepo repository.ReservationRepository
+	eventRepo       repository.EventRepository
+	uow             repository.UnitOfWork
+	emailService    EmailService
+	offerTTL        time.Duration
+}
+
+// NewWaitlistService holds tickets offered to the waitlist for offerTTL.
+func NewWaitlistService(waitlistRepo repository.WaitlistRepository, reservationRepo repository.ReservationRepository, eventRepo repository.EventRepository, uow repository.UnitOfWork, emailService EmailService, offerTTL time.Duration) WaitlistService {
+	return &waitlistService{
+		waitlistRepo:    waitlistRepo,
+		reservationRepo: reservationRepo,
+		eventRepo:       eventRepo,
+		uow:             uow,
+		emailService:    emailService,
+		offerTTL:        offerTTL,
+	}
+}
+
+// waitlistOfferReference is the reference an entry's offer hold is taken under.
+func waitlistOfferReference(entryID uuid.UUID) string {
+	return "WAITLIST_" + entryID.String()
+}
+
+// claimWaitlistOffers hands a buyer's offers on ticketTypeIDs to the checkout
+// or RSVP with reference, releasing the offers' holds so the tickets can be
+// held or issued for it instead. Call it in the same transaction, before
+// availability is checked.
+func claimWaitlistOffers(repos repository.TxRepositories, eventID, userID uuid.UUID, ticketTypeIDs []uuid.UUID, reference string) error {
+	offers, err := repos.Waitlist.GetUserOffers(userID, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to load waitlist offers: %w", err)
+	}
+
+	wanted := make(map[uuid.UUID]bool, len(ticketTypeIDs))
+	for _, id := range ticketTypeIDs {
+		wanted[id] = true
+	}
+	for _, offer := range offers {
+		if !wanted[offer.TicketTypeID] {
+			continue
+		}
+		claimed, err := repos.Waitlist.MarkClaimed(offer.ID, reference)
+		if err != nil {
+			return fmt.Errorf("failed to claim waitlist offer: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+		if _, err := repos.Reservations.ReleaseByReference(offer.OfferReference); err != nil {
+			return fmt.Errorf("failed to release waitlist hold: %w", err)
+		}
+		log.Printf("🎟️ WAITLIST CLAIMED: Offer %s used by %s", offer.ID, reference)
+	}
+	return nil
+}
+
+func (s *waitlistService) Join(userID uuid.UUID, ticketType *models.TicketType, quantity int) (*models.WaitlistEntry, error) {
+	if quantity < 1 {
+		return nil, fmt.Errorf("%w: quantity must be at least 1", ErrWaitlistInvalid)
+	}
+	if ticketType.Price == 0 && quantity > 1 {
+		return nil, fmt.Errorf("%w: free tickets are one per RSVP", ErrWaitlistInvalid)
+	}
+	if ticketType.MaxPerOrder > 0 && quantity > ticketType.MaxPerOrder {
+		return nil, fmt.Errorf("%w: at most %d %s per order", ErrWaitlistInvalid, ticketType.MaxPerOrder, ticketType.Name)
+	}
+	if _, err := s.waitlistRepo.GetActiveEntry(userID, ticketType.ID); err == nil {
+		return nil, ErrWaitlistJoined
+	}
+
+	position, err := s.waitlistRepo.GetNextPosition(ticketType.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waitlist position: %w", err)
+	}
+	entry := &models.WaitlistEntry{
+		TicketTypeID: ticketType.ID,
+		EventID:      ticketType.EventID,
+		UserID:       userID,
+		Quantity:     quantity,
+		Position:     position,
+		Status:       models.WaitlistWaiting,
+	}
+	if err := s.waitlistRepo.Create(entry); err != nil {
+		return nil, err
+	}
+	return entry, s.setPlace(entry)
+}
+
+// setPlace works out a waiting entry's place in line.
+func (s *waitlistService) setPlace(entry *models.WaitlistEntry) error {
+	if entry.Status != models.WaitlistWaiting {
+		return nil
+	}
+	ahead, err := s.waitlistRepo.CountAhead(entry.TicketTypeID, entry.Position)
+	if err != nil {
+		return fmt.Errorf("failed to get place in line: %w", err)
+	}
+	entry.Place = int(ahead) + 1
+	return nil
+}
+
+func (s *waitlistService) Leave(userID, entryID uuid.UUID) error {
+	entry, err := s.waitlistRepo.GetByID(entryID)
+	if err != nil || entry.UserID != userID {
+		return ErrWaitlistEntryNotFound
+	}
+	return s.RemoveEntry(entry)
+}
+
+func (s *waitlistService) GetUserEntries(userID uuid.UUID) ([]models.WaitlistEntry, error) {
+	entries, err := s.waitlistRepo.GetUserEntries(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if err := s.setPlace(&entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (s *waitlistService) GetUserOffers(userID, eventID uuid.UUID) (map[uuid.UUID]*models.WaitlistEntry, error) {
+	offers, err := s.waitlistRepo.GetUserOffers(userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	byTicketType := make(map[uuid.UUID]*models.WaitlistEntry, len(offers))
+	for i := range offers {
+		byTicketType[offers[i].TicketTypeID] = &offers[i]
+	}
+	return byTicketType, nil
+}
+
+func (s *waitlistService) GetEntry(id uuid.UUID) (*models.WaitlistEntry, error) {
+	entry, err := s.waitlistRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return entry, s.setPlace(entry)
+}
+
+func (s *waitlistService) GetTicketTypeWaitlist(ticketTypeID uuid.UUID) ([]models.WaitlistEntry, error) {
+	entries, err := s.waitlistRepo.GetTicketTypeEntries(ticketTypeID)
+	if err != nil {
+		return nil, err
+	}
+	place := 0
+	for i := range entries {
+		if entries[i].Status == models.WaitlistWaiting {
+			place++
+			entries[i].Place = place
+		}
+	}
+	return entries, nil
+}
+
+func (s *waitlistService) MoveEntry(entry *models.WaitlistEntry, place int) error {
+	if entry.Status != models.WaitlistWaiting {
+		return ErrWaitlistNotWaiting
+	}
+
+	waiting, err := s.waitlistRepo.GetTicketTypeEntries(entry.TicketTypeID, models.WaitlistWaiting)
+	if err != nil {
+		return err
+	}
+	order := make([]models.WaitlistEntry, 0, len(waiting))
+	for _, w := range waiting {
+		if w.ID != entry.ID {
+			order = append(order, w)
+		}
+	}
+	place = min(max(place, 1), len(order)+1)
+	order = append(order[:place-1], append([]models.WaitlistEntry{*entry}, order[place-1:]...)...)
+
+	// Renumber the waiting entries; the rest keep their old positions
+	err = s.uow.Do(func(repos repository.TxRepositories) error {
+		for i, w := range order {
+			if w.Position == i+1 {
+				continue
+			}
+			if err := repos.Waitlist.SetPosition(w.ID, i+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	entry.Position = place
+	entry.Place = place
+	return nil
+}
+
+func (s *waitlistService) RemoveEntry(entry *models.WaitlistEntry) error {
+	removed, err := s.waitlistRepo.Transition(entry.ID, []models.WaitlistStatus{models.WaitlistWaiting, models.WaitlistOffered}, models.WaitlistRemoved)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrWaitlistNotWaiting
+	}
+	entry.Status = models.WaitlistRemoved
+
+	// Reloaded in case an offer was made since the entry was read
+	current, err := s.waitlistRepo.GetByID(entry.ID)
+	if err != nil {
+		return err
+	}
+	if current.OfferReference != "" {
+		if _, err := s.reservationRepo.ReleaseByReference(current.OfferReference); err != nil {
+			log.Printf("⚠️ WAITLIST WARNING: Failed to release offer hold %s: %v", current.OfferReference, err)
+		}
+	}
+	return nil
+}
+
+func (s *waitlistService) OfferFreedTickets(ticketTypeID uuid.UUID) (int, error) {
+	var ticketType *models.TicketType
+	var offered []models.WaitlistEntry
+	err := s.uow.Do(func(repos repository.TxRepositories) error {
+		offered = nil
+		var err error
+		ticketType, err = repos.Tickets.GetTicketTypeForUpdate(ticketTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to lock ticket type: %w", err)
+		}
+		if ticketType.SalesEnd != nil && !time.Now().Before(*ticketType.SalesEnd) {
+			return nil
+		}
+
+		held, err := repos.Reservations.GetHeldQuantity(ticketTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to load holds: %w", err)
+		}
+		available := ticketType.TotalQuantity - ticketType.SoldQuantity - held
+		if available <= 0 {
+			return nil
+		}
+
+		entries, err := repos.Waitlist.GetTicketTypeEntries(ticketTypeID, models.WaitlistWaiting)
+		if err != nil {
+			return fmt.Errorf("failed to load waitlist: %w", err)
+		}
+		expiresAt := time.Now().Add(s.offerTTL)
+		for _, entry := range entries {
+			if available <= 0 {
+				break
+			}
+
+			// Whoever is next gets what's left if it's fewer than they asked for
+			quantity := min(entry.Quantity, available)
+			reference := waitlistOfferReference(entry.ID)
+			marked, err := repos.Waitlist.MarkOffered(entry.ID, reference, quantity, expiresAt)
+			if err != nil {
+				return fmt.Errorf("failed to offer tickets: %w", err)
+			}
+			if !marked {
+				continue
+			}
+			hold := &models.TicketReservation{
+				TicketTypeID:     ticketTypeID,
+				EventID:          entry.EventID,
+				UserID:           entry.UserID,
+				PaymentReference: reference,
+				Quantity:         quantity,
+				Status:           models.ReservationActive,
+				ExpiresAt:        expiresAt,
+			}
+			if err := repos.Reservations.Create(hold); err != nil {
+				return fmt.Errorf("failed to hold offered tickets: %w", err)
+			}
+
+			entry.Status = models.WaitlistOffered
+			entry.OfferReference = reference
+			entry.OfferedQuantity = quantity
+			entry.OfferExpiresAt = &expiresAt
+			offered = append(offered, entry)
+			available -= quantity
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(offered) == 0 {
+		return 0, nil
+	}
+
+	event, err := s.eventRepo.GetEventByID(ticketType.EventID)
+	if err != nil {
+		log.Printf("⚠️ WAITLIST WARNING: Failed to load event %s for offer emails: %v", ticketType.EventID, err)
+		return len(offered), nil
+	}
+	for i := range offered {
+		entry := &offered[i]
+		entry.TicketType = *ticketType
+		log.Printf("🎟️ WAITLIST OFFER: %d %s held for %s until %s", entry.OfferedQuantity, ticketType.Name, entry.User.Email, entry.OfferExpiresAt.Format(time.RFC3339))
+		if err := s.emailService.SendWaitlistOffer(entry, event, &entry.User); err != nil {
+			log.Printf("❌ EMAIL ERROR: Failed to send waitlist offer %s: %v", entry.ID, err)
+		}
+	}
+	return len(offered), nil
+}
+
+func (s *waitlistService) ProcessOffers() (int, error) {
+	lapsed, err := s.waitlistRepo.GetLapsedOffers(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to load lapsed offers: %w", err)
+	}
+	for _, entry := range lapsed {
+		expired, err := s.waitlistRepo.Transition(entry.ID, []models.WaitlistStatus{models.WaitlistOffered}, models.WaitlistExpired)
+		if err != nil {
+			log.Printf("⚠️ WAITLIST WARNING: Failed to expire offer %s: %v", entry.ID, err)
+			continue
+		}
+		if !expired {
+			continue
+		}
+		if _, err := s.reservationRepo.ReleaseByReference(entry.OfferReference); err != nil {
+			log.Printf("⚠️ WAITLIST WARNING: Failed to release offer hold %s: %v", entry.OfferReference, err)
+		}
+	}
+
+	ticketTypeIDs, err := s.waitlistRepo.GetWaitingTicketTypeIDs()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load waitlists: %w", err)
+	}
+	offered := 0
+	for _, ticketTypeID := range ticketTypeIDs {
+		count, err := s.OfferFreedTickets(ticketTypeID)
+		if err != nil {
+			log.Printf("❌ WAITLIST ERROR: Failed to offer tickets for %s: %v", ticketTypeID, err)
+			continue
+		}
+		offered += count
+	}
+	return offered, nil
+}
+
+func (s *waitlistService) StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			offered, err := s.ProcessOffers()
+			if err != nil {
+				log.Printf("❌ WAITLIST SCHEDULER ERROR: %v", err)
+				continue
+			}
+			if offered > 0 {
+				log.Printf("🗓️ WAITLIST SCHEDULER: Offered tickets to %d waitlist entries", offered)
+			}
+		}
+	}()
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hidenkeys/motiv-backend/models"
+	"github.com/hidenkeys/motiv-backend/repository"
+)
+
+type WebhookService interface {
+	// RecordDelivery stores a webhook delivery. If the provider has already sent
+	// an event with the same ID, the stored event is returned with duplicate set.
+	RecordDelivery(event *models.WebhookEvent) (stored *models.WebhookEvent, duplicate bool, err error)
+	// BeginProcessing claims a received or previously failed event for processing,
+	// or one whose processing stalled. It returns false if the event is already
+	// being processed or is done.
+	BeginProcessing(id uuid.UUID) (bool, error)
+	// BeginReplay claims a failed or stalled event so an admin can re-run it.
+	BeginReplay(id uuid.UUID) (*models.WebhookEvent, error)
+	MarkProcessed(id uuid.UUID) error
+	MarkIgnored(id uuid.UUID, reason string) error
+	MarkFailed(id uuid.UUID, reason string) error
+	GetWebhookEvent(id uuid.UUID) (*models.WebhookEvent, error)
+	ListWebhookEvents(provider, status string, page, limit int) ([]models.WebhookEvent, int64, error)
+}
+
+// ErrWebhookNotReplayable is returned when replaying an event that hasn't failed.
+var ErrWebhookNotReplayable = errors.New("only failed or stalled webhook events can be replayed")
+
+// webhookStaleAfter is how long an event can sit in processing before it is
+// taken to have stalled, e.g. the server died mid-run, and can be claimed again.
+const webhookStaleAfter = 10 * time.Minute
+
+type webhookService struct {
+	webhookRepo repository.WebhookEventRepository
+}
+
+func NewWebhookService(webhookRepo repository.WebhookEventRepository) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+	}
+}
+
+func (s *webhookService) RecordDelivery(event *models.WebhookEvent) (*models.WebhookEvent, bool, error) {
+	if event.ProviderEventID != nil {
+		existing, err := s.webhookRepo.GetByProviderEventID(event.Provider, *event.ProviderEventID)
+		if err == nil {
+			return existing, true, nil
+		}
+	}
+
+	if err := s.webhookRepo.Create(event); err != nil {
+		// A concurrent retry may have inserted the same event between our lookup and insert
+		if event.ProviderEventID != nil {
+			if existing, lookupErr := s.webhookRepo.GetByProviderEventID(event.Provider, *event.ProviderEventID); lookupErr == nil {
+				return existing, true, nil
+			}
+		}
+		return nil, false, err
+	}
+
+	return event, false, nil
+}
+
+func (s *webhookService) BeginProcessing(id uuid.UUID) (bool, error) {
+	return s.webhookRepo.Claim(id,
+		[]models.WebhookEventStatus{models.WebhookReceived, models.WebhookFailed},
+		time.Now().Add(-webhookStaleAfter))
+}
+
+func (s *webhookService) BeginReplay(id uuid.UUID) (*models.WebhookEvent, error) {
+	claimed, err := s.webhookRepo.Claim(id,
+		[]models.WebhookEventStatus{models.WebhookFailed},
+		time.Now().Add(-webhookStaleAfter))
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := s.webhookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claimed {
+		return event, ErrWebhookNotReplayable
+	}
+
+	return event, nil
+}
+
+func (s *webhookService) MarkProcessed(id uuid.UUID) error {
+	return s.webhookRepo.UpdateResult(id, models.WebhookProcessed, "")
+}
+
+func (s *webhookService) MarkIgnored(id uuid.UUID, reason string) error {
+	return s.webhookRepo.UpdateResult(id, models.WebhookIgnored, reason)
+}
+
+func (s *webhookService) MarkFailed(id uuid.UUID, reason string) error {
+	return s.webhookRepo.UpdateResult(id, models.WebhookFailed, reason)
+}
+
+func (s *webhookService) GetWebhookEvent(id uuid.UUID) (*models.WebhookEvent, error) {
+	return s.webhookRepo.GetByID(id)
+}
+
+func (s *webhookService) ListWebhookEvents(provider, status string, page, limit int) ([]models.WebhookEvent, int64, error) {
+	offset := (page - 1) * limit
+	return s.webhookRepo.List(provider, status, limit, offset)
+}